@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/didiercito/api-go-examen2/models"
+	"github.com/didiercito/api-go-examen2/services"
+)
+
+// Códigos de salida de los comandos
+const (
+	exitOK     = 0
+	exitErrors = 1 // el análisis encontró errores
+	exitUsage  = 2 // argumentos inválidos o archivos que no se pudieron leer
+)
+
+// analyze analiza los archivos y carpetas indicados con los mismos
+// servicios que /batch. Cada archivo es una entrega; cada carpeta contiene
+// entregas organizadas como el zip de /batch, o es un solo proyecto con
+// -project. Devuelve exitErrors si alguna entrega tiene errores.
+func analyze(args []string, out, errOut io.Writer) int {
+	flags := flag.NewFlagSet("analyze", flag.ContinueOnError)
+	flags.SetOutput(errOut)
+	format := flags.String("format", "text", "formato de la salida: text, json, sarif, junit o html")
+	phases := flags.String("phases", "", "fases separadas por comas: lexical, syntax, semantic (por defecto todas)")
+	project := flags.Bool("project", false, "analizar cada carpeta como un solo proyecto")
+	entry := flags.String("entry", "", "punto de entrada de los proyectos (por defecto main.cpp)")
+	flags.Usage = func() {
+		fmt.Fprintln(errOut, "Uso: api analyze [opciones] ARCHIVO|CARPETA...")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return exitUsage
+	}
+	switch *format {
+	case "text", "json", "sarif", "junit", "html":
+	default:
+		fmt.Fprintln(errOut, "Formato desconocido: "+*format)
+		return exitUsage
+	}
+
+	var req models.BatchRequest
+	if *phases != "" {
+		req.Phases = strings.Split(*phases, ",")
+	}
+	for _, path := range flags.Args() {
+		submissions, err := readSubmissions(path, *project, *entry)
+		if err != nil {
+			fmt.Fprintln(errOut, path+": "+err.Error())
+			return exitUsage
+		}
+		req.Submissions = append(req.Submissions, submissions...)
+	}
+	if err := services.ValidateBatch(req); err != nil {
+		fmt.Fprintln(errOut, err)
+		return exitUsage
+	}
+
+	result := services.AnalyzeBatch(req)
+	entries := make([]services.ReportEntry, len(result.Results))
+	for i := range entries {
+		entries[i] = services.ReportEntry{Submission: req.Submissions[i], Result: result.Results[i]}
+	}
+	switch *format {
+	case "json":
+		writeJSON(out, result)
+	case "sarif":
+		writeJSON(out, services.SarifLog(result.Results))
+	case "junit":
+		io.WriteString(out, xml.Header)
+		encoder := xml.NewEncoder(out)
+		encoder.Indent("", "  ")
+		encoder.Encode(services.JUnitReport(entries))
+		fmt.Fprintln(out)
+	case "html":
+		services.HTMLReport(out, entries)
+	default:
+		writeText(out, result)
+	}
+	if result.Summary.Invalid > 0 {
+		return exitErrors
+	}
+	return exitOK
+}
+
+// readSubmissions lee las entregas de un archivo o de una carpeta. Los
+// nombres son rutas, para que los mensajes se puedan abrir en el editor.
+func readSubmissions(path string, project bool, entry string) ([]models.Submission, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		code, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return []models.Submission{{Name: path, Code: string(code)}}, nil
+	}
+
+	if project {
+		files, err := services.ReadProject(os.DirFS(path))
+		if err != nil {
+			return nil, err
+		}
+		return []models.Submission{{Name: path, Files: files, Entry: entry}}, nil
+	}
+	submissions, err := services.ReadSubmissions(os.DirFS(path))
+	for i := range submissions {
+		submissions[i].Name = filepath.Join(path, submissions[i].Name)
+		if submissions[i].Files != nil {
+			submissions[i].Entry = entry
+		}
+	}
+	return submissions, err
+}
+
+// writeJSON escribe el valor indentado, para leerlo o guardarlo en un archivo
+func writeJSON(w io.Writer, v any) {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(v)
+}
+
+// writeText escribe los diagnósticos como los de un compilador
+// (archivo:línea:columna: severidad código: mensaje), el estado de cada
+// entrega y, con varias, el resumen del grupo
+func writeText(w io.Writer, result models.BatchResult) {
+	for _, r := range result.Results {
+		for _, d := range services.AllDiagnostics(r.Analysis) {
+			file := r.Name
+			if d.File != "" {
+				file = filepath.Join(r.Name, d.File)
+			}
+			fmt.Fprintln(w, services.DiagnosticText(file, d))
+		}
+
+		if r.Error != "" {
+			fmt.Fprintf(w, "%s: %s\n", r.Name, r.Error)
+			continue
+		}
+		if semantic := r.Analysis.SemanticAnalysis; semantic != nil && semantic.Status == services.SemanticSkipped {
+			fmt.Fprintf(w, "%s: análisis semántico: %s\n", r.Name, strings.ToLower(semantic.Reason[:1])+semantic.Reason[1:])
+		}
+		if r.Errors == 0 && r.Warnings == 0 {
+			fmt.Fprintf(w, "%s: sin problemas\n", r.Name)
+		} else {
+			fmt.Fprintf(w, "%s: %s, %s\n", r.Name, count(r.Errors, "error", "errores"), count(r.Warnings, "advertencia", "advertencias"))
+		}
+	}
+
+	summary := result.Summary
+	if summary.Total < 2 {
+		return
+	}
+	fmt.Fprintf(w, "\n%s: %d sin errores, %d con errores\n", count(summary.Total, "entrega", "entregas"), summary.Valid, summary.Invalid)
+	if len(summary.TopErrors) > 0 {
+		fmt.Fprintln(w, "Errores más frecuentes:")
+		for _, c := range summary.TopErrors {
+			fmt.Fprintf(w, "  %-8s %-12s %s\n", c.Code, count(c.Submissions, "entrega", "entregas"), c.Title)
+		}
+	}
+}
+
+// count escribe la cantidad con el sustantivo en singular o plural
+func count(n int, singular, plural string) string {
+	if n == 1 {
+		return "1 " + singular
+	}
+	return fmt.Sprintf("%d %s", n, plural)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles crea los archivos en una carpeta temporal y devuelve su ruta
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestAnalyzeCommand(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"ana.cpp":          "int main() { return 0; }\n",
+		"luis.cpp":         "int main() {\n    return y;\n}\n",
+		"equipo/main.cpp":  "int doble(int x);\nint main() { return doble(2); }\n",
+		"equipo/doble.cpp": "int doble(int x) { return 2 * x; }\n",
+	})
+	ana := filepath.Join(dir, "ana.cpp")
+	luis := filepath.Join(dir, "luis.cpp")
+	equipo := filepath.Join(dir, "equipo")
+
+	tests := []struct {
+		name   string
+		args   []string
+		code   int
+		out    []string
+		errOut string
+	}{
+		{"archivo sin problemas", []string{ana}, exitOK, []string{ana + ": sin problemas"}, ""},
+		{"archivo con errores", []string{luis}, exitErrors,
+			[]string{luis + ":2:12: error SEM002: Variable 'y' usada pero no declarada", luis + ": 1 error, 0 advertencias"}, ""},
+		{"carpeta de entregas", []string{dir}, exitErrors,
+			[]string{"3 entregas: 2 sin errores, 1 con errores", "Errores más frecuentes:", "SEM002"}, ""},
+		{"proyecto", []string{"-project", equipo}, exitOK, []string{equipo + ": sin problemas"}, ""},
+		{"solo léxico", []string{"-phases", "lexical", luis}, exitOK, []string{luis + ": sin problemas"}, ""},
+		{"json", []string{"-format", "json", ana}, exitOK, []string{`"results"`, `"summary"`}, ""},
+		{"sarif", []string{"-format", "sarif", luis}, exitErrors, []string{`"version": "2.1.0"`, `"ruleId": "SEM002"`}, ""},
+		{"junit", []string{"-format", "junit", luis}, exitErrors, []string{"<?xml", "<testsuites", "<failure"}, ""},
+		{"html", []string{"-format", "html", ana}, exitOK, []string{"<!DOCTYPE html>"}, ""},
+		{"sin rutas", nil, exitUsage, nil, "Uso: api analyze"},
+		{"formato desconocido", []string{"-format", "pdf", ana}, exitUsage, nil, "Formato desconocido: pdf"},
+		{"fase desconocida", []string{"-phases", "optimizar", ana}, exitUsage, nil, "Fase desconocida: optimizar"},
+		{"ruta inexistente", []string{filepath.Join(dir, "nada.cpp")}, exitUsage, nil, "nada.cpp: "},
+		{"ayuda", []string{"-h"}, exitOK, nil, "-format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			if code := analyze(tt.args, &out, &errOut); code != tt.code {
+				t.Errorf("código de salida = %d, se esperaba %d\n%s%s", code, tt.code, out.String(), errOut.String())
+			}
+			for _, want := range tt.out {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("la salida no contiene %q:\n%s", want, out.String())
+				}
+			}
+			if !strings.Contains(errOut.String(), tt.errOut) {
+				t.Errorf("stderr = %q, se esperaba %q", errOut.String(), tt.errOut)
+			}
+		})
+	}
+}
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{0, "0 errores"},
+		{1, "1 error"},
+		{2, "2 errores"},
+	}
+	for _, tt := range tests {
+		if got := count(tt.n, "error", "errores"); got != tt.want {
+			t.Errorf("count(%d) = %q, se esperaba %q", tt.n, got, tt.want)
+		}
+	}
+}
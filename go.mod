@@ -2,4 +2,4 @@ module github.com/didiercito/api-go-examen2
 
 go 1.24.3
 
-require github.com/gorilla/mux v1.8.1 // indirect
+require github.com/gorilla/mux v1.8.1
@@ -2,33 +2,109 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 	"github.com/didiercito/api-go-examen2/models"
 	"github.com/didiercito/api-go-examen2/services"
 )
 
+// AnalyzeCode responde las fases pedidas en Phases, o todas. Con
+// ?format=sarif, junit o html (o su tipo en Accept) responde en cambio ese
+// informe, igual que los endpoints de cada fase, /batch y /grade.
 func AnalyzeCode(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeCodeRequest(w, r)
+	if !ok {
+		return
+	}
+	if err := services.ValidatePhases(req.Phases); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result := services.Analyze(req)
+	if writeReport(w, r, []services.ReportEntry{programEntry(req, result)}) {
+		json.NewEncoder(w).Encode(result)
+	}
+}
+
+// Lexical responde solo el análisis léxico, sin preprocesar ni analizar
+// sintácticamente el código, para el editor que lo pide en cada tecla
+func Lexical(w http.ResponseWriter, r *http.Request) {
+	if result, ok := analyzePhase(w, r, services.PhaseLexical); ok {
+		json.NewEncoder(w).Encode(result.LexicalAnalysis)
+	}
+}
+
+// Syntax responde solo el análisis sintáctico
+func Syntax(w http.ResponseWriter, r *http.Request) {
+	if result, ok := analyzePhase(w, r, services.PhaseSyntax); ok {
+		json.NewEncoder(w).Encode(result.SyntaxAnalysis)
+	}
+}
+
+// Semantic responde solo el análisis semántico, que se omite si el código
+// tiene errores de sintaxis
+func Semantic(w http.ResponseWriter, r *http.Request) {
+	if result, ok := analyzePhase(w, r, services.PhaseSemantic); ok {
+		json.NewEncoder(w).Encode(result.SemanticAnalysis)
+	}
+}
+
+// analyzePhase analiza solo la fase indicada. Si se pidió un informe ya
+// respondió y devuelve false, como decodeCodeRequest.
+func analyzePhase(w http.ResponseWriter, r *http.Request, phase string) (models.AnalysisResult, bool) {
+	req, ok := decodeCodeRequest(w, r)
+	if !ok {
+		return models.AnalysisResult{}, false
+	}
+	req.Phases = []string{phase}
+	result := services.Analyze(req)
+	return result, writeReport(w, r, []services.ReportEntry{programEntry(req, result)})
+}
+
+// decodeCodeRequest prepara la respuesta JSON y lee el programa. Si no
+// hay nada más que hacer (OPTIONS o JSON inválido) ya respondió y
+// devuelve false.
+func decodeCodeRequest(w http.ResponseWriter, r *http.Request) (models.CodeRequest, bool) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	allowCORS(w)
 
+	var req models.CodeRequest
 	if r.Method == "OPTIONS" {
 		w.WriteHeader(http.StatusOK)
-		return
+		return req, false
 	}
-
-	var req models.CodeRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "JSON inválido", http.StatusBadRequest)
-		return
+	if !decodeJSON(w, r, maxRequestBody, &req) {
+		return req, false
 	}
+	return req, true
+}
 
-	result := models.AnalysisResult{
-		LexicalAnalysis:  services.AnalyzeLexical(req.Code),
-		SyntaxAnalysis:   services.AnalyzeSyntax(req.Code),
-		SemanticAnalysis: services.AnalyzeSemantic(req.Code),
+// maxRequestBody es el tamaño máximo del cuerpo JSON de una petición
+const maxRequestBody = 4 << 20
+
+// decodeJSON lee el cuerpo JSON en v sin aceptar más de limit bytes. Si
+// no pudo leerlo ya respondió el error y devuelve false.
+func decodeJSON(w http.ResponseWriter, r *http.Request, limit int64, v any) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
+	err := json.NewDecoder(r.Body).Decode(v)
+	var maxBytes *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytes):
+		http.Error(w, "El cuerpo de la petición supera los "+strconv.FormatInt(limit>>20, 10)+" MB",
+			http.StatusRequestEntityTooLarge)
+		return false
+	case err != nil:
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return false
 	}
+	return true
+}
 
-	json.NewEncoder(w).Encode(result)
-}
\ No newline at end of file
+// allowCORS permite llamar a la API desde cualquier origen
+func allowCORS(w http.ResponseWriter) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestRequestBodyLimit(t *testing.T) {
+	big := `{"code": "int main() { return 0; }` + strings.Repeat(" ", maxRequestBody) + `"}`
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		body    string
+		status  int
+	}{
+		{"analyze", AnalyzeCode, big, http.StatusRequestEntityTooLarge},
+		{"syntax", Syntax, big, http.StatusRequestEntityTooLarge},
+		{"run", Run, big, http.StatusRequestEntityTooLarge},
+		{"fix", Fix, big, http.StatusRequestEntityTooLarge},
+		{"cfg", ControlFlow, big, http.StatusRequestEntityTooLarge},
+		{"grade", Grade, big, http.StatusRequestEntityTooLarge},
+		{"batch", Batch, `{"submissions": [` + strings.Repeat(`{"code": "int x;"},`, maxZipUpload/18) + `]}`, http.StatusRequestEntityTooLarge},
+		{"JSON inválido", AnalyzeCode, `{"code": `, http.StatusBadRequest},
+		{"dentro del límite", AnalyzeCode, `{"code": "int main() { return 0; }"}`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.handler(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body)))
+			if w.Code != tt.status {
+				t.Errorf("estado = %d, se esperaba %d: %s", w.Code, tt.status, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestPhaseSelection(t *testing.T) {
+	const valid = `int main() { return 0; }`
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		body    string
+		status  int
+		keys    []string
+	}{
+		{"todas las fases", AnalyzeCode, `{"code": "` + valid + `"}`, http.StatusOK,
+			[]string{"lexical_analysis", "semantic_analysis", "syntax_analysis"}},
+		{"solo léxico", AnalyzeCode, `{"code": "` + valid + `", "phases": ["lexical"]}`, http.StatusOK,
+			[]string{"lexical_analysis"}},
+		{"sintáctico y semántico", AnalyzeCode, `{"code": "` + valid + `", "phases": ["syntax", "semantic"]}`, http.StatusOK,
+			[]string{"semantic_analysis", "syntax_analysis"}},
+		{"fase desconocida", AnalyzeCode, `{"code": "` + valid + `", "phases": ["optimizar"]}`, http.StatusBadRequest, nil},
+		{"endpoint léxico", Lexical, `{"code": "` + valid + `"}`, http.StatusOK,
+			[]string{"diagnostics", "summary", "tokens", "total"}},
+		{"endpoint sintáctico", Syntax, `{"code": "` + valid + `"}`, http.StatusOK,
+			[]string{"ast", "diagnostics", "is_valid"}},
+		{"endpoint semántico", Semantic, `{"code": "` + valid + `"}`, http.StatusOK,
+			[]string{"diagnostics", "functions_count", "status", "symbol_table", "variables_count"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.handler(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body)))
+			if w.Code != tt.status {
+				t.Fatalf("estado = %d, se esperaba %d: %s", w.Code, tt.status, w.Body.String())
+			}
+			if tt.keys == nil {
+				return
+			}
+			var body map[string]json.RawMessage
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("respuesta no es JSON: %v", err)
+			}
+			keys := make([]string, 0, len(body))
+			for k := range body {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			if !reflect.DeepEqual(keys, tt.keys) {
+				t.Errorf("claves = %v, se esperaba %v", keys, tt.keys)
+			}
+		})
+	}
+}
+
+func TestUnknownPhaseMessage(t *testing.T) {
+	w := httptest.NewRecorder()
+	AnalyzeCode(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"code": "int x;", "phases": ["optimizar"]}`)))
+	if !strings.Contains(w.Body.String(), "Fase desconocida: optimizar") {
+		t.Errorf("mensaje = %q, se esperaba que nombrara la fase", w.Body.String())
+	}
+}
+
+func TestSemanticSkippedOnSyntaxErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	Semantic(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"code": "int main() { return 0 }"}`)))
+	var result struct {
+		Status string `json:"status"`
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("respuesta no es JSON: %v", err)
+	}
+	if result.Status != "skipped" || !strings.Contains(result.Reason, "sintáctico") {
+		t.Errorf("estado = %q (%q), se esperaba skipped por errores de sintaxis", result.Status, result.Reason)
+	}
+}
+
+func TestPreflight(t *testing.T) {
+	for name, handler := range map[string]http.HandlerFunc{"analyze": AnalyzeCode, "lexical": Lexical, "semantic": Semantic} {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler(w, httptest.NewRequest(http.MethodOptions, "/", nil))
+			if w.Code != http.StatusOK || w.Header().Get("Access-Control-Allow-Origin") != "*" {
+				t.Errorf("estado = %d, CORS = %q", w.Code, w.Header().Get("Access-Control-Allow-Origin"))
+			}
+			if w.Body.Len() != 0 {
+				t.Errorf("cuerpo = %q, se esperaba vacío", w.Body.String())
+			}
+		})
+	}
+}
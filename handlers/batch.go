@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/didiercito/api-go-examen2/models"
+	"github.com/didiercito/api-go-examen2/services"
+)
+
+// maxZipUpload es el tamaño máximo del zip subido a /batch, y también el
+// del cuerpo JSON, que reúne las entregas de todo un grupo
+const maxZipUpload = 32 << 20
+
+// Batch analiza las entregas de un grupo. Acepta un BatchRequest en JSON
+// o un zip, como cuerpo application/zip o en el campo "file" de un
+// formulario multipart; con zip las fases se piden con ?phases=a,b. Los
+// informes (?format=sarif, junit o html) reúnen todas las entregas.
+func Batch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	allowCORS(w)
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var req models.BatchRequest
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/zip" || mediaType == "multipart/form-data" {
+		data, err := readZipUpload(w, r, mediaType)
+		if err == nil {
+			req.Submissions, err = services.ReadSubmissionsZip(data)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if phases := r.URL.Query().Get("phases"); phases != "" {
+			req.Phases = strings.Split(phases, ",")
+		}
+	} else if !decodeJSON(w, r, maxZipUpload, &req) {
+		return
+	}
+	if err := services.ValidateBatch(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result := services.AnalyzeBatch(req)
+	entries := make([]services.ReportEntry, len(result.Results))
+	for i := range entries {
+		entries[i] = services.ReportEntry{Submission: req.Submissions[i], Result: result.Results[i]}
+	}
+	if writeReport(w, r, entries) {
+		json.NewEncoder(w).Encode(result)
+	}
+}
+
+// readZipUpload lee el zip del cuerpo o del formulario
+func readZipUpload(w http.ResponseWriter, r *http.Request, mediaType string) ([]byte, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxZipUpload)
+	tooLarge := errors.New("El zip supera los " + strconv.Itoa(maxZipUpload>>20) + " MB")
+	var maxBytes *http.MaxBytesError
+
+	body := io.Reader(r.Body)
+	if mediaType == "multipart/form-data" {
+		if err := r.ParseMultipartForm(maxZipUpload); errors.As(err, &maxBytes) {
+			return nil, tooLarge
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			return nil, errors.New("Falta el archivo zip en el campo 'file'")
+		}
+		defer file.Close()
+		body = file
+	}
+	data, err := io.ReadAll(body)
+	if errors.As(err, &maxBytes) {
+		return nil, tooLarge
+	}
+	return data, err
+}
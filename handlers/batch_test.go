@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+func TestBatchUpload(t *testing.T) {
+	var zipped bytes.Buffer
+	zw := zip.NewWriter(&zipped)
+	for name, code := range map[string]string{"ana.cpp": "int main() { return 0; }", "luis.cpp": "int main() { return y; }"} {
+		f, _ := zw.Create(name)
+		f.Write([]byte(code))
+	}
+	zw.Close()
+
+	form := func(field string) (string, *bytes.Buffer) {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		f, _ := mw.CreateFormFile(field, "entregas.zip")
+		f.Write(zipped.Bytes())
+		mw.Close()
+		return mw.FormDataContentType(), &body
+	}
+	formType, formBody := form("file")
+	wrongType, wrongBody := form("archivo")
+
+	tests := []struct {
+		name        string
+		url         string
+		contentType string
+		body        *bytes.Buffer
+		status      int
+		valid       int
+		phases      []string
+	}{
+		{"json", "/batch", "application/json", bytes.NewBufferString(`{"submissions": [{"code": "int main() { return 0; }"}, {"code": "int main() { return y; }"}], "phases": ["lexical", "semantic"]}`),
+			http.StatusOK, 1, []string{"lexical_analysis", "semantic_analysis"}},
+		{"zip", "/batch", "application/zip", bytes.NewBuffer(zipped.Bytes()),
+			http.StatusOK, 1, []string{"lexical_analysis", "semantic_analysis", "syntax_analysis"}},
+		{"zip con fases", "/batch?phases=lexical", "application/zip", bytes.NewBuffer(zipped.Bytes()),
+			http.StatusOK, 2, []string{"lexical_analysis"}},
+		{"formulario", "/batch", formType, formBody,
+			http.StatusOK, 1, []string{"lexical_analysis", "semantic_analysis", "syntax_analysis"}},
+		{"formulario sin archivo", "/batch", wrongType, wrongBody, http.StatusBadRequest, 0, nil},
+		{"zip inválido", "/batch", "application/zip", bytes.NewBufferString("no es un zip"), http.StatusBadRequest, 0, nil},
+		{"sin entregas", "/batch", "application/json", bytes.NewBufferString(`{"submissions": []}`), http.StatusBadRequest, 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, tt.url, tt.body)
+			r.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			Batch(w, r)
+			if w.Code != tt.status {
+				t.Fatalf("estado = %d, se esperaba %d: %s", w.Code, tt.status, w.Body.String())
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var result models.BatchResult
+			if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+				t.Fatalf("respuesta no es JSON: %v", err)
+			}
+			if result.Summary.Total != 2 || result.Summary.Valid != tt.valid {
+				t.Errorf("resumen = %+v, se esperaban 2 entregas y %d válidas", result.Summary, tt.valid)
+			}
+			var analysis map[string]json.RawMessage
+			raw, _ := json.Marshal(result.Results[0].Analysis)
+			json.Unmarshal(raw, &analysis)
+			for _, phase := range tt.phases {
+				if _, ok := analysis[phase]; !ok {
+					t.Errorf("falta %s en %s", phase, raw)
+				}
+			}
+			if len(analysis) != len(tt.phases) {
+				t.Errorf("fases = %s, se esperaban %v", raw, tt.phases)
+			}
+		})
+	}
+}
+
+func TestBatchReportCoversAllSubmissions(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/batch?format=junit",
+		strings.NewReader(`{"submissions": [{"name": "ana.cpp", "code": "int x;"}, {"name": "luis.cpp", "code": "int main() { return y; }"}]}`))
+	w := httptest.NewRecorder()
+	Batch(w, r)
+	for _, name := range []string{"ana.cpp", "luis.cpp"} {
+		if !strings.Contains(w.Body.String(), name) {
+			t.Errorf("el informe no incluye %s:\n%s", name, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/didiercito/api-go-examen2/models"
+	"github.com/didiercito/api-go-examen2/services"
+)
+
+// ControlFlow devuelve el grafo de flujo de control de cada función. Con
+// ?format=dot o Accept: text/vnd.graphviz responde solo el texto DOT.
+func ControlFlow(w http.ResponseWriter, r *http.Request) {
+	allowCORS(w)
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var req models.CodeRequest
+	if !decodeJSON(w, r, maxRequestBody, &req) {
+		return
+	}
+
+	var result models.ControlFlowResult
+	if len(req.Files) > 0 {
+		result = services.AnalyzeControlFlowProject(req.Files, req.Entry)
+	} else {
+		result = services.AnalyzeControlFlow(req.Code)
+	}
+
+	if r.URL.Query().Get("format") == "dot" || strings.Contains(r.Header.Get("Accept"), "text/vnd.graphviz") {
+		w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+		w.Write([]byte(result.Dot))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
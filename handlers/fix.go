@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/didiercito/api-go-examen2/models"
+	"github.com/didiercito/api-go-examen2/services"
+)
+
+// Fix aplica correcciones automáticas de los diagnósticos y devuelve el
+// código corregido
+func Fix(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	allowCORS(w)
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var req models.FixRequest
+	if !decodeJSON(w, r, maxRequestBody, &req) {
+		return
+	}
+
+	json.NewEncoder(w).Encode(services.FixCode(req))
+}
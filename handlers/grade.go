@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/didiercito/api-go-examen2/models"
+	"github.com/didiercito/api-go-examen2/services"
+)
+
+// Grade califica el programa con los casos de prueba: el resultado de cada
+// caso, su diff y el puntaje total, junto con el análisis del código. Con
+// ?format=junit hay un caso JUnit por cada caso de prueba.
+func Grade(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	allowCORS(w)
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var req models.GradeRequest
+	if !decodeJSON(w, r, maxRequestBody, &req) {
+		return
+	}
+	if err := services.ValidateGrade(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result := services.Grade(req)
+	entry := programEntry(models.CodeRequest{Code: req.Code, Files: req.Files, Entry: req.Entry}, result.Analysis)
+	entry.Grade = &result
+	if writeReport(w, r, []services.ReportEntry{entry}) {
+		json.NewEncoder(w).Encode(result)
+	}
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/didiercito/api-go-examen2/models"
+	"github.com/didiercito/api-go-examen2/services"
+)
+
+// Formatos de los informes. Se piden con ?format= o con el tipo en el
+// encabezado Accept; sin ninguno se responde el JSON de siempre.
+const (
+	formatSarif = "sarif"
+	formatJUnit = "junit"
+	formatHTML  = "html"
+)
+
+var reportMediaTypes = map[string]string{
+	formatSarif: "application/sarif+json",
+	formatJUnit: "application/xml",
+	formatHTML:  "text/html",
+}
+
+// reportFormat devuelve el formato de informe pedido o "" para JSON. Del
+// encabezado Accept se toma el tipo admitido con mayor calidad (q); ante un
+// empate gana el JSON, así */* no cambia la respuesta de siempre.
+func reportFormat(r *http.Request) string {
+	format := r.URL.Query().Get("format")
+	if _, ok := reportMediaTypes[format]; ok {
+		return format
+	}
+	accept := r.Header.Get("Accept")
+	best, bestQuality := "", acceptQuality(accept, "application/json")
+	for _, format := range []string{formatSarif, formatJUnit, formatHTML} {
+		if quality := acceptQuality(accept, reportMediaTypes[format]); quality > bestQuality {
+			best, bestQuality = format, quality
+		}
+	}
+	return best
+}
+
+// acceptQuality devuelve la calidad que el encabezado Accept da al tipo: la
+// del rango más específico que lo incluye (tipo exacto, tipo/* o */*), o 0
+// si ninguno lo incluye
+func acceptQuality(accept, mediaType string) float64 {
+	kind, _, _ := strings.Cut(mediaType, "/")
+	quality, specificity := 0.0, -1
+	for _, part := range strings.Split(accept, ",") {
+		acceptType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		var matched int
+		switch acceptType {
+		case mediaType:
+			matched = 2
+		case kind + "/*":
+			matched = 1
+		case "*/*":
+			matched = 0
+		default:
+			continue
+		}
+		if matched <= specificity {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		quality, specificity = q, matched
+	}
+	return quality
+}
+
+// writeReport responde el informe de las entregas si se pidió uno y
+// devuelve false, como decodeCodeRequest cuando ya respondió; si no, no
+// escribe nada y el handler sigue con su JSON
+func writeReport(w http.ResponseWriter, r *http.Request, entries []services.ReportEntry) bool {
+	format := reportFormat(r)
+	if format == "" {
+		return true
+	}
+	w.Header().Set("Content-Type", reportMediaTypes[format]+"; charset=utf-8")
+
+	switch format {
+	case formatSarif:
+		var results []models.SubmissionResult
+		for _, e := range entries {
+			results = append(results, e.Result)
+		}
+		json.NewEncoder(w).Encode(services.SarifLog(results))
+	case formatJUnit:
+		w.Write([]byte(xml.Header))
+		encoder := xml.NewEncoder(w)
+		encoder.Indent("", "  ")
+		encoder.Encode(services.JUnitReport(entries))
+	case formatHTML:
+		services.HTMLReport(w, entries)
+	}
+	return false
+}
+
+// programEntry es el programa de una solicitud como entrega sin nombre
+func programEntry(req models.CodeRequest, analysis models.AnalysisResult) services.ReportEntry {
+	return services.ReportEntry{
+		Submission: models.Submission{Code: req.Code, Files: req.Files, Entry: req.Entry},
+		Result:     services.NewSubmissionResult("", analysis),
+	}
+}
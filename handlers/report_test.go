@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReportFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		accept string
+		want   string
+	}{
+		{"sin encabezado", "/", "", ""},
+		{"parámetro", "/?format=junit", "text/html", formatJUnit},
+		{"navegador", "/", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", formatHTML},
+		{"cualquier tipo", "/", "*/*", ""},
+		{"JSON preferido", "/", "application/xml;q=0.5, application/json", ""},
+		{"mayor calidad", "/", "text/html;q=0.2, application/sarif+json;q=0.7", formatSarif},
+		{"rechazado", "/", "application/xml;q=0, */*", ""},
+		{"solo XML", "/", "application/xml", formatJUnit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, tt.target, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			if got := reportFormat(r); got != tt.want {
+				t.Errorf("formato = %q, se esperaba %q", got, tt.want)
+			}
+		})
+	}
+}
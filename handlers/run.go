@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/didiercito/api-go-examen2/models"
+	"github.com/didiercito/api-go-examen2/services"
+)
+
+// Run ejecuta el programa con el intérprete y devuelve su salida, el
+// código de salida y los errores de compilación o de ejecución
+func Run(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	allowCORS(w)
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var req models.RunRequest
+	if !decodeJSON(w, r, maxRequestBody, &req) {
+		return
+	}
+
+	var result models.RunResult
+	if len(req.Files) > 0 {
+		result = services.RunProject(req.Files, req.Entry, req.Stdin, req.Limits)
+	} else {
+		result = services.Run(req.Code, req.Stdin, req.Limits)
+	}
+
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,215 @@
+package lsp
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/didiercito/api-go-examen2/models"
+	"github.com/didiercito/api-go-examen2/services"
+)
+
+// Mensajes de JSON-RPC 2.0. Una solicitud tiene ID y Method; una
+// notificación solo Method.
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// response lleva Result o Error, nunca los dos
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *responseError  `json:"error,omitempty"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type notification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+// Códigos de error de JSON-RPC y LSP
+const (
+	errParse          = -32700
+	errInvalidParams  = -32602
+	errMethodNotFound = -32601
+	errInvalidRequest = -32600
+)
+
+// Posiciones de LSP: líneas y caracteres desde 0, con los caracteres
+// contados en unidades de UTF-16
+type position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start position `json:"start"`
+	End   position `json:"end"`
+}
+
+type location struct {
+	URI   string   `json:"uri"`
+	Range lspRange `json:"range"`
+}
+
+type textDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+type textDocumentPositionParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+	Position     position               `json:"position"`
+}
+
+type didOpenParams struct {
+	TextDocument struct {
+		URI  string `json:"uri"`
+		Text string `json:"text"`
+	} `json:"textDocument"`
+}
+
+// didChangeParams con sincronización completa: cada cambio trae el texto
+// entero y vale el último
+type didChangeParams struct {
+	TextDocument   textDocumentIdentifier `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+type documentParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+}
+
+type diagnostic struct {
+	Range              lspRange            `json:"range"`
+	Severity           int                 `json:"severity"`
+	Code               string              `json:"code"`
+	Source             string              `json:"source"`
+	Message            string              `json:"message"`
+	RelatedInformation []relatedDiagnostic `json:"relatedInformation,omitempty"`
+}
+
+type relatedDiagnostic struct {
+	Location location `json:"location"`
+	Message  string   `json:"message"`
+}
+
+type publishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Diagnostics []diagnostic `json:"diagnostics"`
+}
+
+type hover struct {
+	Contents markupContent `json:"contents"`
+	Range    lspRange      `json:"range"`
+}
+
+type markupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+type documentSymbol struct {
+	Name           string           `json:"name"`
+	Detail         string           `json:"detail,omitempty"`
+	Kind           int              `json:"kind"`
+	Range          lspRange         `json:"range"`
+	SelectionRange lspRange         `json:"selectionRange"`
+	Children       []documentSymbol `json:"children,omitempty"`
+}
+
+type semanticTokens struct {
+	Data []int `json:"data"`
+}
+
+// Gravedad de los diagnósticos en LSP
+var severities = map[string]int{
+	services.SeverityError:   1,
+	services.SeverityWarning: 2,
+	services.SeverityInfo:    3,
+}
+
+// Tipos de símbolo de LSP (SymbolKind) para el esquema del archivo
+var symbolKinds = map[string]int{
+	services.SymbolClass:       5,
+	services.SymbolMethod:      6,
+	services.SymbolField:       8,
+	services.SymbolConstructor: 9,
+	services.SymbolFunction:    12,
+	services.SymbolVariable:    13,
+}
+
+// Leyenda de los tokens semánticos: el índice de cada tipo es el que se
+// envía en los datos
+var tokenTypes = []string{"keyword", "number", "string", "macro", "operator",
+	"variable", "parameter", "function", "method", "class", "property", "namespace"}
+
+// text es un documento abierto con sus líneas, para traducir posiciones
+type text struct {
+	lines []string
+}
+
+func newText(code string) text {
+	return text{lines: strings.Split(code, "\n")}
+}
+
+// position traduce una línea y columna del analizador (desde 1, en
+// caracteres) a una posición de LSP
+func (t text) position(line, column int) position {
+	if line < 1 {
+		return position{}
+	}
+	if line > len(t.lines) {
+		return position{Line: line - 1, Character: max(column-1, 0)}
+	}
+	units := 0
+	rest := t.lines[line-1]
+	for i := 1; i < column; i++ {
+		r, size := utf8.DecodeRuneInString(rest)
+		if size == 0 {
+			units += column - i // más allá del final de la línea
+			break
+		}
+		units += utf16Len(r)
+		rest = rest[size:]
+	}
+	return position{Line: line - 1, Character: units}
+}
+
+// location traduce una posición de LSP a línea y columna del analizador
+func (t text) location(p position) (int, int) {
+	column := 1
+	if p.Line >= 0 && p.Line < len(t.lines) {
+		units := 0
+		for _, r := range t.lines[p.Line] {
+			if units >= p.Character {
+				break
+			}
+			units += utf16Len(r)
+			column++
+		}
+	}
+	return p.Line + 1, column
+}
+
+func (t text) span(r models.Range) lspRange {
+	return lspRange{Start: t.position(r.StartLine, r.StartColumn), End: t.position(r.EndLine, r.EndColumn)}
+}
+
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
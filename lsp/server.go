@@ -0,0 +1,365 @@
+// Package lsp implementa un servidor del Language Server Protocol sobre la
+// entrada y salida estándar, para usar el analizador desde un editor.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+
+	"github.com/didiercito/api-go-examen2/models"
+	"github.com/didiercito/api-go-examen2/services"
+)
+
+// source identifica al analizador en los diagnósticos del editor
+const source = "analizador-cpp"
+
+// document es un archivo abierto en el editor con su último análisis
+type document struct {
+	text     text
+	analysis *services.Document
+}
+
+type server struct {
+	in        *bufio.Reader
+	out       io.Writer
+	documents map[string]*document
+	shutdown  bool
+}
+
+// Serve atiende al editor hasta recibir exit o el fin de la entrada. Cada
+// documento se analiza completo al abrirlo y en cada cambio.
+func Serve(in io.Reader, out io.Writer) error {
+	s := &server{in: bufio.NewReader(in), out: out, documents: make(map[string]*document)}
+	for {
+		body, err := s.read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			if err := s.fail(json.RawMessage("null"), errParse, "JSON inválido"); err != nil {
+				return err
+			}
+			continue
+		}
+		if req.Method == "exit" {
+			if !s.shutdown {
+				return errors.New("exit sin shutdown previo")
+			}
+			return nil
+		}
+		if err := s.handle(req); err != nil {
+			return err
+		}
+	}
+}
+
+// read lee un mensaje: encabezados al estilo HTTP y Content-Length bytes
+func (s *server) read() ([]byte, error) {
+	headers, err := textproto.NewReader(s.in).ReadMIMEHeader()
+	if err != nil {
+		if errors.Is(err, io.EOF) && len(headers) == 0 {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+	length, err := strconv.Atoi(headers.Get("Content-Length"))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("Content-Length inválido: %q", headers.Get("Content-Length"))
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(s.in, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// write envía un mensaje con su encabezado
+func (s *server) write(message any) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = s.out.Write(body)
+	return err
+}
+
+func (s *server) reply(id json.RawMessage, result any) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return s.write(response{JSONRPC: "2.0", ID: id, Result: data})
+}
+
+func (s *server) fail(id json.RawMessage, code int, message string) error {
+	return s.write(response{JSONRPC: "2.0", ID: id, Error: &responseError{Code: code, Message: message}})
+}
+
+// handle atiende una solicitud o notificación. Solo los errores al
+// escribir la respuesta terminan el servidor.
+func (s *server) handle(req request) error {
+	isRequest := len(req.ID) > 0
+	if s.shutdown && isRequest {
+		return s.fail(req.ID, errInvalidRequest, "El servidor se está cerrando")
+	}
+
+	var result any
+	var err error
+	switch req.Method {
+	case "initialize":
+		result = map[string]any{
+			"capabilities": map[string]any{
+				"textDocumentSync":       1, // el texto completo en cada cambio
+				"hoverProvider":          true,
+				"definitionProvider":     true,
+				"documentSymbolProvider": true,
+				"semanticTokensProvider": map[string]any{
+					"legend": map[string]any{"tokenTypes": tokenTypes, "tokenModifiers": []string{}},
+					"full":   true,
+				},
+			},
+			"serverInfo": map[string]any{"name": source},
+		}
+	case "shutdown":
+		s.shutdown = true
+	case "initialized", "$/cancelRequest", "$/setTrace":
+		return nil
+
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			return s.update(params.TextDocument.URI, params.TextDocument.Text)
+		}
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err = json.Unmarshal(req.Params, &params); err == nil && len(params.ContentChanges) > 0 {
+			changes := params.ContentChanges
+			return s.update(params.TextDocument.URI, changes[len(changes)-1].Text)
+		}
+	case "textDocument/didClose":
+		var params documentParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			delete(s.documents, params.TextDocument.URI)
+			return s.write(notification{JSONRPC: "2.0", Method: "textDocument/publishDiagnostics",
+				Params: publishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []diagnostic{}}})
+		}
+
+	case "textDocument/hover":
+		var params textDocumentPositionParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			result = s.hover(params)
+		}
+	case "textDocument/definition":
+		var params textDocumentPositionParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			result = s.definition(params)
+		}
+	case "textDocument/documentSymbol":
+		var params documentParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			result = s.symbols(params.TextDocument.URI)
+		}
+	case "textDocument/semanticTokens/full":
+		var params documentParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			result = s.semanticTokens(params.TextDocument.URI)
+		}
+
+	default:
+		if !isRequest {
+			return nil // las notificaciones desconocidas se ignoran
+		}
+		return s.fail(req.ID, errMethodNotFound, "Método no soportado: "+req.Method)
+	}
+
+	if !isRequest {
+		return nil
+	}
+	if err != nil {
+		return s.fail(req.ID, errInvalidParams, "Parámetros inválidos: "+err.Error())
+	}
+	return s.reply(req.ID, result)
+}
+
+// update analiza el nuevo texto del documento y publica sus diagnósticos
+func (s *server) update(uri, code string) error {
+	doc := &document{text: newText(code), analysis: services.AnalyzeDocument(code)}
+	s.documents[uri] = doc
+
+	diagnostics := []diagnostic{}
+	for _, d := range doc.analysis.Diagnostics {
+		diagnostics = append(diagnostics, doc.diagnostic(uri, d))
+	}
+	return s.write(notification{JSONRPC: "2.0", Method: "textDocument/publishDiagnostics",
+		Params: publishDiagnosticsParams{URI: uri, Diagnostics: diagnostics}})
+}
+
+// diagnostic convierte un diagnóstico del analizador. La sugerencia va en
+// el mensaje y los pasos del camino como información relacionada.
+func (doc *document) diagnostic(uri string, d models.Diagnostic) diagnostic {
+	result := diagnostic{
+		Range: doc.text.span(models.Range{
+			StartLine: d.StartLine, StartColumn: d.StartColumn, EndLine: d.EndLine, EndColumn: d.EndColumn,
+		}),
+		Severity: severities[d.Severity],
+		Code:     d.Code,
+		Source:   source,
+		Message:  d.Message,
+	}
+	if d.Suggestion != "" {
+		result.Message += "\nSugerencia: " + d.Suggestion
+	}
+	for _, step := range d.Path {
+		at := doc.text.position(step.Line, step.Column)
+		result.RelatedInformation = append(result.RelatedInformation, relatedDiagnostic{
+			Location: location{URI: uri, Range: lspRange{Start: at, End: at}},
+			Message:  step.Message,
+		})
+	}
+	return result
+}
+
+// hover devuelve la descripción del símbolo o null si no hay ninguno
+func (s *server) hover(params textDocumentPositionParams) *hover {
+	doc := s.documents[params.TextDocument.URI]
+	if doc == nil {
+		return nil
+	}
+	line, column := doc.text.location(params.Position)
+	text, at, ok := doc.analysis.Hover(line, column)
+	if !ok {
+		return nil
+	}
+	return &hover{Contents: markupContent{Kind: "markdown", Value: text}, Range: doc.text.span(at)}
+}
+
+// definition devuelve dónde se define el símbolo o null si no se conoce
+func (s *server) definition(params textDocumentPositionParams) *location {
+	doc := s.documents[params.TextDocument.URI]
+	if doc == nil {
+		return nil
+	}
+	line, column := doc.text.location(params.Position)
+	at, ok := doc.analysis.Definition(line, column)
+	if !ok {
+		return nil
+	}
+	return &location{URI: params.TextDocument.URI, Range: doc.text.span(at)}
+}
+
+func (s *server) symbols(uri string) []documentSymbol {
+	doc := s.documents[uri]
+	if doc == nil {
+		return []documentSymbol{}
+	}
+	return doc.outline(doc.analysis.Outline())
+}
+
+func (doc *document) outline(items []models.OutlineSymbol) []documentSymbol {
+	symbols := []documentSymbol{}
+	for _, item := range items {
+		kind := symbolKinds[item.Kind]
+		if item.Kind == services.SymbolClass && item.Detail == "struct" {
+			kind = 23
+		}
+		symbols = append(symbols, documentSymbol{
+			Name:           item.Name,
+			Detail:         item.Detail,
+			Kind:           kind,
+			Range:          doc.text.span(item.Range),
+			SelectionRange: doc.text.span(item.NameRange),
+			Children:       doc.outline(item.Children),
+		})
+	}
+	return symbols
+}
+
+// semanticTokens clasifica los tokens del lexer. Cada token son cinco
+// números relativos al anterior: línea, columna (o columna absoluta si
+// cambia la línea), longitud, tipo y modificadores.
+func (s *server) semanticTokens(uri string) semanticTokens {
+	result := semanticTokens{Data: []int{}}
+	doc := s.documents[uri]
+	if doc == nil {
+		return result
+	}
+
+	index := make(map[string]int, len(tokenTypes))
+	for i, name := range tokenTypes {
+		index[name] = i
+	}
+	previous := position{}
+	tokens := doc.analysis.Tokens
+	for i, tok := range tokens {
+		if strings.Contains(tok.Lexeme, "\n") {
+			continue // LSP no admite tokens de varias líneas por defecto
+		}
+		kind := tokenType(doc.analysis, tok)
+		if kind == "" && tok.Lexeme == "std" && i+1 < len(tokens) && tokens[i+1].Lexeme == "::" {
+			kind = "namespace"
+		}
+		if kind == "" {
+			continue
+		}
+		start := doc.text.position(tok.Line, tok.Column)
+		end := doc.text.position(tok.Line, tok.Column+len([]rune(tok.Lexeme)))
+		deltaColumn := start.Character
+		if start.Line == previous.Line {
+			deltaColumn -= previous.Character
+		}
+		result.Data = append(result.Data, start.Line-previous.Line, deltaColumn, end.Character-start.Character, index[kind], 0)
+		previous = start
+	}
+	return result
+}
+
+// tokenType devuelve el tipo de la leyenda para el token, o "" si no se
+// resalta; los identificadores según el símbolo que nombran
+func tokenType(analysis *services.Document, tok models.Token) string {
+	switch tok.Kind {
+	case services.TokenKeyword:
+		return "keyword"
+	case services.TokenNumber:
+		return "number"
+	case services.TokenString, services.TokenChar, services.TokenHeader:
+		return "string"
+	case services.TokenDirective:
+		return "macro"
+	case services.TokenSymbol:
+		switch tok.Lexeme {
+		case "(", ")", "[", "]", "{", "}", ";", ",", "::":
+			return ""
+		}
+		return "operator"
+	case services.TokenIdentifier:
+		switch analysis.IdentifierKind(tok) {
+		case services.SymbolVariable:
+			return "variable"
+		case services.SymbolParameter:
+			return "parameter"
+		case services.SymbolFunction:
+			return "function"
+		case services.SymbolMethod, services.SymbolConstructor:
+			return "method"
+		case services.SymbolClass:
+			return "class"
+		case services.SymbolField:
+			return "property"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,186 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// frame escribe un mensaje con su encabezado Content-Length
+func frame(message string) string {
+	return "Content-Length: " + strconv.Itoa(len(message)) + "\r\n\r\n" + message
+}
+
+// session envía los mensajes al servidor y devuelve lo que respondió, un
+// mensaje por elemento
+func session(t *testing.T, messages ...string) []string {
+	t.Helper()
+	var in strings.Builder
+	for _, message := range messages {
+		in.WriteString(frame(message))
+	}
+	var out bytes.Buffer
+	if err := Serve(strings.NewReader(in.String()), &out); err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+	reader := &server{in: bufio.NewReader(&out)}
+	var replies []string
+	for {
+		body, err := reader.read()
+		if errors.Is(err, io.EOF) {
+			return replies
+		}
+		if err != nil {
+			t.Fatalf("respuesta mal formada: %v", err)
+		}
+		replies = append(replies, string(body))
+	}
+}
+
+// didOpen es la notificación que abre el documento con el código
+func didOpen(uri, code string) string {
+	message, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "method": "textDocument/didOpen", "params": map[string]any{
+		"textDocument": map[string]any{"uri": uri, "languageId": "cpp", "version": 1, "text": code},
+	}})
+	return string(message)
+}
+
+const testURI = "file:///a.cpp"
+
+// El emoji ocupa dos unidades de UTF-16: las columnas posteriores de la
+// línea 3 se corren una posición respecto de los caracteres
+const testCode = "int doble(int x) { return 2 * x; }\nint main() {\n    const char* s = \"😀\"; int v = doble(2);\n    return y;\n}\n"
+
+func TestServe(t *testing.T) {
+	at := func(id, method string, line, character int) string {
+		return `{"jsonrpc":"2.0","id":` + id + `,"method":"` + method + `","params":{"textDocument":{"uri":"` + testURI +
+			`"},"position":{"line":` + strconv.Itoa(line) + `,"character":` + strconv.Itoa(character) + `}}}`
+	}
+	tests := []struct {
+		name    string
+		message string
+		want    string // JSON de la respuesta
+	}{
+		{
+			name:    "hover sobre una llamada",
+			message: at("2", "textDocument/hover", 2, 35),
+			want:    `{"jsonrpc":"2.0","id":2,"result":{"contents":{"kind":"markdown","value":"` + "```cpp\\nint doble(int x)\\n```" + `\nFunción, línea 1"},"range":{"start":{"line":2,"character":34},"end":{"line":2,"character":39}}}}`,
+		},
+		{
+			name:    "hover sin símbolo",
+			message: at("3", "textDocument/hover", 0, 0),
+			want:    `{"jsonrpc":"2.0","id":3,"result":null}`,
+		},
+		{
+			name:    "definición",
+			message: at("4", "textDocument/definition", 2, 35),
+			want:    `{"jsonrpc":"2.0","id":4,"result":{"uri":"file:///a.cpp","range":{"start":{"line":0,"character":4},"end":{"line":0,"character":9}}}}`,
+		},
+		{
+			name:    "símbolos del documento",
+			message: `{"jsonrpc":"2.0","id":5,"method":"textDocument/documentSymbol","params":{"textDocument":{"uri":"` + testURI + `"}}}`,
+			want: `{"jsonrpc":"2.0","id":5,"result":[` +
+				`{"name":"doble","detail":"int(int)","kind":12,"range":{"start":{"line":0,"character":0},"end":{"line":0,"character":34}},"selectionRange":{"start":{"line":0,"character":4},"end":{"line":0,"character":9}}},` +
+				`{"name":"main","detail":"int()","kind":12,"range":{"start":{"line":1,"character":0},"end":{"line":4,"character":1}},"selectionRange":{"start":{"line":1,"character":4},"end":{"line":1,"character":8}}}]}`,
+		},
+		{
+			name:    "método no soportado",
+			message: `{"jsonrpc":"2.0","id":6,"method":"textDocument/formatting","params":{}}`,
+			want:    `{"jsonrpc":"2.0","id":6,"error":{"code":-32601,"message":"Método no soportado: textDocument/formatting"}}`,
+		},
+		{
+			name:    "parámetros inválidos",
+			message: `{"jsonrpc":"2.0","id":7,"method":"textDocument/hover","params":[]}`,
+			want:    `{"jsonrpc":"2.0","id":7,"error":{"code":-32602,"message":"Parámetros inválidos: json: cannot unmarshal array into Go value of type lsp.textDocumentPositionParams"}}`,
+		},
+		{
+			name:    "JSON inválido",
+			message: `{"jsonrpc":`,
+			want:    `{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"JSON inválido"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replies := session(t, didOpen(testURI, testCode), tt.message)
+			if len(replies) != 2 {
+				t.Fatalf("%d respuestas, se esperaban los diagnósticos y una respuesta: %v", len(replies), replies)
+			}
+			if replies[1] != tt.want {
+				t.Errorf("respuesta =\n%s\nse esperaba\n%s", replies[1], tt.want)
+			}
+		})
+	}
+}
+
+func TestPublishDiagnostics(t *testing.T) {
+	replies := session(t, didOpen(testURI, testCode))
+	var published struct {
+		Method string                   `json:"method"`
+		Params publishDiagnosticsParams `json:"params"`
+	}
+	if len(replies) != 1 || json.Unmarshal([]byte(replies[0]), &published) != nil {
+		t.Fatalf("respuestas = %v", replies)
+	}
+	var got []string
+	for _, d := range published.Params.Diagnostics {
+		got = append(got, d.Code+" "+strconv.Itoa(d.Range.Start.Line)+":"+strconv.Itoa(d.Range.Start.Character))
+	}
+	// v está después del emoji: carácter 30 en UTF-16, columna 30 del analizador
+	want := []string{"SEM002 3:11", "SEM004 2:16", "SEM004 2:30"}
+	if published.Method != "textDocument/publishDiagnostics" || strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("%s %v, se esperaba %v", published.Method, got, want)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	replies := session(t,
+		`{"jsonrpc":"2.0","id":1,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"textDocument/documentSymbol","params":{"textDocument":{"uri":"`+testURI+`"}}}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"shutdown"}`,
+	)
+	want := []string{
+		`{"jsonrpc":"2.0","id":1,"result":null}`,
+		`{"jsonrpc":"2.0","id":2,"error":{"code":-32600,"message":"El servidor se está cerrando"}}`,
+	}
+	if strings.Join(replies, "\n") != strings.Join(want, "\n") {
+		t.Errorf("respuestas =\n%s\nse esperaba\n%s", strings.Join(replies, "\n"), strings.Join(want, "\n"))
+	}
+
+	var out bytes.Buffer
+	if err := Serve(strings.NewReader(frame(`{"jsonrpc":"2.0","method":"exit"}`)), &out); err == nil {
+		t.Errorf("exit sin shutdown no informó un error")
+	}
+}
+
+func TestTextPositions(t *testing.T) {
+	doc := newText("abc\nañ😀x\n")
+	tests := []struct {
+		line, column int
+		want         position
+	}{
+		{1, 1, position{0, 0}},
+		{1, 4, position{0, 3}},
+		{2, 3, position{1, 2}},
+		{2, 4, position{1, 4}}, // después del emoji, que ocupa dos unidades
+		{2, 7, position{1, 7}}, // más allá del final de la línea
+	}
+
+	for _, tt := range tests {
+		got := doc.position(tt.line, tt.column)
+		if got != tt.want {
+			t.Errorf("position(%d, %d) = %v, se esperaba %v", tt.line, tt.column, got, tt.want)
+		}
+		if tt.column <= 5 {
+			if line, column := doc.location(got); line != tt.line || column != tt.column {
+				t.Errorf("location(%v) = %d, %d, se esperaba %d, %d", got, line, column, tt.line, tt.column)
+			}
+		}
+	}
+}
@@ -1,17 +1,104 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"os"
 	"github.com/didiercito/api-go-examen2/handlers"
+	"github.com/didiercito/api-go-examen2/lsp"
+	"github.com/didiercito/api-go-examen2/services"
 	"github.com/gorilla/mux"
 )
 
 func main() {
+	// Catálogo propio de la biblioteca estándar (opcional)
+	if path := os.Getenv("STDLIB_CATALOGUE"); path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := services.LoadStdlibCatalogue(file); err != nil {
+			log.Fatalf("Catálogo %s inválido: %v", path, err)
+		}
+		file.Close()
+		log.Println("📚 Catálogo de la biblioteca estándar:", path)
+	}
+
+	// Sin argumentos se levanta el servidor, como antes de los comandos
+	command, args := "serve", []string{}
+	if len(os.Args) > 1 {
+		command, args = os.Args[1], os.Args[2:]
+	}
+	switch command {
+	case "serve":
+		serve(args)
+	case "analyze":
+		os.Exit(analyze(args, os.Stdout, os.Stderr))
+	case "lsp":
+		// El editor se comunica por la entrada y salida estándar; los
+		// mensajes del log van a stderr y no se mezclan con el protocolo
+		if err := lsp.Serve(os.Stdin, os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+	case "help", "-h", "-help", "--help":
+		usage(os.Stdout)
+	default:
+		fmt.Fprintf(os.Stderr, "Comando desconocido: %s\n\n", command)
+		usage(os.Stderr)
+		os.Exit(exitUsage)
+	}
+}
+
+// usage describe los comandos disponibles
+func usage(w io.Writer) {
+	fmt.Fprint(w, `Uso: api [comando] [opciones]
+
+Comandos:
+  serve [-addr :8080]        levanta el servidor HTTP (por defecto)
+  analyze [opciones] RUTA... analiza archivos o carpetas de entregas
+  lsp                        atiende a un editor por la entrada y salida estándar
+
+Use "api analyze -h" para ver las opciones del análisis.
+`)
+}
+
+// serve levanta el servidor HTTP con todos los endpoints
+func serve(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", ":8080", "dirección en la que escucha el servidor")
+	flags.Parse(args)
+
+	// Compilador real para /grade (opcional, solo en un entorno aislado)
+	if name := os.Getenv("GRADER_CXX"); name != "" {
+		if err := services.EnableCompiler(name); err != nil {
+			log.Fatalf("Compilador %s no disponible: %v", name, err)
+		}
+		log.Println("🛠️  Compilador para /grade:", name)
+	}
+
 	r := mux.NewRouter()
 	r.HandleFunc("/analyze", handlers.AnalyzeCode).Methods("POST", "OPTIONS")
+	r.HandleFunc("/lexical", handlers.Lexical).Methods("POST", "OPTIONS")
+	r.HandleFunc("/syntax", handlers.Syntax).Methods("POST", "OPTIONS")
+	r.HandleFunc("/semantic", handlers.Semantic).Methods("POST", "OPTIONS")
+	r.HandleFunc("/batch", handlers.Batch).Methods("POST", "OPTIONS")
+	r.HandleFunc("/cfg", handlers.ControlFlow).Methods("POST", "OPTIONS")
+	r.HandleFunc("/run", handlers.Run).Methods("POST", "OPTIONS")
+	r.HandleFunc("/grade", handlers.Grade).Methods("POST", "OPTIONS")
+	r.HandleFunc("/fix", handlers.Fix).Methods("POST", "OPTIONS")
 	
-	log.Println("🚀 Servidor iniciado en puerto 8080")
-	log.Println("📡 Endpoint: POST /analyze")
-	log.Fatal(http.ListenAndServe(":8080", r))
-}
\ No newline at end of file
+	log.Println("🚀 Servidor iniciado en", *addr)
+	log.Println("📡 Endpoint: POST /analyze (phases: lexical, syntax, semantic)")
+	log.Println("📡 Endpoint: POST /lexical")
+	log.Println("📡 Endpoint: POST /syntax")
+	log.Println("📡 Endpoint: POST /semantic")
+	log.Println("📡 Endpoint: POST /batch (JSON o zip)")
+	log.Println("📡 Endpoint: POST /cfg (JSON o ?format=dot)")
+	log.Println("📡 Endpoint: POST /run")
+	log.Println("📡 Endpoint: POST /grade")
+	log.Println("📡 Endpoint: POST /fix")
+	log.Fatal(http.ListenAndServe(*addr, r))
+}
@@ -0,0 +1,18 @@
+package models
+
+// SyntaxNode es un nodo del árbol sintáctico. Todos los nodos comparten la
+// misma forma para que el árbol se pueda serializar y recorrer de manera
+// genérica; el significado de Value, Type, Operator y de cada hijo depende
+// de Kind (ver services/ast.go).
+type SyntaxNode struct {
+	Kind      string        `json:"kind"`
+	Value     string        `json:"value,omitempty"`
+	Type      string        `json:"type,omitempty"`
+	Operator  string        `json:"operator,omitempty"`
+	File      string        `json:"file,omitempty"`
+	Line      int           `json:"line"`
+	Column    int           `json:"column"`
+	EndLine   int           `json:"end_line"`
+	EndColumn int           `json:"end_column"`
+	Children  []*SyntaxNode `json:"children,omitempty"`
+}
@@ -0,0 +1,54 @@
+package models
+
+// BatchRequest son las entregas de un grupo que se analizan juntas, con
+// las mismas fases para todas (Phases sigue las reglas de CodeRequest)
+type BatchRequest struct {
+	Submissions []Submission `json:"submissions"`
+	Phases      []string     `json:"phases,omitempty"`
+}
+
+// Submission es la entrega de un alumno: el código de un solo archivo o un
+// proyecto de varios, como en CodeRequest
+type Submission struct {
+	Name  string            `json:"name"`
+	Code  string            `json:"code,omitempty"`
+	Files map[string]string `json:"files,omitempty"`
+	Entry string            `json:"entry,omitempty"`
+}
+
+// BatchResult tiene el resultado de cada entrega, en el orden recibido, y
+// el resumen del grupo
+type BatchResult struct {
+	Results []SubmissionResult `json:"results"`
+	Summary BatchSummary       `json:"summary"`
+}
+
+// SubmissionResult es el análisis de una entrega. Valid indica que
+// ninguna de las fases encontró errores. Error solo se indica si el
+// análisis no pudo terminar.
+type SubmissionResult struct {
+	Name     string         `json:"name"`
+	Valid    bool           `json:"valid"`
+	Errors   int            `json:"errors"`
+	Warnings int            `json:"warnings"`
+	Error    string         `json:"error,omitempty"`
+	Analysis AnalysisResult `json:"analysis"`
+}
+
+// BatchSummary resume el grupo: cuántas entregas son válidas y los códigos
+// de error más frecuentes
+type BatchSummary struct {
+	Total     int         `json:"total"`
+	Valid     int         `json:"valid"`
+	Invalid   int         `json:"invalid"`
+	TopErrors []CodeCount `json:"top_errors"`
+}
+
+// CodeCount cuenta un código de error: en cuántas entregas aparece y
+// cuántas veces en total
+type CodeCount struct {
+	Code        string `json:"code"`
+	Title       string `json:"title"`
+	Submissions int    `json:"submissions"`
+	Count       int    `json:"count"`
+}
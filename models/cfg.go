@@ -0,0 +1,50 @@
+package models
+
+// ControlFlowResult contiene el grafo de flujo de control de cada función
+// del programa y su representación en formato DOT de Graphviz
+type ControlFlowResult struct {
+	Functions   []ControlFlowGraph `json:"functions"`
+	Dot         string             `json:"dot"`
+	Diagnostics []Diagnostic       `json:"diagnostics"`
+}
+
+// ControlFlowGraph es el grafo de una función. Los bloques Entry y Exit
+// existen siempre y no contienen sentencias.
+type ControlFlowGraph struct {
+	Function string        `json:"function"`
+	File     string        `json:"file,omitempty"`
+	Line     int           `json:"line"`
+	Entry    int           `json:"entry"`
+	Exit     int           `json:"exit"`
+	Blocks   []BasicBlock  `json:"blocks"`
+	Edges    []ControlEdge `json:"edges"`
+}
+
+// BasicBlock es una secuencia de sentencias que se ejecutan siempre juntas.
+// Label describe el bloque cuando empieza una construcción (case 1, for...).
+type BasicBlock struct {
+	ID         int            `json:"id"`
+	Kind       string         `json:"kind"`
+	Label      string         `json:"label,omitempty"`
+	Reachable  bool           `json:"reachable"`
+	Statements []BlockElement `json:"statements"`
+}
+
+// BlockElement es una sentencia o condición dentro de un bloque
+type BlockElement struct {
+	Kind   string `json:"kind"`
+	Text   string `json:"text"`
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+// ControlEdge es un arco entre bloques. Kind indica por qué se sigue:
+// true, false, back, break, continue, return, case, default, fallthrough
+// o vacío para la continuación normal.
+type ControlEdge struct {
+	From  int    `json:"from"`
+	To    int    `json:"to"`
+	Kind  string `json:"kind,omitempty"`
+	Label string `json:"label,omitempty"`
+}
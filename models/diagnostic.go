@@ -0,0 +1,53 @@
+package models
+
+// Diagnostic es un error o advertencia producido por cualquiera de las fases
+// del análisis. Las líneas y columnas empiezan en 1; el final es exclusivo.
+// File solo se indica en proyectos de varios archivos.
+type Diagnostic struct {
+	Phase       string `json:"phase"`
+	Code        string `json:"code"`
+	Severity    string `json:"severity"`
+	Message     string `json:"message"`
+	File        string `json:"file,omitempty"`
+	StartLine   int    `json:"start_line"`
+	StartColumn int    `json:"start_column"`
+	EndLine     int    `json:"end_line"`
+	EndColumn   int    `json:"end_column"`
+	Suggestion  string `json:"suggestion,omitempty"`
+
+	// Path son los pasos de una ejecución que produce el problema, en
+	// orden: el camino del análisis de flujo de datos o la pila de
+	// llamadas de un error de ejecución
+	Path []PathStep `json:"path,omitempty"`
+
+	// Fixes son las correcciones que se pueden aplicar automáticamente;
+	// la primera es la recomendada
+	Fixes []Fix `json:"fixes,omitempty"`
+}
+
+// PathStep es un paso de la ejecución descrita en Diagnostic.Path
+type PathStep struct {
+	Message string `json:"message"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+}
+
+// Fix es una corrección de un diagnóstico: un título para mostrar y las
+// ediciones que la aplican sobre el código original
+type Fix struct {
+	Title string     `json:"title"`
+	Edits []TextEdit `json:"edits"`
+}
+
+// TextEdit reemplaza el texto entre dos posiciones por NewText. Las
+// posiciones siguen las reglas de Diagnostic; si el inicio y el final
+// coinciden, la edición es una inserción.
+type TextEdit struct {
+	File        string `json:"file,omitempty"`
+	StartLine   int    `json:"start_line"`
+	StartColumn int    `json:"start_column"`
+	EndLine     int    `json:"end_line"`
+	EndColumn   int    `json:"end_column"`
+	NewText     string `json:"new_text"`
+}
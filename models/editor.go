@@ -0,0 +1,22 @@
+package models
+
+// Range es un fragmento del código; sigue las reglas de posiciones de
+// Diagnostic
+type Range struct {
+	StartLine   int `json:"start_line"`
+	StartColumn int `json:"start_column"`
+	EndLine     int `json:"end_line"`
+	EndColumn   int `json:"end_column"`
+}
+
+// OutlineSymbol es una declaración del esquema del archivo: funciones,
+// clases con sus miembros y variables globales. Range abarca la
+// declaración completa y NameRange solo su nombre.
+type OutlineSymbol struct {
+	Name      string          `json:"name"`
+	Kind      string          `json:"kind"`
+	Detail    string          `json:"detail,omitempty"`
+	Range     Range           `json:"range"`
+	NameRange Range           `json:"name_range"`
+	Children  []OutlineSymbol `json:"children,omitempty"`
+}
@@ -0,0 +1,23 @@
+package models
+
+// FixRequest pide aplicar correcciones a un programa. Con Fixes se
+// aplican exactamente esas, tal como las devolvió el análisis; si se
+// omite, se aplica la primera corrección de cada diagnóstico cuyo código
+// esté en Codes, o de todos si Codes también se omite.
+type FixRequest struct {
+	Code  string            `json:"code"`
+	Files map[string]string `json:"files,omitempty"`
+	Entry string            `json:"entry,omitempty"`
+	Fixes []Fix             `json:"fixes,omitempty"`
+	Codes []string          `json:"codes,omitempty"`
+}
+
+// FixResult es el código corregido: Code con un solo archivo o Files en un
+// proyecto. Skipped son las correcciones que no se aplicaron porque
+// se superponen con otra ya aplicada o no corresponden al código.
+type FixResult struct {
+	Code    string            `json:"code"`
+	Files   map[string]string `json:"files,omitempty"`
+	Applied []Fix             `json:"applied"`
+	Skipped []Fix             `json:"skipped"`
+}
@@ -0,0 +1,66 @@
+package models
+
+// GradeRequest es un programa y los casos de prueba con los que se
+// califica. Engine elige cómo se ejecuta: interpreter, compiler (solo si
+// el servidor tiene un compilador habilitado) o vacío para usar el
+// compilador si está disponible y si no el intérprete. Se admiten hasta
+// 100 casos, que entre todos no pueden durar más de 60 s.
+type GradeRequest struct {
+	Code   string            `json:"code"`
+	Files  map[string]string `json:"files,omitempty"`
+	Entry  string            `json:"entry,omitempty"`
+	Tests  []TestCase        `json:"tests"`
+	Engine string            `json:"engine,omitempty"`
+	Limits RunLimits         `json:"limits,omitempty"`
+}
+
+// TestCase es una entrada y la salida esperada. Whitespace decide cómo se
+// comparan los espacios: trailing (por defecto) ignora los espacios al
+// final de cada línea y las líneas vacías finales, exact compara byte a
+// byte e ignore compara solo las palabras. Con Tolerance > 0 las palabras
+// numéricas se comparan con esa tolerancia absoluta o relativa. Points
+// vale 1 si se omite.
+type TestCase struct {
+	Name       string   `json:"name,omitempty"`
+	Stdin      string   `json:"stdin"`
+	Expected   string   `json:"expected_stdout"`
+	Whitespace string   `json:"whitespace,omitempty"`
+	Tolerance  float64  `json:"tolerance,omitempty"`
+	IgnoreCase bool     `json:"ignore_case,omitempty"`
+	Points     *float64 `json:"points,omitempty"`
+}
+
+// GradeResult reúne el análisis del programa y el resultado de cada caso.
+// Status es graded, compile_error o unsupported. Con compile_error ningún
+// caso se ejecuta y CompilerOutput tiene los mensajes del compilador real;
+// con unsupported el programa usa algo que el intérprete no admite y los
+// casos no se ejecutan ni se cobran como errores del alumno.
+type GradeResult struct {
+	Analysis       AnalysisResult `json:"analysis"`
+	Engine         string         `json:"engine"`
+	Status         string         `json:"status"`
+	CompilerOutput string         `json:"compiler_output,omitempty"`
+	Cases          []CaseResult   `json:"cases"`
+	Passed         int            `json:"passed"`
+	Total          int            `json:"total"`
+	Score          float64        `json:"score"`
+	MaxScore       float64        `json:"max_score"`
+}
+
+// CaseResult es la calificación de un caso. Status es accepted,
+// wrong_answer, runtime_error, limit_exceeded, compile_error o
+// unsupported. Diff
+// compara las líneas esperadas (-) con las obtenidas (+).
+type CaseResult struct {
+	Name        string       `json:"name"`
+	Status      string       `json:"status"`
+	Passed      bool         `json:"passed"`
+	Points      float64      `json:"points"`
+	MaxPoints   float64      `json:"max_points"`
+	Message     string       `json:"message,omitempty"`
+	Stdout      string       `json:"stdout"`
+	Stderr      string       `json:"stderr,omitempty"`
+	ExitCode    int          `json:"exit_code"`
+	Diff        string       `json:"diff,omitempty"`
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
+}
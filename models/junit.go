@@ -0,0 +1,56 @@
+package models
+
+import "encoding/xml"
+
+// Tipos del formato JUnit XML que leen los tableros de pruebas (Jenkins,
+// GitLab, GitHub Actions...), solo con los atributos que se usan
+
+type JUnitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Suites   []JUnitTestSuite `xml:"testsuite"`
+}
+
+type JUnitTestSuite struct {
+	Name       string           `xml:"name,attr"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	Errors     int              `xml:"errors,attr"`
+	Properties *JUnitProperties `xml:"properties,omitempty"`
+	Cases      []JUnitTestCase  `xml:"testcase"`
+	SystemOut  *JUnitOutput     `xml:"system-out,omitempty"`
+}
+
+type JUnitProperties struct {
+	Properties []JUnitProperty `xml:"property"`
+}
+
+type JUnitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// JUnitTestCase pasa si no tiene Failure (el resultado no es el esperado)
+// ni Error (no se pudo terminar)
+type JUnitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Failure   *JUnitFailure `xml:"failure,omitempty"`
+	Error     *JUnitFailure `xml:"error,omitempty"`
+	SystemOut *JUnitOutput  `xml:"system-out,omitempty"`
+}
+
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",cdata"`
+}
+
+// JUnitOutput es un texto de varias líneas, que va como CDATA para que se
+// lea tal cual en el XML
+type JUnitOutput struct {
+	Text string `xml:",cdata"`
+}
@@ -1,5 +1,12 @@
 package models
 
+// CodeRequest es el programa a analizar: el código de un solo archivo o,
+// con Files, un proyecto de varios archivos (nombre → contenido) que se
+// analiza a partir de Entry. Phases elige las fases de /analyze (lexical,
+// syntax, semantic); vacío son todas.
 type CodeRequest struct {
-	Code string `json:"code"`
+	Code   string            `json:"code"`
+	Files  map[string]string `json:"files,omitempty"`
+	Entry  string            `json:"entry,omitempty"`
+	Phases []string          `json:"phases,omitempty"`
 }
\ No newline at end of file
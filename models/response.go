@@ -1,23 +1,49 @@
 package models
 
+// AnalysisResult tiene el resultado de cada fase pedida; las demás se omiten
 type AnalysisResult struct {
-	LexicalAnalysis  LexicalResult  `json:"lexical_analysis"`
-	SyntaxAnalysis   SyntaxResult   `json:"syntax_analysis"`
-	SemanticAnalysis SemanticResult `json:"semantic_analysis"`
+	LexicalAnalysis  *LexicalResult  `json:"lexical_analysis,omitempty"`
+	SyntaxAnalysis   *SyntaxResult   `json:"syntax_analysis,omitempty"`
+	SemanticAnalysis *SemanticResult `json:"semantic_analysis,omitempty"`
 }
 
 type LexicalResult struct {
-	Summary map[string]int `json:"summary"`
-	Total   int            `json:"total"`
+	Summary     map[string]int `json:"summary"`
+	Tokens      []Token        `json:"tokens"`
+	Total       int            `json:"total"`
+	Diagnostics []Diagnostic   `json:"diagnostics"`
+}
+
+// Token producido por el scanner, con su posición en el código fuente
+type Token struct {
+	Kind   string `json:"kind"`
+	Lexeme string `json:"lexeme"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Offset int    `json:"offset"`
+	File   string `json:"file,omitempty"`
+
+	// Solo en tokens producidos al expandir una macro: Line y Column
+	// indican dónde se usó la macro y Origin dónde está el token en el #define
+	Macro        string `json:"macro,omitempty"`
+	OriginLine   int    `json:"origin_line,omitempty"`
+	OriginColumn int    `json:"origin_column,omitempty"`
 }
 
 type SyntaxResult struct {
-	IsValid bool     `json:"is_valid"`
-	Errors  []string `json:"errors"`
+	IsValid     bool         `json:"is_valid"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+	AST         *SyntaxNode  `json:"ast"`
 }
 
 type SemanticResult struct {
-	Variables int      `json:"variables_count"`
-	Functions int      `json:"functions_count"`
-	Errors    []string `json:"errors"`
+	Variables   int          `json:"variables_count"`
+	Functions   int          `json:"functions_count"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+	SymbolTable SymbolTable  `json:"symbol_table"`
+
+	// Status indica si el análisis se hizo (analyzed) o se omitió
+	// (skipped) porque el código tiene errores de sintaxis; Reason lo explica
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
 }
\ No newline at end of file
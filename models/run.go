@@ -0,0 +1,40 @@
+package models
+
+// RunRequest es un programa para ejecutar con el intérprete: el código de
+// un solo archivo o un proyecto (ver CodeRequest), la entrada estándar y
+// límites opcionales, que solo pueden ser menores que los del servidor
+type RunRequest struct {
+	Code   string            `json:"code"`
+	Files  map[string]string `json:"files,omitempty"`
+	Entry  string            `json:"entry,omitempty"`
+	Stdin  string            `json:"stdin"`
+	Limits RunLimits         `json:"limits,omitempty"`
+}
+
+// RunLimits acota la ejecución. Un valor 0 usa el límite por defecto.
+// MaxSteps solo se aplica en el intérprete. TimeLimitMS es tiempo real;
+// si se omite, el intérprete usa el máximo del servidor, porque es más
+// lento que el programa compilado.
+type RunLimits struct {
+	MaxSteps    int64 `json:"max_steps,omitempty"`
+	MaxMemoryKB int64 `json:"max_memory_kb,omitempty"`
+	MaxOutputKB int64 `json:"max_output_kb,omitempty"`
+	TimeLimitMS int64 `json:"time_limit_ms,omitempty"`
+}
+
+// RunResult es el resultado de una ejecución. Status es ok,
+// compile_error (el programa tiene errores y no se ejecuta),
+// runtime_error o limit_exceeded. ExitCode imita al proceso real: el
+// valor de main o de exit(), 136 para una división por cero, 139 para un
+// acceso inválido a memoria, 134 para una excepción no capturada y 137
+// cuando se supera un límite. Diagnostics contiene los errores de
+// compilación o el error de ejecución, con la pila de llamadas en Path.
+type RunResult struct {
+	Status      string       `json:"status"`
+	Stdout      string       `json:"stdout"`
+	Stderr      string       `json:"stderr"`
+	ExitCode    int          `json:"exit_code"`
+	Steps       int64        `json:"steps"`
+	MemoryKB    int64        `json:"memory_kb"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
@@ -0,0 +1,118 @@
+package models
+
+// Tipos del formato SARIF 2.1.0, solo con las propiedades que se usan.
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+
+type SarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []SarifRun `json:"runs"`
+}
+
+type SarifRun struct {
+	Tool        SarifTool         `json:"tool"`
+	Invocations []SarifInvocation `json:"invocations"`
+	Results     []SarifResult     `json:"results"`
+	ColumnKind  string            `json:"columnKind"`
+}
+
+type SarifTool struct {
+	Driver SarifDriver `json:"driver"`
+}
+
+type SarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []SarifRule `json:"rules"`
+}
+
+// SarifRule describe un código de diagnóstico
+type SarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     SarifMessage       `json:"shortDescription"`
+	DefaultConfiguration SarifConfiguration `json:"defaultConfiguration"`
+	Properties           map[string]string  `json:"properties,omitempty"`
+}
+
+type SarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+// SarifInvocation informa si el análisis terminó y, como notificaciones,
+// las fases que se omitieron o las entregas que no se pudieron analizar
+type SarifInvocation struct {
+	ExecutionSuccessful bool                `json:"executionSuccessful"`
+	Notifications       []SarifNotification `json:"toolExecutionNotifications,omitempty"`
+}
+
+type SarifNotification struct {
+	Level     string          `json:"level"`
+	Message   SarifMessage    `json:"message"`
+	Locations []SarifLocation `json:"locations,omitempty"`
+}
+
+type SarifResult struct {
+	RuleID     string            `json:"ruleId"`
+	RuleIndex  int               `json:"ruleIndex"`
+	Level      string            `json:"level"`
+	Message    SarifMessage      `json:"message"`
+	Locations  []SarifLocation   `json:"locations"`
+	CodeFlows  []SarifCodeFlow   `json:"codeFlows,omitempty"`
+	Fixes      []SarifFix        `json:"fixes,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type SarifMessage struct {
+	Text string `json:"text"`
+}
+
+type SarifLocation struct {
+	PhysicalLocation SarifPhysicalLocation `json:"physicalLocation"`
+	Message          *SarifMessage         `json:"message,omitempty"`
+}
+
+type SarifPhysicalLocation struct {
+	ArtifactLocation SarifArtifactLocation `json:"artifactLocation"`
+	Region           *SarifRegion          `json:"region,omitempty"`
+}
+
+type SarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// SarifRegion usa las mismas posiciones que Diagnostic: desde 1, en
+// caracteres (columnKind unicodeCodePoints) y con el final exclusivo
+type SarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+// SarifCodeFlow es el camino de Diagnostic.Path
+type SarifCodeFlow struct {
+	ThreadFlows []SarifThreadFlow `json:"threadFlows"`
+}
+
+type SarifThreadFlow struct {
+	Locations []SarifThreadFlowLocation `json:"locations"`
+}
+
+type SarifThreadFlowLocation struct {
+	Location SarifLocation `json:"location"`
+}
+
+type SarifFix struct {
+	Description     SarifMessage          `json:"description"`
+	ArtifactChanges []SarifArtifactChange `json:"artifactChanges"`
+}
+
+type SarifArtifactChange struct {
+	ArtifactLocation SarifArtifactLocation `json:"artifactLocation"`
+	Replacements     []SarifReplacement    `json:"replacements"`
+}
+
+type SarifReplacement struct {
+	DeletedRegion   SarifRegion   `json:"deletedRegion"`
+	InsertedContent *SarifMessage `json:"insertedContent,omitempty"`
+}
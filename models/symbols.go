@@ -0,0 +1,30 @@
+package models
+
+// SymbolTable contiene todos los ámbitos del programa. Los identificadores
+// de ámbito empiezan en 1; Parent es 0 para el ámbito global.
+type SymbolTable struct {
+	Scopes []Scope `json:"scopes"`
+}
+
+type Scope struct {
+	ID      int      `json:"id"`
+	Kind    string   `json:"kind"`
+	Name    string   `json:"name,omitempty"`
+	Parent  int      `json:"parent"`
+	File    string   `json:"file,omitempty"`
+	Line    int      `json:"line"`
+	Symbols []Symbol `json:"symbols"`
+}
+
+type Symbol struct {
+	Name        string `json:"name"`
+	Kind        string `json:"kind"`
+	Type        string `json:"type"`
+	Value       string `json:"value,omitempty"` // valor de las constantes enteras conocido al compilar
+	File        string `json:"file,omitempty"`
+	Line        int    `json:"line"`
+	Column      int    `json:"column"`
+	Used        bool   `json:"used"`
+	Initialized bool   `json:"initialized"`
+	Access      string `json:"access,omitempty"`
+}
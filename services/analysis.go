@@ -0,0 +1,209 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+// Estados del análisis semántico (SemanticResult.Status)
+const (
+	SemanticAnalyzed = "analyzed"
+	SemanticSkipped  = "skipped"
+)
+
+// ValidatePhases comprueba que las fases pedidas existan
+func ValidatePhases(phases []string) error {
+	for _, phase := range phases {
+		switch phase {
+		case PhaseLexical, PhaseSyntax, PhaseSemantic:
+		default:
+			return errors.New("Fase desconocida: " + phase)
+		}
+	}
+	return nil
+}
+
+// Analyze ejecuta las fases pedidas del análisis, o todas si no se pide
+// ninguna. Con errores léxicos o de sintaxis el análisis semántico se
+// omite: el árbol está incompleto y solo produciría errores derivados de
+// ellos.
+func Analyze(req models.CodeRequest) models.AnalysisResult {
+	wanted := make(map[string]bool)
+	for _, phase := range req.Phases {
+		wanted[phase] = true
+	}
+	all := len(wanted) == 0
+	project := len(req.Files) > 0
+
+	var result models.AnalysisResult
+	if all || wanted[PhaseLexical] {
+		var lexical models.LexicalResult
+		if project {
+			lexical = AnalyzeLexicalProject(req.Files)
+		} else {
+			lexical = AnalyzeLexical(req.Code)
+		}
+		result.LexicalAnalysis = &lexical
+	}
+	if !all && !wanted[PhaseSyntax] && !wanted[PhaseSemantic] {
+		return result
+	}
+
+	var syntax models.SyntaxResult
+	var source *sourceFiles
+	if project {
+		syntax, source = analyzeSyntaxProject(req.Files, req.Entry)
+	} else {
+		syntax = AnalyzeSyntax(req.Code)
+	}
+	if all || wanted[PhaseSyntax] {
+		result.SyntaxAnalysis = &syntax
+	}
+	if !all && !wanted[PhaseSemantic] {
+		return result
+	}
+
+	lexical := result.LexicalAnalysis
+	if lexical == nil {
+		var diagnostics []models.Diagnostic
+		if project {
+			diagnostics = AnalyzeLexicalProject(req.Files).Diagnostics
+		} else {
+			_, diagnostics = Tokenize(req.Code)
+		}
+		lexical = &models.LexicalResult{Diagnostics: diagnostics}
+	}
+	var semantic models.SemanticResult
+	lexicalCount, syntaxCount := len(lexicalErrors(lexical.Diagnostics, source)), syntaxErrors(syntax)
+	switch {
+	case lexicalCount > 0 || syntaxCount > 0:
+		semantic = skippedSemantic(lexicalCount, syntaxCount)
+	case project:
+		semantic = AnalyzeSemanticProject(req.Files, req.Entry)
+	default:
+		semantic = AnalyzeSemantic(req.Code)
+	}
+	result.SemanticAnalysis = &semantic
+	return result
+}
+
+// analyzeProgram hace el análisis completo de Analyze y prepara el
+// programa para el intérprete con el mismo árbol y el mismo análisis
+// semántico. En un proyecto el resultado conserva las líneas de la unidad
+// de traducción, que el intérprete necesita: relocate las pasa a las de
+// cada archivo cuando el programa ya no se va a ejecutar.
+func analyzeProgram(req models.CodeRequest) (result models.AnalysisResult, p *program, relocate func()) {
+	project := len(req.Files) > 0
+	var lexical models.LexicalResult
+	var source *sourceFiles
+	var units []translationUnit
+	var tree *models.SyntaxNode
+	var diagnostics []models.Diagnostic
+	if project {
+		lexical = AnalyzeLexicalProject(req.Files)
+		source, units = parseUnits(req.Files, req.Entry)
+		tree, diagnostics = linkUnits(units)
+	} else {
+		lexical = AnalyzeLexical(req.Code)
+		tree, diagnostics = Parse(req.Code)
+	}
+
+	syntax := checkProgram(tree, diagnostics)
+	analyzer := runAnalyzer(tree, source)
+	semantic := semanticResult(analyzer)
+	if project {
+		semantic.Diagnostics = diagnosticList(analyzeUnits(source, units, analyzer))
+	}
+	p = newProgram(tree, analyzer, append(append([]models.Diagnostic{}, syntax.Diagnostics...), semantic.Diagnostics...), source)
+	p.addLexicalErrors(lexical.Diagnostics)
+	lexicalCount, syntaxCount := len(lexicalErrors(lexical.Diagnostics, source)), syntaxErrors(syntax)
+	if lexicalCount > 0 || syntaxCount > 0 {
+		semantic = skippedSemantic(lexicalCount, syntaxCount)
+	}
+
+	result = models.AnalysisResult{LexicalAnalysis: &lexical, SyntaxAnalysis: &syntax, SemanticAnalysis: &semantic}
+	relocate = func() {}
+	if project {
+		relocate = func() {
+			source.relocateDiagnostics(syntax.Diagnostics)
+			source.relocateTree(syntax.AST)
+			source.relocateSemantic(&semantic)
+		}
+	}
+	return result, p, relocate
+}
+
+// skippedSemantic es el resultado semántico de un programa con errores
+// léxicos o de sintaxis
+func skippedSemantic(lexical, syntax int) models.SemanticResult {
+	var causes []string
+	if lexical > 0 {
+		causes = append(causes, "el análisis léxico encontró "+plural(lexical, "error", "errores"))
+	}
+	if syntax > 0 {
+		causes = append(causes, "el análisis sintáctico encontró "+plural(syntax, "error", "errores"))
+	}
+	return models.SemanticResult{
+		Diagnostics: []models.Diagnostic{},
+		SymbolTable: models.SymbolTable{Scopes: []models.Scope{}},
+		Status:      SemanticSkipped,
+		Reason:      "Se omitió porque " + strings.Join(causes, " y "),
+	}
+}
+
+// lexicalErrors devuelve los errores léxicos de los archivos que forman el
+// programa; en un proyecto, los de sus unidades de traducción. El parser
+// salta esos tokens sin informarlos otra vez.
+func lexicalErrors(diagnostics []models.Diagnostic, source *sourceFiles) []models.Diagnostic {
+	var result []models.Diagnostic
+	for _, d := range diagnostics {
+		if d.Severity != SeverityError {
+			continue
+		}
+		if source != nil {
+			if _, ok := source.ids[d.File]; !ok {
+				continue
+			}
+		}
+		result = append(result, d)
+	}
+	return result
+}
+
+// syntaxErrors cuenta los errores del preprocesador y del parser. Los de
+// la función main no impiden analizar el resto del programa.
+func syntaxErrors(syntax models.SyntaxResult) int {
+	count := 0
+	for _, d := range syntax.Diagnostics {
+		if d.Severity == SeverityError && d.Code != CodeMissingMain && d.Code != CodeInvalidMain {
+			count++
+		}
+	}
+	return count
+}
+
+// plural escribe la cantidad con el sustantivo en singular o plural
+func plural(n int, singular, pluralForm string) string {
+	if n == 1 {
+		return "1 " + singular
+	}
+	return strconv.Itoa(n) + " " + pluralForm
+}
+
+// AllDiagnostics junta los diagnósticos de las fases que se ejecutaron
+func AllDiagnostics(result models.AnalysisResult) []models.Diagnostic {
+	var diagnostics []models.Diagnostic
+	if result.LexicalAnalysis != nil {
+		diagnostics = append(diagnostics, result.LexicalAnalysis.Diagnostics...)
+	}
+	if result.SyntaxAnalysis != nil {
+		diagnostics = append(diagnostics, result.SyntaxAnalysis.Diagnostics...)
+	}
+	if result.SemanticAnalysis != nil {
+		diagnostics = append(diagnostics, result.SemanticAnalysis.Diagnostics...)
+	}
+	return diagnostics
+}
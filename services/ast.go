@@ -0,0 +1,205 @@
+package services
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+// Tipos de nodo del árbol sintáctico. Entre paréntesis, la forma de los hijos.
+const (
+	NodeProgram    = "Program"    // (declaraciones de nivel superior...)
+	NodeInclude    = "Include"    // Value: <iostream> o "archivo.h"
+	NodeDirective  = "Directive"  // Value: nombre de la directiva (#define, #pragma...)
+	NodeUsing      = "Using"      // Value: espacio de nombres
+	NodeFunction   = "Function"   // Value: nombre, Type: tipo de retorno, Operator: const en métodos const (Param..., MemberInit..., [Block])
+	NodeParam      = "Param"      // Value: nombre (puede estar vacío), Type: tipo
+	NodeClass      = "Class"      // Value: nombre, Type: struct o class (Base..., [Block de miembros])
+	NodeBase       = "Base"       // Value: clase base, Operator: public, protected o private
+	NodeAccess     = "Access"     // Value: public, protected o private
+	NodeMemberInit = "MemberInit" // Value: atributo (argumentos...); lista de inicialización de un constructor
+
+	NodeVarDecl    = "VarDecl"    // Type: tipo base, Operator: static o extern (Declarator...)
+	NodeDeclarator = "Declarator" // Value: nombre, Type: tipo declarado (ArraySize..., [inicializador])
+	NodeArraySize  = "ArraySize"  // (tamaño); Empty si se omite: int v[]
+	NodeInitList   = "InitList"   // Operator: ( ) si es Tipo x(a, b) (elementos...)
+
+	NodeBlock    = "Block"    // (sentencias...)
+	NodeIf       = "If"       // (condición, entonces, [sino])
+	NodeWhile    = "While"    // (condición, cuerpo)
+	NodeDoWhile  = "DoWhile"  // (cuerpo, condición)
+	NodeFor      = "For"      // (inicio, condición, incremento, cuerpo); las partes omitidas son Empty
+	NodeRangeFor = "RangeFor" // (VarDecl, rango, cuerpo)
+	NodeSwitch   = "Switch"   // (condición, Case|Default...)
+	NodeCase     = "Case"     // (valor, sentencias...)
+	NodeDefault  = "Default"  // (sentencias...)
+	NodeBreak    = "Break"
+	NodeContinue = "Continue"
+	NodeReturn   = "Return"   // ([expresión])
+	NodeExprStmt = "ExprStmt" // (expresión)
+	NodeEmpty    = "Empty"
+
+	NodeAssign     = "Assign"     // Operator: =, +=, ... (destino, valor)
+	NodeBinary     = "Binary"     // Operator (izquierda, derecha)
+	NodeUnary      = "Unary"      // Operator: prefijo (operando)
+	NodePostfix    = "Postfix"    // Operator: ++ o -- (operando)
+	NodeTernary    = "Ternary"    // (condición, si, sino)
+	NodeCall       = "Call"       // (función, argumentos...)
+	NodeIndex      = "Index"      // (arreglo, índice)
+	NodeMember     = "Member"     // Value: miembro, Operator: . o -> (objeto)
+	NodeNew        = "New"        // Type: tipo, Operator: [] para arreglos o {} con llaves (argumentos... | tamaño)
+	NodeDelete     = "Delete"     // Operator: [] para delete[] (puntero)
+	NodeCast       = "Cast"       // Type: tipo destino, Operator: static_cast o ( ) (expresión)
+	NodeSizeof     = "Sizeof"     // Type si es sizeof(tipo), si no (expresión)
+	NodeIdentifier = "Identifier" // Value: nombre, posiblemente calificado (std::cout)
+
+	NodeIntLiteral    = "IntLiteral"
+	NodeFloatLiteral  = "FloatLiteral"
+	NodeStringLiteral = "StringLiteral"
+	NodeCharLiteral   = "CharLiteral"
+	NodeBoolLiteral   = "BoolLiteral"
+	NodeNullLiteral   = "NullLiteral"
+)
+
+// newNode crea un nodo que empieza en el token indicado
+func newNode(kind string, tok models.Token) *models.SyntaxNode {
+	endLine, endColumn := tokenEnd(tok)
+	return &models.SyntaxNode{
+		Kind:      kind,
+		Line:      tok.Line,
+		Column:    tok.Column,
+		EndLine:   endLine,
+		EndColumn: endColumn,
+	}
+}
+
+// tokenEnd devuelve la posición inmediatamente posterior al token
+func tokenEnd(tok models.Token) (int, int) {
+	if tok.Macro != "" {
+		// Un token expandido ocupa el nombre de la macro en el código
+		return tok.Line, tok.Column + utf8.RuneCountInString(tok.Macro)
+	}
+	return tok.Line, tok.Column + utf8.RuneCountInString(tok.Lexeme)
+}
+
+// walkTree recorre el árbol en preorden; si visit devuelve false no se
+// visitan los hijos del nodo
+func walkTree(node *models.SyntaxNode, visit func(*models.SyntaxNode) bool) {
+	if node == nil || !visit(node) {
+		return
+	}
+	for _, child := range node.Children {
+		walkTree(child, visit)
+	}
+}
+
+// Precedencia de los nodos de expresión al reconstruir su texto
+const (
+	precedenceComma   = 0
+	precedenceAssign  = 1
+	precedenceTernary = 2
+	precedenceUnary   = 20
+	precedencePostfix = 21
+)
+
+func exprPrecedence(node *models.SyntaxNode) int {
+	switch node.Kind {
+	case NodeBinary:
+		if node.Operator == "," {
+			return precedenceComma
+		}
+		return precedenceTernary + binaryPrecedence[node.Operator]
+	case NodeAssign:
+		return precedenceAssign
+	case NodeTernary:
+		return precedenceTernary
+	case NodeUnary, NodeCast, NodeSizeof, NodeNew, NodeDelete:
+		return precedenceUnary
+	}
+	return precedencePostfix
+}
+
+// formatExpr reconstruye el texto de una expresión a partir del árbol. Se
+// usa en mensajes y al describir tipos como "int[N + 1]".
+func formatExpr(node *models.SyntaxNode) string {
+	if node == nil {
+		return ""
+	}
+	child := func(i, min int) string {
+		text := formatExpr(node.Children[i])
+		if exprPrecedence(node.Children[i]) < min {
+			return "(" + text + ")"
+		}
+		return text
+	}
+
+	switch node.Kind {
+	case NodeIdentifier, NodeIntLiteral, NodeFloatLiteral, NodeBoolLiteral:
+		return node.Value
+	case NodeNullLiteral:
+		return "nullptr"
+	case NodeStringLiteral:
+		return "\"" + node.Value + "\""
+	case NodeCharLiteral:
+		return "'" + node.Value + "'"
+	case NodeBinary:
+		if node.Operator == "," {
+			return child(0, precedenceComma) + ", " + child(1, precedenceComma+1)
+		}
+		precedence := exprPrecedence(node)
+		return child(0, precedence) + " " + node.Operator + " " + child(1, precedence+1)
+	case NodeAssign:
+		return child(0, precedenceUnary) + " " + node.Operator + " " + child(1, precedenceAssign)
+	case NodeTernary:
+		return child(0, precedenceTernary+1) + " ? " + child(1, precedenceAssign) + " : " + child(2, precedenceTernary)
+	case NodeUnary:
+		return node.Operator + child(0, precedenceUnary)
+	case NodePostfix:
+		return child(0, precedencePostfix) + node.Operator
+	case NodeCall:
+		var args []string
+		for i := range node.Children[1:] {
+			args = append(args, child(i+1, precedenceAssign))
+		}
+		return child(0, precedencePostfix) + "(" + strings.Join(args, ", ") + ")"
+	case NodeIndex:
+		return child(0, precedencePostfix) + "[" + formatExpr(node.Children[1]) + "]"
+	case NodeMember:
+		return child(0, precedencePostfix) + node.Operator + node.Value
+	case NodeNew:
+		var args []string
+		for i := range node.Children {
+			args = append(args, child(i, precedenceAssign))
+		}
+		if node.Operator == "[]" {
+			return "new " + node.Type + "[" + strings.Join(args, ", ") + "]"
+		}
+		if len(args) == 0 {
+			return "new " + node.Type
+		}
+		return "new " + node.Type + "(" + strings.Join(args, ", ") + ")"
+	case NodeDelete:
+		if node.Operator == "[]" {
+			return "delete[] " + child(0, precedenceUnary)
+		}
+		return "delete " + child(0, precedenceUnary)
+	case NodeCast:
+		if node.Operator == "()" {
+			return "(" + node.Type + ") " + child(0, precedenceUnary)
+		}
+		return node.Operator + "<" + node.Type + ">(" + formatExpr(node.Children[0]) + ")"
+	case NodeSizeof:
+		if len(node.Children) == 0 {
+			return "sizeof(" + node.Type + ")"
+		}
+		return "sizeof(" + formatExpr(node.Children[0]) + ")"
+	case NodeInitList:
+		var items []string
+		for i := range node.Children {
+			items = append(items, child(i, precedenceAssign))
+		}
+		return "{" + strings.Join(items, ", ") + "}"
+	}
+	return ""
+}
@@ -0,0 +1,298 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+// Límites del análisis por lotes
+const (
+	maxBatchSubmissions = 1000
+	maxBatchWorkers     = 8
+	maxTopErrors        = 10
+	maxSourceFileBytes  = 1 << 20  // cada archivo, descomprimido
+	maxSubmissionsBytes = 64 << 20 // todos los archivos juntos
+)
+
+// Extensiones de los archivos de C++ que se toman de las entregas; solo las de
+// código fuente forman una entrega por sí solas
+var (
+	sourceExtensions = map[string]bool{".cpp": true, ".cc": true, ".cxx": true}
+	headerExtensions = map[string]bool{".h": true, ".hpp": true, ".hh": true}
+)
+
+// ValidateBatch comprueba que haya entregas, no demasiadas, y que las
+// fases pedidas existan
+func ValidateBatch(req models.BatchRequest) error {
+	if len(req.Submissions) == 0 {
+		return errors.New("No hay entregas para analizar")
+	}
+	if len(req.Submissions) > maxBatchSubmissions {
+		return errors.New("Demasiadas entregas: el máximo es " + strconv.Itoa(maxBatchSubmissions))
+	}
+	return ValidatePhases(req.Phases)
+}
+
+// AnalyzeBatch analiza las entregas como /analyze, varias a la vez con un
+// número acotado de trabajadores, y resume los resultados del grupo
+func AnalyzeBatch(req models.BatchRequest) models.BatchResult {
+	results := make([]models.SubmissionResult, len(req.Submissions))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(runtime.NumCPU(), maxBatchWorkers, len(req.Submissions)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = analyzeSubmission(req.Submissions[i], req.Phases)
+				if results[i].Name == "" {
+					results[i].Name = "Entrega " + strconv.Itoa(i+1)
+				}
+			}
+		}()
+	}
+	for i := range req.Submissions {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return models.BatchResult{Results: results, Summary: summarizeBatch(results)}
+}
+
+// analyzeSubmission analiza una entrega. Un fallo del analizador con una
+// entrega se informa en su resultado sin afectar a las demás.
+func analyzeSubmission(sub models.Submission, phases []string) (result models.SubmissionResult) {
+	result.Name = sub.Name
+	defer func() {
+		if r := recover(); r != nil {
+			result.Valid = false
+			result.Error = fmt.Sprint("El análisis no pudo terminar: ", r)
+		}
+	}()
+
+	analysis := Analyze(models.CodeRequest{Code: sub.Code, Files: sub.Files, Entry: sub.Entry, Phases: phases})
+	return NewSubmissionResult(sub.Name, analysis)
+}
+
+// NewSubmissionResult cuenta los errores y advertencias del análisis de
+// una entrega
+func NewSubmissionResult(name string, analysis models.AnalysisResult) models.SubmissionResult {
+	result := models.SubmissionResult{Name: name, Analysis: analysis}
+	for _, d := range AllDiagnostics(analysis) {
+		switch d.Severity {
+		case SeverityError:
+			result.Errors++
+		case SeverityWarning:
+			result.Warnings++
+		}
+	}
+	result.Valid = result.Errors == 0
+	return result
+}
+
+// summarizeBatch cuenta las entregas válidas y los códigos de error que
+// aparecen en más entregas
+func summarizeBatch(results []models.SubmissionResult) models.BatchSummary {
+	summary := models.BatchSummary{Total: len(results), TopErrors: []models.CodeCount{}}
+	counts := make(map[string]*models.CodeCount)
+	for _, result := range results {
+		if result.Valid {
+			summary.Valid++
+		} else {
+			summary.Invalid++
+		}
+		seen := make(map[string]bool)
+		for _, d := range AllDiagnostics(result.Analysis) {
+			if d.Severity != SeverityError {
+				continue
+			}
+			count := counts[d.Code]
+			if count == nil {
+				count = &models.CodeCount{Code: d.Code, Title: ruleFor(d.Code).Title}
+				counts[d.Code] = count
+			}
+			count.Count++
+			if !seen[d.Code] {
+				seen[d.Code] = true
+				count.Submissions++
+			}
+		}
+	}
+
+	for _, count := range counts {
+		summary.TopErrors = append(summary.TopErrors, *count)
+	}
+	sort.Slice(summary.TopErrors, func(i, j int) bool {
+		a, b := summary.TopErrors[i], summary.TopErrors[j]
+		if a.Submissions != b.Submissions {
+			return a.Submissions > b.Submissions
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Code < b.Code
+	})
+	if len(summary.TopErrors) > maxTopErrors {
+		summary.TopErrors = summary.TopErrors[:maxTopErrors]
+	}
+	return summary
+}
+
+// ReadSubmissionsZip extrae las entregas de un zip como ReadSubmissions
+func ReadSubmissionsZip(data []byte) ([]models.Submission, error) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, errors.New("Archivo zip inválido: " + err.Error())
+	}
+	return ReadSubmissions(reader)
+}
+
+// ReadSubmissions reúne las entregas de un zip o una carpeta. Cada archivo
+// de código de la raíz es una entrega de un solo archivo y cada carpeta de
+// la raíz un proyecto con los archivos de C++ que contiene. Si todo está
+// dentro de una misma carpeta, esa carpeta no cuenta. Los demás archivos y
+// las carpetas ocultas se ignoran.
+func ReadSubmissions(fsys fs.FS) ([]models.Submission, error) {
+	names, err := sourceNames(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	// Quitar las carpetas que envuelven a todo el contenido
+	prefix := ""
+	for len(names) > 0 {
+		folder, _, ok := strings.Cut(strings.TrimPrefix(names[0], prefix), "/")
+		for _, name := range names {
+			ok = ok && strings.HasPrefix(name, prefix+folder+"/")
+		}
+		if !ok {
+			break
+		}
+		prefix += folder + "/"
+	}
+
+	contents, err := readSourceFiles(fsys, names)
+	if err != nil {
+		return nil, err
+	}
+	projects := make(map[string]*models.Submission)
+	var submissions []*models.Submission
+	for i, name := range names {
+		content := contents[i]
+		relative := strings.TrimPrefix(name, prefix)
+		folder, rest, ok := strings.Cut(relative, "/")
+		switch {
+		case ok:
+			project := projects[folder]
+			if project == nil {
+				project = &models.Submission{Name: folder, Files: make(map[string]string)}
+				projects[folder] = project
+				submissions = append(submissions, project)
+			}
+			project.Files[rest] = content
+		case sourceExtensions[strings.ToLower(path.Ext(name))]:
+			submissions = append(submissions, &models.Submission{Name: relative, Code: content})
+		}
+	}
+
+	if len(submissions) == 0 {
+		return nil, errors.New("No hay archivos de C++ para analizar")
+	}
+	sort.SliceStable(submissions, func(i, j int) bool { return submissions[i].Name < submissions[j].Name })
+	result := make([]models.Submission, len(submissions))
+	for i, sub := range submissions {
+		result[i] = *sub
+	}
+	return result, nil
+}
+
+// ReadProject lee los archivos de C++ de una carpeta, con sus nombres
+// relativos a ella, para analizarlos como un solo proyecto
+func ReadProject(fsys fs.FS) (map[string]string, error) {
+	names, err := sourceNames(fsys)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, errors.New("No hay archivos de C++ para analizar")
+	}
+	contents, err := readSourceFiles(fsys, names)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]string, len(names))
+	for i, name := range names {
+		files[name] = contents[i]
+	}
+	return files, nil
+}
+
+// sourceNames busca los archivos de C++ fuera de las carpetas ocultas
+func sourceNames(fsys fs.FS) ([]string, error) {
+	var names []string
+	err := fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		base := entry.Name()
+		if name != "." && (strings.HasPrefix(base, ".") || base == "__MACOSX") {
+			if entry.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		ext := strings.ToLower(path.Ext(name))
+		if entry.Type().IsRegular() && (sourceExtensions[ext] || headerExtensions[ext]) {
+			names = append(names, name)
+		}
+		return nil
+	})
+	return names, err
+}
+
+// readSourceFiles lee los archivos indicados sin superar maxSubmissionsBytes
+func readSourceFiles(fsys fs.FS, names []string) ([]string, error) {
+	contents := make([]string, len(names))
+	total := 0
+	for i, name := range names {
+		content, err := readSourceFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		if total += len(content); total > maxSubmissionsBytes {
+			return nil, errors.New("Los archivos superan los " + strconv.Itoa(maxSubmissionsBytes>>20) + " MB")
+		}
+		contents[i] = content
+	}
+	return contents, nil
+}
+
+// readSourceFile lee un archivo de las entregas hasta maxSourceFileBytes
+func readSourceFile(fsys fs.FS, name string) (string, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return "", errors.New("No se pudo leer " + name + ": " + err.Error())
+	}
+	defer file.Close()
+	content, err := io.ReadAll(io.LimitReader(file, maxSourceFileBytes+1))
+	if err != nil {
+		return "", errors.New("No se pudo leer " + name + ": " + err.Error())
+	}
+	if len(content) > maxSourceFileBytes {
+		return "", errors.New("El archivo " + name + " supera " + strconv.Itoa(maxSourceFileBytes>>20) + " MB")
+	}
+	return string(content), nil
+}
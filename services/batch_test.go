@@ -0,0 +1,194 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+func TestValidateBatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     models.BatchRequest
+		message string
+	}{
+		{"válido", models.BatchRequest{Submissions: []models.Submission{{Code: "int x;"}}}, ""},
+		{"sin entregas", models.BatchRequest{}, "No hay entregas"},
+		{"demasiadas", models.BatchRequest{Submissions: make([]models.Submission, maxBatchSubmissions+1)}, "Demasiadas entregas"},
+		{"fase desconocida", models.BatchRequest{Submissions: []models.Submission{{Code: "int x;"}}, Phases: []string{"optimizar"}}, "Fase desconocida: optimizar"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateBatch(tt.req)
+			switch {
+			case tt.message == "" && err != nil:
+				t.Errorf("error inesperado: %v", err)
+			case tt.message != "" && (err == nil || !strings.Contains(err.Error(), tt.message)):
+				t.Errorf("error = %v, se esperaba %q", err, tt.message)
+			}
+		})
+	}
+}
+
+func TestAnalyzeBatch(t *testing.T) {
+	result := AnalyzeBatch(models.BatchRequest{Submissions: []models.Submission{
+		{Name: "ana.cpp", Code: "int main() { return 0; }"},
+		{Code: "int main() { return y; }"},
+		{Name: "luis.cpp", Code: "int main() { int a = b + c; return a; }"},
+		{Name: "proyecto", Files: map[string]string{
+			"main.cpp":  "int doble(int x);\nint main() { return doble(2); }",
+			"doble.cpp": "int doble(int x) { return 2 * x; }",
+		}},
+	}})
+
+	tests := []struct {
+		name   string
+		valid  bool
+		errors int
+	}{
+		{"ana.cpp", true, 0},
+		{"Entrega 2", false, 1},
+		{"luis.cpp", false, 2},
+		{"proyecto", true, 0},
+	}
+	if len(result.Results) != len(tests) {
+		t.Fatalf("%d resultados, se esperaban %d", len(result.Results), len(tests))
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := result.Results[i]
+			if got.Name != tt.name || got.Valid != tt.valid || got.Errors != tt.errors {
+				t.Errorf("resultado = {%q valid=%v errors=%d}, se esperaba {%q valid=%v errors=%d}",
+					got.Name, got.Valid, got.Errors, tt.name, tt.valid, tt.errors)
+			}
+		})
+	}
+
+	summary := result.Summary
+	if summary.Total != 4 || summary.Valid != 2 || summary.Invalid != 2 {
+		t.Errorf("resumen = %d/%d/%d, se esperaba 4 en total, 2 válidas y 2 inválidas", summary.Total, summary.Valid, summary.Invalid)
+	}
+	want := []models.CodeCount{{Code: CodeUndeclared, Title: ruleFor(CodeUndeclared).Title, Submissions: 2, Count: 3}}
+	if !reflect.DeepEqual(summary.TopErrors, want) {
+		t.Errorf("errores frecuentes = %+v, se esperaba %+v", summary.TopErrors, want)
+	}
+}
+
+func TestSummarizeBatchOrder(t *testing.T) {
+	diagnostics := func(codes ...string) models.SubmissionResult {
+		var ds []models.Diagnostic
+		for _, code := range codes {
+			ds = append(ds, models.Diagnostic{Code: code, Severity: SeverityError})
+		}
+		return models.SubmissionResult{Analysis: models.AnalysisResult{SemanticAnalysis: &models.SemanticResult{Diagnostics: ds}}}
+	}
+	summary := summarizeBatch([]models.SubmissionResult{
+		diagnostics("SEM003", "SEM003", "SEM003"),
+		diagnostics("SEM002", "SEM001"),
+		diagnostics("SEM002", "SEM001"),
+		diagnostics("SEM001"),
+	})
+
+	var codes []string
+	for _, count := range summary.TopErrors {
+		codes = append(codes, count.Code)
+	}
+	// Primero las que aparecen en más entregas, luego las más repetidas
+	if want := []string{"SEM001", "SEM002", "SEM003"}; !reflect.DeepEqual(codes, want) {
+		t.Errorf("orden = %v, se esperaba %v", codes, want)
+	}
+}
+
+func TestReadSubmissions(t *testing.T) {
+	file := func(content string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(content)} }
+	tests := []struct {
+		name  string
+		fsys  fstest.MapFS
+		want  []models.Submission
+		error string
+	}{
+		{
+			name: "archivos y proyectos",
+			fsys: fstest.MapFS{
+				"b.cpp":            file("int b;"),
+				"a.cc":             file("int a;"),
+				"notas.txt":        file("ignorar"),
+				"util.h":           file("int u;"),
+				"equipo/main.cpp":  file("int m;"),
+				"equipo/lib/l.hpp": file("int l;"),
+				".git/x.cpp":       file("int x;"),
+				"__MACOSX/._b.cpp": file("basura"),
+			},
+			want: []models.Submission{
+				{Name: "a.cc", Code: "int a;"},
+				{Name: "b.cpp", Code: "int b;"},
+				{Name: "equipo", Files: map[string]string{"main.cpp": "int m;", "lib/l.hpp": "int l;"}},
+			},
+		},
+		{
+			name: "carpeta que envuelve todo",
+			fsys: fstest.MapFS{
+				"grupo/tareas/ana.cpp":       file("int a;"),
+				"grupo/tareas/luis/main.cpp": file("int l;"),
+			},
+			want: []models.Submission{
+				{Name: "ana.cpp", Code: "int a;"},
+				{Name: "luis", Files: map[string]string{"main.cpp": "int l;"}},
+			},
+		},
+		{
+			name:  "sin código",
+			fsys:  fstest.MapFS{"leeme.md": file("#"), "solo.h": file("int h;")},
+			error: "No hay archivos de C++",
+		},
+		{
+			name:  "archivo demasiado grande",
+			fsys:  fstest.MapFS{"grande.cpp": &fstest.MapFile{Data: make([]byte, maxSourceFileBytes+1)}},
+			error: "supera",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadSubmissions(tt.fsys)
+			if tt.error != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.error) {
+					t.Errorf("error = %v, se esperaba %q", err, tt.error)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error inesperado: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("entregas = %+v, se esperaba %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadSubmissionsZip(t *testing.T) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	f, _ := w.Create("entregas/ana.cpp")
+	f.Write([]byte("int main() { return 0; }"))
+	w.Close()
+
+	got, err := ReadSubmissionsZip(buf.Bytes())
+	if err != nil {
+		t.Fatalf("error inesperado: %v", err)
+	}
+	if want := []models.Submission{{Name: "ana.cpp", Code: "int main() { return 0; }"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("entregas = %+v, se esperaba %+v", got, want)
+	}
+
+	if _, err := ReadSubmissionsZip([]byte("no es un zip")); err == nil || !strings.Contains(err.Error(), "zip inválido") {
+		t.Errorf("error = %v, se esperaba zip inválido", err)
+	}
+}
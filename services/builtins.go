@@ -0,0 +1,1004 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+// inputStream es la entrada estándar del programa. failed y eof son los
+// indicadores de cin: una lectura fallida deja de leer hasta cin.clear().
+type inputStream struct {
+	text   string
+	pos    int
+	failed bool
+	eof    bool
+}
+
+// streamFormat es el estado de formato de cout o cerr. width solo se
+// aplica al siguiente valor, como setw.
+type streamFormat struct {
+	precision  int
+	fixed      bool
+	scientific bool
+	boolalpha  bool
+	left       bool
+	width      int
+	fill       byte
+}
+
+func newStreamFormat() *streamFormat {
+	return &streamFormat{precision: 6, fill: ' '}
+}
+
+func isSpaceByte(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f'
+}
+
+func (s *inputStream) skipSpaces() {
+	for s.pos < len(s.text) && isSpaceByte(s.text[s.pos]) {
+		s.pos++
+	}
+	if s.pos >= len(s.text) {
+		s.eof = true
+	}
+}
+
+func (s *inputStream) scanDigits() int {
+	start := s.pos
+	for s.pos < len(s.text) && isDigit(s.text[s.pos]) {
+		s.pos++
+	}
+	return s.pos - start
+}
+
+// scanNumber lee el texto de un número como operator>>: signo, dígitos y,
+// si floating, la parte decimal y el exponente. Si no hay dígitos la
+// lectura falla.
+func (s *inputStream) scanNumber(floating bool) string {
+	s.skipSpaces()
+	start := s.pos
+	if s.pos < len(s.text) && (s.text[s.pos] == '+' || s.text[s.pos] == '-') {
+		s.pos++
+	}
+	digits := s.scanDigits()
+	if floating {
+		if s.pos < len(s.text) && s.text[s.pos] == '.' {
+			s.pos++
+			digits += s.scanDigits()
+		}
+		if digits > 0 && s.pos < len(s.text) && (s.text[s.pos] == 'e' || s.text[s.pos] == 'E') {
+			mark := s.pos
+			s.pos++
+			if s.pos < len(s.text) && (s.text[s.pos] == '+' || s.text[s.pos] == '-') {
+				s.pos++
+			}
+			if s.scanDigits() == 0 {
+				s.pos = mark
+			}
+		}
+	}
+	if digits == 0 {
+		s.failed = true
+		return ""
+	}
+	if s.pos >= len(s.text) {
+		s.eof = true
+	}
+	return s.text[start:s.pos]
+}
+
+// token lee una palabra separada por espacios
+func (s *inputStream) token() (string, bool) {
+	s.skipSpaces()
+	if s.pos >= len(s.text) {
+		s.failed = true
+		return "", false
+	}
+	start := s.pos
+	for s.pos < len(s.text) && !isSpaceByte(s.text[s.pos]) {
+		s.pos++
+	}
+	if s.pos >= len(s.text) {
+		s.eof = true
+	}
+	return s.text[start:s.pos], true
+}
+
+// read ejecuta cin >> destino según el tipo del destino
+func (in *interpreter) read(target *models.SyntaxNode) {
+	input := in.input
+	if target.Kind == NodeIdentifier && strings.TrimPrefix(target.Value, "std::") == "ws" {
+		input.skipSpaces()
+		return
+	}
+	loc := in.evalLocation(target)
+	if input.failed {
+		return
+	}
+	t := locationType(loc)
+	switch {
+	case t.isArray() && t.elementType().Name == "char":
+		word, ok := input.token()
+		if ok {
+			array := in.load(loc, target)
+			if len(word) >= array.blk.length() {
+				in.fail(target, CodeOutOfBounds, "La palabra leída ("+strconv.Itoa(len(word))+
+					" caracteres) no cabe en "+blockName(array.blk), exitSegv)
+			}
+			in.fillChars(array, word+"\x00", target)
+		}
+	case t.isString():
+		if word, ok := input.token(); ok {
+			in.store(loc, rtValue{t: t, s: word}, target)
+		}
+	case t.Name == "char" || t.Name == "unsigned char":
+		input.skipSpaces()
+		if input.pos >= len(input.text) {
+			input.failed = true
+			return
+		}
+		in.store(loc, rtValue{t: t, i: int64(input.text[input.pos])}, target)
+		input.pos++
+	case t.isIntegral():
+		// Como en C++11, una lectura fallida guarda 0 y un valor fuera de
+		// rango guarda el extremo más cercano
+		text := input.scanNumber(false)
+		value, err := strconv.ParseInt(text, 10, 64)
+		switch {
+		case text == "":
+		case err != nil && isUnsigned64(t) && text[0] != '-':
+			unsigned, uerr := strconv.ParseUint(strings.TrimPrefix(text, "+"), 10, 64)
+			value = int64(unsigned)
+			if uerr != nil {
+				input.failed, value = true, -1
+			}
+		case err != nil:
+			input.failed, value = true, math.MaxInt64
+			if text[0] == '-' {
+				value = math.MinInt64
+			}
+		}
+		switch {
+		case t.Name == "bool" && value != 0 && value != 1:
+			input.failed, value = true, 1
+		case t.Name != "bool" && !isUnsigned(t) && !fitsInt(value, t):
+			input.failed = true
+			if min, max := integerLimits(t); value < 0 {
+				value = min
+			} else {
+				value = max
+			}
+		}
+		in.store(loc, rtValue{t: cppType{Name: "long"}, i: value}, target)
+	case t.isFloating():
+		value, _ := strconv.ParseFloat(input.scanNumber(true), 64)
+		in.store(loc, rtValue{t: cppType{Name: "double"}, f: value}, target)
+	default:
+		in.fail(target, CodeUnsupported, "El intérprete no puede leer un valor de tipo "+t.String(), 1)
+	}
+}
+
+// integerLimits devuelve el rango de un tipo entero con signo
+func integerLimits(t cppType) (int64, int64) {
+	min, max := integerRange(t)
+	return min.Int64(), max.Int64()
+}
+
+func fitsInt(value int64, t cppType) bool {
+	min, max := integerLimits(t)
+	return value >= min && value <= max
+}
+
+// getline lee una línea completa hasta el delimitador, que se descarta
+func (in *interpreter) getline(loc location, delim byte, node *models.SyntaxNode) {
+	input := in.input
+	if input.failed {
+		return
+	}
+	if input.pos >= len(input.text) {
+		input.failed, input.eof = true, true
+		in.store(loc, rtValue{t: cppType{Name: "string"}}, node)
+		return
+	}
+	rest := input.text[input.pos:]
+	line := rest
+	if end := strings.IndexByte(rest, delim); end >= 0 {
+		line = rest[:end]
+		input.pos += end + 1
+	} else {
+		input.pos = len(input.text)
+		input.eof = true
+	}
+	in.store(loc, rtValue{t: cppType{Name: "string"}, s: line}, node)
+}
+
+// write ejecuta flujo << valor
+func (in *interpreter) write(stream string, v rtValue, node *models.SyntaxNode) {
+	format := in.format[stream]
+	if v.t.Name == typeManipulator {
+		switch v.s {
+		case "endl":
+			in.output(stream, "\n", node)
+		case "fixed":
+			format.fixed, format.scientific = true, false
+		case "scientific":
+			format.fixed, format.scientific = false, true
+		case "boolalpha":
+			format.boolalpha = true
+		case "noboolalpha":
+			format.boolalpha = false
+		case "left":
+			format.left = true
+		case "right":
+			format.left = false
+		case "setprecision":
+			format.precision = int(v.i)
+		case "setw":
+			format.width = int(v.i)
+		case "setfill":
+			format.fill = byte(v.i)
+		}
+		return
+	}
+
+	text := in.formatValue(v, format, node)
+	if padding := format.width - len(text); padding > 0 {
+		// Un relleno mayor que la salida disponible solo agota el límite
+		padding = min(padding, int(in.maxOutput-in.outputSize)+1)
+		fill := strings.Repeat(string([]byte{format.fill}), padding)
+		if format.left {
+			text += fill
+		} else {
+			text = fill + text
+		}
+	}
+	format.width = 0
+	in.output(stream, text, node)
+}
+
+// output escribe en la salida del flujo respetando el límite de salida
+func (in *interpreter) output(stream, text string, node *models.SyntaxNode) {
+	target := &in.stdout
+	if stream == "cerr" {
+		target = &in.stderr
+	}
+	if in.outputSize+int64(len(text)) > in.maxOutput {
+		target.WriteString(text[:in.maxOutput-in.outputSize])
+		in.outputSize = in.maxOutput
+		in.failLimit(node, CodeOutputLimit, "Se superó el límite de salida de "+
+			strconv.FormatInt(in.maxOutput/1024, 10)+" KB")
+	}
+	in.outputSize += int64(len(text))
+	target.WriteString(text)
+}
+
+// formatValue convierte un valor en el texto que imprime operator<<
+func (in *interpreter) formatValue(v rtValue, format *streamFormat, node *models.SyntaxNode) string {
+	t := v.t
+	switch {
+	case t.Name == "bool" && t.isPlain():
+		if format.boolalpha {
+			return strconv.FormatBool(v.i != 0)
+		}
+		return strconv.FormatInt(v.i, 10)
+	case (t.Name == "char" || t.Name == "unsigned char") && t.isPlain():
+		return string([]byte{byte(v.i)})
+	case t.isIntegral():
+		if isUnsigned64(t) {
+			return strconv.FormatUint(uint64(v.i), 10)
+		}
+		return strconv.FormatInt(v.i, 10)
+	case t.isFloating():
+		return formatFloat(v.f, format)
+	case t.isString():
+		return v.s
+	case t.Pointer > 0 || t.isArray():
+		if pointee := t.decay(); pointee.Name == "char" && pointee.Pointer == 1 {
+			return in.cString(v, node)
+		}
+		loc := in.decayValue(v).ptr
+		if loc.blk == nil {
+			return "0"
+		}
+		// Una dirección ficticia pero estable dentro del bloque
+		return "0x" + strconv.FormatInt(0x55d0c0de0000+int64(loc.index)*elementSize(t.elementType()), 16)
+	case t.Name == typeNullptr:
+		return "nullptr"
+	}
+	in.fail(node, CodeUnsupported, "El intérprete no puede imprimir un valor de tipo "+t.String(), 1)
+	return ""
+}
+
+// formatFloat imprime un número real como lo hace iostream: %g con la
+// precisión del flujo, o %f y %e con fixed y scientific
+func formatFloat(x float64, format *streamFormat) string {
+	switch {
+	case math.IsNaN(x):
+		if math.Signbit(x) {
+			return "-nan"
+		}
+		return "nan"
+	case math.IsInf(x, 1):
+		return "inf"
+	case math.IsInf(x, -1):
+		return "-inf"
+	case format.fixed:
+		return strconv.FormatFloat(x, 'f', format.precision, 64)
+	case format.scientific:
+		return strconv.FormatFloat(x, 'e', format.precision, 64)
+	}
+	precision := format.precision
+	if precision == 0 {
+		precision = 1
+	}
+	return strconv.FormatFloat(x, 'g', precision, 64)
+}
+
+// Funciones de <cmath> de uno y dos argumentos
+var mathFunctions = map[string]func(float64) float64{
+	"sqrt": math.Sqrt, "cbrt": math.Cbrt, "fabs": math.Abs, "floor": math.Floor, "ceil": math.Ceil,
+	"round": math.Round, "trunc": math.Trunc, "exp": math.Exp, "log": math.Log, "log2": math.Log2,
+	"log10": math.Log10, "sin": math.Sin, "cos": math.Cos, "tan": math.Tan, "asin": math.Asin,
+	"acos": math.Acos, "atan": math.Atan,
+}
+
+var mathFunctions2 = map[string]func(float64, float64) float64{
+	"pow": math.Pow, "fmod": math.Mod, "hypot": math.Hypot, "atan2": math.Atan2,
+}
+
+// Funciones de <cctype>
+var ctypeFunctions = map[string]func(byte) bool{
+	"isdigit": isDigit,
+	"isalpha": func(c byte) bool { return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' },
+	"isalnum": func(c byte) bool { return isDigit(c) || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' },
+	"isspace": isSpaceByte,
+	"isupper": func(c byte) bool { return c >= 'A' && c <= 'Z' },
+	"islower": func(c byte) bool { return c >= 'a' && c <= 'z' },
+}
+
+// callLibrary ejecuta una función de la biblioteca estándar
+func (in *interpreter) callLibrary(name string, node *models.SyntaxNode, args []*models.SyntaxNode) rtValue {
+	double := cppType{Name: "double"}
+	switch name {
+	case "getline":
+		stream := in.eval(args[0])
+		loc := in.evalLocation(args[1])
+		delim := byte('\n')
+		if len(args) > 2 {
+			delim = byte(in.eval(args[2]).i)
+		}
+		in.getline(loc, delim, node)
+		return stream
+	case "swap":
+		a, b := in.evalLocation(args[0]), in.evalLocation(args[1])
+		x, y := in.load(a, node), in.load(b, node)
+		in.store(a, y, node)
+		in.store(b, x, node)
+		return rtValue{t: cppType{Name: "void"}}
+	case "sort", "stable_sort", "reverse", "find", "count", "fill", "max_element", "min_element",
+		"binary_search", "lower_bound", "upper_bound", "unique", "next_permutation":
+		return in.algorithm(name, node, args)
+	case "system":
+		in.fail(node, CodeUnsupported, "El intérprete no permite ejecutar comandos con system()", 1)
+	}
+
+	values := make([]rtValue, len(args))
+	for i, arg := range args {
+		values[i] = in.eval(arg)
+	}
+	if f, ok := mathFunctions[name]; ok {
+		return rtValue{t: double, f: f(toFloat(values[0]))}
+	}
+	if f, ok := mathFunctions2[name]; ok {
+		return rtValue{t: double, f: f(toFloat(values[0]), toFloat(values[1]))}
+	}
+	if f, ok := ctypeFunctions[name]; ok {
+		return rtValue{t: cppType{Name: "int"}, i: boolInt(f(byte(values[0].i)))}
+	}
+
+	switch name {
+	case "setprecision", "setw", "setfill":
+		return rtValue{t: cppType{Name: typeManipulator}, s: name, i: values[0].i}
+	case "toupper", "tolower":
+		c := values[0].i
+		switch {
+		case name == "toupper" && c >= 'a' && c <= 'z':
+			c -= 'a' - 'A'
+		case name == "tolower" && c >= 'A' && c <= 'Z':
+			c += 'a' - 'A'
+		}
+		return rtValue{t: cppType{Name: "int"}, i: c}
+	case "abs":
+		if values[0].t.isFloating() {
+			return rtValue{t: values[0].t, f: math.Abs(values[0].f)}
+		}
+		t := promote(values[0].t)
+		value := in.convert(values[0], t, node).i
+		if value < 0 {
+			value = -value
+		}
+		return rtValue{t: t, i: wrapInteger(value, t)}
+	case "max":
+		if in.operate("<", values[0], values[1], node).i != 0 {
+			return values[1]
+		}
+		return values[0]
+	case "min":
+		if in.operate("<", values[1], values[0], node).i != 0 {
+			return values[1]
+		}
+		return values[0]
+	case "to_string":
+		text := in.formatValue(in.convert(values[0], promote(values[0].t), node), newStreamFormat(), node)
+		if values[0].t.isFloating() {
+			text = strconv.FormatFloat(values[0].f, 'f', 6, 64)
+		}
+		return rtValue{t: cppType{Name: "string"}, s: text}
+	case "stoi", "stol", "stoll":
+		return in.parseInteger(name, in.convert(values[0], cppType{Name: "string"}, node).s, node)
+	case "stof", "stod":
+		number := (&inputStream{text: in.convert(values[0], cppType{Name: "string"}, node).s}).scanNumber(true)
+		if number == "" {
+			in.throw(node, "invalid_argument", name)
+		}
+		value, _ := strconv.ParseFloat(number, 64)
+		if name == "stof" {
+			return in.convert(rtValue{t: double, f: value}, cppType{Name: "float"}, node)
+		}
+		return rtValue{t: double, f: value}
+	case "rand":
+		in.random = in.random*1103515245 + 12345
+		return rtValue{t: cppType{Name: "int"}, i: int64(in.random & math.MaxInt32)}
+	case "srand":
+		in.random = uint32(values[0].i)
+		return rtValue{t: cppType{Name: "void"}}
+	case "time":
+		// Un valor fijo mantiene la ejecución reproducible
+		return rtValue{t: cppType{Name: "long"}}
+	case "clock":
+		return rtValue{t: cppType{Name: "long"}, i: in.steps}
+	case "exit":
+		panic(programExit{code: int(values[0].i)})
+	}
+	in.fail(node, CodeUnsupported, "El intérprete no admite la función '"+name+"'", 1)
+	return rtValue{}
+}
+
+// parseInteger implementa stoi, stol y stoll
+func (in *interpreter) parseInteger(name, text string, node *models.SyntaxNode) rtValue {
+	number := (&inputStream{text: text}).scanNumber(false)
+	if number == "" {
+		in.throw(node, "invalid_argument", name)
+	}
+	t := cppType{Name: "long"}
+	if name == "stoi" {
+		t = cppType{Name: "int"}
+	}
+	value, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || !fitsInt(value, t) {
+		in.throw(node, "out_of_range", name)
+	}
+	return rtValue{t: t, i: value}
+}
+
+// iteratorRange devuelve las ubicaciones entre dos punteros o iteradores
+// del mismo arreglo, vector o cadena
+func (in *interpreter) iteratorRange(first, last rtValue, node *models.SyntaxNode) []location {
+	first, last = in.decayValue(first), in.decayValue(last)
+	if first.t.Pointer == 0 || last.t.Pointer == 0 {
+		in.fail(node, CodeUnsupported, "El intérprete solo admite rangos de punteros o iteradores", 1)
+	}
+	n := distance(first.ptr, last.ptr)
+	if first.ptr.blk != last.ptr.blk || n < 0 {
+		in.fail(node, CodeInvalidMemory, "El rango no es válido: el final está antes del inicio o en otro objeto", exitSegv)
+	}
+	locations := make([]location, n)
+	for i := range locations {
+		locations[i] = advance(first.ptr, i)
+		in.check(locations[i], node)
+	}
+	return locations
+}
+
+// algorithm ejecuta las funciones de <algorithm> sobre un rango
+func (in *interpreter) algorithm(name string, node *models.SyntaxNode, args []*models.SyntaxNode) rtValue {
+	first, last := in.decayValue(in.eval(args[0])), in.decayValue(in.eval(args[1]))
+	locations := in.iteratorRange(first, last, node)
+	values := make([]rtValue, len(locations))
+	for i, loc := range locations {
+		values[i] = in.load(loc, node)
+	}
+	pointer := func(i int) rtValue {
+		return rtValue{t: first.t, ptr: advance(first.ptr, i)}
+	}
+	less := func(a, b rtValue) bool {
+		in.step(node)
+		return in.operate("<", a, b, node).i != 0
+	}
+	boolean := cppType{Name: "bool"}
+
+	switch name {
+	case "sort", "stable_sort":
+		if len(args) > 2 {
+			less = in.comparator(args[2], node)
+		}
+		sort.SliceStable(values, func(i, j int) bool { return less(values[i], values[j]) })
+	case "reverse":
+		for i, j := 0, len(values)-1; i < j; i, j = i+1, j-1 {
+			values[i], values[j] = values[j], values[i]
+		}
+	case "fill":
+		fill := in.eval(args[2])
+		for i := range values {
+			values[i] = fill
+		}
+	case "find", "count":
+		target := in.eval(args[2])
+		count := 0
+		for i, value := range values {
+			in.step(node)
+			if in.operate("==", value, target, node).i == 0 {
+				continue
+			}
+			if name == "find" {
+				return pointer(i)
+			}
+			count++
+		}
+		if name == "find" {
+			return last
+		}
+		return rtValue{t: cppType{Name: "long"}, i: int64(count)}
+	case "max_element", "min_element":
+		best := 0
+		for i := range values {
+			if name == "max_element" && less(values[best], values[i]) || name == "min_element" && less(values[i], values[best]) {
+				best = i
+			}
+		}
+		return pointer(best)
+	case "binary_search", "lower_bound", "upper_bound":
+		target := in.eval(args[2])
+		i := sort.Search(len(values), func(i int) bool {
+			if name == "upper_bound" {
+				return less(target, values[i])
+			}
+			return !less(values[i], target)
+		})
+		if name == "binary_search" {
+			return rtValue{t: boolean, i: boolInt(i < len(values) && !less(target, values[i]))}
+		}
+		return pointer(i)
+	case "unique":
+		end := 0
+		for i, value := range values {
+			if i == 0 || in.operate("==", values[end-1], value, node).i == 0 {
+				values[end] = value
+				end++
+			}
+		}
+		for i := 0; i < end; i++ {
+			in.store(locations[i], values[i], node)
+		}
+		return pointer(end)
+	case "next_permutation":
+		found := nextPermutation(values, less)
+		for i, loc := range locations {
+			in.store(loc, values[i], node)
+		}
+		return rtValue{t: boolean, i: boolInt(found)}
+	}
+
+	for i, loc := range locations {
+		in.store(loc, values[i], node)
+	}
+	return rtValue{t: cppType{Name: "void"}}
+}
+
+// nextPermutation reordena los valores en la siguiente permutación
+// lexicográfica; si ya es la última, los deja en la primera y devuelve false
+func nextPermutation(values []rtValue, less func(a, b rtValue) bool) bool {
+	i := len(values) - 2
+	for i >= 0 && !less(values[i], values[i+1]) {
+		i--
+	}
+	if i >= 0 {
+		j := len(values) - 1
+		for !less(values[i], values[j]) {
+			j--
+		}
+		values[i], values[j] = values[j], values[i]
+	}
+	for a, b := i+1, len(values)-1; a < b; a, b = a+1, b-1 {
+		values[a], values[b] = values[b], values[a]
+	}
+	return i >= 0
+}
+
+// comparator convierte el tercer argumento de sort, que debe nombrar una
+// función del programa, en la comparación del ordenamiento
+func (in *interpreter) comparator(arg, node *models.SyntaxNode) func(a, b rtValue) bool {
+	sym := in.analyzer.references[arg]
+	if arg.Kind != NodeIdentifier || sym == nil || sym.header != "" || sym.Kind != SymbolFunction {
+		in.fail(arg, CodeUnsupported, "El intérprete solo admite comparadores que sean funciones del programa", 1)
+	}
+	function := in.definition(sym, sym.overloads[0])
+	params := functionParams(function)
+	if len(params) != 2 {
+		in.fail(arg, CodeUnsupported, "El comparador '"+sym.Name+"' debe recibir dos parámetros", 1)
+	}
+	return func(a, b rtValue) bool {
+		in.pushScope()
+		arguments := make([]argument, 2)
+		for i, value := range []rtValue{a, b} {
+			arguments[i] = argument{value: value}
+			if typeFromString(params[i].Type).Reference {
+				arguments[i] = argument{loc: in.temporary(value, node), ref: true}
+			}
+		}
+		result := in.truth(in.invoke(function, node, arguments))
+		in.popScope()
+		return result
+	}
+}
+
+// elementAccess resuelve at(), front() y back() de cadenas y vectores,
+// que devuelven una referencia al elemento
+func (in *interpreter) elementAccess(node *models.SyntaxNode) (location, bool) {
+	callee := node.Children[0]
+	if callee.Kind != NodeMember || callee.Value != "at" && callee.Value != "front" && callee.Value != "back" {
+		return location{}, false
+	}
+	loc, object := in.object(callee.Children[0])
+	length := 0
+	switch {
+	case object.t.isString():
+		length = len(object.s)
+	case isVectorType(object.t):
+		length = object.blk.length()
+	default:
+		return location{}, false
+	}
+
+	index := 0
+	switch callee.Value {
+	case "at":
+		value := in.eval(node.Children[1])
+		if value.i < 0 || value.i >= int64(length) {
+			n := strconv.FormatUint(uint64(value.i), 10)
+			prefix := "vector::_M_range_check"
+			if object.t.isString() {
+				prefix = "basic_string::at"
+			}
+			in.throw(node, "out_of_range", prefix+": __n (which is "+n+") >= this->size() (which is "+
+				strconv.Itoa(length)+")")
+		}
+		index = int(value.i)
+	case "front", "back":
+		if length == 0 {
+			in.fail(node, CodeOutOfBounds, "Se llamó a "+callee.Value+"() sobre "+describeObject(object)+" vacío", exitSegv)
+		}
+		if callee.Value == "back" {
+			index = length - 1
+		}
+	}
+	if object.t.isString() {
+		return location{blk: loc.blk, index: loc.index, inString: true, offset: index}, true
+	}
+	return location{blk: object.blk, index: index}, true
+}
+
+func describeObject(v rtValue) string {
+	if v.t.isString() {
+		return "un string"
+	}
+	return "un vector"
+}
+
+// callMethod ejecuta un método de cin, cout, string o vector
+func (in *interpreter) callMethod(node *models.SyntaxNode) rtValue {
+	callee := node.Children[0]
+	if callee.Operator == "->" {
+		in.fail(node, CodeUnsupported, "El intérprete no admite clases ni el operador '->'", 1)
+	}
+	loc, object := in.object(callee.Children[0])
+	switch {
+	case object.t.Name == typeIstream:
+		return in.inputMethod(object, callee.Value, node)
+	case object.t.Name == typeOstream:
+		return in.outputMethod(object, callee.Value, node)
+	case object.t.isString():
+		return in.stringMethod(loc, object, callee.Value, node)
+	case isVectorType(object.t):
+		return in.vectorMethod(loc, object, callee.Value, node)
+	}
+	in.fail(node, CodeUnsupported, "El intérprete no admite clases: no se puede llamar a '"+callee.Value+"'", 1)
+	return rtValue{}
+}
+
+func (in *interpreter) inputMethod(stream rtValue, method string, node *models.SyntaxNode) rtValue {
+	input := in.input
+	args := node.Children[1:]
+	boolean := cppType{Name: "bool"}
+	switch method {
+	case "ignore":
+		n := int64(1)
+		delim := int64(-1)
+		if len(args) > 0 {
+			n = in.eval(args[0]).i
+		}
+		if len(args) > 1 {
+			delim = in.eval(args[1]).i
+		}
+		for ; n > 0 && input.pos < len(input.text); n-- {
+			c := input.text[input.pos]
+			input.pos++
+			if int64(c) == delim {
+				break
+			}
+		}
+		return stream
+	case "get", "peek":
+		c := int64(-1)
+		if !input.failed && input.pos < len(input.text) {
+			c = int64(input.text[input.pos])
+			if method == "get" {
+				input.pos++
+			}
+		} else if method == "get" {
+			input.failed, input.eof = true, true
+		}
+		if len(args) > 0 {
+			if c >= 0 {
+				in.store(in.evalLocation(args[0]), rtValue{t: cppType{Name: "int"}, i: c}, node)
+			}
+			return stream
+		}
+		return rtValue{t: cppType{Name: "int"}, i: c}
+	case "fail":
+		return rtValue{t: boolean, i: boolInt(input.failed)}
+	case "eof":
+		return rtValue{t: boolean, i: boolInt(input.eof)}
+	case "good":
+		return rtValue{t: boolean, i: boolInt(!input.failed && !input.eof)}
+	case "clear":
+		input.failed, input.eof = false, false
+		return rtValue{t: cppType{Name: "void"}}
+	}
+	in.fail(node, CodeUnsupported, "El intérprete no admite cin."+method+"()", 1)
+	return rtValue{}
+}
+
+func (in *interpreter) outputMethod(stream rtValue, method string, node *models.SyntaxNode) rtValue {
+	args := node.Children[1:]
+	format := in.format[stream.s]
+	switch method {
+	case "precision":
+		old := int64(format.precision)
+		if len(args) > 0 {
+			format.precision = int(in.eval(args[0]).i)
+		}
+		return rtValue{t: cppType{Name: "long"}, i: old}
+	case "put":
+		in.output(stream.s, string([]byte{byte(in.eval(args[0]).i)}), node)
+		return stream
+	case "flush":
+		return stream
+	}
+	in.fail(node, CodeUnsupported, "El intérprete no admite "+stream.s+"."+method+"()", 1)
+	return rtValue{}
+}
+
+// position valida una posición de string: como la biblioteca, lanza
+// out_of_range si está después del final
+func (in *interpreter) position(v rtValue, length int, method string, node *models.SyntaxNode) int {
+	if v.i < 0 || v.i > int64(length) {
+		in.throw(node, "out_of_range", "basic_string::"+method+": __pos (which is "+
+			strconv.FormatUint(uint64(v.i), 10)+") > this->size() (which is "+strconv.Itoa(length)+")")
+	}
+	return int(v.i)
+}
+
+// charCount lee un número de caracteres; npos o uno demasiado grande
+// llegan hasta el final
+func charCount(v rtValue, available int) int {
+	if v.i < 0 || v.i > int64(available) {
+		return available
+	}
+	return int(v.i)
+}
+
+func (in *interpreter) stringMethod(loc location, object rtValue, method string, node *models.SyntaxNode) rtValue {
+	text := object.s
+	args := node.Children[1:]
+	values := make([]rtValue, len(args))
+	for i, arg := range args {
+		values[i] = in.eval(arg)
+	}
+	str := cppType{Name: "string"}
+	size := cppType{Name: "unsigned long"}
+	npos := rtValue{t: size, i: -1}
+	arg := func(i int, fallback rtValue) rtValue {
+		if i < len(values) {
+			return values[i]
+		}
+		return fallback
+	}
+	// Los strings no se modifican en su lugar: cada cambio copia el resultado
+	set := func(result string) rtValue {
+		in.work(len(result), node)
+		in.store(loc, rtValue{t: str, s: result}, node)
+		return in.load(loc, node)
+	}
+	pointer := func(offset int) rtValue {
+		return rtValue{t: cppType{Name: "char", Pointer: 1}, ptr: location{blk: loc.blk, index: loc.index, inString: true, offset: offset}}
+	}
+
+	switch method {
+	case "size", "length":
+		return rtValue{t: size, i: int64(len(text))}
+	case "empty":
+		return rtValue{t: cppType{Name: "bool"}, i: boolInt(text == "")}
+	case "clear":
+		set("")
+		return rtValue{t: cppType{Name: "void"}}
+	case "substr":
+		pos := in.position(arg(0, rtValue{t: size}), len(text), method, node)
+		n := charCount(arg(1, npos), len(text)-pos)
+		in.work(n, node)
+		return rtValue{t: str, s: text[pos : pos+n]}
+	case "find", "rfind":
+		needle := in.convert(values[0], str, node).s
+		in.work(len(text)+len(needle), node)
+		index := -1
+		if method == "find" {
+			if pos := arg(1, rtValue{t: size}); pos.i >= 0 && pos.i <= int64(len(text)) {
+				if found := strings.Index(text[pos.i:], needle); found >= 0 {
+					index = found + int(pos.i)
+				}
+			}
+		} else {
+			end := charCount(arg(1, npos), len(text))
+			index = strings.LastIndex(text[:min(end+len(needle), len(text))], needle)
+		}
+		return rtValue{t: size, i: int64(index)}
+	case "append":
+		return set(text + in.convert(values[0], str, node).s)
+	case "push_back":
+		return set(text + in.convert(values[0], str, node).s)
+	case "pop_back":
+		if text == "" {
+			in.fail(node, CodeOutOfBounds, "Se llamó a pop_back() sobre un string vacío", exitSegv)
+		}
+		set(text[:len(text)-1])
+		return rtValue{t: cppType{Name: "void"}}
+	case "insert":
+		pos := in.position(values[0], len(text), method, node)
+		return set(text[:pos] + in.convert(values[1], str, node).s + text[pos:])
+	case "erase":
+		pos := in.position(arg(0, rtValue{t: size}), len(text), method, node)
+		n := charCount(arg(1, npos), len(text)-pos)
+		return set(text[:pos] + text[pos+n:])
+	case "replace":
+		pos := in.position(values[0], len(text), method, node)
+		n := charCount(values[1], len(text)-pos)
+		return set(text[:pos] + in.convert(values[2], str, node).s + text[pos+n:])
+	case "compare":
+		other := in.convert(values[0], str, node).s
+		in.work(min(len(text), len(other)), node)
+		return rtValue{t: cppType{Name: "int"}, i: int64(strings.Compare(text, other))}
+	case "c_str":
+		return in.convert(object, cppType{Name: "char", Const: true, Pointer: 1}, node)
+	case "begin":
+		return pointer(0)
+	case "end":
+		return pointer(len(text))
+	}
+	in.fail(node, CodeUnsupported, "El intérprete no admite string::"+method+"()", 1)
+	return rtValue{}
+}
+
+func (in *interpreter) vectorMethod(loc location, object rtValue, method string, node *models.SyntaxNode) rtValue {
+	b := object.blk
+	args := node.Children[1:]
+	void := rtValue{t: cppType{Name: "void"}}
+	size := cppType{Name: "unsigned long"}
+	iterator := func(index int) rtValue {
+		t := b.elem
+		t.Pointer++
+		return rtValue{t: t, ptr: location{blk: b, index: index}}
+	}
+	// position valida un iterador del propio vector
+	position := func(arg *models.SyntaxNode, allowEnd bool) int {
+		it := in.eval(arg)
+		if it.ptr.blk != b || it.ptr.index < 0 || it.ptr.index > b.length() || !allowEnd && it.ptr.index == b.length() {
+			in.fail(arg, CodeInvalidMemory, "El iterador no pertenece al vector o está fuera de sus límites", exitSegv)
+		}
+		return it.ptr.index
+	}
+	// rebuild reemplaza los elementos desde from por los indicados
+	rebuild := func(from int, tail []rtValue) {
+		in.work(len(tail)*int(elementSize(b.elem)), node)
+		in.truncate(b, from)
+		for _, value := range tail {
+			in.appendElement(b, value, node)
+		}
+	}
+	elements := func(from, to int) []rtValue {
+		var values []rtValue
+		for i := from; i < to; i++ {
+			values = append(values, b.get(i))
+		}
+		return values
+	}
+
+	switch method {
+	case "size", "capacity":
+		return rtValue{t: size, i: int64(b.length())}
+	case "empty":
+		return rtValue{t: cppType{Name: "bool"}, i: boolInt(b.length() == 0)}
+	case "clear":
+		in.truncate(b, 0)
+		return void
+	case "push_back", "emplace_back":
+		in.appendElement(b, in.eval(args[0]), node)
+		return void
+	case "pop_back":
+		if b.length() == 0 {
+			in.fail(node, CodeOutOfBounds, "Se llamó a pop_back() sobre un vector vacío", exitSegv)
+		}
+		in.truncate(b, b.length()-1)
+		return void
+	case "resize", "assign":
+		n := in.size(in.eval(args[0]), node)
+		fill := in.zero(b.elem, "", node)
+		if len(args) > 1 {
+			fill = in.eval(args[1])
+		}
+		if method == "assign" {
+			in.truncate(b, 0)
+		}
+		if n < b.length() {
+			in.truncate(b, n)
+		}
+		in.checkAvailable(int64(n-b.length())*elementSize(b.elem), node)
+		for b.length() < n {
+			in.appendElement(b, fill, node)
+		}
+		return void
+	case "reserve":
+		return void
+	case "begin":
+		return iterator(0)
+	case "end":
+		return iterator(b.length())
+	case "insert":
+		index := position(args[0], true)
+		value := in.eval(args[1])
+		rebuild(index, append([]rtValue{value}, elements(index, b.length())...))
+		return iterator(index)
+	case "erase":
+		first := position(args[0], false)
+		last := first + 1
+		if len(args) > 1 {
+			last = position(args[1], true)
+		}
+		if last < first {
+			in.fail(node, CodeInvalidMemory, "El rango de erase() no es válido", exitSegv)
+		}
+		rebuild(first, elements(last, b.length()))
+		return iterator(first)
+	case "swap":
+		other := in.evalLocation(args[0])
+		value := in.load(other, node)
+		other.blk.set(other.index, object)
+		loc.blk.set(loc.index, value)
+		return void
+	}
+	in.fail(node, CodeUnsupported, "El intérprete no admite vector::"+method+"()", 1)
+	return rtValue{}
+}
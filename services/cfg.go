@@ -0,0 +1,663 @@
+package services
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+// Tipos de bloque del grafo de flujo de control
+const (
+	BlockEntry = "entry"
+	BlockExit  = "exit"
+	BlockBasic = "basic"
+)
+
+// Tipos de arco: la rama que se sigue al evaluar una condición, el salto
+// de una sentencia o la continuación normal (vacío)
+const (
+	EdgeTrue        = "true"
+	EdgeFalse       = "false"
+	EdgeBack        = "back"
+	EdgeBreak       = "break"
+	EdgeContinue    = "continue"
+	EdgeReturn      = "return"
+	EdgeCase        = "case"
+	EdgeDefault     = "default"
+	EdgeFallthrough = "fallthrough"
+)
+
+// Papel de un elemento del bloque que no es una sentencia completa
+const (
+	ElementCondition = "condition" // condición de if, while, for, do-while o switch
+	ElementIncrement = "increment" // incremento de un for
+	ElementRange     = "range"     // expresión recorrida por un for de rango
+	ElementIteration = "iteration" // variable de un for de rango, asignada en cada vuelta
+)
+
+// cfgBlock es un bloque básico. Los elementos son sentencias (VarDecl,
+// ExprStmt, Return) o expresiones con el papel indicado en role.
+type cfgBlock struct {
+	id       int
+	kind     string
+	label    string
+	elements []cfgElement
+	succs    []cfgEdge
+	preds    []*cfgBlock
+}
+
+type cfgElement struct {
+	node *models.SyntaxNode
+	role string
+}
+
+type cfgEdge struct {
+	to    *cfgBlock
+	kind  string
+	label string
+}
+
+// controlFlowGraph es el grafo de una función; las fases posteriores lo
+// recorren con los nodos del árbol de cada elemento
+type controlFlowGraph struct {
+	name     string
+	function *models.SyntaxNode
+	blocks   []*cfgBlock
+	entry    *cfgBlock
+	exit     *cfgBlock
+}
+
+// cfgBuilder construye el grafo recorriendo las sentencias en orden. Tras
+// un salto (return, break, continue) current queda en nil: la siguiente
+// sentencia abre un bloque sin predecesores, es decir, inalcanzable.
+type cfgBuilder struct {
+	graph     *controlFlowGraph
+	current   *cfgBlock
+	breaks    []*cfgBlock
+	continues []*cfgBlock
+}
+
+// buildCFG construye el grafo de una función con cuerpo
+func buildCFG(name string, function *models.SyntaxNode) *controlFlowGraph {
+	g := &controlFlowGraph{name: name, function: function}
+	b := &cfgBuilder{graph: g}
+	g.entry = b.newBlock(BlockEntry, "")
+	g.exit = b.newBlock(BlockExit, "")
+	b.current = b.newBlock(BlockBasic, "")
+	b.connect(g.entry, b.current, "", "")
+
+	for _, stmt := range functionBody(function).Children {
+		b.statement(stmt)
+	}
+	b.jump(g.exit, "")
+	g.prune()
+	return g
+}
+
+func (b *cfgBuilder) newBlock(kind, label string) *cfgBlock {
+	block := &cfgBlock{kind: kind, label: label}
+	b.graph.blocks = append(b.graph.blocks, block)
+	return block
+}
+
+func (b *cfgBuilder) connect(from, to *cfgBlock, kind, label string) {
+	from.succs = append(from.succs, cfgEdge{to: to, kind: kind, label: label})
+	to.preds = append(to.preds, from)
+}
+
+// jump une el bloque actual con el destino, si el bloque actual es alcanzable
+// desde la sentencia anterior
+func (b *cfgBuilder) jump(to *cfgBlock, kind string) {
+	if b.current != nil {
+		b.connect(b.current, to, kind, "")
+	}
+}
+
+// add agrega un elemento al bloque actual, abriendo uno nuevo si el código
+// anterior terminó en un salto
+func (b *cfgBuilder) add(node *models.SyntaxNode, role string) {
+	if b.current == nil {
+		b.current = b.newBlock(BlockBasic, "")
+	}
+	b.current.elements = append(b.current.elements, cfgElement{node: node, role: role})
+}
+
+// condition agrega la condición al bloque actual y lo devuelve
+func (b *cfgBuilder) condition(node *models.SyntaxNode) *cfgBlock {
+	b.add(node, ElementCondition)
+	return b.current
+}
+
+// startLoop abre la cabecera de un ciclo a la que se vuelve en cada vuelta
+func (b *cfgBuilder) startLoop(label string) *cfgBlock {
+	header := b.newBlock(BlockBasic, label)
+	b.jump(header, "")
+	b.current = header
+	return header
+}
+
+func (b *cfgBuilder) pushTargets(breakTo, continueTo *cfgBlock) {
+	b.breaks = append(b.breaks, breakTo)
+	b.continues = append(b.continues, continueTo)
+}
+
+func (b *cfgBuilder) popTargets() {
+	b.breaks = b.breaks[:len(b.breaks)-1]
+	b.continues = b.continues[:len(b.continues)-1]
+}
+
+func (b *cfgBuilder) statement(node *models.SyntaxNode) {
+	switch node.Kind {
+	case NodeBlock:
+		for _, stmt := range node.Children {
+			b.statement(stmt)
+		}
+
+	case NodeEmpty:
+
+	case NodeIf:
+		cond := b.condition(node.Children[0])
+		join := b.newBlock(BlockBasic, "")
+
+		b.current = b.newBlock(BlockBasic, "")
+		b.connect(cond, b.current, EdgeTrue, "")
+		b.statement(node.Children[1])
+		b.jump(join, "")
+
+		if len(node.Children) > 2 {
+			b.current = b.newBlock(BlockBasic, "else")
+			b.connect(cond, b.current, EdgeFalse, "")
+			b.statement(node.Children[2])
+			b.jump(join, "")
+		} else {
+			b.connect(cond, join, EdgeFalse, "")
+		}
+		b.current = join
+
+	case NodeWhile:
+		header := b.startLoop("while")
+		b.condition(node.Children[0])
+		b.loopBody(header, header, node.Children[1], !isConstantTrue(node.Children[0]))
+
+	case NodeDoWhile:
+		body := b.newBlock(BlockBasic, "do")
+		cond := b.newBlock(BlockBasic, "")
+		exit := b.newBlock(BlockBasic, "")
+		b.jump(body, "")
+		b.current = body
+		b.pushTargets(exit, cond)
+		b.statement(node.Children[0])
+		b.popTargets()
+		b.jump(cond, "")
+		b.current = cond
+		b.condition(node.Children[1])
+		b.connect(b.current, body, EdgeBack, "")
+		if !isConstantTrue(node.Children[1]) {
+			b.connect(b.current, exit, EdgeFalse, "")
+		}
+		b.current = exit
+
+	case NodeFor:
+		if init := node.Children[0]; init.Kind != NodeEmpty {
+			b.add(init, "")
+		}
+		header := b.startLoop("for")
+		cond := node.Children[1]
+		if cond.Kind != NodeEmpty {
+			b.condition(cond)
+		}
+		step := b.newBlock(BlockBasic, "")
+		if incr := node.Children[2]; incr.Kind != NodeEmpty {
+			step.elements = append(step.elements, cfgElement{node: incr, role: ElementIncrement})
+		}
+		b.connect(step, header, EdgeBack, "")
+		b.loopBody(header, step, node.Children[3], cond.Kind != NodeEmpty && !isConstantTrue(cond))
+
+	case NodeRangeFor:
+		b.add(node.Children[1], ElementRange)
+		header := b.startLoop("for")
+		b.add(node.Children[0], ElementIteration)
+		b.loopBody(header, header, node.Children[2], true)
+
+	case NodeSwitch:
+		b.switchStatement(node)
+
+	case NodeBreak:
+		if n := len(b.breaks); n > 0 {
+			b.jump(b.breaks[n-1], EdgeBreak)
+		}
+		b.current = nil
+
+	case NodeContinue:
+		for i := len(b.continues) - 1; i >= 0; i-- {
+			// Los switch no tienen destino para continue
+			if b.continues[i] != nil {
+				b.jump(b.continues[i], EdgeContinue)
+				break
+			}
+		}
+		b.current = nil
+
+	case NodeReturn:
+		b.add(node, "")
+		b.jump(b.graph.exit, EdgeReturn)
+		b.current = nil
+
+	default:
+		b.add(node, "")
+	}
+}
+
+// loopBody completa un ciclo cuya cabecera ya evaluó la condición: el
+// cuerpo vuelve a next (la cabecera o el incremento del for) y la salida
+// se alcanza con break o, si el ciclo puede terminar (finite), con la
+// condición falsa. Sin condición o con una siempre verdadera solo se sale
+// con break.
+func (b *cfgBuilder) loopBody(header, next *cfgBlock, body *models.SyntaxNode, finite bool) {
+	check := b.current
+	exit := b.newBlock(BlockBasic, "")
+	if finite {
+		b.connect(check, exit, EdgeFalse, "")
+	}
+
+	b.current = b.newBlock(BlockBasic, "")
+	b.connect(check, b.current, EdgeTrue, "")
+	b.pushTargets(exit, next)
+	b.statement(body)
+	b.popTargets()
+	if next == header {
+		b.jump(header, EdgeBack)
+	} else {
+		b.jump(next, "")
+	}
+	b.current = exit
+}
+
+// switchStatement crea un bloque por etiqueta. Sin break, la última
+// sentencia de una etiqueta continúa en la siguiente (fallthrough).
+func (b *cfgBuilder) switchStatement(node *models.SyntaxNode) {
+	dispatch := b.condition(node.Children[0])
+	exit := b.newBlock(BlockBasic, "")
+	b.pushTargets(exit, nil)
+
+	hasDefault := false
+	b.current = nil
+	for _, label := range node.Children[1:] {
+		stmts := label.Children
+		block := b.newBlock(BlockBasic, "default")
+		if label.Kind == NodeCase {
+			value := formatExpr(label.Children[0])
+			block.label = "case " + value
+			b.connect(dispatch, block, EdgeCase, value)
+			stmts = label.Children[1:]
+		} else {
+			hasDefault = true
+			b.connect(dispatch, block, EdgeDefault, "")
+		}
+		b.jump(block, EdgeFallthrough)
+		b.current = block
+		for _, stmt := range stmts {
+			b.statement(stmt)
+		}
+	}
+	b.jump(exit, "")
+	b.popTargets()
+
+	if !hasDefault {
+		b.connect(dispatch, exit, EdgeDefault, "")
+	}
+	b.current = exit
+}
+
+// isConstantTrue reconoce condiciones como true o 1
+func isConstantTrue(node *models.SyntaxNode) bool {
+	switch node.Kind {
+	case NodeBoolLiteral:
+		return node.Value == "true"
+	case NodeIntLiteral:
+		return node.Value != "0"
+	}
+	return false
+}
+
+// prune elimina los bloques vacíos sin predecesores que quedan tras un
+// salto, saltea los bloques vacíos de paso (una rama con solo break) y
+// numera los bloques en orden, con la salida al final
+func (g *controlFlowGraph) prune() {
+	for _, block := range g.blocks {
+		if block.kind != BlockBasic || block.label != "" || len(block.elements) > 0 ||
+			len(block.succs) != 1 || block.succs[0].to == block || len(block.preds) == 0 {
+			continue
+		}
+		next := block.succs[0]
+		for _, pred := range block.preds {
+			for i, edge := range pred.succs {
+				if edge.to != block {
+					continue
+				}
+				edge.to = next.to
+				if edge.kind == "" {
+					edge.kind, edge.label = next.kind, next.label
+				}
+				pred.succs[i] = edge
+				next.to.preds = append(next.to.preds, pred)
+			}
+		}
+		next.to.removePred(block)
+		block.preds, block.succs = nil, nil
+	}
+
+	for changed := true; changed; {
+		changed = false
+		kept := g.blocks[:0]
+		for _, block := range g.blocks {
+			if block.kind == BlockBasic && len(block.preds) == 0 && len(block.elements) == 0 {
+				for _, edge := range block.succs {
+					edge.to.removePred(block)
+				}
+				changed = true
+				continue
+			}
+			kept = append(kept, block)
+		}
+		g.blocks = kept
+	}
+
+	ordered := make([]*cfgBlock, 0, len(g.blocks))
+	for _, block := range g.blocks {
+		if block != g.exit {
+			ordered = append(ordered, block)
+		}
+	}
+	g.blocks = append(ordered, g.exit)
+	for i, block := range g.blocks {
+		block.id = i
+	}
+}
+
+func (block *cfgBlock) removePred(pred *cfgBlock) {
+	for i, p := range block.preds {
+		if p == pred {
+			block.preds = append(block.preds[:i], block.preds[i+1:]...)
+			return
+		}
+	}
+}
+
+// reachable devuelve los bloques a los que se llega desde la entrada
+func (g *controlFlowGraph) reachable() map[*cfgBlock]bool {
+	seen := map[*cfgBlock]bool{g.entry: true}
+	pending := []*cfgBlock{g.entry}
+	for len(pending) > 0 {
+		block := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		for _, edge := range block.succs {
+			if !seen[edge.to] {
+				seen[edge.to] = true
+				pending = append(pending, edge.to)
+			}
+		}
+	}
+	return seen
+}
+
+// functionGraphs construye el grafo de cada función con cuerpo, incluidos
+// los métodos definidos dentro de las clases
+func functionGraphs(tree *models.SyntaxNode) []*controlFlowGraph {
+	var graphs []*controlFlowGraph
+	for _, node := range tree.Children {
+		switch node.Kind {
+		case NodeFunction:
+			if functionBody(node) != nil {
+				graphs = append(graphs, buildCFG(node.Value, node))
+			}
+		case NodeClass:
+			for _, member := range classMembers(node) {
+				if member.Kind == NodeFunction && functionBody(member) != nil {
+					graphs = append(graphs, buildCFG(node.Value+"::"+member.Value, member))
+				}
+			}
+		}
+	}
+	return graphs
+}
+
+// classMembers devuelve las declaraciones del cuerpo de una clase
+func classMembers(class *models.SyntaxNode) []*models.SyntaxNode {
+	if n := len(class.Children); n > 0 && class.Children[n-1].Kind == NodeBlock {
+		return class.Children[n-1].Children
+	}
+	return nil
+}
+
+// AnalyzeControlFlow construye el grafo de flujo de control de cada
+// función del programa
+func AnalyzeControlFlow(code string) models.ControlFlowResult {
+	tree, diagnostics := Parse(code)
+	return controlFlowResult(tree, diagnostics, nil)
+}
+
+func controlFlowResult(tree *models.SyntaxNode, diagnostics []models.Diagnostic, source *sourceFiles) models.ControlFlowResult {
+	result := models.ControlFlowResult{Functions: []models.ControlFlowGraph{}, Diagnostics: diagnosticList(diagnostics)}
+	var dot strings.Builder
+	dot.WriteString("digraph cfg {\n\tnode [shape=box, fontname=\"monospace\"];\n")
+	for i, g := range functionGraphs(tree) {
+		result.Functions = append(result.Functions, g.export(source))
+		g.writeDot(&dot, "f"+strconv.Itoa(i))
+	}
+	dot.WriteString("}\n")
+	result.Dot = dot.String()
+	return result
+}
+
+func (g *controlFlowGraph) export(source *sourceFiles) models.ControlFlowGraph {
+	reachable := g.reachable()
+	result := models.ControlFlowGraph{
+		Function: g.name,
+		Entry:    g.entry.id,
+		Exit:     g.exit.id,
+		Blocks:   []models.BasicBlock{},
+		Edges:    []models.ControlEdge{},
+	}
+	result.File, result.Line = source.locate(g.function.Line)
+
+	for _, block := range g.blocks {
+		exported := models.BasicBlock{
+			ID:         block.id,
+			Kind:       block.kind,
+			Label:      block.label,
+			Reachable:  reachable[block],
+			Statements: []models.BlockElement{},
+		}
+		for _, element := range block.elements {
+			kind := element.role
+			if kind == "" {
+				kind = element.node.Kind
+			}
+			file, line := source.locate(element.node.Line)
+			exported.Statements = append(exported.Statements, models.BlockElement{
+				Kind:   kind,
+				Text:   elementText(element.node),
+				File:   file,
+				Line:   line,
+				Column: element.node.Column,
+			})
+		}
+		result.Blocks = append(result.Blocks, exported)
+
+		for _, edge := range block.succs {
+			result.Edges = append(result.Edges, models.ControlEdge{
+				From: block.id, To: edge.to.id, Kind: edge.kind, Label: edge.label,
+			})
+		}
+	}
+	return result
+}
+
+// elementText reconstruye el texto de una sentencia o expresión del bloque
+func elementText(node *models.SyntaxNode) string {
+	switch node.Kind {
+	case NodeVarDecl:
+		var declarators []string
+		for _, declarator := range node.Children {
+			text := declarator.Value
+			if init := initializer(declarator); init != nil {
+				if init.Kind == NodeInitList {
+					text += formatExpr(init)
+				} else {
+					text += " = " + formatExpr(init)
+				}
+			}
+			declarators = append(declarators, text)
+		}
+		return node.Type + " " + strings.Join(declarators, ", ")
+	case NodeExprStmt:
+		var exprs []string
+		for _, child := range node.Children {
+			exprs = append(exprs, formatExpr(child))
+		}
+		return strings.Join(exprs, ", ")
+	case NodeReturn:
+		if len(node.Children) == 0 {
+			return "return"
+		}
+		return "return " + formatExpr(node.Children[0])
+	}
+	return formatExpr(node)
+}
+
+// writeDot escribe el grafo como un subgrafo de Graphviz; los bloques
+// inalcanzables se dibujan punteados
+func (g *controlFlowGraph) writeDot(dot *strings.Builder, prefix string) {
+	reachable := g.reachable()
+	id := func(block *cfgBlock) string {
+		return prefix + "_b" + strconv.Itoa(block.id)
+	}
+
+	dot.WriteString("\tsubgraph cluster_" + prefix + " {\n")
+	dot.WriteString("\t\tlabel=" + dotQuote(g.name) + ";\n")
+	for _, block := range g.blocks {
+		var attrs []string
+		switch block.kind {
+		case BlockEntry, BlockExit:
+			attrs = append(attrs, "label="+dotQuote(block.kind), "shape=oval")
+		default:
+			var lines []string
+			if block.label != "" {
+				lines = append(lines, block.label+":")
+			}
+			for _, element := range block.elements {
+				lines = append(lines, elementText(element.node))
+			}
+			attrs = append(attrs, "label="+dotQuote(strings.Join(lines, "\n")+"\n"))
+		}
+		if !reachable[block] {
+			attrs = append(attrs, "style=dashed", "color=gray")
+		}
+		dot.WriteString("\t\t" + id(block) + " [" + strings.Join(attrs, ", ") + "];\n")
+	}
+	for _, block := range g.blocks {
+		for _, edge := range block.succs {
+			line := "\t\t" + id(block) + " -> " + id(edge.to)
+			label := strings.TrimSpace(edge.kind + " " + edge.label)
+			if label != "" {
+				line += " [label=" + dotQuote(label) + "]"
+			}
+			dot.WriteString(line + ";\n")
+		}
+	}
+	dot.WriteString("\t}\n")
+}
+
+// dotQuote escribe una cadena de Graphviz; los saltos de línea alinean el
+// texto a la izquierda
+func dotQuote(text string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\l`).Replace(text) + `"`
+}
+
+// route busca con un recorrido en anchura el camino más corto desde start.
+// visit indica si el bloque es el destino y si se puede continuar por él.
+func (g *controlFlowGraph) route(start *cfgBlock, visit func(*cfgBlock) (bool, bool)) []routeStep {
+	type item struct {
+		block *cfgBlock
+		path  []routeStep
+	}
+	seen := map[*cfgBlock]bool{start: true}
+	queue := []item{{block: start}}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, edge := range current.block.succs {
+			if seen[edge.to] {
+				continue
+			}
+			seen[edge.to] = true
+			path := append(append([]routeStep{}, current.path...), routeStep{from: current.block, edge: edge})
+			done, pass := visit(edge.to)
+			if done {
+				return path
+			}
+			if pass {
+				queue = append(queue, item{edge.to, path})
+			}
+		}
+	}
+	return nil
+}
+
+// routeStep es un arco recorrido en un camino
+type routeStep struct {
+	from *cfgBlock
+	edge cfgEdge
+}
+
+// branchSteps describe las decisiones tomadas en un camino: qué rama de
+// cada condición se sigue y por qué etiqueta se entra a un switch
+func branchSteps(route []routeStep) []models.PathStep {
+	var steps []models.PathStep
+	for _, step := range route {
+		var condition *models.SyntaxNode
+		for _, element := range step.from.elements {
+			if element.role == ElementCondition {
+				condition = element.node
+			}
+		}
+		switch step.edge.kind {
+		case EdgeTrue, EdgeFalse:
+			if condition == nil {
+				if step.from.label == "for" && len(step.from.elements) > 0 {
+					message := "Se entra al ciclo"
+					if step.edge.kind == EdgeFalse {
+						message = "No quedan elementos por recorrer"
+					}
+					steps = append(steps, stepAt(message, step.from.elements[0].node))
+				}
+				continue
+			}
+			value := "verdadera"
+			if step.edge.kind == EdgeFalse {
+				value = "falsa"
+			}
+			steps = append(steps, stepAt("La condición '"+formatExpr(condition)+"' es "+value, condition))
+		case EdgeCase:
+			if condition != nil {
+				steps = append(steps, stepAt("Se entra por 'case "+step.edge.label+"'", condition))
+			}
+		case EdgeDefault:
+			switch {
+			case condition == nil:
+			case step.edge.to.label == "default":
+				steps = append(steps, stepAt("Se entra por 'default'", condition))
+			default:
+				steps = append(steps, stepAt("Ningún case coincide con '"+formatExpr(condition)+"'", condition))
+			}
+		}
+	}
+	return steps
+}
+
+// stepAt crea un paso del camino en la posición del nodo
+func stepAt(message string, node *models.SyntaxNode) models.PathStep {
+	return models.PathStep{Message: message, Line: node.Line, Column: node.Column}
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+// graphText escribe cada bloque como "id tipo etiqueta: elementos", con un
+// signo ! si es inalcanzable, seguido de los arcos "origen->destino tipo"
+func graphText(g models.ControlFlowGraph) []string {
+	lines := []string{}
+	for _, block := range g.Blocks {
+		var elements []string
+		for _, element := range block.Statements {
+			elements = append(elements, element.Kind+" "+element.Text)
+		}
+		text := strings.TrimSpace(strconv.Itoa(block.ID)+" "+block.Kind+" "+block.Label) + ": " + strings.Join(elements, "; ")
+		if !block.Reachable {
+			text = "!" + text
+		}
+		lines = append(lines, strings.TrimSpace(text))
+	}
+	for _, edge := range g.Edges {
+		lines = append(lines, strings.TrimSpace(strconv.Itoa(edge.From)+"->"+strconv.Itoa(edge.To)+" "+edge.Kind+" "+edge.Label))
+	}
+	return lines
+}
+
+func TestControlFlowGraph(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want []string
+	}{
+		{
+			name: "if con else",
+			code: "int main() {\n    int x = 1;\n    if (x > 0) {\n        x = 2;\n    } else {\n        x = 3;\n    }\n    return x;\n}\n",
+			want: []string{
+				"0 entry:", "1 basic: VarDecl int x = 1; condition x > 0", "2 basic: Return return x",
+				"3 basic: ExprStmt x = 2", "4 basic else: ExprStmt x = 3", "5 exit:",
+				"0->1", "1->3 true", "1->4 false", "2->5 return", "3->2", "4->2",
+			},
+		},
+		{
+			name: "for con continue y break",
+			code: "int main() {\n    int s = 0;\n    for (int i = 0; i < 3; i++) {\n        if (i == 1) continue;\n        if (i == 2) break;\n        s += i;\n    }\n    return s;\n}\n",
+			want: []string{
+				"0 entry:", "1 basic: VarDecl int s = 0; VarDecl int i = 0", "2 basic for: condition i < 3",
+				"3 basic: increment i++", "4 basic: Return return s", "5 basic: condition i == 1",
+				"6 basic: condition i == 2", "7 basic: ExprStmt s += i", "8 exit:",
+				"0->1", "1->2", "2->4 false", "2->5 true", "3->2 back", "4->8 return",
+				"5->3 true", "5->6 false", "6->4 true", "6->7 false", "7->3",
+			},
+		},
+		{
+			name: "do-while",
+			code: "int main() {\n    int i = 0;\n    do {\n        i++;\n    } while (i < 3);\n    return i;\n}\n",
+			want: []string{
+				"0 entry:", "1 basic: VarDecl int i = 0", "2 basic do: ExprStmt i++", "3 basic: condition i < 3",
+				"4 basic: Return return i", "5 exit:",
+				"0->1", "1->2", "2->3", "3->2 back", "3->4 false", "4->5 return",
+			},
+		},
+		{
+			name: "switch con caída",
+			code: "int main() {\n    int x = 1;\n    switch (x) {\n    case 1:\n        x = 2;\n    case 2:\n        x = 3;\n        break;\n    default:\n        x = 4;\n    }\n    return x;\n}\n",
+			want: []string{
+				"0 entry:", "1 basic: VarDecl int x = 1; condition x", "2 basic: Return return x",
+				"3 basic case 1: ExprStmt x = 2", "4 basic case 2: ExprStmt x = 3", "5 basic default: ExprStmt x = 4", "6 exit:",
+				"0->1", "1->3 case 1", "1->4 case 2", "1->5 default", "2->6 return", "3->4 fallthrough", "4->2 break", "5->2",
+			},
+		},
+		{
+			name: "ciclo infinito",
+			code: "int main() {\n    while (true) {\n    }\n    return 0;\n}\n",
+			want: []string{"0 entry:", "1 basic while: condition true", "!2 basic: Return return 0", "!3 exit:", "0->1", "1->1 true", "2->3 return"},
+		},
+		{
+			name: "código después de return",
+			code: "int main() {\n    return 0;\n    int y = 1;\n}\n",
+			want: []string{"0 entry:", "1 basic: Return return 0", "!2 basic: VarDecl int y = 1", "3 exit:", "0->1", "1->3 return", "2->3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := AnalyzeControlFlow(tt.code)
+			if len(result.Functions) != 1 {
+				t.Fatalf("%d grafos, se esperaba 1", len(result.Functions))
+			}
+			g := result.Functions[0]
+			if g.Function != "main" || g.Entry != 0 || g.Exit != len(g.Blocks)-1 {
+				t.Errorf("función %q con entrada %d y salida %d", g.Function, g.Entry, g.Exit)
+			}
+			if got := graphText(g); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("grafo =\n%s\nse esperaba\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestControlFlowFunctions(t *testing.T) {
+	code := "int doble(int x) { return 2 * x; }\nclass A { public: int f() { return 1; } };\nint main() { return doble(1); }\n"
+	result := AnalyzeControlFlow(code)
+	var names []string
+	for _, g := range result.Functions {
+		names = append(names, g.Function)
+	}
+	if want := []string{"doble", "A::f", "main"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("funciones = %v, se esperaba %v", names, want)
+	}
+	if !strings.HasPrefix(result.Dot, "digraph cfg {") || strings.Count(result.Dot, "subgraph") != len(names) {
+		t.Errorf("DOT inesperado:\n%s", result.Dot)
+	}
+}
@@ -0,0 +1,589 @@
+package services
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+// classInfo describe un struct o class declarado en el programa. Sus
+// miembros viven en un ámbito de tipo class cuyo padre es el ámbito de la
+// clase base, de modo que la búsqueda de miembros heredados y la
+// resolución de nombres dentro de los métodos siguen la cadena de ámbitos.
+type classInfo struct {
+	name  string
+	kind  string // struct o class
+	node  *models.SyntaxNode
+	scope *scope // nil mientras solo exista una declaración anticipada
+	base  *classInfo
+
+	// Definiciones fuera de la clase de los métodos declarados en ella
+	definitions map[*models.SyntaxNode]*models.SyntaxNode
+
+	// Solo para las clases de la biblioteca estándar: el tipo de los
+	// elementos de v[i] y si se inicializan con una lista {a, b, c}
+	library bool
+	element cppType
+	list    bool
+}
+
+// derivesFrom indica si la clase es other o hereda de ella
+func (c *classInfo) derivesFrom(other *classInfo) bool {
+	for current := c; current != nil; current = current.base {
+		if current == other {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupMember busca un miembro en la clase y en sus clases base
+func (c *classInfo) lookupMember(name string) *symbol {
+	for s := c.scope; s != nil && s.kind == ScopeClass; s = s.parent {
+		if sym := s.lookupLocal(name); sym != nil {
+			return sym
+		}
+	}
+	return nil
+}
+
+// fields devuelve los atributos propios en orden de declaración
+func (c *classInfo) fields() []*symbol {
+	var fields []*symbol
+	for _, sym := range c.scope.order {
+		if sym.Kind == SymbolField {
+			fields = append(fields, sym)
+		}
+	}
+	return fields
+}
+
+// classOf devuelve la clase de un tipo objeto (no puntero ni arreglo),
+// declarada en el programa o de la biblioteca estándar
+func (a *semanticAnalyzer) classOf(t cppType) *classInfo {
+	if !t.isPlain() {
+		return nil
+	}
+	if info := a.classes[t.Name]; info != nil {
+		return info
+	}
+	return a.libraryClass(t)
+}
+
+// splitQualified separa "Clase::miembro" en sus dos partes
+func splitQualified(name string) (string, string, bool) {
+	i := strings.LastIndex(name, "::")
+	if i <= 0 {
+		return "", name, false
+	}
+	return name[:i], name[i+2:], true
+}
+
+// declareClass registra la clase en el ámbito global y, si tiene cuerpo,
+// sus atributos, métodos y constructores con su nivel de acceso
+func (a *semanticAnalyzer) declareClass(node *models.SyntaxNode) {
+	global := a.table.global()
+	body := functionBody(node)
+
+	info := a.classes[node.Value]
+	if existing := global.lookupLocal(node.Value); existing != nil {
+		if existing.Kind != SymbolClass {
+			a.report(nameDiagnosticAt(CodeRedeclared,
+				"'"+node.Value+"' ya fue declarada como "+kindNoun(existing.Kind)+" en la línea "+a.source.lineRef(existing.Line), node))
+			return
+		}
+		if body == nil {
+			return
+		}
+		if info.scope != nil {
+			a.report(nameDiagnosticAt(CodeRedeclared,
+				"La clase '"+node.Value+"' ya fue definida en la línea "+a.source.lineRef(existing.Line), node))
+			return
+		}
+		// Definición de una clase declarada antes con "class Nombre;"
+		existing.Line, existing.Column = node.Line, node.Column
+		existing.Initialized = true
+		existing.node = node
+	} else {
+		global.add(&symbol{
+			Name:        node.Value,
+			Kind:        SymbolClass,
+			Type:        node.Type,
+			Line:        node.Line,
+			Column:      node.Column,
+			Initialized: body != nil,
+			node:        node,
+		})
+		info = &classInfo{
+			name:        node.Value,
+			kind:        node.Type,
+			definitions: make(map[*models.SyntaxNode]*models.SyntaxNode),
+		}
+		a.classes[node.Value] = info
+	}
+
+	info.node = node
+	if body != nil {
+		a.declareMembers(info, node, body)
+	}
+}
+
+func (a *semanticAnalyzer) declareMembers(info *classInfo, node, body *models.SyntaxNode) {
+	parent := a.table.global()
+	for _, child := range node.Children {
+		if child.Kind != NodeBase {
+			continue
+		}
+		base := a.classes[child.Value]
+		switch {
+		case base == nil || base.scope == nil:
+			a.report(diagnosticAtNode(CodeUndeclared,
+				"La clase base '"+child.Value+"' no fue declarada", child))
+		case info.base == nil:
+			info.base = base
+			parent = base.scope
+		}
+	}
+
+	a.table.current = parent
+	info.scope = a.table.push(ScopeClass, info.name, node.Line)
+	a.table.current = a.table.global()
+
+	access := "private"
+	if info.kind == "struct" {
+		access = "public"
+	}
+	for _, member := range body.Children {
+		switch member.Kind {
+		case NodeAccess:
+			access = member.Value
+
+		case NodeVarDecl:
+			for _, declarator := range member.Children {
+				if existing := info.scope.lookupLocal(declarator.Value); existing != nil {
+					a.report(nameDiagnostic(CodeRedeclared,
+						"El miembro '"+declarator.Value+"' ya fue declarado en '"+info.name+"' (línea "+
+							a.source.lineRef(existing.Line)+")", declarator, declarator.Value))
+					continue
+				}
+				info.scope.add(&symbol{
+					Name:        declarator.Value,
+					Kind:        SymbolField,
+					Type:        declarator.Type,
+					Line:        declarator.Line,
+					Column:      declarator.Column,
+					Initialized: true,
+					node:        declarator,
+					access:      access,
+				})
+			}
+
+		case NodeFunction:
+			kind := SymbolMethod
+			if member.Value == info.name {
+				kind = SymbolConstructor
+			}
+			a.declareOverload(info.scope, member.Value, kind, access, member)
+		}
+	}
+}
+
+// declareMethodDefinition asocia la definición "Clase::metodo(...) { }" con
+// la declaración correspondiente dentro de la clase
+func (a *semanticAnalyzer) declareMethodDefinition(node *models.SyntaxNode) {
+	className, method, _ := splitQualified(node.Value)
+	info := a.classes[className]
+	if info == nil || info.scope == nil {
+		a.report(nameDiagnosticAt(CodeUndeclared, "La clase '"+className+"' no fue declarada", node))
+		return
+	}
+
+	sym := info.scope.lookupLocal(method)
+	if sym == nil || (sym.Kind != SymbolMethod && sym.Kind != SymbolConstructor) {
+		a.report(nameDiagnosticAt(CodeUnknownMember,
+			"La clase '"+className+"' no declara un método llamado '"+method+"'", node))
+		return
+	}
+
+	signature := parameterSignature(node)
+	for _, declaration := range sym.overloads {
+		if parameterSignature(declaration) != signature {
+			continue
+		}
+		previous := info.definitions[declaration]
+		if functionBody(declaration) != nil {
+			previous = declaration
+		}
+		if previous != nil {
+			a.report(nameDiagnosticAt(CodeRedeclared,
+				"El método '"+node.Value+"' ya fue definido en la línea "+a.source.lineRef(previous.Line), node))
+			return
+		}
+		if typeFromString(declaration.Type).Name != typeFromString(node.Type).Name {
+			a.report(nameDiagnosticAt(CodeRedeclared,
+				"El método '"+node.Value+"' fue declarado con tipo de retorno "+declaration.Type+
+					" en la línea "+a.source.lineRef(declaration.Line), node))
+			return
+		}
+		info.definitions[declaration] = node
+		sym.Initialized = true
+		return
+	}
+	a.report(nameDiagnosticAt(CodeUnknownMember,
+		"'"+node.Value+signature+"' no coincide con ninguna declaración de la clase '"+className+"'", node))
+}
+
+// nameDiagnosticAt crea un diagnóstico que cubre solo la primera línea del
+// nodo, para no marcar el cuerpo completo de una clase o función
+func nameDiagnosticAt(code, message string, node *models.SyntaxNode) models.Diagnostic {
+	endLine, endColumn := node.EndLine, node.EndColumn
+	if endLine != node.Line {
+		endLine, endColumn = node.Line, node.Column+1
+	}
+	return newDiagnostic(code, message, node.Line, node.Column, endLine, endColumn)
+}
+
+// analyzeClass valida los valores iniciales de los atributos y los cuerpos
+// de los métodos definidos dentro de la clase
+func (a *semanticAnalyzer) analyzeClass(node *models.SyntaxNode) {
+	info := a.classes[node.Value]
+	body := functionBody(node)
+	if info == nil || info.node != node || body == nil {
+		return
+	}
+
+	a.class = info
+	a.table.current = info.scope
+	for _, member := range body.Children {
+		switch member.Kind {
+		case NodeVarDecl:
+			a.checkTypeName(member.Type, member)
+			for _, declarator := range member.Children {
+				a.checkArraySizes(declarator)
+				init := initializer(declarator)
+				sym := info.scope.lookupLocal(declarator.Value)
+				if sym == nil || sym.node != declarator {
+					continue
+				}
+				sym.Type = a.resolvedArrayType(declarator)
+				if init == nil {
+					continue
+				}
+				initType := unknownType
+				if init.Kind != NodeInitList {
+					initType = a.checkExpr(init)
+				}
+				a.checkInitializer(declarator, sym, init, initType)
+				a.recordConstant(sym, init)
+			}
+		case NodeFunction:
+			a.analyzeFunction(member)
+		}
+	}
+	a.table.current = a.table.global()
+	a.class = nil
+}
+
+// analyzeMethod analiza un método definido fuera de la clase con acceso a
+// los miembros de la clase
+func (a *semanticAnalyzer) analyzeMethod(node *models.SyntaxNode) {
+	className, _, _ := splitQualified(node.Value)
+	info := a.classes[className]
+	if info == nil || info.scope == nil {
+		return
+	}
+	a.class = info
+	a.table.current = info.scope
+	a.analyzeFunction(node)
+	a.table.current = a.table.global()
+	a.class = nil
+}
+
+// checkMemberInits valida la lista de inicialización de un constructor
+func (a *semanticAnalyzer) checkMemberInits(function *models.SyntaxNode) {
+	for _, init := range function.Children {
+		if init.Kind != NodeMemberInit {
+			continue
+		}
+		argTypes := a.checkExprs(init.Children)
+		if a.class == nil {
+			continue
+		}
+
+		if base := a.class.base; base != nil && init.Value == base.name {
+			a.checkConstruction(base, init, init.Children, argTypes, false)
+			continue
+		}
+		sym := a.class.scope.lookupLocal(init.Value)
+		if sym == nil || sym.Kind != SymbolField {
+			a.report(nameDiagnostic(CodeUnknownMember,
+				"La clase '"+a.class.name+"' no tiene un atributo llamado '"+init.Value+"'", init, init.Value))
+			continue
+		}
+		sym.Used = true
+
+		fieldType := typeFromString(sym.Type)
+		if info := a.classOf(fieldType); info != nil {
+			a.checkConstruction(info, init, init.Children, argTypes, false)
+			continue
+		}
+		if len(init.Children) == 1 {
+			a.checkConversion(argTypes[0], fieldType, init.Children[0], CodeTypeMismatch,
+				"Atributo '"+init.Value+"' de tipo "+fieldType.String()+
+					" no puede inicializarse con valor de tipo "+argTypes[0].String())
+		}
+	}
+}
+
+// checkStaticDefinition valida "int Clase::contador = 0;"
+func (a *semanticAnalyzer) checkStaticDefinition(declarator *models.SyntaxNode) {
+	className, member, _ := splitQualified(declarator.Value)
+	init := initializer(declarator)
+	initType := unknownType
+	if init != nil && init.Kind != NodeInitList {
+		initType = a.checkExpr(init)
+	}
+
+	info := a.classes[className]
+	if info == nil || info.scope == nil {
+		a.report(nameDiagnostic(CodeUndeclared,
+			"La clase '"+className+"' no fue declarada", declarator, declarator.Value))
+		return
+	}
+	sym := info.scope.lookupLocal(member)
+	if sym == nil || sym.Kind != SymbolField {
+		a.report(nameDiagnostic(CodeUnknownMember,
+			"La clase '"+className+"' no tiene un atributo llamado '"+member+"'", declarator, declarator.Value))
+		return
+	}
+	if init != nil && init.Kind != NodeInitList {
+		fieldType := typeFromString(sym.Type)
+		a.checkConversion(initType, fieldType, init, CodeTypeMismatch,
+			"Atributo '"+declarator.Value+"' de tipo "+fieldType.String()+
+				" no puede inicializarse con valor de tipo "+initType.String())
+	}
+}
+
+// resolveMember busca el miembro de obj.m u ptr->m y verifica que se
+// pueda acceder a él desde el código actual. Devuelve también el tipo del
+// objeto (en ptr->m, el tipo apuntado).
+func (a *semanticAnalyzer) resolveMember(node *models.SyntaxNode) (*symbol, cppType) {
+	object := node.Children[0]
+	t := a.checkExpr(object)
+	if t.isUnknown() {
+		return nil, t
+	}
+
+	if node.Operator == "->" {
+		if !t.isPointer() {
+			message := "El operador '->' requiere un puntero, se encontró " + t.String()
+			if a.classOf(t) != nil {
+				message = "'" + formatExpr(object) + "' no es un puntero: use '.' en lugar de '->'"
+			}
+			a.report(diagnosticAtNode(CodeInvalidPointer, message, node))
+			return nil, unknownType
+		}
+		t = t.elementType()
+	} else if t.isPointer() && a.classOf(t.elementType()) != nil {
+		a.report(diagnosticAtNode(CodeInvalidPointer,
+			"'"+formatExpr(object)+"' es un puntero: use '->' en lugar de '.'", node))
+		return nil, unknownType
+	}
+
+	info := a.classOf(t)
+	if info == nil {
+		a.report(memberDiagnostic(CodeUnknownMember, "El tipo "+t.String()+" no tiene miembros", node))
+		return nil, t
+	}
+	if info.scope == nil {
+		a.report(memberDiagnostic(CodeUnknownMember,
+			"La clase '"+info.name+"' está declarada pero no definida", node))
+		return nil, t
+	}
+
+	sym := info.lookupMember(node.Value)
+	if sym == nil {
+		a.report(memberDiagnostic(CodeUnknownMember,
+			"La clase '"+t.unqualified().String()+"' no tiene un miembro llamado '"+node.Value+"'", node))
+		return nil, t
+	}
+	sym.Used = true
+	a.references[node] = sym
+	a.checkAccess(sym, node)
+	return sym, t
+}
+
+// memberDiagnostic señala solo el nombre del miembro en obj.miembro
+func memberDiagnostic(code, message string, node *models.SyntaxNode) models.Diagnostic {
+	column := node.EndColumn - len(node.Value)
+	return newDiagnostic(code, message, node.EndLine, column, node.EndLine, node.EndColumn)
+}
+
+// checkAccess informa el uso de un miembro private o protected desde fuera
+// de la clase (o de sus clases derivadas, para protected)
+func (a *semanticAnalyzer) checkAccess(sym *symbol, at *models.SyntaxNode) {
+	owner := a.classes[sym.scope.name]
+	if owner == nil {
+		return
+	}
+
+	var level string
+	switch sym.access {
+	case "private":
+		if a.class == owner {
+			return
+		}
+		level = "privado"
+	case "protected":
+		if a.class != nil && a.class.derivesFrom(owner) {
+			return
+		}
+		level = "protegido"
+	default:
+		return
+	}
+
+	diagnostic := diagnosticAtNode(CodeInaccessibleMember,
+		"'"+sym.Name+"' es "+level+" en '"+owner.name+"'", at)
+	if at.Kind == NodeMember {
+		diagnostic = memberDiagnostic(CodeInaccessibleMember, diagnostic.Message, at)
+	}
+	if sym.Kind == SymbolField {
+		diagnostic.Suggestion = "Usar un método público de '" + owner.name + "' para acceder a '" + sym.Name + "'"
+	}
+	a.report(diagnostic)
+}
+
+// checkMember devuelve el tipo de obj.atributo; un objeto const tiene
+// atributos const
+func (a *semanticAnalyzer) checkMember(node *models.SyntaxNode) cppType {
+	sym, object := a.resolveMember(node)
+	if sym == nil || sym.Kind != SymbolField {
+		return unknownType
+	}
+	t := typeFromString(sym.Type).value()
+	if object.Const && t.isPlain() {
+		t.Const = true
+	}
+	return t
+}
+
+// checkConstruction valida los argumentos con que se construye un objeto:
+// Punto p(1, 2); Punto p{1, 2}; new Punto(1, 2); Punto(1, 2). Sin
+// constructores declarados, las llaves inicializan los atributos en orden.
+func (a *semanticAnalyzer) checkConstruction(info *classInfo, at *models.SyntaxNode, args []*models.SyntaxNode, argTypes []cppType, braces bool) {
+	if info == nil || info.scope == nil {
+		return
+	}
+
+	// vector<int> v{1, 2, 3}; cada valor es un elemento
+	if info.list && braces {
+		for i, arg := range args {
+			a.checkConversion(argTypes[i], info.element, arg, CodeTypeMismatch,
+				"Elemento de '"+info.name+"': se esperaba "+info.element.String()+", se encontró "+argTypes[i].String())
+		}
+		return
+	}
+
+	constructor := info.scope.lookupLocal(info.name)
+	if constructor == nil || constructor.Kind != SymbolConstructor {
+		if len(args) == 0 {
+			return
+		}
+		if !braces {
+			a.report(diagnosticAtNode(CodeArgumentCount,
+				"La clase '"+info.name+"' no tiene un constructor que reciba "+strconv.Itoa(len(args))+" argumento(s)", at))
+			return
+		}
+		fields := info.fields()
+		if len(args) > len(fields) {
+			a.report(diagnosticAtNode(CodeArgumentCount,
+				"Demasiados valores para inicializar '"+info.name+"': tiene "+strconv.Itoa(len(fields))+" atributo(s)", at))
+			return
+		}
+		for i, arg := range args {
+			fieldType := typeFromString(fields[i].Type)
+			a.checkConversion(argTypes[i], fieldType, arg, CodeArgumentType,
+				"Atributo '"+fields[i].Name+"' de '"+info.name+"': se esperaba "+fieldType.String()+
+					", se encontró "+argTypes[i].String())
+		}
+		return
+	}
+
+	constructor.Used = true
+	a.checkAccess(constructor, at)
+	if function := a.selectOverload(constructor, at, args, argTypes); function != nil {
+		a.checkArguments(constructor, function, args, argTypes)
+	}
+}
+
+// checkClassOperator resuelve un operador aplicado a objetos buscando su
+// sobrecarga como método (a.operator<(b)) o como función (operator<(a, b)).
+// handled es false si ningún operando es un objeto.
+func (a *semanticAnalyzer) checkClassOperator(node *models.SyntaxNode, op string, left, right cppType) (result cppType, handled bool) {
+	// Los operadores de string se validan como los de los tipos primitivos
+	if left.isString() || right.isString() {
+		return unknownType, false
+	}
+	info := a.classOf(left)
+	if info == nil && a.classOf(right) == nil {
+		return unknownType, false
+	}
+
+	name := "operator" + op
+	var sym *symbol
+	if info != nil && info.scope != nil {
+		sym = info.lookupMember(name)
+	}
+	if sym == nil {
+		sym = a.table.global().lookupLocal(name)
+	}
+	if sym == nil || len(sym.overloads) == 0 {
+		className := right.Name
+		if info != nil {
+			className = info.name
+		}
+		a.report(diagnosticAtNode(CodeInvalidOperands,
+			"La clase '"+className+"' no define el operador '"+op+"'", node))
+		return unknownType, true
+	}
+	sym.Used = true
+	return typeFromString(sym.overloads[0].Type).value(), true
+}
+
+// checkNew valida new Tipo(args) y new Tipo[n]; el resultado es un puntero
+func (a *semanticAnalyzer) checkNew(node *models.SyntaxNode) cppType {
+	t := typeFromString(node.Type)
+	a.checkType(t, node)
+	argTypes := a.checkExprs(node.Children)
+
+	switch {
+	case node.Operator == "[]":
+		if len(argTypes) > 0 && !argTypes[0].isUnknown() && !argTypes[0].isIntegral() {
+			a.report(diagnosticAtNode(CodeInvalidArray,
+				"El tamaño del arreglo debe ser un entero, se encontró "+argTypes[0].String(), node.Children[0]))
+		}
+	case a.classOf(t) != nil:
+		a.checkConstruction(a.classOf(t), node, node.Children, argTypes, node.Operator == "{}")
+	case len(node.Children) == 1:
+		a.checkConversion(argTypes[0], t, node.Children[0], CodeTypeMismatch,
+			"No se puede crear un "+t.String()+" a partir de un valor de tipo "+argTypes[0].String())
+	}
+
+	if t.isUnknown() {
+		return unknownType
+	}
+	t.Pointer++
+	return t
+}
+
+// checkDelete valida que delete reciba un puntero
+func (a *semanticAnalyzer) checkDelete(node *models.SyntaxNode) cppType {
+	t := a.checkExpr(node.Children[0])
+	if !t.isUnknown() && !t.isPointer() {
+		a.report(diagnosticAtNode(CodeInvalidPointer,
+			"delete solo puede liberar un puntero, se encontró "+t.String(), node.Children[0]))
+	}
+	return cppType{Name: "void"}
+}
@@ -0,0 +1,60 @@
+package services
+
+import "testing"
+
+func TestClasses(t *testing.T) {
+	checkSemantic(t, []semanticCase{
+		{
+			name:    "miembro inexistente",
+			code:    "struct P { int x; };\nint main() { P p; p.x = 1; return p.y; }\n",
+			want:    []string{CodeUnknownMember},
+			message: "La clase 'P' no tiene un miembro llamado 'y'",
+		},
+		{
+			name:    "método inexistente",
+			code:    "class A { public: void f() {} };\nint main() { A a; a.g(); return 0; }\n",
+			want:    []string{CodeUnknownMember},
+			message: "no tiene un miembro llamado 'g'",
+		},
+		{
+			name:    "miembro estático inexistente",
+			code:    "class A { public: static int n; };\nint main() { return A::n + A::m; }\n",
+			want:    []string{CodeUnknownMember},
+			message: "no tiene un miembro llamado 'm'",
+		},
+		{
+			name:    "miembro privado",
+			code:    "class A { int secreto; public: int v; };\nint main() { A a; a.v = 1; return a.secreto; }\n",
+			want:    []string{CodeInaccessibleMember},
+			message: "'secreto' es privado en 'A'",
+		},
+		{
+			name:    "clase definida dos veces",
+			code:    "class A { public: int x; };\nclass A { public: int y; };\nint main() { return 0; }\n",
+			want:    []string{CodeRedeclared},
+			message: "La clase 'A' ya fue definida en la línea 1",
+		},
+		{
+			name:    "constructor sin sus argumentos",
+			code:    "class A { public: A(int v) {} };\nint main() { A a; A b(1); return 0; }\n",
+			want:    []string{CodeArgumentCount, CodeUnusedVariable, CodeUnusedVariable},
+			message: "El constructor de 'A' espera 1 argumento(s), se pasaron 0",
+		},
+		{
+			name:    "tipo no declarado",
+			code:    "int main() { Q q; return 0; }\n",
+			want:    []string{CodeUnknownType, CodeUnusedVariable},
+			message: "El tipo 'Q' no está declarado",
+		},
+		{
+			name: "constructor, método const y miembro privado",
+			code: "class A {\npublic:\n    A(int v) : v(v) {}\n    int get() const { return v; }\nprivate:\n    int v;\n};\nint main() { A a(2); return a.get(); }\n",
+			want: []string{},
+		},
+		{
+			name: "puntero a struct",
+			code: "struct P { int x; };\nint main() { P* p = new P; p->x = 1; int r = p->x; delete p; return r; }\n",
+			want: []string{},
+		},
+	})
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+// compilerPath es el compilador de C++ con el que /grade puede ejecutar el
+// programa real. Está deshabilitado salvo que el servidor lo configure: el
+// programa corre como un proceso más, en un directorio temporal, sin
+// variables de entorno y acotado por los límites de ulimit, así que solo
+// debe habilitarse dentro de un contenedor aislado.
+var compilerPath string
+
+// Límites de la ejecución con el compilador
+const (
+	compileTimeout     = 30 * time.Second
+	defaultTimeLimitMS = 2000
+	maxTimeLimitMS     = 10000
+	nativeOverheadKB   = 64 * 1024 // memoria virtual de la biblioteca de C++
+	exitCPULimit       = 152       // SIGXCPU: se agotó el tiempo de CPU de ulimit -t
+)
+
+// EnableCompiler habilita la ejecución con el compilador indicado
+func EnableCompiler(name string) error {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return err
+	}
+	compilerPath = path
+	return nil
+}
+
+// CompilerAvailable indica si /grade puede usar el compilador
+func CompilerAvailable() bool {
+	return compilerPath != ""
+}
+
+// nativeProgram es un programa compilado en su directorio temporal
+type nativeProgram struct {
+	dir    string
+	binary string
+}
+
+// compileNative escribe los archivos en un directorio temporal y compila
+// el punto de entrada junto con los demás archivos fuente del proyecto, para
+// que se enlacen sus definiciones. Si la compilación falla devuelve nil y
+// los mensajes del compilador; el error indica un problema del servidor.
+func compileNative(files map[string]string, entry string) (*nativeProgram, string, error) {
+	cleaned := make(map[string]string, len(files))
+	for name, content := range files {
+		cleaned[path.Clean(name)] = content
+	}
+	files = cleaned
+	if _, ok := files[entry]; !ok {
+		return nil, "No se encontró el archivo de entrada '" + entry + "'", nil
+	}
+	// Los archivos van con ./ para que ninguno se tome por una opción del
+	// compilador (g++ no acepta -- para terminar las opciones)
+	sources := []string{"./" + entry}
+	for _, name := range sortedKeys(files) {
+		if name != entry && sourceExtensions[strings.ToLower(path.Ext(name))] {
+			sources = append(sources, "./"+name)
+		}
+	}
+
+	dir, err := os.MkdirTemp("", "grade-")
+	if err != nil {
+		return nil, "", err
+	}
+	for name, content := range files {
+		if !filepath.IsLocal(name) {
+			os.RemoveAll(dir)
+			return nil, "Nombre de archivo inválido: " + name, nil
+		}
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			os.RemoveAll(dir)
+			return nil, "", err
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			os.RemoveAll(dir)
+			return nil, "", err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), compileTimeout)
+	defer cancel()
+	binary := filepath.Join(dir, "programa")
+	args := append([]string{"-std=c++17", "-O2", "-o", binary}, sources...)
+	cmd := exec.CommandContext(ctx, compilerPath, args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		os.RemoveAll(dir)
+		return nil, "La compilación superó el tiempo límite de " + compileTimeout.String(), nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		os.RemoveAll(dir)
+		return nil, string(output), nil
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, "", err
+	}
+	return &nativeProgram{dir: dir, binary: binary}, string(output), nil
+}
+
+// remove borra el directorio temporal del programa
+func (p *nativeProgram) remove() {
+	os.RemoveAll(p.dir)
+}
+
+// cappedBuffer guarda la salida del programa hasta max bytes y descarta
+// el resto
+type cappedBuffer struct {
+	text     strings.Builder
+	max      int64
+	exceeded bool
+}
+
+func (b *cappedBuffer) Write(data []byte) (int, error) {
+	if room := b.max - int64(b.text.Len()); int64(len(data)) > room {
+		b.exceeded = true
+		data = data[:max(room, 0)]
+	}
+	b.text.Write(data)
+	return len(data), nil
+}
+
+// run ejecuta el programa con la entrada indicada. El código de salida
+// sigue la convención del shell: 128 + señal si el proceso termina por una
+// señal, como los del intérprete.
+func (p *nativeProgram) run(stdin string, limits models.RunLimits) models.RunResult {
+	timeLimit := int64(defaultTimeLimitMS)
+	if limits.TimeLimitMS > 0 {
+		timeLimit = min(limits.TimeLimitMS, maxTimeLimitMS)
+	}
+	memoryKB := limit(limits.MaxMemoryKB, defaultMaxMemoryKB)
+	outputKB := limit(limits.MaxOutputKB, defaultMaxOutputKB)
+
+	// ulimit fija los límites del propio shell, que luego se reemplaza
+	// por el programa con exec
+	script := "ulimit -v " + strconv.FormatInt(memoryKB+nativeOverheadKB, 10) +
+		" && ulimit -t " + strconv.FormatInt(timeLimit/1000+1, 10) +
+		" && ulimit -f " + strconv.FormatInt(outputKB, 10) +
+		` && exec "$0"`
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeLimit)*time.Millisecond)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", script, p.binary)
+	cmd.Dir = p.dir
+	cmd.Env = []string{}
+	cmd.Stdin = strings.NewReader(stdin)
+	stdout := &cappedBuffer{max: outputKB * 1024}
+	stderr := &cappedBuffer{max: outputKB * 1024}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	err := cmd.Run()
+
+	result := models.RunResult{Status: RunOK, Diagnostics: []models.Diagnostic{}}
+	if cmd.ProcessState != nil {
+		result.ExitCode = cmd.ProcessState.ExitCode()
+		if status, ok := cmd.ProcessState.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			result.ExitCode = 128 + int(status.Signal())
+		}
+	}
+	switch {
+	case ctx.Err() != nil, result.ExitCode == exitCPULimit:
+		result.Status, result.ExitCode = RunLimitExceeded, exitKilled
+		stderr.Write([]byte("Se superó el tiempo límite de " + strconv.FormatInt(timeLimit, 10) + " ms\n"))
+	case stdout.exceeded || stderr.exceeded:
+		result.Status, result.ExitCode = RunLimitExceeded, exitKilled
+	case err != nil && cmd.ProcessState == nil:
+		result.Status, result.ExitCode = RunRuntimeError, 1
+		stderr.Write([]byte(err.Error() + "\n"))
+	case result.ExitCode > 128:
+		result.Status = RunRuntimeError
+	}
+	result.Stdout = stdout.text.String()
+	result.Stderr = stderr.text.String()
+	return result
+}
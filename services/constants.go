@@ -0,0 +1,467 @@
+package services
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+// constValue es el valor de una expresión constante entera, ya convertido a
+// su tipo. overflow indica que la operación del propio nodo se salió del
+// rango de un tipo con signo: value queda truncado y exact guarda el
+// resultado matemático. invalid indica una expresión constante cuyo valor
+// no se pudo calcular por un error ya informado (división por cero, literal
+// demasiado grande) y evita errores en cascada.
+type constValue struct {
+	value    *big.Int
+	exact    *big.Int
+	typ      cppType
+	overflow bool
+	invalid  bool
+}
+
+// Ancho en bits de los tipos enteros según el modelo de g++ en Linux (LP64)
+var integerBits = map[string]uint{
+	"bool":               1,
+	"char":               8,
+	"unsigned char":      8,
+	"short":              16,
+	"unsigned short":     16,
+	"int":                32,
+	"unsigned int":       32,
+	"long":               64,
+	"unsigned long":      64,
+	"long long":          64,
+	"unsigned long long": 64,
+}
+
+// Tamaño en bytes de los tipos básicos para sizeof(tipo)
+var typeSizes = map[string]int64{
+	"bool": 1, "char": 1, "unsigned char": 1, "short": 2, "unsigned short": 2,
+	"int": 4, "unsigned int": 4, "long": 8, "unsigned long": 8,
+	"long long": 8, "unsigned long long": 8,
+	"float": 4, "double": 8, "long double": 16,
+}
+
+func isUnsigned(t cppType) bool {
+	return strings.HasPrefix(t.Name, "unsigned ")
+}
+
+// integerRange devuelve el menor y el mayor valor representable en el tipo
+func integerRange(t cppType) (*big.Int, *big.Int) {
+	if t.Name == "bool" {
+		return big.NewInt(0), big.NewInt(1)
+	}
+	bits := integerBits[t.Name]
+	if isUnsigned(t) {
+		max := new(big.Int).Lsh(big.NewInt(1), bits)
+		return big.NewInt(0), max.Sub(max, big.NewInt(1))
+	}
+	max := new(big.Int).Lsh(big.NewInt(1), bits-1)
+	min := new(big.Int).Neg(max)
+	return min, max.Sub(max, big.NewInt(1))
+}
+
+// fitsIn indica si el valor se puede representar en el tipo entero
+func fitsIn(value *big.Int, t cppType) bool {
+	min, max := integerRange(t)
+	return value.Cmp(min) >= 0 && value.Cmp(max) <= 0
+}
+
+// convertConstant convierte el valor al tipo entero como lo hace g++:
+// módulo 2^n, con los tipos con signo en complemento a dos. En bool
+// cualquier valor distinto de cero vale 1.
+func convertConstant(value *big.Int, t cppType) *big.Int {
+	if t.Name == "bool" {
+		if value.Sign() != 0 {
+			return big.NewInt(1)
+		}
+		return big.NewInt(0)
+	}
+	bits := integerBits[t.Name]
+	modulus := new(big.Int).Lsh(big.NewInt(1), bits)
+	result := new(big.Int).Mod(value, modulus)
+	if !isUnsigned(t) && result.Cmp(new(big.Int).Rsh(modulus, 1)) >= 0 {
+		result.Sub(result, modulus)
+	}
+	return result
+}
+
+// parseIntLiteral lee el valor de un literal entero en cualquier base
+// (10, 0x1F, 017, 0b101) ignorando los sufijos y separadores ('). ok es
+// false si el literal no es válido; tooBig indica que no cabe en ningún
+// tipo entero.
+func parseIntLiteral(lexeme string) (value *big.Int, ok bool, tooBig bool) {
+	digits := strings.ReplaceAll(strings.TrimRight(strings.ToLower(lexeme), "ul"), "'", "")
+	value, ok = new(big.Int).SetString(digits, 0)
+	if !ok {
+		return nil, false, false
+	}
+	if !fitsIn(value, cppType{Name: "unsigned long long"}) {
+		return nil, false, true
+	}
+	return value, true, false
+}
+
+// integerLiteralType elige el tipo de un literal entero como el
+// compilador: el primero de la lista de su sufijo en el que cabe el valor.
+// Los literales decimales sin u solo toman tipos con signo.
+func integerLiteralType(lexeme string) cppType {
+	lower := strings.ToLower(lexeme)
+	digits := strings.TrimRight(lower, "ul")
+	suffix := lower[len(digits):]
+	unsigned := strings.Contains(suffix, "u")
+	decimal := !strings.HasPrefix(digits, "0") || digits == "0"
+
+	candidates := []string{"int", "unsigned int", "long", "unsigned long", "long long", "unsigned long long"}
+	switch {
+	case strings.Contains(suffix, "ll"):
+		candidates = candidates[4:]
+	case strings.Contains(suffix, "l"):
+		candidates = candidates[2:]
+	}
+
+	value, ok, _ := parseIntLiteral(lexeme)
+	var last cppType
+	for _, name := range candidates {
+		t := cppType{Name: name}
+		if unsigned != isUnsigned(t) && (unsigned || decimal) {
+			continue
+		}
+		if !ok || fitsIn(value, t) {
+			return t
+		}
+		last = t
+	}
+	return last
+}
+
+// constantValue evalúa una expresión constante entera. ok es false si la
+// expresión depende de valores que solo se conocen al ejecutar o usa
+// operandos no enteros. Debe llamarse después de checkExpr, que resuelve
+// los identificadores; el resultado se guarda por nodo.
+func (a *semanticAnalyzer) constantValue(node *models.SyntaxNode) (constValue, bool) {
+	if cached, seen := a.constants[node]; seen {
+		if cached == nil {
+			return constValue{}, false
+		}
+		return *cached, true
+	}
+	value, ok := a.evaluate(node)
+	if ok {
+		a.constants[node] = &value
+	} else {
+		a.constants[node] = nil
+	}
+	return value, ok
+}
+
+func (a *semanticAnalyzer) evaluate(node *models.SyntaxNode) (constValue, bool) {
+	switch node.Kind {
+	case NodeIntLiteral:
+		t := integerLiteralType(node.Value)
+		value, ok, tooBig := parseIntLiteral(node.Value)
+		if !ok {
+			return constValue{typ: t, invalid: true}, tooBig
+		}
+		return constValue{value: value, typ: t}, true
+
+	case NodeCharLiteral:
+		r, _, tail, err := strconv.UnquoteChar(node.Value, '\'')
+		if err != nil || tail != "" {
+			return constValue{}, false
+		}
+		t := cppType{Name: "char"}
+		return constValue{value: convertConstant(big.NewInt(int64(r)), t), typ: t}, true
+
+	case NodeBoolLiteral:
+		value := big.NewInt(0)
+		if node.Value == "true" {
+			value = big.NewInt(1)
+		}
+		return constValue{value: value, typ: cppType{Name: "bool"}}, true
+
+	case NodeIdentifier:
+		sym := a.references[node]
+		if sym == nil || sym.constant == nil {
+			return constValue{}, false
+		}
+		return constValue{value: sym.constant.value, typ: sym.constant.typ, invalid: sym.constant.invalid}, true
+
+	case NodeUnary:
+		return a.evaluateUnary(node)
+	case NodeBinary:
+		return a.evaluateBinary(node)
+
+	case NodeTernary:
+		condition, ok := a.constantValue(node.Children[0])
+		if !ok || condition.invalid {
+			return condition, ok
+		}
+		// Solo se evalúa la rama elegida, como en el compilador
+		if condition.value.Sign() != 0 {
+			return a.constantValue(node.Children[1])
+		}
+		return a.constantValue(node.Children[2])
+
+	case NodeCast:
+		to := typeFromString(node.Type).unqualified()
+		operand, ok := a.constantValue(node.Children[0])
+		if !to.isIntegral() || !ok || operand.invalid {
+			return constValue{typ: to, invalid: operand.invalid}, ok && to.isIntegral()
+		}
+		return constValue{value: convertConstant(operand.value, to), typ: to}, true
+
+	case NodeSizeof:
+		if len(node.Children) > 0 {
+			return constValue{}, false
+		}
+		t := typeFromString(node.Type)
+		size, known := typeSizes[t.Name]
+		if t.Pointer > 0 {
+			size, known = 8, true
+		}
+		for _, dim := range t.Dims {
+			size *= int64(dim)
+			known = known && dim > 0
+		}
+		return constValue{value: big.NewInt(size), typ: cppType{Name: "unsigned long"}}, known
+	}
+	return constValue{}, false
+}
+
+func (a *semanticAnalyzer) evaluateUnary(node *models.SyntaxNode) (constValue, bool) {
+	operand, ok := a.constantValue(node.Children[0])
+	switch node.Operator {
+	case "+", "-", "~", "!":
+	default:
+		return constValue{}, false
+	}
+	if !ok || operand.invalid {
+		return operand, ok
+	}
+
+	if node.Operator == "!" {
+		result := big.NewInt(0)
+		if operand.value.Sign() == 0 {
+			result = big.NewInt(1)
+		}
+		return constValue{value: result, typ: cppType{Name: "bool"}}, true
+	}
+
+	t := promote(operand.typ)
+	value := convertConstant(operand.value, t)
+	switch node.Operator {
+	case "-":
+		value = new(big.Int).Neg(value)
+	case "~":
+		value = new(big.Int).Not(value)
+	}
+	return foldResult(value, t), true
+}
+
+func (a *semanticAnalyzer) evaluateBinary(node *models.SyntaxNode) (constValue, bool) {
+	op := node.Operator
+	left, ok := a.constantValue(node.Children[0])
+	if !ok || left.invalid {
+		return left, ok
+	}
+	boolType := cppType{Name: "bool"}
+
+	// && y || no evalúan el operando derecho si el izquierdo decide
+	if op == "&&" || op == "||" {
+		if (op == "&&") == (left.value.Sign() == 0) {
+			return constValue{value: big.NewInt(boolInt(op == "||")), typ: boolType}, true
+		}
+		right, ok := a.constantValue(node.Children[1])
+		if !ok || right.invalid {
+			return right, ok
+		}
+		return constValue{value: big.NewInt(boolInt(right.value.Sign() != 0)), typ: boolType}, true
+	}
+
+	right, ok := a.constantValue(node.Children[1])
+	if !ok || right.invalid {
+		return right, ok
+	}
+
+	if op == "<<" || op == ">>" {
+		t := promote(left.typ)
+		count := right.value
+		if count.Sign() < 0 || count.Cmp(big.NewInt(int64(integerBits[t.Name]))) >= 0 {
+			return constValue{typ: t, invalid: true}, true
+		}
+		value := convertConstant(left.value, t)
+		if op == "<<" {
+			return foldResult(new(big.Int).Lsh(value, uint(count.Int64())), t), true
+		}
+		return foldResult(new(big.Int).Rsh(value, uint(count.Int64())), t), true
+	}
+
+	t := arithmeticResult(left.typ, right.typ)
+	l, r := convertConstant(left.value, t), convertConstant(right.value, t)
+	switch op {
+	case "+":
+		return foldResult(new(big.Int).Add(l, r), t), true
+	case "-":
+		return foldResult(new(big.Int).Sub(l, r), t), true
+	case "*":
+		return foldResult(new(big.Int).Mul(l, r), t), true
+	case "/", "%":
+		if r.Sign() == 0 {
+			return constValue{typ: t, invalid: true}, true
+		}
+		// Quo y Rem truncan hacia cero, como la división entera de C++
+		if op == "/" {
+			return foldResult(new(big.Int).Quo(l, r), t), true
+		}
+		return foldResult(new(big.Int).Rem(l, r), t), true
+	case "&":
+		return foldResult(new(big.Int).And(l, r), t), true
+	case "|":
+		return foldResult(new(big.Int).Or(l, r), t), true
+	case "^":
+		return foldResult(new(big.Int).Xor(l, r), t), true
+	}
+
+	var result bool
+	switch cmp := l.Cmp(r); op {
+	case "==":
+		result = cmp == 0
+	case "!=":
+		result = cmp != 0
+	case "<":
+		result = cmp < 0
+	case "<=":
+		result = cmp <= 0
+	case ">":
+		result = cmp > 0
+	case ">=":
+		result = cmp >= 0
+	default:
+		return constValue{}, false
+	}
+	return constValue{value: big.NewInt(boolInt(result)), typ: boolType}, true
+}
+
+// foldResult ajusta el resultado exacto de una operación al tipo: en los
+// tipos sin signo el módulo es el comportamiento definido; en los tipos
+// con signo salirse del rango es un desbordamiento
+func foldResult(exact *big.Int, t cppType) constValue {
+	result := constValue{value: convertConstant(exact, t), typ: t}
+	if !isUnsigned(t) && !fitsIn(exact, t) {
+		result.overflow = true
+		result.exact = exact
+	}
+	return result
+}
+
+// recordConstant guarda el valor de una constante entera inicializada con
+// una expresión constante (const int N = 10 * 2;) para usarla en tamaños
+// de arreglo, etiquetas case y otras constantes
+func (a *semanticAnalyzer) recordConstant(sym *symbol, init *models.SyntaxNode) {
+	t := typeFromString(sym.Type)
+	if init == nil || !t.Const || t.Reference || !t.isIntegral() {
+		return
+	}
+	if init.Kind == NodeInitList {
+		if len(init.Children) != 1 {
+			return
+		}
+		init = init.Children[0]
+	}
+	value, ok := a.constantValue(init)
+	if !ok {
+		return
+	}
+	t = t.unqualified()
+	if !value.invalid {
+		value = constValue{value: convertConstant(value.value, t), typ: t}
+	}
+	sym.constant = &value
+}
+
+// checkFolding informa lo que el compilador detecta al plegar una
+// operación entera: la división por cero y el desbordamiento con signo
+func (a *semanticAnalyzer) checkFolding(node, divisor *models.SyntaxNode, op string) {
+	if op == "/" || op == "%" {
+		if value, ok := a.constantValue(divisor); ok && !value.invalid && value.value.Sign() == 0 {
+			message := "División por cero en '" + formatExpr(node) + "'"
+			if divisor.Kind != NodeIntLiteral {
+				message += ": '" + formatExpr(divisor) + "' vale 0"
+			}
+			a.report(diagnosticAtNode(CodeDivisionByZero, message, node))
+			return
+		}
+	}
+
+	if value, ok := a.constantValue(node); ok && value.overflow {
+		diagnostic := diagnosticAtNode(CodeIntegerOverflow,
+			"Desbordamiento de entero: '"+formatExpr(node)+"' vale "+value.exact.String()+
+				", que no cabe en "+value.typ.String()+" (se obtiene "+value.value.String()+")", node)
+		diagnostic.Suggestion = "Usar un tipo más amplio, por ejemplo long long, en alguno de los operandos"
+		a.report(diagnostic)
+	}
+}
+
+// checkIntegerLiteral informa los literales que no caben en ningún tipo entero
+func (a *semanticAnalyzer) checkIntegerLiteral(node *models.SyntaxNode) {
+	if _, _, tooBig := parseIntLiteral(node.Value); tooBig {
+		diagnostic := diagnosticAtNode(CodeIntegerOverflow,
+			"El literal "+node.Value+" es demasiado grande para cualquier tipo entero", node)
+		diagnostic.Severity = SeverityError
+		a.report(diagnostic)
+	}
+}
+
+// checkConstantFits informa una constante que no cabe en el tipo entero de
+// destino (char c = 300;). Asignar un negativo a un tipo sin signo es un
+// modismo habitual (unsigned x = -1;) y no se informa.
+func (a *semanticAnalyzer) checkConstantFits(value *models.SyntaxNode, to cppType) bool {
+	to = to.value().unqualified()
+	if !to.isIntegral() || to.Name == "bool" {
+		return true
+	}
+	constant, ok := a.constantValue(value)
+	if !ok || constant.invalid || fitsIn(constant.value, to) || (isUnsigned(to) && constant.value.Sign() < 0) {
+		return true
+	}
+	a.report(diagnosticAtNode(CodeIntegerOverflow,
+		"El valor "+constant.value.String()+" no cabe en "+to.String()+
+			": se guarda "+convertConstant(constant.value, to).String(), value))
+	return false
+}
+
+// checkCaseLabel valida que la etiqueta sea una constante entera y que su
+// valor no se repita en el mismo switch
+func (a *semanticAnalyzer) checkCaseLabel(value *models.SyntaxNode, seen map[string]*models.SyntaxNode) {
+	if a.checkExpr(value).isUnknown() {
+		return
+	}
+	constant, ok := a.constantValue(value)
+	if !ok {
+		a.report(diagnosticAtNode(CodeNotConstant,
+			"La etiqueta case debe ser una constante entera, se encontró '"+formatExpr(value)+"'", value))
+		return
+	}
+	if constant.invalid {
+		return
+	}
+
+	key := constant.value.String()
+	previous := seen[key]
+	if previous == nil {
+		seen[key] = value
+		return
+	}
+	text := formatExpr(value)
+	if text != key {
+		text += " (" + key + ")"
+	}
+	diagnostic := diagnosticAtNode(CodeDuplicateCase,
+		"El valor "+text+" ya aparece en el case de la línea "+a.source.lineRef(previous.Line), value)
+	diagnostic.Suggestion = "Unir los dos case o cambiar uno de los valores"
+	a.report(diagnostic)
+}
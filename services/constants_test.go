@@ -0,0 +1,80 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConstantValues(t *testing.T) {
+	code := "const int n = 3 * 4;\nconst long long big = 1LL << 40;\nconstexpr int m = n / 5 - 7;\nconst char c = 'A' + 1;\n" +
+		"const int k = sizeof(int) * 2;\nint v = 5;\nint main() { return n + m + c + k + v + (big > 0); }\n"
+	result := AnalyzeSemantic(code)
+	if len(result.Diagnostics) > 0 {
+		t.Fatalf("diagnósticos inesperados: %v", result.Diagnostics)
+	}
+	got := make(map[string]string)
+	for _, sym := range result.SymbolTable.Scopes[0].Symbols {
+		got[sym.Name] = sym.Value
+	}
+	want := map[string]string{"main": "", "n": "12", "big": "1099511627776", "m": "-5", "c": "66", "k": "8", "v": ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("valores = %v, se esperaba %v", got, want)
+	}
+}
+
+func TestConstantFolding(t *testing.T) {
+	checkSemantic(t, []semanticCase{
+		{
+			name:    "división por cero literal",
+			code:    "int main() {\n    int x = 10 / 0;\n    return x;\n}\n",
+			want:    []string{CodeDivisionByZero},
+			message: "División por cero en '10 / 0'",
+		},
+		{
+			name:    "módulo por una constante que vale cero",
+			code:    "int main() {\n    const int z = 2 - 2;\n    int x = 5 % z;\n    return x;\n}\n",
+			want:    []string{CodeDivisionByZero},
+			message: "División por cero en '5 % z': 'z' vale 0",
+		},
+		{
+			name:    "desbordamiento de int",
+			code:    "int main() {\n    int x = 2147483647 + 1;\n    return x;\n}\n",
+			want:    []string{CodeIntegerOverflow},
+			message: "'2147483647 + 1' vale 2147483648, que no cabe en int (se obtiene -2147483648)",
+		},
+		{
+			name:    "case repetido",
+			code:    "int main() {\n    int x = 1;\n    switch (x) {\n    case 1: return 1;\n    case 2 - 1: return 2;\n    }\n    return 0;\n}\n",
+			want:    []string{CodeDuplicateCase},
+			message: "El valor 2 - 1 (1) ya aparece en el case de la línea 4",
+		},
+		{
+			name:    "case no constante",
+			code:    "int main() {\n    int n = 3;\n    int x = 1;\n    switch (x) {\n    case n: return 1;\n    }\n    return 0;\n}\n",
+			want:    []string{CodeNotConstant},
+			message: "La etiqueta case debe ser una constante entera, se encontró 'n'",
+		},
+		{
+			name:    "tamaño de arreglo variable",
+			code:    "int main() {\n    int n = 3;\n    int a[n];\n    a[0] = 1;\n    return a[0];\n}\n",
+			want:    []string{CodeNotConstant},
+			message: "'n' solo se conoce al ejecutar el programa",
+		},
+		{
+			name: "tamaño de arreglo constante",
+			code: "int main() {\n    const int n = 3;\n    int a[n * 2];\n    a[0] = 1;\n    return a[0];\n}\n",
+			want: []string{},
+		},
+		{
+			name: "long long sin desbordamiento",
+			code: "int main() {\n    long long x = 2147483647LL + 1;\n    return x > 0;\n}\n",
+			want: []string{},
+		},
+		{
+			// La división de punto flotante por cero da infinito
+			name: "double dividido por cero",
+			code: "int main() {\n    double d = 1.0 / 0;\n    return d > 0;\n}\n",
+			want: []string{},
+		},
+	})
+}
@@ -0,0 +1,602 @@
+package services
+
+import (
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+// Efecto de un elemento del bloque sobre una variable
+const (
+	eventRead    = iota
+	eventWrite   // asignación, incremento o declaración con valor inicial
+	eventDeclare // declaración sin valor inicial
+	eventEscape  // &x o argumento por referencia: puede leerse y escribirse en otra parte
+)
+
+// dataEvent es la lectura o escritura de una variable seguida por el
+// análisis. site es la expresión completa (x = 5, x++ o el Declarator) y
+// target el nombre de la variable.
+type dataEvent struct {
+	kind    int
+	v       int
+	site    *models.SyntaxNode
+	target  *models.SyntaxNode
+	initial bool // valor inicial de la declaración
+	literal bool // el valor asignado es un literal
+}
+
+// dataFlow analiza las variables locales de una función sobre su grafo de
+// flujo de control. Solo sigue variables de tipos básicos y punteros: los
+// arreglos, las referencias y los objetos tienen su propia inicialización.
+type dataFlow struct {
+	a      *semanticAnalyzer
+	graph  *controlFlowGraph
+	vars   []*symbol
+	index  map[*symbol]int
+	events map[*cfgBlock][]dataEvent
+}
+
+// checkDataFlow informa lecturas de variables posiblemente sin inicializar,
+// valores asignados que nunca se leen y variables que se asignan pero
+// nunca se leen, cada uno con un camino de ejecución que lo produce
+func (a *semanticAnalyzer) checkDataFlow(graph *controlFlowGraph) {
+	d := &dataFlow{
+		a:      a,
+		graph:  graph,
+		index:  make(map[*symbol]int),
+		events: make(map[*cfgBlock][]dataEvent),
+	}
+	d.collectVariables()
+	if len(d.vars) == 0 {
+		return
+	}
+	for _, block := range graph.blocks {
+		var events []dataEvent
+		for _, element := range block.elements {
+			d.element(element, &events)
+		}
+		d.events[block] = events
+	}
+
+	unread := d.reportNeverRead()
+	d.reportUninitialized()
+	d.reportDeadStores(unread)
+}
+
+// collectVariables registra los parámetros y variables locales seguidos
+func (d *dataFlow) collectVariables() {
+	walkTree(d.graph.function, func(node *models.SyntaxNode) bool {
+		switch node.Kind {
+		case NodeVarDecl:
+			// Las variables static y extern no se inicializan en cada ejecución
+			return node.Operator == ""
+		case NodeParam, NodeDeclarator:
+			if sym := d.a.declarations[node]; sym != nil && d.a.isTrackedVariable(sym) {
+				d.index[sym] = len(d.vars)
+				d.vars = append(d.vars, sym)
+			}
+		case NodeClass:
+			return false
+		}
+		return true
+	})
+}
+
+func (a *semanticAnalyzer) isTrackedVariable(sym *symbol) bool {
+	t := typeFromString(sym.Type)
+	// Las constantes sin valor o modificadas ya son errores de declaración
+	return !t.isUnknown() && !t.isArray() && !t.Reference && !t.isConstObject() && a.classOf(t) == nil
+}
+
+// variable devuelve el índice de la variable seguida que nombra el nodo
+func (d *dataFlow) variable(node *models.SyntaxNode) (int, bool) {
+	if node.Kind != NodeIdentifier {
+		return 0, false
+	}
+	v, ok := d.index[d.a.references[node]]
+	return v, ok
+}
+
+func (d *dataFlow) element(element cfgElement, events *[]dataEvent) {
+	node := element.node
+	switch node.Kind {
+	case NodeVarDecl:
+		if node.Operator != "" {
+			return
+		}
+		for _, declarator := range node.Children {
+			for _, size := range declarator.Children {
+				if size.Kind == NodeArraySize {
+					d.expr(size.Children[0], events)
+				}
+			}
+			init := initializer(declarator)
+			if init != nil {
+				d.expr(init, events)
+			}
+			v, ok := d.index[d.a.declarations[declarator]]
+			switch {
+			case !ok:
+			case init != nil || element.role == ElementIteration:
+				*events = append(*events, dataEvent{kind: eventWrite, v: v, site: declarator, target: declarator,
+					initial: true, literal: init != nil && isLiteralValue(init)})
+			default:
+				*events = append(*events, dataEvent{kind: eventDeclare, v: v, site: declarator, target: declarator})
+			}
+		}
+	case NodeExprStmt, NodeReturn:
+		for _, child := range node.Children {
+			d.expr(child, events)
+		}
+	default:
+		d.expr(node, events)
+	}
+}
+
+// expr agrega los efectos de una expresión en el orden en que se evalúa:
+// en x = x + 1 primero se lee x y después se escribe
+func (d *dataFlow) expr(node *models.SyntaxNode, events *[]dataEvent) {
+	if node == nil {
+		return
+	}
+	add := func(kind int, v int, site, target *models.SyntaxNode) {
+		*events = append(*events, dataEvent{kind: kind, v: v, site: site, target: target})
+	}
+
+	switch node.Kind {
+	case NodeIdentifier:
+		if v, ok := d.variable(node); ok {
+			add(eventRead, v, node, node)
+		}
+		return
+
+	case NodeAssign:
+		target := node.Children[0]
+		d.expr(node.Children[1], events)
+		if v, ok := d.variable(target); ok {
+			if node.Operator != "=" {
+				add(eventRead, v, target, target)
+			}
+			*events = append(*events, dataEvent{kind: eventWrite, v: v, site: node, target: target,
+				literal: node.Operator == "=" && isLiteralValue(node.Children[1])})
+			return
+		}
+		d.expr(target, events)
+		return
+
+	case NodeUnary, NodePostfix:
+		if v, ok := d.variable(node.Children[0]); ok {
+			switch node.Operator {
+			case "&":
+				add(eventEscape, v, node, node.Children[0])
+				return
+			case "++", "--":
+				add(eventRead, v, node.Children[0], node.Children[0])
+				add(eventWrite, v, node, node.Children[0])
+				return
+			}
+		}
+
+	case NodeBinary:
+		// cin >> x asigna x
+		if node.Operator == ">>" && isInputStream(node.Children[0]) {
+			if v, ok := d.variable(node.Children[1]); ok {
+				d.expr(node.Children[0], events)
+				add(eventWrite, v, node.Children[1], node.Children[1])
+				return
+			}
+		}
+
+	case NodeCall:
+		byReference := d.referenceParams(node)
+		d.expr(node.Children[0], events)
+		for i, arg := range node.Children[1:] {
+			if v, ok := d.variable(arg); ok && byReference[i] {
+				add(eventEscape, v, arg, arg)
+				continue
+			}
+			d.expr(arg, events)
+		}
+		return
+
+	case NodeSizeof:
+		// El operando de sizeof no se evalúa
+		return
+	}
+
+	for _, child := range node.Children {
+		d.expr(child, events)
+	}
+}
+
+// referenceParams indica qué argumentos de la llamada se pasan a un
+// parámetro referencia no const en alguna de las sobrecargas
+func (d *dataFlow) referenceParams(call *models.SyntaxNode) map[int]bool {
+	byReference := make(map[int]bool)
+	sym := d.a.references[call.Children[0]]
+	if sym == nil {
+		return byReference
+	}
+	for _, overload := range sym.overloads {
+		i := 0
+		for _, param := range overload.Children {
+			if param.Kind != NodeParam {
+				continue
+			}
+			if t := typeFromString(param.Type); t.Reference && !t.Const {
+				byReference[i] = true
+			}
+			i++
+		}
+	}
+	return byReference
+}
+
+// isInputStream reconoce cin y las cadenas cin >> a >> b
+func isInputStream(node *models.SyntaxNode) bool {
+	switch node.Kind {
+	case NodeIdentifier:
+		return node.Value == "cin" || node.Value == "std::cin"
+	case NodeBinary:
+		return node.Operator == ">>" && isInputStream(node.Children[0])
+	}
+	return false
+}
+
+// isLiteralValue reconoce los valores iniciales habituales que no indican
+// un error si se reemplazan antes de leerse: 0, -1, false, nullptr, {}
+func isLiteralValue(node *models.SyntaxNode) bool {
+	switch node.Kind {
+	case NodeIntLiteral, NodeFloatLiteral, NodeBoolLiteral, NodeCharLiteral, NodeStringLiteral, NodeNullLiteral:
+		return true
+	case NodeUnary:
+		return node.Operator == "-" && isLiteralValue(node.Children[0])
+	case NodeInitList:
+		return len(node.Children) == 0 || (len(node.Children) == 1 && isLiteralValue(node.Children[0]))
+	}
+	return false
+}
+
+// reportNeverRead informa las variables que se asignan después de
+// declararse pero cuyo valor nunca se lee. Devuelve todas las variables que
+// no se leen, para no informar además cada asignación como valor perdido;
+// las que ni siquiera se asignan ya se advierten como no usadas.
+func (d *dataFlow) reportNeverRead() map[int]bool {
+	read := make(map[int]bool)
+	writes := make(map[int][]dataEvent)
+	for _, block := range d.graph.blocks {
+		for _, event := range d.events[block] {
+			switch {
+			case event.kind == eventRead || event.kind == eventEscape:
+				read[event.v] = true
+			case event.kind == eventWrite && !event.initial:
+				writes[event.v] = append(writes[event.v], event)
+			}
+		}
+	}
+
+	unread := make(map[int]bool)
+	for v, sym := range d.vars {
+		if read[v] {
+			continue
+		}
+		unread[v] = true
+		if len(writes[v]) == 0 {
+			continue
+		}
+		noun := "La variable"
+		if sym.Kind == SymbolParameter {
+			noun = "El parámetro"
+		}
+		diagnostic := nameDiagnostic(CodeNeverRead, noun+" '"+sym.Name+"' se asigna pero nunca se lee", sym.node, sym.Name)
+		diagnostic.Path = append(diagnostic.Path, stepAt("Se declara '"+sym.Name+"'", sym.node))
+		for _, event := range d.sortedEvents(writes[v]) {
+			diagnostic.Path = append(diagnostic.Path, stepAt("Se asigna '"+sym.Name+"' y el valor no se usa", event.site))
+		}
+		diagnostic.Suggestion = "Eliminar '" + sym.Name + "' o usar su valor"
+		d.a.report(diagnostic)
+	}
+	return unread
+}
+
+// sortedEvents ordena los eventos por su posición en el código
+func (d *dataFlow) sortedEvents(events []dataEvent) []dataEvent {
+	sorted := append([]dataEvent{}, events...)
+	for i := 1; i < len(sorted); i++ {
+		for j := i; j > 0 && d.a.source.before(sorted[j].site.Line, sorted[j].site.Column,
+			sorted[j-1].site.Line, sorted[j-1].site.Column); j-- {
+			sorted[j], sorted[j-1] = sorted[j-1], sorted[j]
+		}
+	}
+	return sorted
+}
+
+// initState es el estado de inicialización de cada variable en un punto:
+// si en algún camino llega sin valor y si en alguno llega con valor
+type initState struct {
+	maybeUninit []bool
+	maybeInit   []bool
+}
+
+func (d *dataFlow) newInitState() initState {
+	return initState{make([]bool, len(d.vars)), make([]bool, len(d.vars))}
+}
+
+func (s initState) copy() initState {
+	return initState{append([]bool{}, s.maybeUninit...), append([]bool{}, s.maybeInit...)}
+}
+
+// merge agrega a s los caminos de other y devuelve si s cambió
+func (s initState) merge(other initState) bool {
+	changed := false
+	for v := range s.maybeUninit {
+		if other.maybeUninit[v] && !s.maybeUninit[v] {
+			s.maybeUninit[v], changed = true, true
+		}
+		if other.maybeInit[v] && !s.maybeInit[v] {
+			s.maybeInit[v], changed = true, true
+		}
+	}
+	return changed
+}
+
+func (s initState) apply(event dataEvent) {
+	switch event.kind {
+	case eventDeclare:
+		s.maybeUninit[event.v], s.maybeInit[event.v] = true, false
+	case eventWrite, eventEscape:
+		s.maybeUninit[event.v], s.maybeInit[event.v] = false, true
+	}
+}
+
+// reportUninitialized propaga hacia adelante el estado de inicialización
+// e informa la primera lectura de cada variable que puede no tener valor
+func (d *dataFlow) reportUninitialized() {
+	reachable := d.graph.reachable()
+	in := make(map[*cfgBlock]initState)
+	entry := d.newInitState()
+	for v, sym := range d.vars {
+		if sym.Kind == SymbolParameter {
+			entry.maybeInit[v] = true
+		}
+	}
+	in[d.graph.entry] = entry
+
+	pending := []*cfgBlock{d.graph.entry}
+	for len(pending) > 0 {
+		block := pending[0]
+		pending = pending[1:]
+		state := in[block].copy()
+		for _, event := range d.events[block] {
+			state.apply(event)
+		}
+		for _, edge := range block.succs {
+			next, seen := in[edge.to]
+			if !seen {
+				in[edge.to] = state.copy()
+				pending = append(pending, edge.to)
+			} else if next.merge(state) {
+				pending = append(pending, edge.to)
+			}
+		}
+	}
+
+	type finding struct {
+		block *cfgBlock
+		index int
+		event dataEvent
+		sure  bool
+	}
+	first := make(map[int]finding)
+	for _, block := range d.graph.blocks {
+		if !reachable[block] {
+			continue
+		}
+		state := in[block].copy()
+		for i, event := range d.events[block] {
+			if event.kind == eventRead && state.maybeUninit[event.v] {
+				previous, found := first[event.v]
+				if !found || d.a.source.before(event.site.Line, event.site.Column,
+					previous.event.site.Line, previous.event.site.Column) {
+					first[event.v] = finding{block, i, event, !state.maybeInit[event.v]}
+				}
+			}
+			state.apply(event)
+		}
+	}
+
+	for v, sym := range d.vars {
+		f, found := first[v]
+		if !found {
+			continue
+		}
+		message := "La variable '" + sym.Name + "' puede usarse sin inicializar"
+		if f.sure {
+			message = "La variable '" + sym.Name + "' se usa sin inicializar"
+		}
+		diagnostic := diagnosticAtNode(CodeUninitialized, message, f.event.site)
+		diagnostic.Path = d.uninitializedPath(v, f.block, f.index)
+		diagnostic.Suggestion = "Inicializar '" + sym.Name + "' al declararla"
+		d.a.report(diagnostic)
+	}
+}
+
+// uninitializedPath busca un camino desde la declaración sin valor de la
+// variable hasta la lectura sin pasar por ninguna asignación
+func (d *dataFlow) uninitializedPath(v int, target *cfgBlock, index int) []models.PathStep {
+	sym := d.vars[v]
+	read := d.events[target][index]
+
+	var start *cfgBlock
+	for _, block := range d.graph.blocks {
+		for i, event := range d.events[block] {
+			if event.v == v && event.kind == eventDeclare && !d.kills(block, i+1, len(d.events[block]), v) {
+				start = block
+			}
+		}
+	}
+
+	path := []models.PathStep{stepAt("Se declara '"+sym.Name+"' sin valor inicial", sym.node)}
+	if start != nil && start != target {
+		route := d.graph.route(start, func(block *cfgBlock) (bool, bool) {
+			if block == target {
+				return true, true
+			}
+			return false, !d.kills(block, 0, len(d.events[block]), v)
+		})
+		path = append(path, branchSteps(route)...)
+	}
+	return append(path, stepAt("Se lee '"+sym.Name+"' sin que se le haya asignado un valor", read.site))
+}
+
+// kills indica si algún evento del bloque entre from y to asigna o vuelve a
+// declarar la variable
+func (d *dataFlow) kills(block *cfgBlock, from, to int, v int) bool {
+	for _, event := range d.events[block][from:to] {
+		if event.v == v && event.kind != eventRead {
+			return true
+		}
+	}
+	return false
+}
+
+// liveness calcula, para cada bloque, qué variables pueden leerse después
+// de su salida antes de volver a asignarse
+func (d *dataFlow) liveness() map[*cfgBlock][]bool {
+	liveOut := make(map[*cfgBlock][]bool)
+	for _, block := range d.graph.blocks {
+		liveOut[block] = make([]bool, len(d.vars))
+	}
+	for changed := true; changed; {
+		changed = false
+		for i := len(d.graph.blocks) - 1; i >= 0; i-- {
+			block := d.graph.blocks[i]
+			for _, edge := range block.succs {
+				live := d.liveIn(edge.to, liveOut[edge.to])
+				for v, isLive := range live {
+					if isLive && !liveOut[block][v] {
+						liveOut[block][v], changed = true, true
+					}
+				}
+			}
+		}
+	}
+	return liveOut
+}
+
+// liveIn aplica hacia atrás los eventos del bloque a las variables vivas a la salida
+func (d *dataFlow) liveIn(block *cfgBlock, out []bool) []bool {
+	live := append([]bool{}, out...)
+	events := d.events[block]
+	for i := len(events) - 1; i >= 0; i-- {
+		d.applyBackward(live, events[i])
+	}
+	return live
+}
+
+func (d *dataFlow) applyBackward(live []bool, event dataEvent) {
+	switch event.kind {
+	case eventRead, eventEscape:
+		live[event.v] = true
+	default:
+		live[event.v] = false
+	}
+}
+
+// reportDeadStores informa las asignaciones cuyo valor no se lee en ningún
+// camino antes de reemplazarse o de terminar la función. No se informan
+// los valores iniciales literales (int suma = 0;) ni las variables cuya
+// dirección se toma.
+func (d *dataFlow) reportDeadStores(unread map[int]bool) {
+	escaped := make(map[int]bool)
+	for _, block := range d.graph.blocks {
+		for _, event := range d.events[block] {
+			if event.kind == eventEscape {
+				escaped[event.v] = true
+			}
+		}
+	}
+
+	reachable := d.graph.reachable()
+	liveOut := d.liveness()
+	for _, block := range d.graph.blocks {
+		if !reachable[block] {
+			continue
+		}
+		live := append([]bool{}, liveOut[block]...)
+		events := d.events[block]
+		var dead []int
+		for i := len(events) - 1; i >= 0; i-- {
+			event := events[i]
+			if event.kind == eventWrite && !live[event.v] && !unread[event.v] && !escaped[event.v] &&
+				!(event.initial && (event.literal || event.site.Kind == NodeDeclarator && initializer(event.site) == nil)) {
+				dead = append([]int{i}, dead...)
+			}
+			d.applyBackward(live, event)
+		}
+		for _, i := range dead {
+			d.reportDeadStore(block, i)
+		}
+	}
+}
+
+func (d *dataFlow) reportDeadStore(block *cfgBlock, index int) {
+	event := d.events[block][index]
+	sym := d.vars[event.v]
+
+	var diagnostic models.Diagnostic
+	if event.initial {
+		diagnostic = nameDiagnostic(CodeDeadStore,
+			"El valor inicial de '"+sym.Name+"' nunca se lee", event.site, sym.Name)
+	} else {
+		diagnostic = diagnosticAtNode(CodeDeadStore, "El valor asignado a '"+sym.Name+"' nunca se lee", event.site)
+	}
+	diagnostic.Path = []models.PathStep{stepAt("Se asigna un valor a '"+sym.Name+"'", event.site)}
+
+	// Hasta la próxima asignación o el final de la función
+	var end *models.PathStep
+	for _, next := range d.events[block][index+1:] {
+		if next.v == event.v {
+			step := d.overwriteStep(sym, next)
+			end = &step
+			diagnostic.Path = append(diagnostic.Path, step)
+			break
+		}
+	}
+	if end == nil {
+		var last models.PathStep
+		route := d.graph.route(block, func(b *cfgBlock) (bool, bool) {
+			if b == block {
+				return false, true
+			}
+			for _, next := range d.events[b] {
+				if next.v == event.v {
+					last = d.overwriteStep(sym, next)
+					return true, true
+				}
+			}
+			if b == d.graph.exit {
+				last = d.exitStep(sym)
+				return true, true
+			}
+			return false, true
+		})
+		diagnostic.Path = append(diagnostic.Path, branchSteps(route)...)
+		diagnostic.Path = append(diagnostic.Path, last)
+	}
+	diagnostic.Suggestion = "Eliminar la asignación o usar el valor antes de reemplazarlo"
+	d.a.report(diagnostic)
+}
+
+func (d *dataFlow) overwriteStep(sym *symbol, event dataEvent) models.PathStep {
+	if event.kind == eventDeclare || event.initial {
+		return stepAt("'"+sym.Name+"' se vuelve a declarar sin que se lea el valor", event.site)
+	}
+	return stepAt("Se reemplaza el valor de '"+sym.Name+"' sin haberlo leído", event.site)
+}
+
+// exitStep señala el final de la función en la llave de cierre
+func (d *dataFlow) exitStep(sym *symbol) models.PathStep {
+	body := functionBody(d.graph.function)
+	return models.PathStep{Message: "La función termina sin leer '" + sym.Name + "'",
+		Line: body.EndLine, Column: body.EndColumn - 1}
+}
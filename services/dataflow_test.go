@@ -0,0 +1,83 @@
+package services
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestDataFlow(t *testing.T) {
+	checkSemantic(t, []semanticCase{
+		{
+			name:    "lectura sin inicializar",
+			code:    "int main() {\n    int x;\n    return x;\n}\n",
+			want:    []string{CodeUninitialized},
+			message: "La variable 'x' se usa sin inicializar",
+		},
+		{
+			name:    "inicializada en una sola rama",
+			code:    "int main() {\n    int x;\n    int c = 1;\n    if (c > 0) {\n        x = 1;\n    }\n    return x;\n}\n",
+			want:    []string{CodeUninitialized},
+			message: "La variable 'x' puede usarse sin inicializar",
+		},
+		{
+			name:    "asignada dentro de un ciclo",
+			code:    "int main() {\n    int s;\n    for (int i = 0; i < 3; i++) s = i;\n    return s;\n}\n",
+			want:    []string{CodeUninitialized},
+			message: "'s' puede usarse sin inicializar",
+		},
+		{
+			name:    "valor sobrescrito",
+			code:    "int main() {\n    int x = 1;\n    x = 2;\n    x = 3;\n    return x;\n}\n",
+			want:    []string{CodeDeadStore},
+			message: "El valor asignado a 'x' nunca se lee",
+		},
+		{
+			name:    "asignada y nunca leída",
+			code:    "int main() {\n    int x;\n    x = 5;\n    return 0;\n}\n",
+			want:    []string{CodeNeverRead},
+			message: "La variable 'x' se asigna pero nunca se lee",
+		},
+		{
+			name: "inicializada en ambas ramas",
+			code: "int main() {\n    int x;\n    int c = 1;\n    if (c > 0) x = 1; else x = 2;\n    return x;\n}\n",
+			want: []string{},
+		},
+		{
+			name: "leída con cin",
+			code: "#include <iostream>\nint main() {\n    int n;\n    std::cin >> n;\n    return n;\n}\n",
+			want: []string{},
+		},
+		{
+			name: "inicializada por referencia",
+			code: "void init(int& v) { v = 1; }\nint main() {\n    int x;\n    init(x);\n    return x;\n}\n",
+			want: []string{},
+		},
+		{
+			name: "elemento de un arreglo",
+			code: "int main() {\n    int a[3];\n    a[0] = 1;\n    return a[0];\n}\n",
+			want: []string{},
+		},
+	})
+}
+
+// El diagnóstico muestra el camino por el que la variable llega sin valor
+func TestUninitializedPath(t *testing.T) {
+	code := "int main() {\n    int x;\n    int c = 1;\n    if (c > 0) {\n        x = 1;\n    }\n    return x;\n}\n"
+	diagnostics := AnalyzeSemantic(code).Diagnostics
+	if len(diagnostics) != 1 {
+		t.Fatalf("diagnósticos = %v", diagnostics)
+	}
+	var got []string
+	for _, step := range diagnostics[0].Path {
+		got = append(got, strconv.Itoa(step.Line)+": "+step.Message)
+	}
+	want := []string{
+		"2: Se declara 'x' sin valor inicial",
+		"4: La condición 'c > 0' es falsa",
+		"7: Se lee 'x' sin que se le haya asignado un valor",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("camino = %v, se esperaba %v", got, want)
+	}
+}
@@ -0,0 +1,284 @@
+package services
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+// checkArraySizes valida las dimensiones de un Declarator o Param: deben
+// ser enteras y positivas, y solo la primera puede omitirse
+func (a *semanticAnalyzer) checkArraySizes(node *models.SyntaxNode) {
+	label := "del arreglo"
+	if node.Value != "" {
+		label += " '" + node.Value + "'"
+	}
+
+	dimension := 0
+	for _, size := range node.Children {
+		if size.Kind != NodeArraySize {
+			continue
+		}
+		dimension++
+		expr := size.Children[0]
+		if expr.Kind == NodeEmpty {
+			if dimension > 1 {
+				a.report(diagnosticAtNode(CodeInvalidArray,
+					"Solo la primera dimensión "+label+" puede omitirse", size))
+			}
+			continue
+		}
+
+		t := a.checkExpr(expr)
+		if t.isUnknown() {
+			continue
+		}
+		if !t.isIntegral() {
+			a.report(diagnosticAtNode(CodeInvalidArray,
+				"El tamaño "+label+" debe ser un entero, se encontró "+t.String(), expr))
+			continue
+		}
+
+		value, ok := a.constantValue(expr)
+		switch {
+		case !ok:
+			diagnostic := diagnosticAtNode(CodeNotConstant,
+				"El tamaño "+label+" debe ser una expresión constante: '"+formatExpr(expr)+
+					"' solo se conoce al ejecutar el programa", expr)
+			diagnostic.Suggestion = "Declarar el tamaño como const int o usar vector para un tamaño variable"
+			a.report(diagnostic)
+		case !value.invalid && value.value.Sign() <= 0:
+			message := "El tamaño " + label + " debe ser mayor que cero"
+			if expr.Kind != NodeIntLiteral {
+				message += ", '" + formatExpr(expr) + "' vale " + value.value.String()
+			}
+			a.report(diagnosticAtNode(CodeInvalidArray, message, expr))
+		}
+	}
+}
+
+// resolvedArrayType devuelve el tipo declarado con las dimensiones
+// constantes reemplazadas por su valor: "int[N + 1]" → "int[11]"
+func (a *semanticAnalyzer) resolvedArrayType(node *models.SyntaxNode) string {
+	typ := node.Type
+	for _, size := range node.Children {
+		if size.Kind != NodeArraySize || size.Children[0].Kind == NodeEmpty {
+			continue
+		}
+		expr := size.Children[0]
+		if value, ok := a.constantValue(expr); ok && !value.invalid {
+			typ = strings.Replace(typ, "["+formatExpr(expr)+"]", "["+value.value.String()+"]", 1)
+		}
+	}
+	return typ
+}
+
+// hasOmittedSize indica si la primera dimensión se escribió vacía: int v[]
+func hasOmittedSize(node *models.SyntaxNode) bool {
+	for _, size := range node.Children {
+		if size.Kind == NodeArraySize {
+			return size.Children[0].Kind == NodeEmpty
+		}
+	}
+	return false
+}
+
+// withFirstSize completa la dimensión omitida con el tamaño deducido del
+// inicializador: "int[]" → "int[3]"
+func withFirstSize(typ string, size int) string {
+	return strings.Replace(typ, "[]", "["+strconv.Itoa(size)+"]", 1)
+}
+
+// isAutoType indica si el tipo base de la declaración es auto
+func isAutoType(base string) bool {
+	return strings.TrimPrefix(base, "const ") == "auto"
+}
+
+// deduceAuto calcula el tipo de una variable auto a partir del valor
+// inicial. Sin referencia, los arreglos decaen a punteros y se descarta el
+// const del valor.
+func deduceAuto(declared string, init cppType) string {
+	if init.isUnknown() {
+		return declared
+	}
+	d := typeFromString(declared)
+	t := init.value()
+	if !d.Reference {
+		t = t.decay()
+		if t.isPlain() {
+			t.Const = false
+		}
+	}
+	if d.Const && t.isPlain() {
+		t.Const = true
+	}
+	t.Reference = d.Reference
+	return t.String()
+}
+
+// checkInitializer valida el valor inicial de una variable según su tipo:
+// listas para arreglos, variables para referencias y conversiones para el resto
+func (a *semanticAnalyzer) checkInitializer(declarator *models.SyntaxNode, sym *symbol, init *models.SyntaxNode, initType cppType) {
+	name := declarator.Value
+	declType := typeFromString(sym.Type)
+
+	// Objetos: Punto p; Punto p(1, 2); Punto p{1, 2};
+	if info := a.classOf(declType); info != nil && !declType.Reference {
+		switch {
+		case init == nil:
+			a.checkConstruction(info, declarator, nil, nil, false)
+			return
+		case init.Kind == NodeInitList:
+			a.checkConstruction(info, init, init.Children, a.checkExprs(init.Children), init.Operator != "()")
+			return
+		}
+	}
+
+	if init == nil {
+		switch {
+		case declType.Reference:
+			a.report(nameDiagnostic(CodeInvalidReference,
+				"La referencia '"+name+"' debe inicializarse al declararse", declarator, name))
+		case declType.isConstObject():
+			a.report(nameDiagnostic(CodeConstModified,
+				"La constante '"+name+"' debe inicializarse al declararse", declarator, name))
+		case hasOmittedSize(declarator):
+			a.report(nameDiagnostic(CodeInvalidArray,
+				"El arreglo '"+name+"' necesita un tamaño o una lista de inicialización", declarator, name))
+		}
+		return
+	}
+
+	mismatch := "Error de tipo - Variable '" + name + "' de tipo " + declType.String() +
+		" no puede ser asignada con valor de tipo "
+
+	switch {
+	case init.Kind == NodeInitList && declType.isArray():
+		count := a.checkInitList(init, declType, name)
+		if hasOmittedSize(declarator) {
+			sym.Type = withFirstSize(sym.Type, count)
+		}
+
+	case init.Kind == NodeInitList:
+		// int x{5}; o int x = {}; admiten a lo sumo un valor
+		if len(init.Children) > 1 {
+			a.report(diagnosticAtNode(CodeTypeMismatch,
+				"La variable '"+name+"' de tipo "+declType.String()+" no puede inicializarse con "+
+					strconv.Itoa(len(init.Children))+" valores", init))
+		}
+		for _, item := range init.Children {
+			t := a.checkExpr(item)
+			if len(init.Children) == 1 {
+				a.checkConversion(t, declType, item, CodeTypeMismatch, mismatch+t.String())
+			}
+		}
+
+	case declType.isArray():
+		element := declType.elementType()
+		if init.Kind == NodeStringLiteral && element.Name == "char" && element.isPlain() {
+			// char nombre[] = "Ana"; reserva un lugar más para el '\0'
+			length := stringLiteralLength(init) + 1
+			if size := declType.Dims[0]; size >= 0 && length > size {
+				a.report(diagnosticAtNode(CodeInvalidArray,
+					"La cadena necesita "+strconv.Itoa(length)+" caracteres (incluido '\\0') y el arreglo '"+
+						name+"' tiene tamaño "+strconv.Itoa(size), init))
+			}
+			if hasOmittedSize(declarator) {
+				sym.Type = withFirstSize(sym.Type, length)
+			}
+			return
+		}
+		a.report(diagnosticAtNode(CodeInvalidArray,
+			"El arreglo '"+name+"' solo puede inicializarse con una lista {...}", init))
+
+	case declType.Reference && !declType.Const && !a.isLValue(init):
+		a.report(diagnosticAtNode(CodeInvalidReference,
+			"La referencia '"+name+"' debe inicializarse con una variable", init))
+
+	default:
+		a.checkConversion(initType, declType, init, CodeTypeMismatch, mismatch+initType.String())
+	}
+}
+
+// checkInitList valida cada elemento de la lista contra el tipo de los
+// elementos del arreglo y devuelve la cantidad de elementos
+func (a *semanticAnalyzer) checkInitList(list *models.SyntaxNode, arrayType cppType, name string) int {
+	element := arrayType.elementType()
+	nested := len(list.Children) > 0 && list.Children[0].Kind == NodeInitList
+
+	// Con listas planas en arreglos de varias dimensiones (int m[2][2] = {1, 2, 3, 4})
+	// los elementos llenan el arreglo en orden y no se comparan con la primera dimensión
+	if size := arrayType.Dims[0]; size >= 0 && len(list.Children) > size && (nested || !element.isArray()) {
+		a.report(diagnosticAtNode(CodeInvalidArray,
+			"Demasiados valores para el arreglo '"+name+"': tiene tamaño "+strconv.Itoa(size)+
+				" y se dieron "+strconv.Itoa(len(list.Children)), list))
+	}
+
+	innermost := element
+	for innermost.isArray() {
+		innermost = innermost.elementType()
+	}
+	for _, item := range list.Children {
+		if item.Kind == NodeInitList {
+			if element.isArray() {
+				a.checkInitList(item, element, name)
+			} else {
+				a.report(diagnosticAtNode(CodeInvalidArray,
+					"Lista anidada inesperada: los elementos de '"+name+"' son de tipo "+element.String(), item))
+			}
+			continue
+		}
+		t := a.checkExpr(item)
+		a.checkConversion(t, innermost, item, CodeTypeMismatch,
+			"Elemento del arreglo '"+name+"': se esperaba "+innermost.String()+", se encontró "+t.String())
+	}
+	return len(list.Children)
+}
+
+// stringLiteralLength cuenta los caracteres de una cadena literal
+// interpretando las secuencias de escape
+func stringLiteralLength(node *models.SyntaxNode) int {
+	if text, err := strconv.Unquote(`"` + node.Value + `"`); err == nil {
+		return len(text)
+	}
+	return len(node.Value)
+}
+
+// analyzeRangeFor declara la variable de un for de rango con el tipo de los
+// elementos recorridos y analiza el cuerpo
+func (a *semanticAnalyzer) analyzeRangeFor(node *models.SyntaxNode) {
+	decl, rangeExpr, body := node.Children[0], node.Children[1], node.Children[2]
+	declarator := decl.Children[0]
+	a.checkTypeName(decl.Type, decl)
+
+	rangeType := a.checkExpr(rangeExpr)
+	element := unknownType
+	switch {
+	case rangeType.isUnknown():
+	case rangeType.isArray():
+		element = rangeType.elementType()
+	case rangeType.isString():
+		element = cppType{Name: "char", Const: rangeType.Const}
+	case a.classOf(rangeType) != nil && !a.classOf(rangeType).element.isUnknown():
+		element = a.classOf(rangeType).element
+	default:
+		a.report(diagnosticAtNode(CodeInvalidArray,
+			"No se puede recorrer con for una expresión de tipo "+rangeType.String(), rangeExpr))
+	}
+
+	typ := declarator.Type
+	if isAutoType(decl.Type) {
+		typ = deduceAuto(typ, element)
+	} else if !element.isUnknown() {
+		declType := typeFromString(typ)
+		a.checkConversion(element, declType, rangeExpr, CodeTypeMismatch,
+			"Los elementos de tipo "+element.String()+" no se pueden asignar a '"+declarator.Value+
+				"' de tipo "+declType.String())
+	}
+
+	if a.declareVariable(declarator, declarator.Value, typ, SymbolVariable, true) != nil {
+		a.variables++
+	}
+	a.analyzeStatement(body)
+}
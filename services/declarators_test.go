@@ -0,0 +1,89 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDeclaratorTypes(t *testing.T) {
+	code := "int main() {\n    int x = 1, *p = &x, **pp = &p, &r = x, m[2][3], v[] = {1, 2};\n    const char* s = \"a\";\n    return 0;\n}\n"
+	want := map[string]string{
+		"x": "int", "p": "int*", "pp": "int**", "r": "int&",
+		"m": "int[2][3]", "v": "int[2]", "s": "const char*",
+	}
+	got := make(map[string]string)
+	for _, scope := range AnalyzeSemantic(code).SymbolTable.Scopes {
+		if scope.Kind == "function" {
+			for _, sym := range scope.Symbols {
+				got[sym.Name] = sym.Type
+			}
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tipos = %v, se esperaba %v", got, want)
+	}
+}
+
+func TestDeclarators(t *testing.T) {
+	checkSemantic(t, []semanticCase{
+		{
+			name:    "demasiados valores",
+			code:    "int main() {\n    int a[3] = {1, 2, 3, 4};\n    return a[0];\n}\n",
+			want:    []string{CodeInvalidArray},
+			message: "Demasiados valores para el arreglo 'a': tiene tamaño 3 y se dieron 4",
+		},
+		{
+			name:    "tamaño no entero",
+			code:    "int main() {\n    int a[2.5];\n    return a[0];\n}\n",
+			want:    []string{CodeInvalidArray},
+			message: "El tamaño del arreglo 'a' debe ser un entero, se encontró double",
+		},
+		{
+			name:    "asignar un arreglo completo",
+			code:    "int main() {\n    int a[3] = {1, 2, 3};\n    int b[3];\n    b = a;\n    return b[0];\n}\n",
+			want:    []string{CodeNotAssignable},
+			message: "Un arreglo no se puede asignar completo",
+		},
+		{
+			name:    "desreferenciar un int",
+			code:    "int main() {\n    int x = 1;\n    return *x;\n}\n",
+			want:    []string{CodeInvalidPointer},
+			message: "No se puede desreferenciar una expresión de tipo int",
+		},
+		{
+			name:    "puntero a otro tipo",
+			code:    "int main() {\n    double d = 1;\n    int* p = &d;\n    return *p;\n}\n",
+			want:    []string{CodeTypeMismatch},
+			message: "de tipo int* no puede ser asignada con valor de tipo double*",
+		},
+		{
+			name:    "modificar una constante",
+			code:    "int main() {\n    const int c = 1;\n    c = 2;\n    return c;\n}\n",
+			want:    []string{CodeConstModified},
+			message: "No se puede modificar 'c' porque es constante",
+		},
+		{
+			name:    "modificar a través de un puntero a const",
+			code:    "int main() {\n    int x = 1;\n    const int* p = &x;\n    *p = 2;\n    return x;\n}\n",
+			want:    []string{CodeConstModified},
+			message: "No se puede modificar una expresión constante",
+		},
+		{
+			name:    "referencia sin inicializar",
+			code:    "int main() {\n    int x = 1;\n    int& r;\n    r = x;\n    return r;\n}\n",
+			want:    []string{CodeInvalidReference},
+			message: "La referencia 'r' debe inicializarse al declararse",
+		},
+		{
+			name:    "referencia a un literal",
+			code:    "int main() {\n    int& r = 5;\n    return r;\n}\n",
+			want:    []string{CodeInvalidReference},
+			message: "La referencia 'r' debe inicializarse con una variable",
+		},
+		{
+			name: "punteros, referencias y matrices",
+			code: "int main() {\n    int x = 1;\n    int* p = &x;\n    int** pp = &p;\n    int& r = x;\n    r = **pp + *p;\n    int m[2][3] = {{1, 2, 3}, {4, 5, 6}};\n    return m[1][2] + r;\n}\n",
+			want: []string{},
+		},
+	})
+}
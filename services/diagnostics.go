@@ -0,0 +1,268 @@
+package services
+
+import (
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+// Fases del análisis
+const (
+	PhaseLexical      = "lexical"
+	PhasePreprocessor = "preprocessor"
+	PhaseSyntax       = "syntax"
+	PhaseSemantic     = "semantic"
+	PhaseRuntime      = "runtime"
+)
+
+// Severidades de los diagnósticos
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// Códigos estables de diagnóstico. Nunca se reutiliza un código para otro
+// significado: los clientes dependen de ellos.
+const (
+	CodeInvalidCharacter    = "LEX001"
+	CodeMalformedNumber     = "LEX002"
+	CodeUnterminatedString  = "LEX003"
+	CodeUnterminatedChar    = "LEX004"
+	CodeUnterminatedComment = "LEX005"
+	CodeEmptyChar           = "LEX006"
+
+	CodeMalformedDirective = "PRE001"
+	CodeUnbalancedIf       = "PRE002"
+	CodeMacroArguments     = "PRE003"
+	CodeMacroRedefined     = "PRE004"
+	CodeErrorDirective     = "PRE005"
+	CodeUnknownDirective   = "PRE006"
+	CodeIncludeNotFound    = "PRE007"
+	CodeIncludeCycle       = "PRE008"
+	CodeExpansionLimit     = "PRE009"
+
+	CodeMissingSemicolon  = "SYN001"
+	CodeMalformedInclude  = "SYN002"
+	CodeMalformedUsing    = "SYN003"
+	CodeMalformedControl  = "SYN004"
+	CodeInvalidExpression = "SYN005"
+	CodeUnbalanced        = "SYN006"
+	CodeInvalidDecl       = "SYN007"
+	CodeMissingMain       = "SYN008"
+	CodeInvalidMain       = "SYN009"
+	CodeMisplacedKeyword  = "SYN010"
+	CodeNestingDepth      = "SYN012"
+
+	CodeRedeclared           = "SEM001"
+	CodeUndeclared           = "SEM002"
+	CodeTypeMismatch         = "SEM003"
+	CodeUnusedVariable       = "SEM004"
+	CodeShadowedVariable     = "SEM005"
+	CodeInvalidOperands      = "SEM006"
+	CodeNarrowing            = "SEM007"
+	CodeNotAssignable        = "SEM008"
+	CodeInvalidCast          = "SEM009"
+	CodeUseBeforeDeclaration = "SEM010"
+	CodeArgumentCount        = "SEM011"
+	CodeArgumentType         = "SEM012"
+	CodeReturnType           = "SEM013"
+	CodeMissingReturn        = "SEM014"
+	CodeNotCallable          = "SEM015"
+	CodeInvalidArray         = "SEM016"
+	CodeInvalidPointer       = "SEM017"
+	CodeConstModified        = "SEM018"
+	CodeInvalidReference     = "SEM019"
+	CodeUnknownMember        = "SEM020"
+	CodeInaccessibleMember   = "SEM021"
+	CodeMissingInclude       = "SEM022"
+	CodeMissingNamespace     = "SEM023"
+	CodeUnknownType          = "SEM024"
+	CodeUninitialized        = "SEM025"
+	CodeDeadStore            = "SEM026"
+	CodeNeverRead            = "SEM027"
+	CodeUnreachable          = "SEM028"
+	CodeDivisionByZero       = "SEM029"
+	CodeIntegerOverflow      = "SEM030"
+	CodeDuplicateCase        = "SEM031"
+	CodeNotConstant          = "SEM032"
+	CodeUndefinedFunction    = "SEM033"
+	CodeMultipleDefinition   = "SEM034"
+
+	CodeRuntimeDivision   = "RUN001"
+	CodeOutOfBounds       = "RUN002"
+	CodeInvalidMemory     = "RUN003"
+	CodeStackOverflow     = "RUN004"
+	CodeUncaughtException = "RUN005"
+	CodeStepLimit         = "RUN006"
+	CodeMemoryLimit       = "RUN007"
+	CodeOutputLimit       = "RUN008"
+	CodeUnsupported       = "RUN009"
+	CodeTimeLimit         = "RUN010"
+)
+
+// DiagnosticRule describe un tipo de diagnóstico: su fase, su severidad por
+// defecto y un título corto
+type DiagnosticRule struct {
+	Code     string `json:"code"`
+	Phase    string `json:"phase"`
+	Severity string `json:"severity"`
+	Title    string `json:"title"`
+}
+
+var diagnosticRules = []DiagnosticRule{
+	{CodeInvalidCharacter, PhaseLexical, SeverityError, "Carácter inválido"},
+	{CodeMalformedNumber, PhaseLexical, SeverityError, "Número mal formado"},
+	{CodeUnterminatedString, PhaseLexical, SeverityError, "Cadena sin cerrar"},
+	{CodeUnterminatedChar, PhaseLexical, SeverityError, "Carácter literal sin cerrar"},
+	{CodeUnterminatedComment, PhaseLexical, SeverityError, "Comentario de bloque sin cerrar"},
+	{CodeEmptyChar, PhaseLexical, SeverityError, "Carácter literal vacío"},
+
+	{CodeMalformedDirective, PhasePreprocessor, SeverityError, "Directiva del preprocesador mal formada"},
+	{CodeUnbalancedIf, PhasePreprocessor, SeverityError, "#if, #else o #endif sin pareja"},
+	{CodeMacroArguments, PhasePreprocessor, SeverityError, "Cantidad de argumentos de macro incorrecta"},
+	{CodeMacroRedefined, PhasePreprocessor, SeverityWarning, "Macro redefinida"},
+	{CodeErrorDirective, PhasePreprocessor, SeverityError, "Directiva #error"},
+	{CodeUnknownDirective, PhasePreprocessor, SeverityWarning, "Directiva desconocida"},
+	{CodeIncludeNotFound, PhasePreprocessor, SeverityError, "Archivo incluido no encontrado"},
+	{CodeIncludeCycle, PhasePreprocessor, SeverityError, "Inclusión circular"},
+	{CodeExpansionLimit, PhasePreprocessor, SeverityError, "Límite de expansión de macros superado"},
+
+	{CodeMissingSemicolon, PhaseSyntax, SeverityError, "Falta punto y coma"},
+	{CodeMalformedInclude, PhaseSyntax, SeverityError, "Include mal formado"},
+	{CodeMalformedUsing, PhaseSyntax, SeverityError, "Declaración using namespace incorrecta"},
+	{CodeMalformedControl, PhaseSyntax, SeverityError, "Estructura de control mal formada"},
+	{CodeInvalidExpression, PhaseSyntax, SeverityError, "Expresión inválida"},
+	{CodeUnbalanced, PhaseSyntax, SeverityError, "Delimitadores desbalanceados"},
+	{CodeInvalidDecl, PhaseSyntax, SeverityError, "Declaración inválida"},
+	{CodeMissingMain, PhaseSyntax, SeverityError, "No se encontró la función main"},
+	{CodeInvalidMain, PhaseSyntax, SeverityError, "Declaración de main incorrecta"},
+	{CodeMisplacedKeyword, PhaseSyntax, SeverityError, "Palabra reservada fuera de contexto"},
+	{CodeNestingDepth, PhaseSyntax, SeverityError, "Anidamiento demasiado profundo"},
+
+	{CodeRedeclared, PhaseSemantic, SeverityError, "Identificador declarado anteriormente"},
+	{CodeUndeclared, PhaseSemantic, SeverityError, "Nombre usado pero no declarado"},
+	{CodeTypeMismatch, PhaseSemantic, SeverityError, "Tipos incompatibles"},
+	{CodeUnusedVariable, PhaseSemantic, SeverityWarning, "Variable declarada pero no usada"},
+	{CodeShadowedVariable, PhaseSemantic, SeverityWarning, "Variable que oculta otra declaración"},
+	{CodeInvalidOperands, PhaseSemantic, SeverityError, "Operandos inválidos para el operador"},
+	{CodeNarrowing, PhaseSemantic, SeverityWarning, "Conversión con posible pérdida de datos"},
+	{CodeNotAssignable, PhaseSemantic, SeverityError, "Expresión no asignable"},
+	{CodeInvalidCast, PhaseSemantic, SeverityError, "Conversión explícita inválida"},
+	{CodeUseBeforeDeclaration, PhaseSemantic, SeverityError, "Función usada antes de su declaración"},
+	{CodeArgumentCount, PhaseSemantic, SeverityError, "Cantidad de argumentos incorrecta"},
+	{CodeArgumentType, PhaseSemantic, SeverityError, "Tipo de argumento incompatible"},
+	{CodeReturnType, PhaseSemantic, SeverityError, "Valor de retorno incorrecto"},
+	{CodeMissingReturn, PhaseSemantic, SeverityError, "Falta return en función no void"},
+	{CodeNotCallable, PhaseSemantic, SeverityError, "El identificador no es una función"},
+	{CodeInvalidArray, PhaseSemantic, SeverityError, "Uso inválido de arreglo"},
+	{CodeInvalidPointer, PhaseSemantic, SeverityError, "Operación de puntero inválida"},
+	{CodeConstModified, PhaseSemantic, SeverityError, "Modificación de una constante"},
+	{CodeInvalidReference, PhaseSemantic, SeverityError, "Referencia inválida"},
+	{CodeUnknownMember, PhaseSemantic, SeverityError, "Miembro inexistente"},
+	{CodeInaccessibleMember, PhaseSemantic, SeverityError, "Miembro inaccesible"},
+	{CodeMissingInclude, PhaseSemantic, SeverityError, "Falta el #include del encabezado"},
+	{CodeMissingNamespace, PhaseSemantic, SeverityError, "Nombre de std sin calificar"},
+	{CodeUnknownType, PhaseSemantic, SeverityError, "Tipo no declarado"},
+	{CodeUninitialized, PhaseSemantic, SeverityWarning, "Variable posiblemente sin inicializar"},
+	{CodeDeadStore, PhaseSemantic, SeverityWarning, "Valor asignado que nunca se lee"},
+	{CodeNeverRead, PhaseSemantic, SeverityWarning, "Variable asignada pero nunca leída"},
+	{CodeUnreachable, PhaseSemantic, SeverityWarning, "Código inalcanzable"},
+	{CodeDivisionByZero, PhaseSemantic, SeverityError, "División por cero"},
+	{CodeIntegerOverflow, PhaseSemantic, SeverityWarning, "Desbordamiento de entero"},
+	{CodeDuplicateCase, PhaseSemantic, SeverityError, "Valor de case duplicado"},
+	{CodeNotConstant, PhaseSemantic, SeverityError, "Se requiere una expresión constante"},
+	{CodeUndefinedFunction, PhaseSemantic, SeverityError, "Función llamada pero no definida"},
+	{CodeMultipleDefinition, PhaseSemantic, SeverityError, "Función definida en varios archivos"},
+
+	{CodeRuntimeDivision, PhaseRuntime, SeverityError, "División por cero"},
+	{CodeOutOfBounds, PhaseRuntime, SeverityError, "Acceso fuera de los límites"},
+	{CodeInvalidMemory, PhaseRuntime, SeverityError, "Acceso a memoria inválida"},
+	{CodeStackOverflow, PhaseRuntime, SeverityError, "Recursión demasiado profunda"},
+	{CodeUncaughtException, PhaseRuntime, SeverityError, "Excepción no capturada"},
+	{CodeStepLimit, PhaseRuntime, SeverityError, "Límite de pasos excedido"},
+	{CodeMemoryLimit, PhaseRuntime, SeverityError, "Límite de memoria excedido"},
+	{CodeOutputLimit, PhaseRuntime, SeverityError, "Límite de salida excedido"},
+	{CodeUnsupported, PhaseRuntime, SeverityError, "Construcción no admitida por el intérprete"},
+	{CodeTimeLimit, PhaseRuntime, SeverityError, "Límite de tiempo excedido"},
+}
+
+// DiagnosticRules devuelve el catálogo completo de diagnósticos
+func DiagnosticRules() []DiagnosticRule {
+	return diagnosticRules
+}
+
+func ruleFor(code string) DiagnosticRule {
+	for _, rule := range diagnosticRules {
+		if rule.Code == code {
+			return rule
+		}
+	}
+	return DiagnosticRule{Code: code, Severity: SeverityError}
+}
+
+// cloneDiagnostic copia el diagnóstico con su camino y sus correcciones,
+// que de otro modo compartiría con el original
+func cloneDiagnostic(d models.Diagnostic) models.Diagnostic {
+	d.Path = append([]models.PathStep(nil), d.Path...)
+	fixes := d.Fixes
+	d.Fixes = nil
+	for _, fix := range fixes {
+		fix.Edits = append([]models.TextEdit(nil), fix.Edits...)
+		d.Fixes = append(d.Fixes, fix)
+	}
+	return d
+}
+
+// diagnosticList devuelve una lista vacía en lugar de nil, para que las
+// respuestas tengan "diagnostics": [] cuando el código no tiene problemas
+func diagnosticList(diagnostics []models.Diagnostic) []models.Diagnostic {
+	if diagnostics == nil {
+		return []models.Diagnostic{}
+	}
+	return diagnostics
+}
+
+// newDiagnostic crea un diagnóstico tomando fase y severidad del catálogo
+func newDiagnostic(code, message string, startLine, startColumn, endLine, endColumn int) models.Diagnostic {
+	rule := ruleFor(code)
+	return models.Diagnostic{
+		Phase:       rule.Phase,
+		Code:        code,
+		Severity:    rule.Severity,
+		Message:     message,
+		StartLine:   startLine,
+		StartColumn: startColumn,
+		EndLine:     endLine,
+		EndColumn:   endColumn,
+	}
+}
+
+// diagnosticAtToken crea un diagnóstico que cubre el token indicado
+func diagnosticAtToken(code, message string, tok models.Token) models.Diagnostic {
+	endLine, endColumn := tokenEnd(tok)
+	if endColumn == tok.Column {
+		endColumn++
+	}
+	return newDiagnostic(code, message, tok.Line, tok.Column, endLine, endColumn)
+}
+
+// diagnosticAtNode crea un diagnóstico que cubre el nodo indicado
+func diagnosticAtNode(code, message string, node *models.SyntaxNode) models.Diagnostic {
+	return newDiagnostic(code, message, node.Line, node.Column, node.EndLine, node.EndColumn)
+}
+
+// diagnosticAfterToken crea un diagnóstico de un carácter justo después del
+// token, útil para señalar algo que falta (por ejemplo un ';')
+func diagnosticAfterToken(code, message string, tok models.Token) models.Diagnostic {
+	line, column := tokenEnd(tok)
+	return newDiagnostic(code, message, line, column, line, column+1)
+}
+
+// hasErrors indica si algún diagnóstico tiene severidad de error
+func hasErrors(diagnostics []models.Diagnostic) bool {
+	for _, d := range diagnostics {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+func TestDiagnosticRules(t *testing.T) {
+	prefixes := map[string]string{
+		"LEX": PhaseLexical,
+		"PRE": PhasePreprocessor,
+		"SYN": PhaseSyntax,
+		"SEM": PhaseSemantic,
+		"RUN": PhaseRuntime,
+	}
+	seen := make(map[string]bool)
+	for _, rule := range DiagnosticRules() {
+		if seen[rule.Code] {
+			t.Errorf("el código %s aparece dos veces en el catálogo", rule.Code)
+		}
+		seen[rule.Code] = true
+		if len(rule.Code) != 6 || prefixes[rule.Code[:3]] != rule.Phase {
+			t.Errorf("el código %s no corresponde a la fase %s", rule.Code, rule.Phase)
+		}
+		if _, ok := SeverityNames[rule.Severity]; !ok {
+			t.Errorf("%s: severidad desconocida %q", rule.Code, rule.Severity)
+		}
+		if rule.Title == "" {
+			t.Errorf("%s: falta el título", rule.Code)
+		}
+	}
+}
+
+func TestDiagnosticPositions(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		want     string // código
+		phase    string
+		severity string
+		message  string
+		span     [4]int // línea y columna de inicio y de fin
+	}{
+		{
+			name:     "falta punto y coma",
+			code:     "int main() {\n    int x = 1\n    return x;\n}\n",
+			want:     CodeMissingSemicolon,
+			phase:    PhaseSyntax,
+			severity: SeverityError,
+			message:  "Falta punto y coma",
+			span:     [4]int{2, 14, 2, 15},
+		},
+		{
+			name:     "variable no declarada",
+			code:     "int main() {\n    return z;\n}\n",
+			want:     CodeUndeclared,
+			phase:    PhaseSemantic,
+			severity: SeverityError,
+			message:  "Variable 'z' usada pero no declarada",
+			span:     [4]int{2, 12, 2, 13},
+		},
+		{
+			name:     "variable sin usar",
+			code:     "int main() {\n    int y;\n    return 0;\n}\n",
+			want:     CodeUnusedVariable,
+			phase:    PhaseSemantic,
+			severity: SeverityWarning,
+			message:  "Variable 'y' declarada pero no usada",
+			span:     [4]int{2, 9, 2, 10},
+		},
+		{
+			name:     "carácter inválido",
+			code:     "int main() {\n    return 1 @ 2;\n}\n",
+			want:     CodeInvalidCharacter,
+			phase:    PhaseLexical,
+			severity: SeverityError,
+			message:  "Carácter inválido '@'",
+			span:     [4]int{2, 14, 2, 15},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagnostics := AllDiagnostics(Analyze(models.CodeRequest{Code: tt.code}))
+			if len(diagnostics) != 1 {
+				t.Fatalf("diagnósticos = %v, se esperaba solo %s", diagnostics, tt.want)
+			}
+			d := diagnostics[0]
+			if d.Code != tt.want || d.Phase != tt.phase || d.Severity != tt.severity || d.Message != tt.message {
+				t.Errorf("%s %s %s %q, se esperaba %s %s %s %q",
+					d.Code, d.Phase, d.Severity, d.Message, tt.want, tt.phase, tt.severity, tt.message)
+			}
+			if span := [4]int{d.StartLine, d.StartColumn, d.EndLine, d.EndColumn}; span != tt.span {
+				t.Errorf("posición = %v, se esperaba %v", span, tt.span)
+			}
+		})
+	}
+}
+
+// Un programa sin problemas responde listas vacías, nunca null
+func TestDiagnosticListsAreEmpty(t *testing.T) {
+	result := Analyze(models.CodeRequest{Code: "int main() { return 0; }\n"})
+	body, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(body), `"diagnostics":null`) {
+		t.Errorf("la respuesta tiene diagnostics null: %s", body)
+	}
+	if got := strings.Count(string(body), `"diagnostics":[]`); got != 3 {
+		t.Errorf("%d fases con diagnostics vacío, se esperaban 3: %s", got, body)
+	}
+}
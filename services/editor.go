@@ -0,0 +1,341 @@
+package services
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+// Document es un archivo analizado para las consultas de un editor: los
+// diagnósticos de las tres fases, los tokens del lexer y el símbolo que
+// nombra cada identificador
+type Document struct {
+	Diagnostics []models.Diagnostic
+	Tokens      []models.Token // como están escritos, sin preprocesar
+
+	tree     *models.SyntaxNode
+	expanded []models.Token // los que recibió el parser
+	analyzer *semanticAnalyzer
+
+	// Símbolo nombrado por el identificador que empieza en cada posición,
+	// tanto en sus usos como en su declaración
+	names map[textPosition]*symbol
+}
+
+type textPosition struct {
+	line, column int
+}
+
+// AnalyzeDocument analiza un archivo completo con las tres fases
+func AnalyzeDocument(code string) *Document {
+	tokens, lexical := Tokenize(code)
+	expanded, diagnostics := Preprocess(code)
+	tree, diagnostics := parseTokens(expanded, diagnostics, nil)
+	syntax := checkProgram(tree, diagnostics)
+	a := runAnalyzer(tree, nil)
+
+	d := &Document{
+		Diagnostics: append(append(lexical, syntax.Diagnostics...), a.diagnostics...),
+		Tokens:      tokens,
+		tree:        tree,
+		expanded:    expanded,
+		analyzer:    a,
+		names:       make(map[textPosition]*symbol),
+	}
+	d.indexNames()
+	return d
+}
+
+// indexNames ubica el nombre de cada referencia y cada declaración
+func (d *Document) indexNames() {
+	// Los tokens de una macro expandida ocupan el nombre de la macro, que
+	// no es ninguno de ellos
+	macros := make(map[textPosition]bool)
+	for _, tok := range d.expanded {
+		if tok.Macro != "" {
+			macros[textPosition{tok.Line, tok.Column}] = true
+		}
+	}
+
+	for node, sym := range d.analyzer.references {
+		if node.Kind != NodeIdentifier && node.Kind != NodeMember || macros[textPosition{node.Line, node.Column}] {
+			continue
+		}
+		// El nombre es lo último del nodo: std::cout, p.x, p->x
+		_, name, _ := splitQualified(node.Value)
+		d.names[textPosition{node.EndLine, node.EndColumn - utf8.RuneCountInString(name)}] = sym
+	}
+
+	for _, s := range d.analyzer.table.scopes {
+		for _, sym := range s.order {
+			declarations := sym.overloads
+			if len(declarations) == 0 && sym.node != nil {
+				declarations = []*models.SyntaxNode{sym.node}
+			}
+			for _, node := range declarations {
+				if pos, ok := d.namePosition(node, sym.Name); ok {
+					d.names[pos] = sym
+				}
+			}
+		}
+	}
+	for _, info := range d.analyzer.classes {
+		for declaration, definition := range info.definitions {
+			if sym := info.scope.lookupLocal(declaration.Value); sym != nil {
+				if pos, ok := d.namePosition(definition, sym.Name); ok {
+					d.names[pos] = sym
+				}
+			}
+		}
+	}
+}
+
+// namePosition busca el nombre declarado por el nodo. En un Declarator es
+// donde empieza; en funciones, parámetros y clases es el último
+// identificador con ese nombre antes de los parámetros, el valor por
+// defecto o el cuerpo (en Punto::Punto(), el segundo).
+func (d *Document) namePosition(node *models.SyntaxNode, name string) (textPosition, bool) {
+	if node.Kind == NodeDeclarator {
+		return textPosition{node.Line, node.Column}, true
+	}
+	_, name, _ = splitQualified(name)
+	i := sort.Search(len(d.expanded), func(i int) bool {
+		tok := d.expanded[i]
+		return tok.Line > node.Line || tok.Line == node.Line && tok.Column >= node.Column
+	})
+
+	var pos textPosition
+	found := false
+	for ; i < len(d.expanded); i++ {
+		tok := d.expanded[i]
+		if tok.Line > node.EndLine || tok.Line == node.EndLine && tok.Column >= node.EndColumn {
+			break
+		}
+		if tok.Kind == TokenSymbol {
+			switch tok.Lexeme {
+			case "(", "[", "{", "=", ";", ",", ":":
+				return pos, found
+			}
+		}
+		if tok.Kind == TokenIdentifier && tok.Macro == "" && tok.Lexeme == name {
+			pos, found = textPosition{tok.Line, tok.Column}, true
+		}
+	}
+	return pos, found
+}
+
+// tokenAt devuelve el token del lexer que contiene la posición. El cursor
+// justo después de un token también lo señala, salvo que ahí empiece otro.
+func (d *Document) tokenAt(line, column int) (models.Token, bool) {
+	i := sort.Search(len(d.Tokens), func(i int) bool {
+		tok := d.Tokens[i]
+		return tok.Line > line || tok.Line == line && tok.Column > column
+	})
+	if i == 0 {
+		return models.Token{}, false
+	}
+	tok := d.Tokens[i-1]
+	endLine, endColumn := tokenEnd(tok)
+	return tok, tok.Line == line && endLine == line && column <= endColumn
+}
+
+// symbolAt devuelve el identificador de la posición y el símbolo que nombra.
+// El nombre de una clase del programa dentro de un tipo (Punto p;) no es
+// una referencia del árbol y se busca en el ámbito global.
+func (d *Document) symbolAt(line, column int) (models.Token, *symbol) {
+	tok, ok := d.tokenAt(line, column)
+	if !ok || tok.Kind != TokenIdentifier {
+		return tok, nil
+	}
+	if sym := d.names[textPosition{tok.Line, tok.Column}]; sym != nil {
+		return tok, sym
+	}
+	if sym := d.analyzer.table.global().lookupLocal(tok.Lexeme); sym != nil && sym.Kind == SymbolClass {
+		return tok, sym
+	}
+	return tok, nil
+}
+
+// IdentifierKind devuelve el tipo de símbolo (SymbolVariable,
+// SymbolFunction...) que nombra el identificador, o "" si no se conoce.
+// Los nombres de clases se reconocen también en las declaraciones, donde
+// forman parte del tipo.
+func (d *Document) IdentifierKind(tok models.Token) string {
+	if sym := d.names[textPosition{tok.Line, tok.Column}]; sym != nil {
+		return sym.Kind
+	}
+	if _, ok := stdlibType(tok.Lexeme); ok || d.analyzer.classes[tok.Lexeme] != nil {
+		return SymbolClass
+	}
+	return ""
+}
+
+// Hover describe el símbolo nombrado en la posición: su declaración, qué
+// es y dónde se declaró. Devuelve también el fragmento del identificador.
+func (d *Document) Hover(line, column int) (string, models.Range, bool) {
+	tok, sym := d.symbolAt(line, column)
+	if sym == nil {
+		return "", models.Range{}, false
+	}
+
+	var declaration []string
+	switch sym.Kind {
+	case SymbolFunction, SymbolMethod, SymbolConstructor:
+		for _, overload := range sym.overloads {
+			declaration = append(declaration, strings.TrimSpace(strings.TrimSuffix(prototypeText(overload), ";")))
+		}
+	case SymbolClass:
+		declaration = append(declaration, sym.Type+" "+sym.Name)
+	default:
+		text := sym.Type + " " + sym.Name
+		if sym.constant != nil && !sym.constant.invalid {
+			text += " = " + sym.constant.value.String()
+		}
+		declaration = append(declaration, text)
+	}
+
+	description := kindNoun(sym.Kind)
+	switch {
+	case sym.header != "":
+		description += " de la biblioteca estándar (<" + sym.header + ">)"
+	case sym.scope != nil && sym.scope.kind == ScopeClass:
+		description += " de " + sym.scope.name + ", línea " + strconv.Itoa(sym.Line)
+	case sym.Kind == SymbolVariable && sym.scope != nil && sym.scope.kind == ScopeGlobal:
+		description += " global, línea " + strconv.Itoa(sym.Line)
+	case sym.Kind == SymbolVariable:
+		description += " local, línea " + strconv.Itoa(sym.Line)
+	default:
+		description += ", línea " + strconv.Itoa(sym.Line)
+	}
+	if sym.header == "" && !sym.Used && (sym.Kind == SymbolVariable || sym.Kind == SymbolParameter) {
+		description += " (sin usar)"
+	}
+
+	text := "```cpp\n" + strings.Join(declaration, "\n") + "\n```\n" +
+		strings.ToUpper(description[:1]) + description[1:]
+	return text, tokenRange(tok), true
+}
+
+// Definition devuelve dónde está el nombre en la definición del símbolo
+// nombrado en la posición: el cuerpo de una función si lo tiene, aunque esté
+// fuera de su clase, o si no su declaración. Los símbolos de la
+// biblioteca estándar no tienen una.
+func (d *Document) Definition(line, column int) (models.Range, bool) {
+	_, sym := d.symbolAt(line, column)
+	if sym == nil || sym.header != "" || sym.node == nil {
+		return models.Range{}, false
+	}
+
+	target := sym.node
+	var owner *classInfo
+	if sym.scope != nil && sym.scope.kind == ScopeClass {
+		owner = d.analyzer.classes[sym.scope.name]
+	}
+	for _, overload := range sym.overloads {
+		if functionBody(overload) != nil {
+			target = overload
+			break
+		}
+		if owner != nil && owner.definitions[overload] != nil {
+			target = owner.definitions[overload]
+			break
+		}
+	}
+
+	pos, ok := d.namePosition(target, sym.Name)
+	if !ok {
+		pos = textPosition{target.Line, target.Column}
+	}
+	_, name, _ := splitQualified(sym.Name)
+	return models.Range{
+		StartLine:   pos.line,
+		StartColumn: pos.column,
+		EndLine:     pos.line,
+		EndColumn:   pos.column + utf8.RuneCountInString(name),
+	}, true
+}
+
+// Outline devuelve el esquema del archivo: funciones, clases con sus
+// miembros y variables globales, en el orden del código
+func (d *Document) Outline() []models.OutlineSymbol {
+	outline := []models.OutlineSymbol{}
+	for _, node := range d.tree.Children {
+		outline = append(outline, d.outlineNode(node, "")...)
+	}
+	return outline
+}
+
+// outlineNode convierte una declaración de nivel superior, o un miembro
+// de la clase indicada
+func (d *Document) outlineNode(node *models.SyntaxNode, class string) []models.OutlineSymbol {
+	switch node.Kind {
+	case NodeFunction:
+		kind := SymbolFunction
+		name := node.Value
+		if owner, method, ok := splitQualified(node.Value); ok {
+			class, name = owner, method
+		}
+		if class != "" {
+			kind = SymbolMethod
+			if name == class {
+				kind = SymbolConstructor
+			}
+		}
+		return []models.OutlineSymbol{d.outlineSymbol(node, node.Value, kind, functionSignature(node))}
+
+	case NodeClass:
+		item := d.outlineSymbol(node, node.Value, SymbolClass, node.Type)
+		if body := functionBody(node); body != nil {
+			for _, member := range body.Children {
+				item.Children = append(item.Children, d.outlineNode(member, node.Value)...)
+			}
+		}
+		return []models.OutlineSymbol{item}
+
+	case NodeVarDecl:
+		kind := SymbolVariable
+		if class != "" {
+			kind = SymbolField
+		}
+		var items []models.OutlineSymbol
+		for _, declarator := range node.Children {
+			items = append(items, d.outlineSymbol(declarator, declarator.Value, kind, declarator.Type))
+		}
+		return items
+	}
+	return nil
+}
+
+func (d *Document) outlineSymbol(node *models.SyntaxNode, name, kind, detail string) models.OutlineSymbol {
+	item := models.OutlineSymbol{
+		Name:   name,
+		Kind:   kind,
+		Detail: detail,
+		Range: models.Range{
+			StartLine:   node.Line,
+			StartColumn: node.Column,
+			EndLine:     node.EndLine,
+			EndColumn:   node.EndColumn,
+		},
+	}
+	item.NameRange = models.Range{StartLine: node.Line, StartColumn: node.Column, EndLine: node.Line, EndColumn: node.Column}
+	if pos, ok := d.namePosition(node, name); ok {
+		_, last, _ := splitQualified(name)
+		item.NameRange = models.Range{
+			StartLine:   pos.line,
+			StartColumn: pos.column,
+			EndLine:     pos.line,
+			EndColumn:   pos.column + utf8.RuneCountInString(last),
+		}
+	}
+	return item
+}
+
+// tokenRange devuelve el fragmento que ocupa el token
+func tokenRange(tok models.Token) models.Range {
+	endLine, endColumn := tokenEnd(tok)
+	return models.Range{StartLine: tok.Line, StartColumn: tok.Column, EndLine: endLine, EndColumn: endColumn}
+}
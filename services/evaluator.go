@@ -0,0 +1,763 @@
+package services
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+// typeFunction es el tipo del valor de un identificador que nombra una
+// función del programa, por ejemplo el comparador de sort
+const typeFunction = "function"
+
+// argument es un argumento ya evaluado: la ubicación si el parámetro es
+// una referencia o el valor en otro caso
+type argument struct {
+	loc   location
+	value rtValue
+	ref   bool
+}
+
+// eval evalúa una expresión y devuelve su valor
+func (in *interpreter) eval(node *models.SyntaxNode) rtValue {
+	switch node.Kind {
+	case NodeIntLiteral, NodeFloatLiteral, NodeCharLiteral, NodeStringLiteral, NodeBoolLiteral:
+		return in.literal(node)
+	case NodeNullLiteral:
+		return rtValue{t: cppType{Name: typeNullptr}}
+
+	case NodeIdentifier:
+		sym := in.analyzer.references[node]
+		switch {
+		case sym == nil || sym.header != "":
+			return in.libraryValue(node)
+		case sym.Kind == SymbolVariable || sym.Kind == SymbolParameter:
+			return in.load(in.lookup(sym, node), node)
+		case sym.Kind == SymbolFunction:
+			return rtValue{t: cppType{Name: typeFunction}, s: sym.Name}
+		}
+		in.fail(node, CodeUnsupported, "El intérprete no admite el uso de '"+node.Value+"'", 1)
+
+	case NodeAssign:
+		return in.load(in.assign(node), node)
+
+	case NodeBinary:
+		return in.evalBinary(node)
+
+	case NodeUnary:
+		switch node.Operator {
+		case "*", "++", "--":
+			return in.load(in.evalLocation(node), node)
+		case "&":
+			loc := in.evalLocation(node.Children[0])
+			t := locationType(loc)
+			t.Pointer++
+			return rtValue{t: t, ptr: loc}
+		case "!":
+			return rtValue{t: cppType{Name: "bool"}, i: boolInt(!in.truth(in.eval(node.Children[0])))}
+		}
+		operand := in.eval(node.Children[0])
+		t := promote(operand.t)
+		if t.isFloating() {
+			if node.Operator == "-" {
+				return rtValue{t: t, f: -operand.f}
+			}
+			return operand
+		}
+		value := in.convert(operand, t, node).i
+		switch node.Operator {
+		case "-":
+			value = -value
+		case "~":
+			value = ^value
+		}
+		return rtValue{t: t, i: wrapInteger(value, t)}
+
+	case NodePostfix:
+		loc := in.evalLocation(node.Children[0])
+		old := in.load(loc, node)
+		in.store(loc, in.increment(old, node.Operator, node), node)
+		return old
+
+	case NodeTernary:
+		if in.truth(in.eval(node.Children[0])) {
+			return in.eval(node.Children[1])
+		}
+		return in.eval(node.Children[2])
+
+	case NodeCall:
+		if loc, ok := in.elementAccess(node); ok {
+			return in.load(loc, node)
+		}
+		return in.evalCall(node)
+
+	case NodeIndex:
+		return in.load(in.evalLocation(node), node)
+
+	case NodeCast:
+		return in.convert(in.eval(node.Children[0]), typeFromString(node.Type), node)
+
+	case NodeSizeof:
+		t := typeFromString(node.Type)
+		if len(node.Children) > 0 {
+			t = in.eval(node.Children[0]).t
+		}
+		return rtValue{t: cppType{Name: "unsigned long"}, i: typeBytes(t)}
+
+	case NodeNew:
+		return in.allocate(node)
+	case NodeDelete:
+		in.release(node)
+		return rtValue{t: cppType{Name: "void"}}
+	}
+
+	in.fail(node, CodeUnsupported, "El intérprete no admite esta expresión ("+node.Kind+")", 1)
+	return rtValue{}
+}
+
+// evalLocation evalúa una expresión que designa un objeto y devuelve su
+// ubicación. Los valores sin ubicación se guardan en un temporal, como
+// cuando se pasa to_string(n) a un parámetro const string&.
+func (in *interpreter) evalLocation(node *models.SyntaxNode) location {
+	switch node.Kind {
+	case NodeIdentifier:
+		sym := in.analyzer.references[node]
+		if sym != nil && sym.header == "" && (sym.Kind == SymbolVariable || sym.Kind == SymbolParameter) {
+			return in.lookup(sym, node)
+		}
+
+	case NodeIndex:
+		return in.indexLocation(node)
+
+	case NodeUnary:
+		switch node.Operator {
+		case "*":
+			pointer := in.eval(node.Children[0])
+			if pointer.t.isArray() {
+				return location{blk: pointer.blk}
+			}
+			if pointer.t.Pointer == 0 {
+				in.fail(node, CodeUnsupported, "El intérprete no puede desreferenciar un valor de tipo "+pointer.t.String(), 1)
+			}
+			in.check(pointer.ptr, node)
+			return pointer.ptr
+		case "++", "--":
+			loc := in.evalLocation(node.Children[0])
+			in.store(loc, in.increment(in.load(loc, node), node.Operator, node), node)
+			return loc
+		}
+
+	case NodeAssign:
+		return in.assign(node)
+
+	case NodeCall:
+		if loc, ok := in.elementAccess(node); ok {
+			return loc
+		}
+	}
+	return in.temporary(in.eval(node), node)
+}
+
+// literal devuelve el valor de un literal; se calcula una sola vez
+func (in *interpreter) literal(node *models.SyntaxNode) rtValue {
+	if value, ok := in.literals[node]; ok {
+		return value
+	}
+	value := rtValue{t: literalType(node)}
+	switch node.Kind {
+	case NodeIntLiteral:
+		if constant, ok := in.analyzer.constantValue(node); ok && !constant.invalid {
+			value.t = constant.typ
+			value.i = int64(constant.value.Uint64())
+		}
+	case NodeFloatLiteral:
+		text := strings.TrimRight(strings.ToLower(strings.ReplaceAll(node.Value, "'", "")), "fl")
+		value.f, _ = strconv.ParseFloat(text, 64)
+		if value.t.Name == "float" {
+			value.f = float64(float32(value.f))
+		}
+	case NodeCharLiteral:
+		// El lexer rechaza '', pero un literal vacío no debe detener el servidor
+		if text := unescape(node.Value); text != "" {
+			value.i = int64(int8(text[0]))
+		}
+	case NodeStringLiteral:
+		// Un arreglo de char con su '\0' que dura todo el programa: el
+		// mismo literal es siempre el mismo arreglo
+		text := unescape(node.Value)
+		value.blk = in.newBlock(cppType{Name: "char"}, len(text)+1, "", node)
+		for i := 0; i < len(text); i++ {
+			value.blk.ints[i] = int64(int8(text[i]))
+		}
+		value.t.Dims = []int{len(text) + 1}
+	case NodeBoolLiteral:
+		value.i = boolInt(node.Value == "true")
+	}
+	in.literals[node] = value
+	return value
+}
+
+// unescape interpreta las secuencias de escape de un literal de C++. A
+// diferencia de Go, admite escapes octales de uno o dos dígitos ('\0').
+func unescape(text string) string {
+	var result []byte
+	for text != "" {
+		if len(text) > 1 && text[0] == '\\' && text[1] >= '0' && text[1] <= '7' {
+			value, n := 0, 1
+			for n < 4 && n < len(text) && text[n] >= '0' && text[n] <= '7' {
+				value = value*8 + int(text[n]-'0')
+				n++
+			}
+			result = append(result, byte(value))
+			text = text[n:]
+			continue
+		}
+		if strings.HasPrefix(text, "\\'") {
+			result = append(result, '\'')
+			text = text[2:]
+			continue
+		}
+		r, multibyte, tail, err := strconv.UnquoteChar(text, '"')
+		if err != nil {
+			result = append(result, text[0])
+			text = text[1:]
+			continue
+		}
+		if multibyte {
+			result = append(result, string(r)...)
+		} else {
+			result = append(result, byte(r))
+		}
+		text = tail
+	}
+	return string(result)
+}
+
+// libraryValue devuelve el valor de un objeto o constante de la
+// biblioteca estándar: los flujos, los manipuladores y las constantes
+func (in *interpreter) libraryValue(node *models.SyntaxNode) rtValue {
+	name := strings.TrimPrefix(node.Value, "std::")
+	switch name {
+	case "cout":
+		return rtValue{t: cppType{Name: typeOstream}, s: "cout"}
+	case "cerr", "clog":
+		return rtValue{t: cppType{Name: typeOstream}, s: "cerr"}
+	case "cin":
+		return rtValue{t: cppType{Name: typeIstream}, s: "cin"}
+	case "endl", "flush", "ws", "fixed", "scientific", "boolalpha", "noboolalpha", "left", "right":
+		return rtValue{t: cppType{Name: typeManipulator}, s: name}
+	case "RAND_MAX":
+		return rtValue{t: cppType{Name: "int"}, i: math.MaxInt32}
+	case "EXIT_SUCCESS":
+		return rtValue{t: cppType{Name: "int"}, i: 0}
+	case "EXIT_FAILURE":
+		return rtValue{t: cppType{Name: "int"}, i: 1}
+	case "CLOCKS_PER_SEC":
+		return rtValue{t: cppType{Name: "long"}, i: 1000000}
+	case "NULL":
+		return rtValue{t: cppType{Name: typeNullptr}}
+	case "string::npos":
+		return rtValue{t: cppType{Name: "unsigned long"}, i: -1}
+	}
+	in.fail(node, CodeUnsupported, "El intérprete no admite '"+node.Value+"'", 1)
+	return rtValue{}
+}
+
+// assign ejecuta una asignación y devuelve la ubicación modificada. Como
+// en C++17, el valor se evalúa antes que el destino.
+func (in *interpreter) assign(node *models.SyntaxNode) location {
+	value := in.eval(node.Children[1])
+	loc := in.evalLocation(node.Children[0])
+	if node.Operator != "=" {
+		op := strings.TrimSuffix(node.Operator, "=")
+		value = in.operate(op, in.load(loc, node), value, node)
+	}
+	in.store(loc, value, node)
+	return loc
+}
+
+// increment calcula el valor de ++ o -- aplicado a v
+func (in *interpreter) increment(v rtValue, op string, node *models.SyntaxNode) rtValue {
+	one := rtValue{t: cppType{Name: "int"}, i: 1}
+	if op == "++" {
+		return in.operate("+", v, one, node)
+	}
+	return in.operate("-", v, one, node)
+}
+
+func (in *interpreter) evalBinary(node *models.SyntaxNode) rtValue {
+	left, right := node.Children[0], node.Children[1]
+	switch node.Operator {
+	case "&&":
+		result := in.truth(in.eval(left)) && in.truth(in.eval(right))
+		return rtValue{t: cppType{Name: "bool"}, i: boolInt(result)}
+	case "||":
+		result := in.truth(in.eval(left)) || in.truth(in.eval(right))
+		return rtValue{t: cppType{Name: "bool"}, i: boolInt(result)}
+	case ",":
+		in.eval(left)
+		return in.eval(right)
+	}
+
+	l := in.eval(left)
+	switch {
+	case node.Operator == "<<" && l.t.Name == typeOstream:
+		in.write(l.s, in.eval(right), right)
+		return l
+	case node.Operator == ">>" && l.t.Name == typeIstream:
+		in.read(right)
+		return l
+	}
+	return in.operate(node.Operator, l, in.eval(right), node)
+}
+
+// operate aplica un operador binario a dos valores ya evaluados
+func (in *interpreter) operate(op string, l, r rtValue, node *models.SyntaxNode) rtValue {
+	boolean := cppType{Name: "bool"}
+	switch {
+	case l.t.isString() || r.t.isString():
+		a, b := in.convert(l, cppType{Name: "string"}, node).s, in.convert(r, cppType{Name: "string"}, node).s
+		if op == "+" {
+			in.checkAvailable(int64(len(a)+len(b)), node)
+			in.work(len(a)+len(b), node)
+			return rtValue{t: cppType{Name: "string"}, s: a + b}
+		}
+		in.work(min(len(a), len(b)), node)
+		return rtValue{t: boolean, i: boolInt(compareResult(op, strings.Compare(a, b)))}
+
+	case isVectorType(l.t) && isVectorType(r.t):
+		return rtValue{t: boolean, i: boolInt(compareResult(op, in.compareBlocks(l.blk, r.blk, node)))}
+
+	case l.t.Pointer > 0 || l.t.isArray() || r.t.Pointer > 0 || r.t.isArray() ||
+		l.t.Name == typeNullptr || r.t.Name == typeNullptr:
+		return in.operatePointers(op, in.decayValue(l), in.decayValue(r), node)
+
+	case l.t.isFloating() || r.t.isFloating():
+		t := arithmeticResult(l.t, r.t)
+		a, b := in.convert(l, t, node).f, in.convert(r, t, node).f
+		var result float64
+		switch op {
+		case "+":
+			result = a + b
+		case "-":
+			result = a - b
+		case "*":
+			result = a * b
+		case "/":
+			result = a / b
+		default:
+			return rtValue{t: boolean, i: boolInt(compareFloats(op, a, b))}
+		}
+		return in.convert(rtValue{t: cppType{Name: "double"}, f: result}, t, node)
+	}
+
+	// Los desplazamientos toman el tipo del operando izquierdo promovido
+	if op == "<<" || op == ">>" {
+		t := promote(l.t)
+		a := in.convert(l, t, node).i
+		shift := uint(r.i) % uint(integerBits[t.Name])
+		if op == "<<" {
+			return rtValue{t: t, i: wrapInteger(a<<shift, t)}
+		}
+		if isUnsigned64(t) {
+			return rtValue{t: t, i: int64(uint64(a) >> shift)}
+		}
+		return rtValue{t: t, i: wrapInteger(a>>shift, t)}
+	}
+
+	t := arithmeticResult(l.t, r.t)
+	a, b := in.convert(l, t, node).i, in.convert(r, t, node).i
+	unsigned := isUnsigned64(t)
+	var result int64
+	switch op {
+	case "+":
+		result = a + b
+	case "-":
+		result = a - b
+	case "*":
+		result = a * b
+	case "/", "%":
+		if b == 0 {
+			in.fail(node, CodeRuntimeDivision, "División entera por cero", exitFPE)
+		}
+		if min, _ := integerRange(t); !unsigned && b == -1 && a == min.Int64() {
+			in.fail(node, CodeRuntimeDivision, "Desbordamiento en la división: "+strconv.FormatInt(a, 10)+
+				" "+op+" -1 no cabe en "+t.String(), exitFPE)
+		}
+		switch {
+		case unsigned && op == "/":
+			result = int64(uint64(a) / uint64(b))
+		case unsigned:
+			result = int64(uint64(a) % uint64(b))
+		case op == "/":
+			result = a / b
+		default:
+			result = a % b
+		}
+	case "&":
+		result = a & b
+	case "|":
+		result = a | b
+	case "^":
+		result = a ^ b
+	default:
+		order := 0
+		switch {
+		case unsigned && uint64(a) < uint64(b), !unsigned && a < b:
+			order = -1
+		case a != b:
+			order = 1
+		}
+		return rtValue{t: boolean, i: boolInt(compareResult(op, order))}
+	}
+	return rtValue{t: t, i: wrapInteger(result, t)}
+}
+
+// compareResult aplica un operador de comparación al orden de dos valores
+func compareResult(op string, order int) bool {
+	switch op {
+	case "==":
+		return order == 0
+	case "!=":
+		return order != 0
+	case "<":
+		return order < 0
+	case "<=":
+		return order <= 0
+	case ">":
+		return order > 0
+	}
+	return order >= 0
+}
+
+func compareFloats(op string, a, b float64) bool {
+	switch op {
+	case "==":
+		return a == b
+	case "!=":
+		return a != b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	}
+	return a >= b
+}
+
+// compareBlocks compara dos vectores elemento a elemento
+func (in *interpreter) compareBlocks(a, b *block, node *models.SyntaxNode) int {
+	in.work(min(a.length(), b.length())*int(elementSize(a.elem)), node)
+	for i := 0; i < a.length() && i < b.length(); i++ {
+		x, y := a.get(i), b.get(i)
+		if in.operate("<", x, y, node).i != 0 {
+			return -1
+		}
+		if in.operate("<", y, x, node).i != 0 {
+			return 1
+		}
+	}
+	return a.length() - b.length()
+}
+
+// decayValue convierte un arreglo en un puntero a su primer elemento
+func (in *interpreter) decayValue(v rtValue) rtValue {
+	if v.t.isArray() {
+		return rtValue{t: v.t.decay(), ptr: location{blk: v.blk}}
+	}
+	return v
+}
+
+// advance mueve un puntero n elementos
+func advance(loc location, n int) location {
+	if loc.inString {
+		loc.offset += n
+	} else {
+		loc.index += n
+	}
+	return loc
+}
+
+// distance cuenta los elementos entre dos punteros al mismo bloque
+func distance(from, to location) int {
+	if from.inString {
+		return to.offset - from.offset
+	}
+	return to.index - from.index
+}
+
+// operatePointers aplica la aritmética y las comparaciones de punteros
+func (in *interpreter) operatePointers(op string, l, r rtValue, node *models.SyntaxNode) rtValue {
+	switch {
+	case op == "+" && l.t.Pointer > 0 && r.t.isIntegral():
+		return rtValue{t: l.t, ptr: advance(l.ptr, int(r.i))}
+	case op == "+" && r.t.Pointer > 0 && l.t.isIntegral():
+		return rtValue{t: r.t, ptr: advance(r.ptr, int(l.i))}
+	case op == "-" && l.t.Pointer > 0 && r.t.isIntegral():
+		return rtValue{t: l.t, ptr: advance(l.ptr, -int(r.i))}
+	case op == "-" && l.t.Pointer > 0 && r.t.Pointer > 0:
+		if l.ptr.blk != r.ptr.blk {
+			in.fail(node, CodeInvalidMemory, "Se restaron punteros a objetos distintos", exitSegv)
+		}
+		return rtValue{t: cppType{Name: "long"}, i: int64(distance(r.ptr, l.ptr))}
+	}
+
+	a, b := l.ptr, r.ptr
+	order := 0
+	switch {
+	case a.blk != b.blk:
+		order = 1
+		if op != "==" && op != "!=" {
+			in.fail(node, CodeInvalidMemory, "Se compararon punteros a objetos distintos", exitSegv)
+		}
+	case distance(b, a) != 0:
+		order = distance(b, a)
+	}
+	switch op {
+	case "==", "!=", "<", "<=", ">", ">=":
+		return rtValue{t: cppType{Name: "bool"}, i: boolInt(compareResult(op, order))}
+	}
+	in.fail(node, CodeUnsupported, "El intérprete no admite el operador '"+op+"' con punteros", 1)
+	return rtValue{}
+}
+
+// indexLocation calcula la ubicación de a[i] en arreglos, vectores,
+// cadenas y punteros
+func (in *interpreter) indexLocation(node *models.SyntaxNode) location {
+	base, object := in.object(node.Children[0])
+	index := in.eval(node.Children[1])
+	if isUnsigned64(index.t) && index.i < 0 {
+		index.i = math.MaxInt32
+	}
+	i := int(index.i)
+	switch {
+	case object.t.isString():
+		return location{blk: base.blk, index: base.index, inString: true, offset: i}
+	case object.t.isArray() || isVectorType(object.t):
+		return location{blk: object.blk, index: i}
+	case object.t.Pointer > 0:
+		if object.ptr.blk == nil {
+			in.fail(node, CodeInvalidMemory, "Se usó un puntero nulo", exitSegv)
+		}
+		return advance(object.ptr, i)
+	}
+	in.fail(node, CodeUnsupported, "El intérprete no puede indexar un valor de tipo "+object.t.String(), 1)
+	return location{}
+}
+
+// object evalúa el objeto de un índice o de una llamada a un método y
+// devuelve su ubicación, necesaria para modificar cadenas, y su valor
+func (in *interpreter) object(node *models.SyntaxNode) (location, rtValue) {
+	switch node.Kind {
+	case NodeIdentifier:
+		sym := in.analyzer.references[node]
+		if sym == nil || sym.header != "" {
+			return location{}, in.libraryValue(node)
+		}
+	case NodeIndex, NodeUnary, NodeCall:
+	default:
+		value := in.eval(node)
+		if !value.t.isString() {
+			return location{}, value
+		}
+		return in.temporary(value, node), value
+	}
+	loc := in.evalLocation(node)
+	return loc, in.load(loc, node)
+}
+
+// allocate reserva memoria con new y devuelve el puntero
+func (in *interpreter) allocate(node *models.SyntaxNode) rtValue {
+	t := typeFromString(node.Type).unqualified()
+	count := 1
+	if node.Operator == "[]" {
+		n := in.eval(node.Children[0])
+		if n.i < 0 || isUnsigned64(n.t) && n.i < 0 {
+			in.fail(node, CodeUncaughtException, "new "+node.Type+"["+strconv.FormatInt(n.i, 10)+
+				"]: tamaño de arreglo negativo", exitAbort)
+		}
+		if n.i > 1<<40 {
+			n.i = 1 << 40
+		}
+		count = int(n.i)
+		in.checkAvailable(int64(count)*elementSize(t), node)
+	}
+	b := in.newBlock(t, count, "", node)
+	b.heap = true
+	in.charge(blockBytes(b), node)
+	switch {
+	case node.Operator != "[]" && len(node.Children) > 0:
+		in.store(location{blk: b}, in.eval(node.Children[0]), node)
+	case node.Operator == "{}":
+		for i, item := range node.Children {
+			if i < count {
+				in.store(location{blk: b, index: i}, in.eval(item), item)
+			}
+		}
+	}
+	pointer := t
+	pointer.Pointer++
+	return rtValue{t: pointer, ptr: location{blk: b}}
+}
+
+// release libera la memoria de delete; con un puntero nulo no hace nada
+func (in *interpreter) release(node *models.SyntaxNode) {
+	pointer := in.eval(node.Children[0])
+	b := pointer.ptr.blk
+	switch {
+	case b == nil:
+		return
+	case b.freed:
+		in.abort(node, CodeInvalidMemory, "free(): double free detected", "Se liberó dos veces la misma memoria con delete")
+	case !b.heap || pointer.ptr.index != 0 || pointer.ptr.inString:
+		in.abort(node, CodeInvalidMemory, "free(): invalid pointer", "Se usó delete con memoria que no se reservó con new")
+	}
+	b.freed = true
+	in.memory -= blockBytes(b)
+}
+
+// abort termina el programa como lo hace la biblioteca de C al detectar
+// un error: mensaje en stderr y SIGABRT
+func (in *interpreter) abort(node *models.SyntaxNode, code, stderr, message string) {
+	in.stderr.WriteString(stderr + "\n")
+	in.fail(node, code, message, exitAbort)
+}
+
+// throw lanza una excepción de la biblioteca estándar. El intérprete no
+// admite try/catch, así que termina el programa como std::terminate.
+func (in *interpreter) throw(node *models.SyntaxNode, exception, what string) {
+	in.stderr.WriteString("terminate called after throwing an instance of 'std::" + exception + "'\n  what():  " + what + "\n")
+	in.fail(node, CodeUncaughtException, "Excepción no capturada std::"+exception+": "+what, exitAbort)
+}
+
+// evalCall ejecuta una llamada a una función del programa, a una función
+// de la biblioteca, a un método o la construcción de un objeto temporal
+func (in *interpreter) evalCall(node *models.SyntaxNode) rtValue {
+	callee, args := node.Children[0], node.Children[1:]
+	switch {
+	case callee.Kind == NodeMember:
+		return in.callMethod(node)
+	case callee.Kind != NodeIdentifier:
+		in.fail(node, CodeUnsupported, "El intérprete solo admite llamadas a funciones por su nombre", 1)
+	case strings.Contains(callee.Value, "<"):
+		return in.construct(typeFromString(callee.Value), node, args)
+	}
+
+	if function := in.analyzer.calls[node]; function != nil {
+		return in.call(in.definition(in.analyzer.references[callee], function), node, args)
+	}
+	name := strings.TrimPrefix(callee.Value, "std::")
+	if name == "string" {
+		return in.construct(cppType{Name: "string"}, node, args)
+	}
+	return in.callLibrary(name, node, args)
+}
+
+// construct crea un string o un vector a partir de los argumentos de su
+// constructor: string(n, c), vector<T>(n), vector<T>(n, valor) o una copia
+func (in *interpreter) construct(t cppType, node *models.SyntaxNode, args []*models.SyntaxNode) rtValue {
+	t = t.unqualified()
+	values := make([]rtValue, len(args))
+	for i, arg := range args {
+		values[i] = in.eval(arg)
+	}
+	switch {
+	case len(values) == 0:
+		return in.zero(t, "", node)
+	case len(values) == 1 && (values[0].t.isString() || isVectorType(values[0].t) || t.isString()):
+		return in.convert(values[0], t, node)
+	case t.isString():
+		n := in.size(values[0], node)
+		in.checkAvailable(int64(n), node)
+		in.work(n, node)
+		return rtValue{t: t, s: strings.Repeat(string([]byte{byte(values[1].i)}), n)}
+	case isVectorType(t):
+		n := in.size(values[0], node)
+		in.checkAvailable(int64(n)*elementSize(t.Args[0]), node)
+		b := in.newBlock(t.Args[0], 0, "", node)
+		fill := in.zero(t.Args[0], "", node)
+		if len(values) > 1 {
+			fill = values[1]
+		}
+		for i := 0; i < n; i++ {
+			in.appendValue(b, fill, node)
+		}
+		return rtValue{t: t, blk: b}
+	}
+	in.fail(node, CodeUnsupported, "El intérprete no puede construir un objeto de tipo "+t.String(), 1)
+	return rtValue{}
+}
+
+// size lee un tamaño pedido por el programa; uno negativo convertido a
+// size_t no cabe en la memoria, como en la biblioteca real
+func (in *interpreter) size(v rtValue, node *models.SyntaxNode) int {
+	n := in.convert(v, cppType{Name: "long"}, node).i
+	if n < 0 || n > 1<<40 {
+		in.throw(node, "length_error", "cannot create std::vector larger than max_size()")
+	}
+	return int(n)
+}
+
+// vectorOf crea un vector con los elementos de una lista {…}
+func (in *interpreter) vectorOf(t cppType, items []*models.SyntaxNode) rtValue {
+	t = t.unqualified()
+	elem := t.Args[0]
+	b := in.newBlock(elem, 0, "", nil)
+	for _, item := range items {
+		if item.Kind == NodeInitList && isVectorType(elem) {
+			in.appendValue(b, in.vectorOf(elem, item.Children), item)
+			continue
+		}
+		in.appendValue(b, in.eval(item), item)
+	}
+	return rtValue{t: t, blk: b}
+}
+
+// appendElement agrega un elemento al final del vector de una variable y
+// contabiliza la memoria que ocupa
+func (in *interpreter) appendElement(b *block, v rtValue, node *models.SyntaxNode) {
+	v = in.appendValue(b, v, node)
+	in.charge(elementSize(b.elem)+int64(len(v.s)), node)
+	in.adopt(v, node)
+}
+
+// appendValue agrega un elemento a un vector temporal, que todavía no
+// pertenece a ninguna variable, y devuelve el valor ya convertido
+func (in *interpreter) appendValue(b *block, v rtValue, node *models.SyntaxNode) rtValue {
+	v = in.convert(v, b.elem, node)
+	b.bytes += elementSize(b.elem)
+	switch storageOf(b.elem) {
+	case storeInts:
+		b.ints = append(b.ints, v.i)
+	case storeFloats:
+		b.floats = append(b.floats, v.f)
+	case storeStrings:
+		b.strs = append(b.strs, v.s)
+	default:
+		b.vals = append(b.vals, v)
+	}
+	return v
+}
+
+// truncate quita los elementos de un bloque de vector a partir de n
+func (in *interpreter) truncate(b *block, n int) {
+	for i := n; i < b.length(); i++ {
+		v := b.get(i)
+		in.disown(v)
+		in.memory -= elementSize(b.elem) + int64(len(v.s))
+		b.bytes -= elementSize(b.elem)
+	}
+	switch storageOf(b.elem) {
+	case storeInts:
+		b.ints = b.ints[:n]
+	case storeFloats:
+		b.floats = b.floats[:n]
+	case storeStrings:
+		b.strs = b.strs[:n]
+	default:
+		b.vals = b.vals[:n]
+	}
+}
@@ -0,0 +1,275 @@
+package services
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+// insertFix crea una corrección que inserta texto en una posición
+func insertFix(title string, line, column int, text string) models.Fix {
+	return replaceFix(title, line, column, line, column, text)
+}
+
+// replaceFix crea una corrección que reemplaza el texto de un rango
+func replaceFix(title string, startLine, startColumn, endLine, endColumn int, text string) models.Fix {
+	return models.Fix{Title: title, Edits: []models.TextEdit{{
+		StartLine:   startLine,
+		StartColumn: startColumn,
+		EndLine:     endLine,
+		EndColumn:   endColumn,
+		NewText:     text,
+	}}}
+}
+
+// FixCode aplica las correcciones pedidas y devuelve el código resultante
+func FixCode(req models.FixRequest) models.FixResult {
+	files := req.Files
+	if len(files) == 0 {
+		files = map[string]string{"": req.Code}
+	}
+	fixes := req.Fixes
+	if len(fixes) == 0 {
+		fixes = availableFixes(req)
+	}
+
+	fixed, applied, skipped := applyFixes(files, fixes)
+	result := models.FixResult{Applied: applied, Skipped: skipped}
+	if len(req.Files) == 0 {
+		result.Code = fixed[""]
+	} else {
+		result.Files = fixed
+	}
+	return result
+}
+
+// availableFixes analiza el programa y devuelve la primera corrección de
+// cada diagnóstico con uno de los códigos pedidos
+func availableFixes(req models.FixRequest) []models.Fix {
+	var diagnostics []models.Diagnostic
+	if len(req.Files) > 0 {
+		source, units := parseUnits(req.Files, req.Entry)
+		tree, parsed := linkUnits(units)
+		diagnostics = append(checkProgram(tree, parsed).Diagnostics, analyzeUnits(source, units, runAnalyzer(tree, source))...)
+		source.relocateDiagnostics(diagnostics)
+	} else {
+		tree, parsed := Parse(req.Code)
+		diagnostics = append(checkProgram(tree, parsed).Diagnostics, runAnalyzer(tree, nil).diagnostics...)
+	}
+
+	wanted := make(map[string]bool)
+	for _, code := range req.Codes {
+		wanted[code] = true
+	}
+	var fixes []models.Fix
+	for _, d := range diagnostics {
+		if len(d.Fixes) > 0 && (len(wanted) == 0 || wanted[d.Code]) {
+			fixes = append(fixes, d.Fixes[0])
+		}
+	}
+	return fixes
+}
+
+// textEdit es una edición traducida a posiciones de bytes de su archivo
+type textEdit struct {
+	start, end int
+	text       string
+	order      int
+}
+
+// applyFixes aplica las correcciones en orden sobre el código original.
+// Una corrección se aplica entera o no se aplica: se omite si alguna de
+// sus ediciones no corresponde al código o se superpone con una ya
+// aceptada. Una edición idéntica a una aceptada (por ejemplo el mismo
+// #include que falta para dos nombres) no se repite.
+func applyFixes(files map[string]string, fixes []models.Fix) (map[string]string, []models.Fix, []models.Fix) {
+	accepted := make(map[string][]textEdit)
+	applied, skipped := []models.Fix{}, []models.Fix{}
+	order := 0
+
+	for _, fix := range fixes {
+		pending := make(map[string][]textEdit)
+		ok := len(fix.Edits) > 0
+		for _, edit := range fix.Edits {
+			text, exists := files[edit.File]
+			start, valid1 := byteOffset(text, edit.StartLine, edit.StartColumn)
+			end, valid2 := byteOffset(text, edit.EndLine, edit.EndColumn)
+			if !exists || !valid1 || !valid2 || start > end {
+				ok = false
+				break
+			}
+			e := textEdit{start: start, end: end, text: edit.NewText, order: order}
+			order++
+			if duplicateEdit(accepted[edit.File], e) {
+				continue
+			}
+			if overlapsEdits(accepted[edit.File], e) || overlapsEdits(pending[edit.File], e) {
+				ok = false
+				break
+			}
+			pending[edit.File] = append(pending[edit.File], e)
+		}
+		if !ok {
+			skipped = append(skipped, fix)
+			continue
+		}
+		for file, edits := range pending {
+			accepted[file] = append(accepted[file], edits...)
+		}
+		applied = append(applied, fix)
+	}
+
+	result := make(map[string]string, len(files))
+	for name, text := range files {
+		edits := accepted[name]
+		// De atrás hacia adelante para no mover las posiciones pendientes;
+		// las inserciones en el mismo lugar quedan en el orden pedido
+		sort.Slice(edits, func(i, j int) bool {
+			if edits[i].start != edits[j].start {
+				return edits[i].start > edits[j].start
+			}
+			return edits[i].order > edits[j].order
+		})
+		for _, e := range edits {
+			text = text[:e.start] + e.text + text[e.end:]
+		}
+		result[name] = text
+	}
+	return result, applied, skipped
+}
+
+// byteOffset traduce una línea y una columna (en caracteres, desde 1) a
+// la posición en bytes. La columna puede estar justo después del último
+// carácter de la línea.
+func byteOffset(text string, line, column int) (int, bool) {
+	if line < 1 || column < 1 {
+		return 0, false
+	}
+	offset := 0
+	for ; line > 1; line-- {
+		i := strings.IndexByte(text[offset:], '\n')
+		if i < 0 {
+			return 0, false
+		}
+		offset += i + 1
+	}
+	for ; column > 1; column-- {
+		if offset >= len(text) || text[offset] == '\n' {
+			return 0, false
+		}
+		_, size := utf8.DecodeRuneInString(text[offset:])
+		offset += size
+	}
+	return offset, true
+}
+
+// overlapsEdits indica si la edición toca el texto de otra. Dos
+// inserciones en el mismo lugar no se superponen.
+func overlapsEdits(edits []textEdit, e textEdit) bool {
+	for _, other := range edits {
+		if e.start < other.end && other.start < e.end {
+			return true
+		}
+	}
+	return false
+}
+
+func duplicateEdit(edits []textEdit, e textEdit) bool {
+	for _, other := range edits {
+		if other.start == e.start && other.end == e.end && other.text == e.text {
+			return true
+		}
+	}
+	return false
+}
+
+// declStatement es una declaración de variables escrita como sentencia de
+// un bloque, con la línea donde termina lo anterior y donde empieza lo
+// siguiente, para saber si ocupa sus líneas sola
+type declStatement struct {
+	node               *models.SyntaxNode
+	prevLine, nextLine int
+}
+
+// recordStatement registra la declaración que es la sentencia i del bloque
+func (a *semanticAnalyzer) recordStatement(block *models.SyntaxNode, i int) {
+	stmt := declStatement{node: block.Children[i], prevLine: block.Line, nextLine: block.EndLine}
+	if i > 0 {
+		stmt.prevLine = block.Children[i-1].EndLine
+	}
+	if i+1 < len(block.Children) {
+		stmt.nextLine = block.Children[i+1].Line
+	}
+	for _, declarator := range stmt.node.Children {
+		a.statements[declarator] = stmt
+	}
+}
+
+// removeDeclarationFix borra la declaración de una variable que no se usa.
+// Solo se ofrece si quitarla no cambia lo que hace el programa: es una
+// sentencia propia, el inicializador no tiene efectos y no es un objeto de
+// una clase del programa, cuyo constructor podría tenerlos.
+func (a *semanticAnalyzer) removeDeclarationFix(sym *symbol) []models.Fix {
+	stmt, ok := a.statements[sym.node]
+	if !ok || hasSideEffects(initializer(sym.node)) || a.classes[strings.TrimPrefix(sym.Type, "const ")] != nil {
+		return nil
+	}
+	title := "Eliminar la declaración de '" + sym.Name + "'"
+	decl, declarator := stmt.node, sym.node
+
+	if len(decl.Children) == 1 {
+		if stmt.prevLine < decl.Line && stmt.nextLine > decl.EndLine {
+			// Sola en sus líneas: se borran las líneas completas
+			return []models.Fix{replaceFix(title, decl.Line, 1, decl.EndLine+1, 1, "")}
+		}
+		return []models.Fix{replaceFix(title, decl.Line, decl.Column, decl.EndLine, decl.EndColumn, "")}
+	}
+
+	// En "int a, b, c;" se borra el declarador con una de sus comas. Con
+	// punteros o arreglos el '*' o el tamaño no siempre se irían con él.
+	for _, other := range decl.Children {
+		if other.Type != decl.Type {
+			return nil
+		}
+	}
+	for i, d := range decl.Children {
+		switch {
+		case d != declarator:
+		case i+1 < len(decl.Children):
+			next := decl.Children[i+1]
+			return []models.Fix{replaceFix(title, d.Line, d.Column, next.Line, next.Column, "")}
+		default:
+			prev := decl.Children[i-1]
+			return []models.Fix{replaceFix(title, prev.EndLine, prev.EndColumn, d.EndLine, d.EndColumn, "")}
+		}
+	}
+	return nil
+}
+
+// hasSideEffects indica si evaluar la expresión puede cambiar algo además
+// de producir su valor
+func hasSideEffects(expr *models.SyntaxNode) bool {
+	found := false
+	walkTree(expr, func(node *models.SyntaxNode) bool {
+		switch node.Kind {
+		case NodeCall, NodeAssign, NodePostfix, NodeNew, NodeDelete:
+			found = true
+		case NodeUnary:
+			found = found || node.Operator == "++" || node.Operator == "--"
+		}
+		return !found
+	})
+	return found
+}
+
+// afterIncludesFix agrega una línea después del último #include del
+// archivo donde está el nodo, o al inicio del archivo si no tiene
+func (a *semanticAnalyzer) afterIncludesFix(title, line string, at *models.SyntaxNode) models.Fix {
+	file := at.Line / fileLineStride
+	if include := a.lastInclude[file]; include != nil {
+		return insertFix(title, include.EndLine, include.EndColumn, "\n"+line)
+	}
+	return insertFix(title, file*fileLineStride+1, 1, line+"\n")
+}
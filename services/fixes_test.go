@@ -0,0 +1,97 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+func TestFixCode(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     models.FixRequest
+		want    string
+		applied []string
+	}{
+		{
+			name:    "punto y coma",
+			req:     models.FixRequest{Code: "int main() {\n    int x = 1\n    return x;\n}\n"},
+			want:    "int main() {\n    int x = 1;\n    return x;\n}\n",
+			applied: []string{"Agregar ';'"},
+		},
+		{
+			name:    "include que falta",
+			req:     models.FixRequest{Code: "int main() {\n    cout << 1;\n    return 0;\n}\n"},
+			want:    "#include <iostream>\nint main() {\n    cout << 1;\n    return 0;\n}\n",
+			applied: []string{"Agregar '#include <iostream>'"},
+		},
+		{
+			name:    "using namespace std después de los includes",
+			req:     models.FixRequest{Code: "#include <iostream>\nint main() {\n    cout << 1;\n    return 0;\n}\n"},
+			want:    "#include <iostream>\nusing namespace std;\nint main() {\n    cout << 1;\n    return 0;\n}\n",
+			applied: []string{"Agregar 'using namespace std;'"},
+		},
+		{
+			name:    "variable sin usar",
+			req:     models.FixRequest{Code: "int main() {\n    int y = 2;\n    return 0;\n}\n"},
+			want:    "int main() {\n    return 0;\n}\n",
+			applied: []string{"Eliminar la declaración de 'y'"},
+		},
+		{
+			// Quitarla quitaría también la llamada a f
+			name:    "variable sin usar con un inicializador con efectos",
+			req:     models.FixRequest{Code: "int f();\nint main() {\n    int y = f();\n    return 0;\n}\nint f() { return 1; }\n"},
+			want:    "int f();\nint main() {\n    int y = f();\n    return 0;\n}\nint f() { return 1; }\n",
+			applied: []string{},
+		},
+		{
+			name:    "varias correcciones",
+			req:     models.FixRequest{Code: "#include <iostream>\nint main() {\n    int x = 1\n    cout << x\n    return 0;\n}\n"},
+			want:    "#include <iostream>\nusing namespace std;\nint main() {\n    int x = 1;\n    cout << x;\n    return 0;\n}\n",
+			applied: []string{"Agregar ';'", "Agregar ';'", "Agregar 'using namespace std;'"},
+		},
+		{
+			name: "solo los códigos pedidos",
+			req: models.FixRequest{
+				Code:  "#include <iostream>\nint main() {\n    int y = 2;\n    std::cout << 1\n    return 0;\n}\n",
+				Codes: []string{CodeMissingSemicolon},
+			},
+			want:    "#include <iostream>\nint main() {\n    int y = 2;\n    std::cout << 1;\n    return 0;\n}\n",
+			applied: []string{"Agregar ';'"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := FixCode(tt.req)
+			if result.Code != tt.want {
+				t.Errorf("código =\n%s\nse esperaba\n%s", result.Code, tt.want)
+			}
+			titles := []string{}
+			for _, fix := range result.Applied {
+				titles = append(titles, fix.Title)
+			}
+			if !reflect.DeepEqual(titles, tt.applied) {
+				t.Errorf("aplicadas = %v, se esperaba %v", titles, tt.applied)
+			}
+		})
+	}
+}
+
+// Las correcciones explícitas se aplican enteras o se omiten
+func TestFixCodeSkipped(t *testing.T) {
+	code := "int main() {\n    return 0;\n}\n"
+	fixes := []models.Fix{
+		replaceFix("Cambiar 0 por 1", 2, 12, 2, 13, "1"),
+		replaceFix("Cambiar return 0", 2, 5, 2, 13, "return 2"),
+		insertFix("Fuera del código", 9, 1, "x"),
+	}
+	result := FixCode(models.FixRequest{Code: code, Fixes: fixes})
+	if want := "int main() {\n    return 1;\n}\n"; result.Code != want {
+		t.Errorf("código =\n%s\nse esperaba\n%s", result.Code, want)
+	}
+	if len(result.Applied) != 1 || len(result.Skipped) != 2 {
+		t.Errorf("%d aplicadas y %d omitidas, se esperaba 1 y 2", len(result.Applied), len(result.Skipped))
+	}
+}
@@ -0,0 +1,339 @@
+package services
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+// declareFunction registra una declaración de función en el ámbito global.
+// Cada lista de parámetros distinta es una sobrecarga; un prototipo seguido
+// de la definición con la misma firma es válido.
+func (a *semanticAnalyzer) declareFunction(node *models.SyntaxNode) {
+	a.declareOverload(a.table.global(), node.Value, SymbolFunction, "", node)
+}
+
+// declareOverload registra una función, método o constructor en el ámbito
+// indicado, agrupando las sobrecargas con el mismo nombre
+func (a *semanticAnalyzer) declareOverload(s *scope, name, kind, access string, node *models.SyntaxNode) {
+	existing := s.lookupLocal(name)
+
+	if existing == nil {
+		if kind == SymbolFunction {
+			a.functions++
+		}
+		s.add(&symbol{
+			Name:        name,
+			Kind:        kind,
+			Type:        functionSignature(node),
+			Line:        node.Line,
+			Column:      node.Column,
+			Initialized: functionBody(node) != nil,
+			node:        node,
+			access:      access,
+			overloads:   []*models.SyntaxNode{node},
+			declLine:    node.Line,
+			declColumn:  node.Column,
+		})
+		return
+	}
+
+	if existing.Kind != kind {
+		a.report(diagnosticAtNode(CodeRedeclared,
+			"'"+node.Value+"' ya fue declarada como "+kindNoun(existing.Kind)+" en la línea "+a.source.lineRef(existing.Line), node))
+		return
+	}
+
+	params := parameterSignature(node)
+	for i, other := range existing.overloads {
+		if parameterSignature(other) != params {
+			continue
+		}
+		if typeFromString(other.Type).Name != typeFromString(node.Type).Name {
+			a.report(diagnosticAtNode(CodeRedeclared,
+				"La función '"+node.Value+"' fue declarada con tipo de retorno "+other.Type+
+					" en la línea "+a.source.lineRef(other.Line), node))
+			return
+		}
+		if functionBody(node) != nil {
+			if functionBody(other) != nil {
+				a.report(diagnosticAtNode(CodeRedeclared,
+					"La función '"+node.Value+"' ya fue definida en la línea "+a.source.lineRef(other.Line), node))
+				return
+			}
+			existing.overloads[i] = node
+			existing.Initialized = true
+			existing.node = node
+			existing.Line, existing.Column = node.Line, node.Column
+		}
+		return
+	}
+
+	// Nueva sobrecarga
+	existing.overloads = append(existing.overloads, node)
+	var signatures []string
+	for _, overload := range existing.overloads {
+		signatures = append(signatures, functionSignature(overload))
+	}
+	existing.Type = strings.Join(signatures, " | ")
+}
+
+// kindNoun nombra un tipo de símbolo en los mensajes
+func kindNoun(kind string) string {
+	switch kind {
+	case SymbolFunction:
+		return "función"
+	case SymbolClass:
+		return "clase"
+	case SymbolField:
+		return "atributo"
+	case SymbolMethod:
+		return "método"
+	case SymbolConstructor:
+		return "constructor"
+	case SymbolParameter:
+		return "parámetro"
+	}
+	return "variable"
+}
+
+// functionSignature describe la función como "tipo(param, ...)"
+func functionSignature(node *models.SyntaxNode) string {
+	return node.Type + parameterSignature(node)
+}
+
+// parameterSignature describe solo los tipos de los parámetros: "(int, double)"
+func parameterSignature(node *models.SyntaxNode) string {
+	var params []string
+	for _, param := range functionParams(node) {
+		params = append(params, param.Type)
+	}
+	return "(" + strings.Join(params, ", ") + ")"
+}
+
+func functionParams(node *models.SyntaxNode) []*models.SyntaxNode {
+	var params []*models.SyntaxNode
+	for _, child := range node.Children {
+		if child.Kind == NodeParam {
+			params = append(params, child)
+		}
+	}
+	return params
+}
+
+// requiredParams cuenta los parámetros sin valor por defecto
+func requiredParams(node *models.SyntaxNode) int {
+	count := 0
+	for _, param := range functionParams(node) {
+		if initializer(param) == nil {
+			count++
+		}
+	}
+	return count
+}
+
+// checkCall valida una llamada contra la firma declarada y devuelve el
+// tipo de retorno de la función
+func (a *semanticAnalyzer) checkCall(node *models.SyntaxNode) cppType {
+	callee := node.Children[0]
+	args := node.Children[1:]
+	argTypes := a.checkExprs(args)
+
+	var sym *symbol
+	switch callee.Kind {
+	case NodeIdentifier:
+		if strings.Contains(callee.Value, "<") {
+			// vector<int>(3) crea un objeto temporal
+			t := typeFromString(callee.Value)
+			a.checkTypeName(callee.Value, callee)
+			a.checkConstruction(a.classOf(t), node, args, argTypes, false)
+			return t
+		}
+		sym = a.resolveIdentifier(callee, true)
+	case NodeMember:
+		sym, _ = a.resolveMember(callee)
+	default:
+		a.checkExpr(callee)
+		return unknownType
+	}
+	if sym == nil {
+		return unknownType
+	}
+	a.references[callee] = sym
+
+	switch sym.Kind {
+	case SymbolClass:
+		// Punto(1, 2) crea un objeto temporal
+		t := cppType{Name: sym.Name}
+		a.checkConstruction(a.classOf(t), node, args, argTypes, false)
+		return t
+	case SymbolFunction, SymbolMethod:
+	default:
+		a.report(diagnosticAtNode(CodeNotCallable, "'"+sym.Name+"' no es una función", callee))
+		return unknownType
+	}
+
+	// Los métodos se pueden usar dentro de la clase antes de su declaración
+	if sym.Kind == SymbolFunction && a.source.before(callee.Line, callee.Column, sym.declLine, sym.declColumn) {
+		diagnostic := diagnosticAtNode(CodeUseBeforeDeclaration,
+			"La función '"+sym.Name+"' se usa antes de ser declarada (línea "+a.source.lineRef(sym.declLine)+")", callee)
+		diagnostic.Suggestion = "Declarar el prototipo '" + prototypeText(sym.overloads[0]) + "' antes de usarla"
+		a.report(diagnostic)
+	}
+
+	function := a.selectOverload(sym, node, args, argTypes)
+	if function != nil && sym.header != "" {
+		function = a.instantiateCall(sym, function, args, argTypes)
+	}
+	if function == nil {
+		return unknownType
+	}
+	if sym.header == "" && sym.Kind == SymbolFunction {
+		a.calls[node] = function
+	}
+	a.checkArguments(sym, function, args, argTypes)
+	return typeFromString(function.Type).value()
+}
+
+// checkExprs analiza una lista de expresiones y devuelve sus tipos
+func (a *semanticAnalyzer) checkExprs(nodes []*models.SyntaxNode) []cppType {
+	types := make([]cppType, len(nodes))
+	for i, node := range nodes {
+		types[i] = a.checkExpr(node)
+	}
+	return types
+}
+
+// checkArguments valida cada argumento contra el parámetro correspondiente
+// de la sobrecarga elegida
+func (a *semanticAnalyzer) checkArguments(sym *symbol, function *models.SyntaxNode, args []*models.SyntaxNode, argTypes []cppType) {
+	params := functionParams(function)
+	for i, arg := range args {
+		if i >= len(params) {
+			break
+		}
+		paramType := typeFromString(params[i].Type)
+		if paramType.Reference && !paramType.Const && !a.isLValue(arg) {
+			a.report(diagnosticAtNode(CodeInvalidReference,
+				"Argumento "+strconv.Itoa(i+1)+" de '"+sym.Name+"': el parámetro '"+params[i].Value+
+					"' es una referencia y necesita una variable", arg))
+			continue
+		}
+		a.checkConversion(argTypes[i], paramType, arg, CodeArgumentType,
+			"Argumento "+strconv.Itoa(i+1)+" de '"+sym.Name+"': se esperaba "+paramType.String()+
+				", se encontró "+argTypes[i].String())
+	}
+}
+
+// selectOverload elige la declaración que corresponde a la llamada. Si no
+// hay ninguna compatible informa el error y devuelve nil.
+func (a *semanticAnalyzer) selectOverload(sym *symbol, call *models.SyntaxNode, args []*models.SyntaxNode, argTypes []cppType) *models.SyntaxNode {
+	var candidates []*models.SyntaxNode
+	for _, overload := range sym.overloads {
+		if len(args) >= requiredParams(overload) && len(args) <= len(functionParams(overload)) {
+			candidates = append(candidates, overload)
+		}
+	}
+
+	if len(candidates) == 0 {
+		if len(sym.overloads) == 1 {
+			expected := functionParams(sym.overloads[0])
+			count := strconv.Itoa(len(expected))
+			if required := requiredParams(sym.overloads[0]); required != len(expected) {
+				count = "entre " + strconv.Itoa(required) + " y " + count
+			}
+			a.report(diagnosticAtNode(CodeArgumentCount,
+				callableName(sym)+" espera "+count+" argumento(s), se pasaron "+strconv.Itoa(len(args)), call))
+		} else {
+			a.report(diagnosticAtNode(CodeArgumentCount,
+				"Ninguna versión de '"+sym.Name+"' acepta "+strconv.Itoa(len(args))+" argumento(s)", call))
+		}
+		return nil
+	}
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+
+	// Entre varias sobrecargas se prefiere la de coincidencia exacta y luego
+	// la primera cuyas conversiones sean todas válidas
+	var viable *models.SyntaxNode
+	for _, candidate := range candidates {
+		exact, valid := true, true
+		for i, param := range functionParams(candidate)[:len(args)] {
+			paramType := typeFromString(param.Type)
+			if !argTypes[i].equals(paramType) {
+				exact = false
+			}
+			if classifyConversion(argTypes[i], paramType) == conversionInvalid {
+				valid = false
+			}
+		}
+		if exact {
+			return candidate
+		}
+		if valid && viable == nil {
+			viable = candidate
+		}
+	}
+	if viable != nil {
+		return viable
+	}
+
+	var names []string
+	for _, t := range argTypes {
+		names = append(names, t.String())
+	}
+	a.report(diagnosticAtNode(CodeArgumentType,
+		"Ninguna versión de '"+sym.Name+"' acepta argumentos ("+strings.Join(names, ", ")+")", call))
+	return nil
+}
+
+// callableName describe el símbolo llamado en los mensajes
+func callableName(sym *symbol) string {
+	switch sym.Kind {
+	case SymbolMethod:
+		return "El método '" + sym.Name + "'"
+	case SymbolConstructor:
+		return "El constructor de '" + sym.Name + "'"
+	}
+	return "La función '" + sym.Name + "'"
+}
+
+// prototypeText reconstruye el prototipo de una función: "int suma(int a, int b);"
+func prototypeText(node *models.SyntaxNode) string {
+	var params []string
+	for _, param := range functionParams(node) {
+		params = append(params, strings.TrimSpace(param.Type+" "+param.Value))
+	}
+	return node.Type + " " + node.Value + "(" + strings.Join(params, ", ") + ");"
+}
+
+// checkReturn valida el valor retornado contra el tipo de retorno de la función actual
+func (a *semanticAnalyzer) checkReturn(node *models.SyntaxNode) {
+	var valueType cppType
+	var value *models.SyntaxNode
+	if len(node.Children) > 0 {
+		value = node.Children[0]
+		valueType = a.checkExpr(value)
+	}
+	// Los constructores y destructores no tienen tipo de retorno
+	if a.function == nil || a.function.Type == "" {
+		return
+	}
+
+	name := a.function.Value
+	returnType := typeFromString(a.function.Type).value()
+
+	switch {
+	case returnType.isVoid() && value != nil && !valueType.isVoid():
+		a.report(diagnosticAtNode(CodeReturnType,
+			"La función void '"+name+"' no puede retornar un valor", value))
+	case !returnType.isVoid() && value == nil:
+		a.report(diagnosticAtNode(CodeReturnType,
+			"La función '"+name+"' debe retornar un valor de tipo "+returnType.String(), node))
+	case !returnType.isVoid():
+		a.checkConversion(valueType, returnType, value, CodeReturnType,
+			"Error de tipo - La función '"+name+"' retorna "+returnType.String()+" pero se retorna "+valueType.String())
+	}
+}
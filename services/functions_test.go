@@ -0,0 +1,73 @@
+package services
+
+import "testing"
+
+func TestFunctionCalls(t *testing.T) {
+	checkSemantic(t, []semanticCase{
+		{
+			name:    "faltan argumentos",
+			code:    "int f(int a, int b) { return a + b; }\nint main() { return f(1); }\n",
+			want:    []string{CodeArgumentCount},
+			message: "La función 'f' espera 2 argumento(s), se pasaron 1",
+		},
+		{
+			name:    "argumento de otro tipo",
+			code:    "#include <string>\nint f(int a) { return a; }\nint main() { std::string s = \"x\"; return f(s); }\n",
+			want:    []string{CodeArgumentType},
+			message: "Argumento 1 de 'f': se esperaba int, se encontró string",
+		},
+		{
+			name:    "retorno de otro tipo",
+			code:    "int f() { return \"x\"; }\nint main() { return f(); }\n",
+			want:    []string{CodeReturnType},
+			message: "La función 'f' retorna int pero se retorna const char[2]",
+		},
+		{
+			name:    "falta return en un camino",
+			code:    "int f(int a) { if (a > 0) return 1; }\nint main() { return f(1); }\n",
+			want:    []string{CodeMissingReturn},
+			message: "no retorna un valor en todos los caminos",
+		},
+		{
+			name:    "llamar a una variable",
+			code:    "int main() { int x = 1; return x(2); }\n",
+			want:    []string{CodeNotCallable},
+			message: "'x' no es una función",
+		},
+		{
+			name:    "uso antes de la declaración",
+			code:    "int main() { return g(2); }\nint g(int a) { return a; }\n",
+			want:    []string{CodeUseBeforeDeclaration},
+			message: "La función 'g' se usa antes de ser declarada (línea 2)",
+		},
+		{
+			name:    "función no declarada",
+			code:    "int main() { return bar(2); }\n",
+			want:    []string{CodeUndeclared},
+			message: "Función 'bar' llamada pero no declarada",
+		},
+		{
+			name:    "valor de una función void",
+			code:    "void f() {}\nint main() { int x = f(); return x; }\n",
+			want:    []string{CodeTypeMismatch},
+			message: "La expresión no produce un valor (es de tipo void)",
+		},
+		{
+			// f(2.5) elige la sobrecarga de double y su resultado se convierte a int
+			name:    "sobrecarga",
+			code:    "int f(int a) { return a; }\ndouble f(double a) { return a; }\nint main() { return f(1) + f(2.5); }\n",
+			want:    []string{CodeNarrowing},
+			message: "Conversión de double a int",
+		},
+		{
+			name: "argumento por defecto",
+			code: "int f(int a, int b = 2) { return a + b; }\nint main() { return f(1); }\n",
+			want: []string{},
+		},
+		{
+			name: "prototipo antes del uso",
+			code: "int g(int a);\nint main() { return g(2); }\nint g(int a) { return a; }\n",
+			want: []string{},
+		},
+	})
+}
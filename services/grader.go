@@ -0,0 +1,399 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+// Formas de ejecutar el programa en /grade
+const (
+	EngineInterpreter = "interpreter"
+	EngineCompiler    = "compiler"
+)
+
+// Reglas para comparar los espacios de la salida (TestCase.Whitespace)
+const (
+	WhitespaceTrailing = "trailing"
+	WhitespaceExact    = "exact"
+	WhitespaceIgnore   = "ignore"
+)
+
+// Estados de la calificación (GradeResult.Status y CaseResult.Status)
+const (
+	GradeGraded       = "graded"
+	GradeUnsupported  = "unsupported"
+	CaseAccepted      = "accepted"
+	CaseWrongAnswer   = "wrong_answer"
+	CaseRuntimeError  = RunRuntimeError
+	CaseLimitExceeded = RunLimitExceeded
+	CaseCompileError  = RunCompileError
+	CaseUnsupported   = GradeUnsupported
+)
+
+// Límites del diff de cada caso
+const (
+	maxDiffLines = 100
+	maxDiffCells = 1_000_000 // celdas de la tabla de LCS
+)
+
+// Límites de una calificación: los casos se ejecutan uno tras otro y cada
+// uno puede durar hasta maxTimeLimitMS
+const (
+	maxGradeTests  = 100
+	maxGradeTimeMS = 60000
+)
+
+// ValidateGrade comprueba que haya casos de prueba, no demasiados, y que
+// el motor pedido exista y esté disponible
+func ValidateGrade(req models.GradeRequest) error {
+	if len(req.Tests) == 0 {
+		return errors.New("No hay casos de prueba")
+	}
+	if len(req.Tests) > maxGradeTests {
+		return errors.New("Demasiados casos de prueba: el máximo es " + strconv.Itoa(maxGradeTests))
+	}
+	switch req.Engine {
+	case "", EngineInterpreter:
+	case EngineCompiler:
+		if !CompilerAvailable() {
+			return errors.New("El servidor no tiene un compilador habilitado")
+		}
+	default:
+		return errors.New("Motor desconocido: " + req.Engine)
+	}
+	return nil
+}
+
+// Grade analiza el programa y lo ejecuta con cada caso de prueba. El
+// análisis se hace una sola vez; cada caso se ejecuta desde cero. Entre
+// todos los casos no pueden durar más de maxGradeTimeMS: el tiempo de cada
+// uno se recorta al que queda, y los casos que no alcanzan a empezar se
+// califican como limit_exceeded sin ejecutarse.
+func Grade(req models.GradeRequest) models.GradeResult {
+	result := models.GradeResult{Engine: req.Engine, Status: GradeGraded, Cases: []models.CaseResult{}}
+	if result.Engine == "" {
+		result.Engine = EngineInterpreter
+		if CompilerAvailable() {
+			result.Engine = EngineCompiler
+		}
+	}
+	analysis, p, relocate := analyzeProgram(models.CodeRequest{Code: req.Code, Files: req.Files, Entry: req.Entry})
+	result.Analysis = analysis
+	files, entry := req.Files, req.Entry
+	if len(files) > 0 {
+		entry = projectEntry(files, entry)
+	} else {
+		files, entry = map[string]string{"main.cpp": req.Code}, "main.cpp"
+	}
+
+	// run ejecuta un caso con el motor elegido; caseTime es el tiempo
+	// límite de cada caso con ese motor
+	var run func(stdin string, limits models.RunLimits) models.RunResult
+	caseTime := int64(maxTimeLimitMS)
+	if result.Engine == EngineCompiler {
+		caseTime = defaultTimeLimitMS
+		native, output, err := compileNative(files, entry)
+		result.CompilerOutput = output
+		if err != nil {
+			result.CompilerOutput = "No se pudo compilar el programa: " + err.Error()
+		}
+		if native == nil {
+			result.Status = CaseCompileError
+			run = func(string, models.RunLimits) models.RunResult {
+				return models.RunResult{Status: RunCompileError, ExitCode: 1}
+			}
+		} else {
+			defer native.remove()
+			run = native.run
+		}
+	} else {
+		run = p.run
+		if len(p.errors) > 0 {
+			result.Status = CaseCompileError
+		} else if unsupported := p.unsupported(); unsupported != nil {
+			// No se cobra al alumno un error de ejecución que es una
+			// limitación del intérprete
+			result.Status = GradeUnsupported
+			run = func(string, models.RunLimits) models.RunResult {
+				return models.RunResult{Status: CaseUnsupported, Diagnostics: []models.Diagnostic{*unsupported}}
+			}
+		}
+	}
+	if req.Limits.TimeLimitMS > 0 {
+		caseTime = min(req.Limits.TimeLimitMS, maxTimeLimitMS)
+	}
+
+	deadline := time.Now().Add(maxGradeTimeMS * time.Millisecond)
+	for i, test := range req.Tests {
+		var c models.CaseResult
+		if remaining := time.Until(deadline).Milliseconds(); remaining <= 0 {
+			c = unrunCase(test)
+		} else {
+			limits := req.Limits
+			limits.TimeLimitMS = min(caseTime, remaining)
+			c = gradeCase(test, run(test.Stdin, limits))
+		}
+		if c.Name == "" {
+			c.Name = "Caso " + strconv.Itoa(i+1)
+		}
+		result.Cases = append(result.Cases, c)
+		result.Total++
+		result.MaxScore += c.MaxPoints
+		if c.Passed {
+			result.Passed++
+			result.Score += c.Points
+		}
+	}
+	relocate()
+	return result
+}
+
+// unrunCase es un caso que no se ejecutó porque la calificación agotó su
+// tiempo total
+func unrunCase(test models.TestCase) models.CaseResult {
+	c := models.CaseResult{
+		Name:      test.Name,
+		Status:    CaseLimitExceeded,
+		MaxPoints: 1,
+		Message:   "No se ejecutó: los casos anteriores agotaron los " + strconv.Itoa(maxGradeTimeMS/1000) + " s de la calificación",
+	}
+	if test.Points != nil {
+		c.MaxPoints = *test.Points
+	}
+	return c
+}
+
+// gradeCase califica la ejecución de un caso
+func gradeCase(test models.TestCase, run models.RunResult) models.CaseResult {
+	c := models.CaseResult{
+		Name:        test.Name,
+		Status:      run.Status,
+		MaxPoints:   1,
+		Stdout:      run.Stdout,
+		Stderr:      run.Stderr,
+		ExitCode:    run.ExitCode,
+		Diagnostics: run.Diagnostics,
+	}
+	if test.Points != nil {
+		c.MaxPoints = *test.Points
+	}
+
+	switch {
+	case run.Status == RunCompileError:
+		c.Message = "El programa no compila"
+	case run.Status != RunOK:
+		c.Message = "El programa terminó con código " + strconv.Itoa(run.ExitCode)
+		if len(run.Diagnostics) > 0 {
+			c.Message = run.Diagnostics[0].Message
+		} else if run.Status == RunLimitExceeded {
+			c.Message = "El programa superó un límite de ejecución"
+		}
+	case run.ExitCode != 0:
+		c.Status = CaseRuntimeError
+		c.Message = "El programa terminó con código " + strconv.Itoa(run.ExitCode)
+	default:
+		if message, ok := compareOutput(test, run.Stdout); ok {
+			c.Status, c.Passed, c.Points = CaseAccepted, true, c.MaxPoints
+		} else {
+			c.Status, c.Message = CaseWrongAnswer, message
+			if test.Whitespace == WhitespaceExact {
+				c.Diff = lineDiff(exactLines(test.Expected), exactLines(run.Stdout))
+			} else {
+				c.Diff = lineDiff(outputLines(test.Expected), outputLines(run.Stdout))
+			}
+		}
+	}
+	return c
+}
+
+// compareOutput compara la salida con la esperada según las reglas del
+// caso. Si no coinciden devuelve la primera diferencia.
+func compareOutput(test models.TestCase, actual string) (string, bool) {
+	expected := strings.ReplaceAll(test.Expected, "\r\n", "\n")
+	actual = strings.ReplaceAll(actual, "\r\n", "\n")
+	if test.IgnoreCase {
+		expected, actual = strings.ToLower(expected), strings.ToLower(actual)
+	}
+
+	if test.Whitespace == WhitespaceIgnore || test.Tolerance > 0 {
+		var want, got []string
+		if test.Whitespace == WhitespaceIgnore {
+			want, got = strings.Fields(expected), strings.Fields(actual)
+		} else {
+			want, got = outputLines(expected), outputLines(actual)
+		}
+		return compareTokens(want, got, test.Whitespace != WhitespaceIgnore, test.Tolerance)
+	}
+	if test.Whitespace == WhitespaceExact {
+		if expected == actual {
+			return "", true
+		}
+		return compareLines(exactLines(expected), exactLines(actual))
+	}
+	return compareLines(outputLines(expected), outputLines(actual))
+}
+
+// outputLines separa la salida en líneas sin los espacios finales de cada
+// una ni las líneas vacías del final
+func outputLines(text string) []string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// exactLines separa la salida en líneas sin cambiar sus espacios
+func exactLines(text string) []string {
+	return strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+}
+
+// compareLines busca la primera línea distinta
+func compareLines(want, got []string) (string, bool) {
+	for i := 0; i < len(want) || i < len(got); i++ {
+		switch {
+		case i >= len(got):
+			return "Línea " + strconv.Itoa(i+1) + ": se esperaba " + strconv.Quote(want[i]) + " y la salida terminó", false
+		case i >= len(want):
+			return "Línea " + strconv.Itoa(i+1) + ": sobra " + strconv.Quote(got[i]), false
+		case want[i] != got[i]:
+			return "Línea " + strconv.Itoa(i+1) + ": se esperaba " + strconv.Quote(want[i]) +
+				", se obtuvo " + strconv.Quote(got[i]), false
+		}
+	}
+	return "", true
+}
+
+// compareTokens compara palabra por palabra; las numéricas dentro de la
+// tolerancia. Con byLine los elementos son líneas y se comparan sus
+// palabras, para que la diferencia indique la línea.
+func compareTokens(want, got []string, byLine bool, tolerance float64) (string, bool) {
+	if byLine {
+		for i := 0; i < len(want) || i < len(got); i++ {
+			if i >= len(want) || i >= len(got) {
+				return compareLines(want, got)
+			}
+			if message, ok := compareTokens(strings.Fields(want[i]), strings.Fields(got[i]), false, tolerance); !ok {
+				return "Línea " + strconv.Itoa(i+1) + ": " + message, false
+			}
+		}
+		return "", true
+	}
+
+	for i := 0; i < len(want) || i < len(got); i++ {
+		switch {
+		case i >= len(got):
+			return "Palabra " + strconv.Itoa(i+1) + ": se esperaba " + strconv.Quote(want[i]) + " y la salida terminó", false
+		case i >= len(want):
+			return "Palabra " + strconv.Itoa(i+1) + ": sobra " + strconv.Quote(got[i]), false
+		case !tokensMatch(want[i], got[i], tolerance):
+			return "Palabra " + strconv.Itoa(i+1) + ": se esperaba " + strconv.Quote(want[i]) +
+				", se obtuvo " + strconv.Quote(got[i]), false
+		}
+	}
+	return "", true
+}
+
+// tokensMatch compara dos palabras; si ambas son números, con la
+// tolerancia absoluta o relativa indicada
+func tokensMatch(want, got string, tolerance float64) bool {
+	if want == got {
+		return true
+	}
+	if tolerance <= 0 {
+		return false
+	}
+	x, err1 := strconv.ParseFloat(want, 64)
+	y, err2 := strconv.ParseFloat(got, 64)
+	if err1 != nil || err2 != nil || math.IsNaN(x) || math.IsNaN(y) {
+		return false
+	}
+	diff := math.Abs(x - y)
+	return diff <= tolerance || diff <= tolerance*math.Abs(x)
+}
+
+// lineDiff compara las líneas esperadas con las obtenidas con la
+// subsecuencia común más larga. Marca con - las que faltan y con + las que
+// sobran; solo muestra hasta maxDiffLines líneas.
+func lineDiff(want, got []string) string {
+	var out strings.Builder
+	out.WriteString("--- esperado\n+++ obtenido\n")
+	lines := 0
+	emit := func(prefix, line string) {
+		if lines < maxDiffLines {
+			out.WriteString(prefix + line + "\n")
+		} else if lines == maxDiffLines {
+			out.WriteString("...\n")
+		}
+		lines++
+	}
+
+	// Sin las líneas iguales del principio y del final, la tabla suele
+	// ser pequeña
+	start := 0
+	for start < len(want) && start < len(got) && want[start] == got[start] {
+		start++
+	}
+	end := 0
+	for end < len(want)-start && end < len(got)-start && want[len(want)-1-end] == got[len(got)-1-end] {
+		end++
+	}
+	a, b := want[start:len(want)-end], got[start:len(got)-end]
+	for _, line := range want[:start] {
+		emit(" ", line)
+	}
+
+	if len(a)*len(b) > maxDiffCells {
+		// Demasiado grande para la tabla: todas las esperadas y luego
+		// todas las obtenidas
+		for _, line := range a {
+			emit("-", line)
+		}
+		for _, line := range b {
+			emit("+", line)
+		}
+	} else {
+		// lcs[i][j] es la longitud de la subsecuencia común de a[i:] y b[j:]
+		lcs := make([][]int, len(a)+1)
+		for i := range lcs {
+			lcs[i] = make([]int, len(b)+1)
+		}
+		for i := len(a) - 1; i >= 0; i-- {
+			for j := len(b) - 1; j >= 0; j-- {
+				if a[i] == b[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else {
+					lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < len(a) || j < len(b) {
+			switch {
+			case i < len(a) && j < len(b) && a[i] == b[j]:
+				emit(" ", a[i])
+				i++
+				j++
+			case j >= len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+				emit("-", a[i])
+				i++
+			default:
+				emit("+", b[j])
+				j++
+			}
+		}
+	}
+
+	for _, line := range want[len(want)-end:] {
+		emit(" ", line)
+	}
+	return out.String()
+}
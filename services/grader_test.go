@@ -0,0 +1,161 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+func TestCompareOutput(t *testing.T) {
+	tests := []struct {
+		name    string
+		test    models.TestCase
+		actual  string
+		message string // "" si la salida es correcta
+	}{
+		{"igual", models.TestCase{Expected: "1 2\n3\n"}, "1 2\n3\n", ""},
+		{"espacios al final y líneas vacías", models.TestCase{Expected: "1 2\n3"}, "1 2  \r\n3\n\n", ""},
+		{"línea distinta", models.TestCase{Expected: "1\n2\n"}, "1\n3\n", `Línea 2: se esperaba "2", se obtuvo "3"`},
+		{"salida incompleta", models.TestCase{Expected: "1\n2\n"}, "1\n", `Línea 2: se esperaba "2" y la salida terminó`},
+		{"línea de más", models.TestCase{Expected: "1\n"}, "1\n2\n", `Línea 2: sobra "2"`},
+		{"exacta", models.TestCase{Expected: "1\n", Whitespace: WhitespaceExact}, "1 \n", `Línea 1: se esperaba "1", se obtuvo "1 "`},
+		{"ignorar espacios", models.TestCase{Expected: "1 2 3", Whitespace: WhitespaceIgnore}, "1\n2   3\n", ""},
+		{"ignorar mayúsculas", models.TestCase{Expected: "Hola Mundo", IgnoreCase: true}, "HOLA mundo", ""},
+		{"tolerancia", models.TestCase{Expected: "3.14159 x", Tolerance: 0.001}, "3.1414 x", ""},
+		{"fuera de la tolerancia", models.TestCase{Expected: "3.14", Tolerance: 0.001}, "3.2", `Línea 1: Palabra 1: se esperaba "3.14", se obtuvo "3.2"`},
+		{"tolerancia relativa", models.TestCase{Expected: "1000000", Tolerance: 0.001}, "1000500", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, ok := compareOutput(tt.test, tt.actual)
+			if ok != (tt.message == "") || message != tt.message {
+				t.Errorf("compareOutput = %q, %v; se esperaba %q", message, ok, tt.message)
+			}
+		})
+	}
+}
+
+func TestGrade(t *testing.T) {
+	echo := "#include <iostream>\nusing namespace std;\nint main() { int a, b; cin >> a >> b; cout << a + b << endl; return 0; }\n"
+	points := 3.0
+	tests := []struct {
+		name   string
+		req    models.GradeRequest
+		status string
+		cases  []string
+		score  float64
+		max    float64
+	}{
+		{
+			name: "aceptado y respuesta incorrecta",
+			req: models.GradeRequest{Code: echo, Tests: []models.TestCase{
+				{Stdin: "1 2", Expected: "3"},
+				{Stdin: "2 2", Expected: "5", Points: &points},
+			}},
+			status: GradeGraded,
+			cases:  []string{CaseAccepted, CaseWrongAnswer},
+			score:  1,
+			max:    4,
+		},
+		{
+			name: "error de ejecución",
+			req: models.GradeRequest{
+				Code:  "int main() { int a[2] = {1, 2}; int i = 5; return a[i]; }\n",
+				Tests: []models.TestCase{{Expected: ""}},
+			},
+			status: GradeGraded,
+			cases:  []string{CaseRuntimeError},
+			max:    1,
+		},
+		{
+			name: "límite de ejecución",
+			req: models.GradeRequest{
+				Code:   "int main() { while (true) {} }\n",
+				Limits: models.RunLimits{MaxSteps: 1000},
+				Tests:  []models.TestCase{{Expected: ""}},
+			},
+			status: GradeGraded,
+			cases:  []string{CaseLimitExceeded},
+			max:    1,
+		},
+		{
+			name: "no compila",
+			req: models.GradeRequest{
+				Code:  "int main() { return x; }\n",
+				Tests: []models.TestCase{{Expected: ""}, {Expected: ""}},
+			},
+			status: CaseCompileError,
+			cases:  []string{CaseCompileError, CaseCompileError},
+			max:    2,
+		},
+		{
+			name: "no admitido por el intérprete",
+			req: models.GradeRequest{
+				Code:  "#include <iostream>\nstruct P { int x; };\nint main() { P p; p.x = 1; std::cout << p.x; }\n",
+				Tests: []models.TestCase{{Expected: "1"}},
+			},
+			status: GradeUnsupported,
+			cases:  []string{CaseUnsupported},
+			max:    1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result models.GradeResult
+			withTimeout(t, 10*time.Second, func() { result = Grade(tt.req) })
+			var cases []string
+			for _, c := range result.Cases {
+				cases = append(cases, c.Status)
+			}
+			if result.Status != tt.status || !reflect.DeepEqual(cases, tt.cases) {
+				t.Fatalf("estado = %s %v, se esperaba %s %v", result.Status, cases, tt.status, tt.cases)
+			}
+			if result.Score != tt.score || result.MaxScore != tt.max || result.Total != len(tt.cases) {
+				t.Errorf("puntaje = %v/%v en %d casos, se esperaba %v/%v", result.Score, result.MaxScore, result.Total, tt.score, tt.max)
+			}
+			if result.Analysis.SyntaxAnalysis == nil || result.Analysis.SemanticAnalysis == nil {
+				t.Errorf("falta el análisis del programa")
+			}
+		})
+	}
+}
+
+func TestGradeDiff(t *testing.T) {
+	code := "#include <iostream>\nint main() { std::cout << \"a\\nx\\nc\\n\"; return 0; }\n"
+	result := Grade(models.GradeRequest{Code: code, Tests: []models.TestCase{{Expected: "a\nb\nc\n"}}})
+	c := result.Cases[0]
+	if c.Status != CaseWrongAnswer || c.Message != `Línea 2: se esperaba "b", se obtuvo "x"` {
+		t.Fatalf("caso = %s %q", c.Status, c.Message)
+	}
+	if want := "--- esperado\n+++ obtenido\n a\n-b\n+x\n c\n"; c.Diff != want {
+		t.Errorf("diff =\n%s\nse esperaba\n%s", c.Diff, want)
+	}
+}
+
+func TestValidateGrade(t *testing.T) {
+	many := make([]models.TestCase, maxGradeTests+1)
+	tests := []struct {
+		name string
+		req  models.GradeRequest
+		want string // "" si es válida
+	}{
+		{"válida", models.GradeRequest{Tests: many[:1]}, ""},
+		{"sin casos", models.GradeRequest{}, "No hay casos de prueba"},
+		{"demasiados casos", models.GradeRequest{Tests: many}, "Demasiados casos de prueba"},
+		{"motor desconocido", models.GradeRequest{Tests: many[:1], Engine: "jit"}, "Motor desconocido: jit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateGrade(tt.req)
+			if (err == nil) != (tt.want == "") || (err != nil && !strings.Contains(err.Error(), tt.want)) {
+				t.Errorf("error = %v, se esperaba %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+// withTimeout falla la prueba si f no termina a tiempo: un ciclo que no
+// avanza o una expansión sin límite dejaría colgada la petición
+func withTimeout(t *testing.T, limit time.Duration, f func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f()
+	}()
+	select {
+	case <-done:
+	case <-time.After(limit):
+		t.Fatalf("no terminó en %v", limit)
+	}
+}
+
+// diagnosticCodes devuelve los códigos en el orden en que se informaron
+func diagnosticCodes(diagnostics []models.Diagnostic) []string {
+	codes := []string{}
+	for _, d := range diagnostics {
+		codes = append(codes, d.Code)
+	}
+	return codes
+}
+
+// semanticCase es un programa con los códigos que debe informar el
+// análisis semántico, en orden, y un texto del primer mensaje
+type semanticCase struct {
+	name    string
+	code    string
+	want    []string
+	message string
+}
+
+func checkSemantic(t *testing.T, tests []semanticCase) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := AnalyzeSemantic(tt.code)
+			if got := diagnosticCodes(result.Diagnostics); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("códigos = %v, se esperaba %v (%v)", got, tt.want, result.Diagnostics)
+			}
+			if tt.message != "" && !strings.Contains(result.Diagnostics[0].Message, tt.message) {
+				t.Errorf("mensaje = %q, se esperaba que contuviera %q", result.Diagnostics[0].Message, tt.message)
+			}
+		})
+	}
+}
@@ -0,0 +1,358 @@
+package services
+
+import (
+	"html/template"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+// Nombres de los estados de los casos en el informe HTML
+var caseStatusNames = map[string]string{
+	CaseAccepted:      "Correcto",
+	CaseWrongAnswer:   "Respuesta incorrecta",
+	CaseRuntimeError:  "Error de ejecución",
+	CaseLimitExceeded: "Límite excedido",
+	CaseCompileError:  "No compila",
+	CaseUnsupported:   "No admitido por el intérprete",
+}
+
+// Prioridad de cada severidad al marcar un fragmento con varios diagnósticos
+var severityRank = map[string]int{SeverityInfo: 1, SeverityWarning: 2, SeverityError: 3}
+
+type htmlReport struct {
+	Title       string
+	Submissions []htmlSubmission
+}
+
+type htmlSubmission struct {
+	ID     string
+	Name   string
+	Result models.SubmissionResult
+	Grade  *models.GradeResult
+	Cases  []htmlCase
+	Status string
+
+	Tokens     []htmlCount
+	TokenTotal int
+	Semantic   *models.SemanticResult
+	Scopes     int
+	Symbols    int
+	Unused     int
+
+	Files []htmlFile
+	Other []htmlAnnotation // diagnósticos fuera de los archivos de la entrega
+}
+
+type htmlCount struct {
+	Name  string
+	Count int
+}
+
+type htmlCase struct {
+	models.CaseResult
+	StatusName string
+}
+
+type htmlFile struct {
+	Name  string
+	Lines []htmlLine
+}
+
+type htmlLine struct {
+	Number      int
+	Segments    []htmlSegment
+	Annotations []htmlAnnotation
+}
+
+// htmlSegment es un fragmento de una línea; Severity indica el diagnóstico
+// más grave que lo cubre
+type htmlSegment struct {
+	Text     string
+	Severity string
+}
+
+type htmlAnnotation struct {
+	Severity   string
+	Label      string
+	Code       string
+	Location   string
+	Message    string
+	Suggestion string
+	Path       []string
+}
+
+// HTMLReport escribe un informe HTML autónomo, sin recursos externos, para
+// entregarlo al alumno: el código de cada entrega con los diagnósticos
+// marcados en su lugar, los tokens por categoría, las estadísticas del
+// análisis semántico y, si se calificó, el resultado de cada caso
+func HTMLReport(w io.Writer, entries []ReportEntry) error {
+	report := htmlReport{Title: "Informe de " + plural(len(entries), "entrega", "entregas")}
+	if len(entries) == 1 {
+		report.Title = "Informe de " + entries[0].reportName()
+	}
+	for i, e := range entries {
+		report.Submissions = append(report.Submissions, newHTMLSubmission(i, e))
+	}
+	return htmlTemplate.Execute(w, report)
+}
+
+func newHTMLSubmission(i int, e ReportEntry) htmlSubmission {
+	r := e.Result
+	s := htmlSubmission{ID: "entrega-" + strconv.Itoa(i+1), Name: e.reportName(), Result: r, Grade: e.Grade}
+	switch {
+	case r.Error != "":
+		s.Status = r.Error
+	case r.Errors == 0 && r.Warnings == 0:
+		s.Status = "Sin problemas"
+	default:
+		s.Status = plural(r.Errors, "error", "errores") + ", " + plural(r.Warnings, "advertencia", "advertencias")
+	}
+	if e.Grade != nil {
+		for _, c := range e.Grade.Cases {
+			s.Cases = append(s.Cases, htmlCase{CaseResult: c, StatusName: caseStatusNames[c.Status]})
+		}
+	}
+
+	if lexical := r.Analysis.LexicalAnalysis; lexical != nil {
+		for name, count := range lexical.Summary {
+			s.Tokens = append(s.Tokens, htmlCount{name, count})
+		}
+		sort.Slice(s.Tokens, func(i, j int) bool { return s.Tokens[i].Name < s.Tokens[j].Name })
+		s.TokenTotal = lexical.Total
+	}
+	if semantic := r.Analysis.SemanticAnalysis; semantic != nil {
+		s.Semantic = semantic
+		s.Scopes = len(semantic.SymbolTable.Scopes)
+		for _, scope := range semantic.SymbolTable.Scopes {
+			s.Symbols += len(scope.Symbols)
+			for _, sym := range scope.Symbols {
+				if !sym.Used && (sym.Kind == SymbolVariable || sym.Kind == SymbolParameter) {
+					s.Unused++
+				}
+			}
+		}
+	}
+
+	// Los diagnósticos sin archivo son del único archivo o del punto de
+	// entrada del proyecto
+	names := []string{""}
+	sources := map[string]string{"": e.Submission.Code}
+	if len(e.Submission.Files) > 0 {
+		entry := projectEntry(e.Submission.Files, e.Submission.Entry)
+		names = []string{entry}
+		for name := range e.Submission.Files {
+			if name != entry {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names[1:])
+		sources = e.Submission.Files
+	}
+	byFile := make(map[string][]models.Diagnostic)
+	for _, d := range AllDiagnostics(r.Analysis) {
+		file := d.File
+		if file == "" {
+			file = names[0]
+		}
+		if _, ok := sources[file]; ok {
+			byFile[file] = append(byFile[file], d)
+		} else {
+			s.Other = append(s.Other, newHTMLAnnotation(r.Name, d))
+		}
+	}
+	for _, name := range names {
+		file, other := newHTMLFile(r.Name, name, sources[name], byFile[name])
+		s.Files = append(s.Files, file)
+		s.Other = append(s.Other, other...)
+	}
+	return s
+}
+
+// newHTMLFile divide el código en líneas, marca los fragmentos de cada
+// diagnóstico y lo anota debajo de la línea donde empieza. Devuelve aparte
+// los diagnósticos con líneas que no existen en el archivo.
+func newHTMLFile(submission, name, code string, diagnostics []models.Diagnostic) (htmlFile, []htmlAnnotation) {
+	file := htmlFile{Name: submissionFile(submission, name)}
+	lines := strings.Split(strings.ReplaceAll(code, "\r\n", "\n"), "\n")
+	if len(lines) > 1 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	// Severidad más grave en cada carácter; uno más por línea para marcar
+	// los diagnósticos que señalan el final
+	marks := make([][]string, len(lines))
+	for i, line := range lines {
+		marks[i] = make([]string, len([]rune(line))+1)
+	}
+	file.Lines = make([]htmlLine, len(lines))
+	var other []htmlAnnotation
+	for _, d := range diagnostics {
+		if d.StartLine < 1 || d.StartLine > len(lines) {
+			other = append(other, newHTMLAnnotation(submission, d))
+			continue
+		}
+		line := &file.Lines[d.StartLine-1]
+		line.Annotations = append(line.Annotations, newHTMLAnnotation(submission, d))
+
+		endLine, endColumn := d.EndLine, d.EndColumn
+		if endLine < d.StartLine || endLine == d.StartLine && endColumn <= d.StartColumn {
+			endLine, endColumn = d.StartLine, d.StartColumn+1
+		}
+		for l := d.StartLine; l <= endLine && l <= len(lines); l++ {
+			from, to := 1, len(marks[l-1])+1
+			if l == d.StartLine {
+				from = max(d.StartColumn, 1)
+			}
+			if l == endLine {
+				to = min(endColumn, to)
+			}
+			for c := from; c < to; c++ {
+				if severityRank[d.Severity] > severityRank[marks[l-1][c-1]] {
+					marks[l-1][c-1] = d.Severity
+				}
+			}
+		}
+	}
+
+	for i, line := range lines {
+		file.Lines[i].Number = i + 1
+		runes := append([]rune(line), ' ')
+		for start := 0; start < len(runes); {
+			end := start + 1
+			for end < len(runes) && marks[i][end] == marks[i][start] {
+				end++
+			}
+			text := string(runes[start:end])
+			if end == len(runes) {
+				text = text[:len(text)-1] // el espacio agregado solo se ve marcado
+				if marks[i][start] != "" && text == "" {
+					text = " "
+				}
+			}
+			if text != "" {
+				file.Lines[i].Segments = append(file.Lines[i].Segments, htmlSegment{text, marks[i][start]})
+			}
+			start = end
+		}
+	}
+	return file, other
+}
+
+func newHTMLAnnotation(submission string, d models.Diagnostic) htmlAnnotation {
+	a := htmlAnnotation{
+		Severity:   d.Severity,
+		Label:      SeverityNames[d.Severity],
+		Code:       d.Code,
+		Location:   submissionFile(submission, d.File) + ":" + strconv.Itoa(d.StartLine) + ":" + strconv.Itoa(d.StartColumn),
+		Message:    d.Message,
+		Suggestion: d.Suggestion,
+	}
+	for _, step := range d.Path {
+		a.Path = append(a.Path, "Línea "+strconv.Itoa(step.Line)+": "+step.Message)
+	}
+	return a
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="es">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem auto; max-width: 60rem; padding: 0 1rem; color: #1f2328; }
+h2 { border-bottom: 1px solid #d0d7de; padding-bottom: .3rem; margin-top: 2.5rem; }
+table { border-collapse: collapse; margin: .5rem 0 1rem; }
+th, td { border: 1px solid #d0d7de; padding: .25rem .6rem; text-align: left; vertical-align: top; }
+td.number { text-align: right; }
+.stats { display: flex; flex-wrap: wrap; gap: 0 2rem; }
+.valid { color: #1a7f37; }
+.invalid { color: #cf222e; }
+.source { border: 1px solid #d0d7de; width: 100%; font: .85rem/1.4 ui-monospace, monospace; }
+.source td { border: 0; padding: 0 .6rem; height: 1.2em; white-space: pre-wrap; tab-size: 4; }
+.source td.line { color: #6e7781; text-align: right; user-select: none; width: 1%; white-space: nowrap; }
+.source mark { background: none; text-decoration: underline wavy; text-underline-offset: 3px; }
+mark.error { text-decoration-color: #cf222e; }
+mark.warning { text-decoration-color: #bf8700; }
+mark.info { text-decoration-color: #0969da; }
+.note { font-family: system-ui, sans-serif; margin: .2rem 0 .4rem; padding: .3rem .6rem; border-left: 4px solid; white-space: normal; }
+.note.error { border-color: #cf222e; background: #ffebe9; }
+.note.warning { border-color: #bf8700; background: #fff8c5; }
+.note.info { border-color: #0969da; background: #ddf4ff; }
+.note ul { margin: .2rem 0; padding-left: 1.2rem; }
+pre { background: #f6f8fa; padding: .5rem; overflow-x: auto; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{if gt (len .Submissions) 1}}
+<table>
+<tr><th>Entrega</th><th>Errores</th><th>Advertencias</th></tr>
+{{range .Submissions}}<tr><td><a href="#{{.ID}}">{{.Name}}</a></td><td class="number">{{.Result.Errors}}</td><td class="number">{{.Result.Warnings}}</td></tr>
+{{end}}</table>
+{{end}}
+{{range .Submissions}}{{$submission := .}}
+<section id="{{.ID}}">
+<h2>{{.Name}}</h2>
+<p class="{{if and .Result.Valid (not .Result.Error)}}valid{{else}}invalid{{end}}">{{.Status}}</p>
+{{with .Grade}}
+<h3>Calificación</h3>
+<p>{{.Passed}} de {{.Total}} casos correctos, {{.Score}} de {{.MaxScore}} puntos</p>
+{{if .CompilerOutput}}<pre>{{.CompilerOutput}}</pre>{{end}}
+{{end}}
+{{if .Cases}}
+<table>
+<tr><th>Caso</th><th>Resultado</th><th>Puntos</th><th>Detalle</th></tr>
+{{range .Cases}}<tr>
+<td>{{.Name}}</td>
+<td class="{{if .Passed}}valid{{else}}invalid{{end}}">{{.StatusName}}</td>
+<td class="number">{{.Points}} / {{.MaxPoints}}</td>
+<td>{{.Message}}{{if .Diff}}<pre>{{.Diff}}</pre>{{end}}{{if .Stderr}}<pre>{{.Stderr}}</pre>{{end}}</td>
+</tr>
+{{end}}</table>
+{{end}}
+<div class="stats">
+{{if .Tokens}}
+<div>
+<h3>Tokens</h3>
+<table>
+{{range .Tokens}}<tr><td>{{.Name}}</td><td class="number">{{.Count}}</td></tr>
+{{end}}<tr><th>Total</th><th class="number">{{.TokenTotal}}</th></tr>
+</table>
+</div>
+{{end}}
+{{with .Semantic}}
+<div>
+<h3>Análisis semántico</h3>
+{{if .Reason}}<p>{{.Reason}}</p>{{else}}
+<table>
+<tr><td>Variables</td><td class="number">{{.Variables}}</td></tr>
+<tr><td>Funciones</td><td class="number">{{.Functions}}</td></tr>
+<tr><td>Ámbitos</td><td class="number">{{$submission.Scopes}}</td></tr>
+<tr><td>Símbolos</td><td class="number">{{$submission.Symbols}}</td></tr>
+<tr><td>Variables y parámetros sin usar</td><td class="number">{{$submission.Unused}}</td></tr>
+</table>
+{{end}}
+</div>
+{{end}}
+</div>
+{{range .Files}}
+<h3>{{.Name}}</h3>
+<table class="source">
+{{range .Lines}}<tr><td class="line">{{.Number}}</td><td>{{range .Segments}}{{if .Severity}}<mark class="{{.Severity}}">{{.Text}}</mark>{{else}}{{.Text}}{{end}}{{end}}{{range .Annotations}}{{template "note" .}}{{end}}</td></tr>
+{{end}}</table>
+{{end}}
+{{if .Other}}
+<h3>Otros diagnósticos</h3>
+{{range .Other}}{{template "note" .}}{{end}}
+{{end}}
+</section>
+{{end}}
+</body>
+</html>
+{{define "note"}}<div class="note {{.Severity}}"><strong>{{.Label}} {{.Code}}</strong> <small>{{.Location}}</small><br>{{.Message}}{{if .Path}}<ul>{{range .Path}}<li>{{.}}</li>{{end}}</ul>{{end}}{{if .Suggestion}}<br><em>Sugerencia: {{.Suggestion}}</em>{{end}}</div>{{end}}
+`))
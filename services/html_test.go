@@ -0,0 +1,113 @@
+package services
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+func TestHTMLFileMarks(t *testing.T) {
+	diagnostic := func(severity string, startLine, startColumn, endLine, endColumn int) models.Diagnostic {
+		return models.Diagnostic{Code: "X", Severity: severity, StartLine: startLine, StartColumn: startColumn, EndLine: endLine, EndColumn: endColumn}
+	}
+	tests := []struct {
+		name        string
+		code        string
+		diagnostics []models.Diagnostic
+		want        []string // segmentos de cada línea como severidad:texto
+		other       int
+	}{
+		{"sin diagnósticos", "int x;\n", nil, []string{":int x;"}, 0},
+		{"un fragmento", "int x = y;", []models.Diagnostic{diagnostic(SeverityError, 1, 9, 1, 10)},
+			[]string{":int x = |error:y|:;"}, 0},
+		{"el más grave", "int x = y;", []models.Diagnostic{
+			diagnostic(SeverityWarning, 1, 5, 1, 10),
+			diagnostic(SeverityError, 1, 9, 1, 10),
+		}, []string{":int |warning:x = |error:y|:;"}, 0},
+		{"varias líneas", "a(\nb);", []models.Diagnostic{diagnostic(SeverityInfo, 1, 2, 2, 2)},
+			[]string{":a|info:(", "info:b|:);"}, 0},
+		{"al final de la línea", "return 0\n}", []models.Diagnostic{diagnostic(SeverityError, 1, 9, 1, 9)},
+			[]string{":return 0|error: ", ":}"}, 0},
+		{"sin final", "int x;", []models.Diagnostic{diagnostic(SeverityWarning, 1, 5, 0, 0)},
+			[]string{":int |warning:x|:;"}, 0},
+		{"fuera del archivo", "int x;", []models.Diagnostic{diagnostic(SeverityError, 5, 1, 5, 2)},
+			[]string{":int x;"}, 1},
+		{"ñ", "int año;", []models.Diagnostic{diagnostic(SeverityWarning, 1, 5, 1, 8)},
+			[]string{":int |warning:año|:;"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, other := newHTMLFile("a.cpp", "", tt.code, tt.diagnostics)
+			var lines []string
+			for _, line := range file.Lines {
+				var segments []string
+				for _, s := range line.Segments {
+					segments = append(segments, s.Severity+":"+s.Text)
+				}
+				lines = append(lines, strings.Join(segments, "|"))
+			}
+			if !reflect.DeepEqual(lines, tt.want) {
+				t.Errorf("líneas = %q, se esperaba %q", lines, tt.want)
+			}
+			if len(other) != tt.other {
+				t.Errorf("%d diagnósticos fuera del archivo, se esperaban %d", len(other), tt.other)
+			}
+		})
+	}
+}
+
+func TestHTMLReport(t *testing.T) {
+	undeclared := models.Diagnostic{Code: CodeUndeclared, Severity: SeverityError, Message: "Variable 'y' usada pero no declarada",
+		StartLine: 1, StartColumn: 21, EndLine: 1, EndColumn: 22, Suggestion: "Declare <y>"}
+	invalid := submissionWith("luis.cpp", undeclared)
+	invalid.Errors = 1
+	valid := submissionWith("ana.cpp")
+	valid.Valid = true
+	project := submissionWith("equipo", models.Diagnostic{Code: "SEM004", Severity: SeverityWarning, File: "util.cpp", StartLine: 1, StartColumn: 5, EndLine: 1, EndColumn: 6})
+	project.Warnings = 1
+	grade := &models.GradeResult{Passed: 1, Total: 2, Score: 5, MaxScore: 10, Cases: []models.CaseResult{
+		{Name: "suma", Status: CaseAccepted, Passed: true},
+		{Name: "resta", Status: CaseUnsupported},
+	}}
+
+	tests := []struct {
+		name    string
+		entries []ReportEntry
+		want    []string
+	}{
+		{"una entrega", []ReportEntry{{Submission: models.Submission{Code: "int main() { return y; }"}, Result: invalid}}, []string{
+			"<title>Informe de luis.cpp</title>",
+			`<p class="invalid">1 error, 0 advertencias</p>`,
+			`<mark class="error">y</mark>`,
+			"luis.cpp:1:21",
+			"Sugerencia: Declare &lt;y&gt;",
+		}},
+		{"varias entregas", []ReportEntry{
+			{Submission: models.Submission{Code: "int main() { return 0; }"}, Result: valid},
+			{Submission: models.Submission{Code: "int main() { return y; }"}, Result: invalid},
+		}, []string{"<title>Informe de 2 entregas</title>", `<a href="#entrega-2">luis.cpp</a>`, `<p class="valid">Sin problemas</p>`}},
+		{"proyecto", []ReportEntry{{Submission: models.Submission{Files: map[string]string{
+			"main.cpp": "int main() { return 0; }", "util.cpp": "int x;",
+		}}, Result: project}}, []string{"<h3>equipo/main.cpp</h3>", "<h3>equipo/util.cpp</h3>", `int <mark class="warning">x</mark>;`}},
+		{"calificada", []ReportEntry{{Submission: models.Submission{Code: "int main() { return 0; }"}, Result: submissionWith("eva.cpp"), Grade: grade}},
+			[]string{"1 de 2 casos correctos, 5 de 10 puntos", "No admitido por el intérprete"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := HTMLReport(&buf, tt.entries); err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("el informe no contiene %q", want)
+				}
+			}
+		})
+	}
+}
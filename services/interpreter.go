@@ -0,0 +1,790 @@
+package services
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+// Límites por defecto de una ejecución. Los de la petición solo pueden
+// ser menores.
+const (
+	defaultMaxSteps    = 10_000_000
+	defaultMaxMemoryKB = 32 * 1024
+	defaultMaxOutputKB = 1024
+	maxCallDepth       = 5000
+
+	// Las operaciones que recorren un string o copian un bloque cuestan un
+	// paso cada bytesPerStep bytes, para que no valgan lo mismo que una suma
+	bytesPerStep = 64
+	// El reloj se consulta cada clockInterval pasos
+	clockInterval = 1024
+)
+
+// Estados de una ejecución (RunResult.Status)
+const (
+	RunOK            = "ok"
+	RunCompileError  = "compile_error"
+	RunRuntimeError  = "runtime_error"
+	RunLimitExceeded = "limit_exceeded"
+)
+
+// Códigos de salida con los que termina el proceso real ante cada señal
+const (
+	exitAbort  = 134 // SIGABRT: excepción no capturada
+	exitFPE    = 136 // SIGFPE: división entera por cero
+	exitKilled = 137 // SIGKILL: el juez detiene el proceso al superar un límite
+	exitSegv   = 139 // SIGSEGV: acceso inválido a memoria o pila agotada
+)
+
+// interpreter ejecuta el árbol sintáctico de un programa ya analizado. Usa
+// la resolución del análisis semántico: el símbolo de cada identificador y
+// la sobrecarga elegida en cada llamada.
+type interpreter struct {
+	analyzer *semanticAnalyzer
+	globals  map[*symbol]location
+	statics  map[*models.SyntaxNode]location // variables locales static ya creadas
+	frames   []*frame
+	literals map[*models.SyntaxNode]rtValue
+
+	input  *inputStream
+	stdout strings.Builder
+	stderr strings.Builder
+	format map[string]*streamFormat // estado de cout y cerr
+	random uint32                   // estado de rand()
+
+	steps, maxSteps         int64
+	memory, peak, maxMemory int64
+	outputSize, maxOutput   int64
+
+	// El programa se detiene en deadline aunque no haya agotado los pasos
+	deadline  time.Time
+	timeLimit int64 // en milisegundos, para el mensaje
+	nextClock int64 // paso en que se vuelve a consultar el reloj
+}
+
+// frame es el marco de una llamada: sus variables y los bloques de cada
+// ámbito abierto, que se liberan al cerrarlo
+type frame struct {
+	function *models.SyntaxNode
+	call     *models.SyntaxNode // llamada que lo creó; nil en main
+	locals   map[*symbol]location
+	scopes   [][]*block
+	result   rtValue
+}
+
+// Resultado de ejecutar una sentencia
+type flow int
+
+const (
+	flowNormal flow = iota
+	flowBreak
+	flowContinue
+	flowReturn
+)
+
+// runtimeError detiene el programa con el diagnóstico, el estado y el
+// código de salida del proceso
+type runtimeError struct {
+	diagnostic models.Diagnostic
+	status     string
+	exitCode   int
+}
+
+// programExit detiene el programa cuando llama a exit()
+type programExit struct {
+	code int
+}
+
+// program es un programa ya analizado que se puede ejecutar varias veces
+// con distintas entradas, por ejemplo con cada caso de prueba de /grade
+type program struct {
+	tree     *models.SyntaxNode
+	analyzer *semanticAnalyzer
+	source   *sourceFiles        // nil con un solo archivo
+	errors   []models.Diagnostic // errores de compilación: si hay, no se ejecuta
+}
+
+// Run analiza el programa y, si no tiene errores, lo ejecuta con la
+// entrada indicada
+func Run(code, stdin string, limits models.RunLimits) models.RunResult {
+	tree, diagnostics := Parse(code)
+	p := compileProgram(tree, diagnostics, nil)
+	_, lexical := Tokenize(code)
+	p.addLexicalErrors(lexical)
+	return p.run(stdin, limits)
+}
+
+// RunProject ejecuta un proyecto de varios archivos a partir de entry
+func RunProject(files map[string]string, entry, stdin string, limits models.RunLimits) models.RunResult {
+	return compileProject(files, entry).run(stdin, limits)
+}
+
+// compileProgram completa el análisis del árbol y reúne los errores que
+// impiden ejecutarlo
+func compileProgram(tree *models.SyntaxNode, diagnostics []models.Diagnostic, source *sourceFiles) *program {
+	diagnostics = checkProgram(tree, diagnostics).Diagnostics
+	analyzer := runAnalyzer(tree, source)
+	return newProgram(tree, analyzer, append(diagnostics, analyzer.diagnostics...), source)
+}
+
+// newProgram prepara el árbol ya analizado con los diagnósticos de su
+// compilación
+func newProgram(tree *models.SyntaxNode, analyzer *semanticAnalyzer, diagnostics []models.Diagnostic, source *sourceFiles) *program {
+	p := &program{tree: tree, analyzer: analyzer, source: source}
+
+	// Como un compilador, no se ejecuta un programa con errores. Se copian
+	// porque se ubican en sus archivos y el análisis puede compartirlos.
+	for _, d := range diagnostics {
+		if d.Severity == SeverityError {
+			p.errors = append(p.errors, cloneDiagnostic(d))
+		}
+	}
+	if source != nil {
+		source.relocateDiagnostics(p.errors)
+	}
+	return p
+}
+
+// addLexicalErrors agrega al programa los errores léxicos de sus archivos,
+// que ya están ubicados en ellos. El parser salta los tokens inválidos sin
+// informarlos otra vez, pero con ellos el programa tampoco compila.
+func (p *program) addLexicalErrors(diagnostics []models.Diagnostic) {
+	var errors []models.Diagnostic
+	for _, d := range lexicalErrors(diagnostics, p.source) {
+		errors = append(errors, cloneDiagnostic(d))
+	}
+	p.errors = append(errors, p.errors...)
+}
+
+// unsupported busca antes de ejecutar el programa las construcciones que el
+// intérprete no admite: clases y structs y el acceso a miembros que no son
+// métodos de string, vector o los flujos. Al ejecutarlo fallarían recién
+// al llegar a ellas, después de haber escrito parte de la salida.
+func (p *program) unsupported() *models.Diagnostic {
+	var found *models.Diagnostic
+	callees := make(map[*models.SyntaxNode]bool)
+	walkTree(p.tree, func(node *models.SyntaxNode) bool {
+		if found != nil {
+			return false
+		}
+		var d models.Diagnostic
+		switch {
+		case node.Kind == NodeCall && node.Children[0].Kind == NodeMember:
+			callees[node.Children[0]] = true
+			return true
+		case node.Kind == NodeClass:
+			d = nameDiagnosticAt(CodeUnsupported, "El intérprete no admite clases ni structs: '"+node.Value+"'", node)
+		case node.Kind == NodeMember && node.Operator == "->":
+			d = diagnosticAtNode(CodeUnsupported, "El intérprete no admite clases ni el operador '->'", node)
+		case node.Kind == NodeMember && !callees[node]:
+			d = diagnosticAtNode(CodeUnsupported, "El intérprete no admite el acceso al miembro '"+node.Value+"'", node)
+		default:
+			return true
+		}
+		if p.source != nil {
+			p.source.relocateDiagnostics([]models.Diagnostic{d})
+		}
+		found = &d
+		return false
+	})
+	return found
+}
+
+func (p *program) run(stdin string, limits models.RunLimits) models.RunResult {
+	if len(p.errors) > 0 {
+		errors := append([]models.Diagnostic{}, p.errors...)
+		return models.RunResult{Status: RunCompileError, ExitCode: 1, Diagnostics: errors}
+	}
+
+	in := &interpreter{
+		analyzer:  p.analyzer,
+		globals:   make(map[*symbol]location),
+		statics:   make(map[*models.SyntaxNode]location),
+		literals:  make(map[*models.SyntaxNode]rtValue),
+		input:     &inputStream{text: stdin},
+		format:    map[string]*streamFormat{"cout": newStreamFormat(), "cerr": newStreamFormat()},
+		random:    1,
+		maxSteps:  limit(limits.MaxSteps, defaultMaxSteps),
+		maxMemory: limit(limits.MaxMemoryKB, defaultMaxMemoryKB) * 1024,
+		maxOutput: limit(limits.MaxOutputKB, defaultMaxOutputKB) * 1024,
+		timeLimit: limit(limits.TimeLimitMS, maxTimeLimitMS),
+	}
+	in.deadline = time.Now().Add(time.Duration(in.timeLimit) * time.Millisecond)
+	result := in.run(p.tree)
+	if p.source != nil {
+		p.source.relocateDiagnostics(result.Diagnostics)
+	}
+	return result
+}
+
+// limit aplica el límite pedido si es menor que el del servidor
+func limit(requested, max int64) int64 {
+	if requested > 0 && requested < max {
+		return requested
+	}
+	return max
+}
+
+func (in *interpreter) run(tree *models.SyntaxNode) models.RunResult {
+	result := models.RunResult{Status: RunOK, Diagnostics: []models.Diagnostic{}}
+	code, failure := in.execute(tree)
+	if failure != nil {
+		result.Status = failure.status
+		result.Diagnostics = append(result.Diagnostics, failure.diagnostic)
+		code = failure.exitCode
+	}
+	result.ExitCode = code
+	result.Stdout = in.stdout.String()
+	result.Stderr = in.stderr.String()
+	result.Steps = in.steps
+	result.MemoryKB = (in.peak + 1023) / 1024
+	return result
+}
+
+// execute inicializa las variables globales y llama a main. Devuelve el
+// código de salida o el error que detuvo el programa.
+func (in *interpreter) execute(tree *models.SyntaxNode) (code int, failure *runtimeError) {
+	defer func() {
+		switch stop := recover().(type) {
+		case nil:
+		case programExit:
+			code = stop.code & 0xff
+		case *runtimeError:
+			failure = stop
+		default:
+			panic(stop)
+		}
+	}()
+
+	in.frames = []*frame{{locals: in.globals, scopes: [][]*block{nil}}}
+	for _, node := range tree.Children {
+		if node.Kind == NodeVarDecl {
+			in.declare(node)
+		}
+	}
+	result := in.call(findFunction(tree, "main"), nil, nil)
+	return int(result.i) & 0xff, nil
+}
+
+// fail detiene el programa con un error de ejecución en el nodo indicado
+func (in *interpreter) fail(node *models.SyntaxNode, code, message string, exitCode int) {
+	panic(&runtimeError{diagnostic: in.runtimeDiagnostic(node, code, message), status: RunRuntimeError, exitCode: exitCode})
+}
+
+// failLimit detiene el programa porque superó uno de los límites
+func (in *interpreter) failLimit(node *models.SyntaxNode, code, message string) {
+	panic(&runtimeError{diagnostic: in.runtimeDiagnostic(node, code, message), status: RunLimitExceeded, exitCode: exitKilled})
+}
+
+// runtimeDiagnostic ubica el error y agrega la pila de llamadas como Path
+func (in *interpreter) runtimeDiagnostic(node *models.SyntaxNode, code, message string) models.Diagnostic {
+	var diagnostic models.Diagnostic
+	if node != nil {
+		diagnostic = diagnosticAtNode(code, message, node)
+	} else {
+		diagnostic = newDiagnostic(code, message, 1, 1, 1, 1)
+	}
+
+	// Un paso por cada llamada activa desde main; con recursión profunda
+	// solo se muestran los extremos de la pila
+	var calls []int
+	for i, f := range in.frames {
+		if f.call != nil {
+			calls = append(calls, i)
+		}
+	}
+	const shown = 5
+	for n, i := range calls {
+		f := in.frames[i]
+		if len(calls) > 2*shown && n >= shown && n < len(calls)-shown {
+			if n == shown {
+				diagnostic.Path = append(diagnostic.Path, stepAt(
+					"... "+strconv.Itoa(len(calls)-2*shown)+" llamadas más", f.call))
+			}
+			continue
+		}
+		caller := "la inicialización de las variables globales"
+		if function := in.frames[i-1].function; function != nil {
+			caller = "'" + function.Value + "'"
+		}
+		diagnostic.Path = append(diagnostic.Path, stepAt(caller+" llama a '"+f.function.Value+"'", f.call))
+	}
+	return diagnostic
+}
+
+// step cuenta un paso de ejecución y detiene el programa si supera el
+// límite de pasos o el de tiempo
+func (in *interpreter) step(node *models.SyntaxNode) {
+	in.steps++
+	if in.steps > in.maxSteps {
+		in.failLimit(node, CodeStepLimit, "Se superó el límite de "+strconv.FormatInt(in.maxSteps, 10)+
+			" pasos de ejecución; puede haber un ciclo infinito")
+	}
+	if in.steps >= in.nextClock {
+		in.nextClock = in.steps + clockInterval
+		if time.Now().After(in.deadline) {
+			in.failLimit(node, CodeTimeLimit, "Se superó el tiempo límite de "+strconv.FormatInt(in.timeLimit, 10)+" ms")
+		}
+	}
+}
+
+// work cobra en pasos una operación que recorre los bytes indicados
+func (in *interpreter) work(bytes int, node *models.SyntaxNode) {
+	in.steps += int64(bytes / bytesPerStep)
+	in.step(node)
+}
+
+func (in *interpreter) frame() *frame {
+	return in.frames[len(in.frames)-1]
+}
+
+func (in *interpreter) pushScope() {
+	f := in.frame()
+	f.scopes = append(f.scopes, nil)
+}
+
+func (in *interpreter) popScope() {
+	f := in.frame()
+	in.releaseScope(f.scopes[len(f.scopes)-1])
+	f.scopes = f.scopes[:len(f.scopes)-1]
+}
+
+// lookup devuelve la ubicación de una variable local o global
+func (in *interpreter) lookup(sym *symbol, node *models.SyntaxNode) location {
+	if loc, ok := in.frame().locals[sym]; ok {
+		return loc
+	}
+	if loc, ok := in.globals[sym]; ok {
+		return loc
+	}
+	in.fail(node, CodeUnsupported, "El intérprete no puede usar '"+sym.Name+"' en este punto", 1)
+	return location{}
+}
+
+// call ejecuta una función del programa con los argumentos de la llamada.
+// Los argumentos se evalúan en el marco de quien llama.
+func (in *interpreter) call(function, call *models.SyntaxNode, args []*models.SyntaxNode) rtValue {
+	var arguments []argument
+	for i, param := range functionParams(function) {
+		arg := initializer(param)
+		if i < len(args) {
+			arg = args[i]
+		}
+		if arg == nil {
+			// Solo main puede quedar sin argumentos: argc es 0 y argv nulo
+			arguments = append(arguments, argument{value: in.zero(typeFromString(param.Type).decay(), param.Value, function)})
+			continue
+		}
+		if typeFromString(param.Type).Reference {
+			arguments = append(arguments, argument{loc: in.evalLocation(arg), ref: true})
+		} else {
+			arguments = append(arguments, argument{value: in.eval(arg)})
+		}
+	}
+	return in.invoke(function, call, arguments)
+}
+
+// invoke ejecuta el cuerpo de la función en un marco nuevo con los
+// argumentos ya evaluados
+func (in *interpreter) invoke(function, call *models.SyntaxNode, arguments []argument) rtValue {
+	in.step(call)
+	if len(in.frames) > maxCallDepth {
+		in.fail(call, CodeStackOverflow, "Se superó la profundidad máxima de "+strconv.Itoa(maxCallDepth)+
+			" llamadas anidadas; puede haber una recursión infinita", exitSegv)
+	}
+	body := functionBody(function)
+	if body == nil {
+		in.fail(call, CodeUnsupported, "La función '"+function.Value+"' se declaró pero no se definió", 1)
+	}
+
+	callee := &frame{function: function, call: call, locals: make(map[*symbol]location), scopes: [][]*block{nil}}
+	in.frames = append(in.frames, callee)
+	for i, param := range functionParams(function) {
+		loc := arguments[i].loc
+		if !arguments[i].ref {
+			if t := parameterType(typeFromString(param.Type)); t.isArray() {
+				loc = in.arrayParameter(arguments[i].value, param)
+			} else {
+				loc = in.variable(t, param.Value, param)
+				in.store(loc, arguments[i].value, param)
+			}
+		}
+		if sym := in.analyzer.declarations[param]; sym != nil {
+			callee.locals[sym] = loc
+		}
+	}
+	for _, stmt := range body.Children {
+		if in.exec(stmt) == flowReturn {
+			break
+		}
+	}
+	in.popScope()
+	in.frames = in.frames[:len(in.frames)-1]
+	return callee.result
+}
+
+// parameterType convierte los parámetros arreglo en punteros: int v[] → int*.
+// Un arreglo de arreglos (int m[][3]) sigue siendo un arreglo: el intérprete
+// no tiene punteros a arreglos.
+func parameterType(t cppType) cppType {
+	if t.isArray() {
+		return t.decay()
+	}
+	return t
+}
+
+// arrayParameter enlaza un parámetro int m[][3] con la matriz de quien
+// llama, como lo haría el puntero a su primera fila: comparte sus filas sin
+// copiarlas y sin contabilizar de nuevo su memoria
+func (in *interpreter) arrayParameter(v rtValue, param *models.SyntaxNode) location {
+	if !v.t.isArray() || v.blk == nil {
+		in.fail(param, CodeUnsupported, "El intérprete solo admite pasar un arreglo al parámetro '"+param.Value+"'", 1)
+	}
+	return location{blk: &block{elem: v.t, name: param.Value, vals: []rtValue{v}}}
+}
+
+// definition devuelve la definición de la sobrecarga elegida en la
+// llamada, que puede haberse resuelto contra un prototipo
+func (in *interpreter) definition(sym *symbol, selected *models.SyntaxNode) *models.SyntaxNode {
+	if functionBody(selected) != nil {
+		return selected
+	}
+	signature := parameterSignature(selected)
+	for _, overload := range sym.overloads {
+		if functionBody(overload) != nil && parameterSignature(overload) == signature {
+			return overload
+		}
+	}
+	return selected
+}
+
+// exec ejecuta una sentencia
+func (in *interpreter) exec(node *models.SyntaxNode) flow {
+	in.step(node)
+	switch node.Kind {
+	case NodeBlock:
+		in.pushScope()
+		for _, stmt := range node.Children {
+			if result := in.exec(stmt); result != flowNormal {
+				in.popScope()
+				return result
+			}
+		}
+		in.popScope()
+
+	case NodeVarDecl:
+		in.declare(node)
+
+	case NodeExprStmt:
+		for _, child := range node.Children {
+			in.eval(child)
+		}
+
+	case NodeIf:
+		if in.truth(in.eval(node.Children[0])) {
+			return in.exec(node.Children[1])
+		}
+		if len(node.Children) > 2 {
+			return in.exec(node.Children[2])
+		}
+
+	case NodeWhile:
+		for in.truth(in.eval(node.Children[0])) {
+			if result := in.exec(node.Children[1]); result == flowBreak {
+				break
+			} else if result == flowReturn {
+				return result
+			}
+			in.step(node)
+		}
+
+	case NodeDoWhile:
+		for {
+			if result := in.exec(node.Children[0]); result == flowBreak {
+				break
+			} else if result == flowReturn {
+				return result
+			}
+			if !in.truth(in.eval(node.Children[1])) {
+				break
+			}
+		}
+
+	case NodeFor:
+		in.pushScope()
+		result := in.execFor(node)
+		in.popScope()
+		return result
+
+	case NodeRangeFor:
+		in.pushScope()
+		result := in.execRangeFor(node)
+		in.popScope()
+		return result
+
+	case NodeSwitch:
+		in.pushScope()
+		result := in.execSwitch(node)
+		in.popScope()
+		return result
+
+	case NodeBreak:
+		return flowBreak
+	case NodeContinue:
+		return flowContinue
+
+	case NodeReturn:
+		f := in.frame()
+		if len(node.Children) > 0 {
+			value := in.eval(node.Children[0])
+			if t := typeFromString(f.function.Type); !t.isVoid() {
+				value = in.convert(value, t, node.Children[0])
+			}
+			f.result = value
+		}
+		return flowReturn
+
+	case NodeEmpty, NodeUsing, NodeInclude, NodeDirective:
+
+	default:
+		in.fail(node, CodeUnsupported, "El intérprete no admite esta sentencia ("+node.Kind+")", 1)
+	}
+	return flowNormal
+}
+
+func (in *interpreter) execFor(node *models.SyntaxNode) flow {
+	init, condition, increment, body := node.Children[0], node.Children[1], node.Children[2], node.Children[3]
+	in.exec(init)
+	for condition.Kind == NodeEmpty || in.truth(in.eval(condition)) {
+		if result := in.exec(body); result == flowBreak {
+			break
+		} else if result == flowReturn {
+			return result
+		}
+		if increment.Kind != NodeEmpty {
+			in.eval(increment)
+		}
+		in.step(node)
+	}
+	return flowNormal
+}
+
+// execRangeFor recorre un arreglo, vector o string. La variable es una
+// copia del elemento o, si es una referencia, el elemento mismo.
+func (in *interpreter) execRangeFor(node *models.SyntaxNode) flow {
+	decl, rangeExpr, body := node.Children[0], node.Children[1], node.Children[2]
+	declarator := decl.Children[0]
+	sym := in.analyzer.declarations[declarator]
+
+	container := in.evalLocation(rangeExpr)
+	value := in.load(container, rangeExpr)
+	var elements []location
+	switch {
+	case value.t.isString():
+		for i := range value.s {
+			elements = append(elements, location{blk: container.blk, index: container.index, inString: true, offset: i})
+		}
+	case value.blk != nil:
+		for i := 0; i < value.blk.length(); i++ {
+			elements = append(elements, location{blk: value.blk, index: i})
+		}
+	default:
+		in.fail(rangeExpr, CodeUnsupported, "El intérprete no puede recorrer un valor de tipo "+value.t.String(), 1)
+	}
+
+	t := typeFromString(declarator.Type)
+	for _, element := range elements {
+		in.pushScope()
+		loc := element
+		if !t.Reference {
+			elementType := t
+			if elementType.isUnknown() {
+				elementType = locationType(element)
+			}
+			loc = in.variable(elementType, declarator.Value, declarator)
+			in.store(loc, in.load(element, rangeExpr), rangeExpr)
+		}
+		if sym != nil {
+			in.frame().locals[sym] = loc
+		}
+		result := in.exec(body)
+		in.popScope()
+		if result == flowBreak {
+			break
+		} else if result == flowReturn {
+			return result
+		}
+		in.step(node)
+	}
+	return flowNormal
+}
+
+// execSwitch salta al case que coincide y sigue de largo por los
+// siguientes hasta un break
+func (in *interpreter) execSwitch(node *models.SyntaxNode) flow {
+	value := in.eval(node.Children[0])
+	labels := node.Children[1:]
+	start := -1
+	for i, label := range labels {
+		if label.Kind == NodeCase && in.operate("==", value, in.eval(label.Children[0]), label).i != 0 {
+			start = i
+			break
+		}
+	}
+	// default solo se usa si ningún case coincide, aunque esté antes
+	if start < 0 {
+		for i, label := range labels {
+			if label.Kind == NodeDefault {
+				start = i
+			}
+		}
+	}
+	if start < 0 {
+		return flowNormal
+	}
+
+	for _, label := range labels[start:] {
+		stmts := label.Children
+		if label.Kind == NodeCase {
+			stmts = stmts[1:]
+		}
+		for _, stmt := range stmts {
+			switch result := in.exec(stmt); result {
+			case flowBreak:
+				return flowNormal
+			case flowNormal:
+			default:
+				return result
+			}
+		}
+	}
+	return flowNormal
+}
+
+// declare crea las variables de una declaración con su valor inicial
+func (in *interpreter) declare(node *models.SyntaxNode) {
+	static := node.Operator == "static" && len(in.frames) > 1
+	for _, declarator := range node.Children {
+		sym := in.analyzer.declarations[declarator]
+		if sym == nil {
+			continue
+		}
+		if static {
+			if loc, ok := in.statics[declarator]; ok {
+				in.frame().locals[sym] = loc
+				continue
+			}
+		}
+
+		t := in.declaredType(sym, declarator)
+		init := initializer(declarator)
+		if t.Reference {
+			in.frame().locals[sym] = in.evalLocation(init)
+			continue
+		}
+
+		var loc location
+		if static {
+			// Las variables static viven en el marco global
+			current := in.frames
+			in.frames = in.frames[:1]
+			loc = in.variable(t, declarator.Value, declarator)
+			in.frames = current
+			in.statics[declarator] = loc
+		} else {
+			loc = in.variable(t, declarator.Value, declarator)
+		}
+		in.frame().locals[sym] = loc
+		if init != nil {
+			in.initialize(loc, init)
+		}
+	}
+}
+
+// declaredType es el tipo de la variable con las dimensiones que solo se
+// conocen al ejecutar (int v[n]) y el tipo de auto deducido del valor
+func (in *interpreter) declaredType(sym *symbol, declarator *models.SyntaxNode) cppType {
+	t := typeFromString(sym.Type)
+	dimension := 0
+	for _, size := range declarator.Children {
+		if size.Kind != NodeArraySize {
+			continue
+		}
+		if dimension < len(t.Dims) && t.Dims[dimension] < 0 && size.Children[0].Kind != NodeEmpty {
+			n := in.eval(size.Children[0])
+			if n.i <= 0 {
+				in.fail(size, CodeInvalidMemory, "El tamaño del arreglo '"+declarator.Value+"' vale "+
+					strconv.FormatInt(n.i, 10), exitSegv)
+			}
+			t.Dims[dimension] = int(n.i)
+		}
+		dimension++
+	}
+	if t.isUnknown() {
+		if init := initializer(declarator); init != nil && init.Kind != NodeInitList {
+			valueType := in.eval(init).t
+			if !t.Reference {
+				valueType = valueType.decay()
+			}
+			valueType.Reference = t.Reference
+			return valueType
+		}
+	}
+	return t
+}
+
+// initialize guarda el valor inicial de una variable recién creada
+func (in *interpreter) initialize(loc location, init *models.SyntaxNode) {
+	t := locationType(loc)
+	if t.isArray() && init.Kind == NodeStringLiteral {
+		// char s[] = "hola"; copia el literal en el arreglo
+		in.fillChars(in.load(loc, init), unescape(init.Value), init)
+		return
+	}
+	if init.Kind != NodeInitList {
+		in.store(loc, in.eval(init), init)
+		return
+	}
+
+	switch {
+	case t.isArray():
+		in.fillArray(in.load(loc, init), init)
+	case isVectorType(t) && init.Operator == "()":
+		in.store(loc, in.construct(t, init, init.Children), init)
+	case isVectorType(t):
+		in.store(loc, in.vectorOf(t, init.Children), init)
+	case t.isString() && init.Operator == "()":
+		in.store(loc, in.construct(t, init, init.Children), init)
+	case len(init.Children) > 0:
+		in.store(loc, in.eval(init.Children[0]), init)
+	}
+}
+
+// fillArray copia una lista {…} en un arreglo; los elementos que faltan
+// quedan en cero
+func (in *interpreter) fillArray(array rtValue, list *models.SyntaxNode) {
+	for i, item := range list.Children {
+		if i >= array.blk.length() {
+			break
+		}
+		element := location{blk: array.blk, index: i}
+		switch elementType := array.t.elementType(); {
+		case item.Kind == NodeInitList && elementType.isArray():
+			in.fillArray(in.load(element, item), item)
+		case item.Kind == NodeStringLiteral && elementType.isArray():
+			in.fillChars(in.load(element, item), unescape(item.Value), item)
+		default:
+			in.store(element, in.eval(item), item)
+		}
+	}
+}
+
+// fillChars copia una cadena literal en un arreglo de char con su '\0'
+func (in *interpreter) fillChars(array rtValue, text string, node *models.SyntaxNode) {
+	for i := 0; i < len(text) && i < array.blk.length(); i++ {
+		array.blk.ints[i] = int64(int8(text[i]))
+	}
+}
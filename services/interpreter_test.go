@@ -0,0 +1,189 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+func TestRunLimits(t *testing.T) {
+	tests := []struct {
+		name       string
+		code       string
+		limits     models.RunLimits
+		status     string
+		diagnostic string
+		exitCode   int
+	}{
+		{
+			name:       "ciclo infinito",
+			code:       "int main() { while (true) {} }",
+			limits:     models.RunLimits{MaxSteps: 10000},
+			status:     RunLimitExceeded,
+			diagnostic: CodeStepLimit,
+			exitCode:   exitKilled,
+		},
+		{
+			name:       "tiempo real",
+			code:       "int main() { long long x = 0; while (true) { x++; } }",
+			limits:     models.RunLimits{TimeLimitMS: 50},
+			status:     RunLimitExceeded,
+			diagnostic: CodeTimeLimit,
+			exitCode:   exitKilled,
+		},
+		{
+			// Cada find recorre un millón de caracteres: no puede costar un
+			// solo paso
+			name: "búsquedas sobre un string grande",
+			code: `#include <string>
+using namespace std;
+int main() {
+    string s(1000000, 'a');
+    int n = 0;
+    for (int i = 0; i < 100000; i++) { n += s.find("b") == string::npos; }
+    return n;
+}`,
+			limits:     models.RunLimits{MaxSteps: 200000},
+			status:     RunLimitExceeded,
+			diagnostic: CodeStepLimit,
+			exitCode:   exitKilled,
+		},
+		{
+			name: "concatenaciones que copian el string",
+			code: `#include <string>
+using namespace std;
+int main() {
+    string s(100000, 'a');
+    for (int i = 0; i < 100000; i++) { s = s + "b"; s.pop_back(); }
+    return 0;
+}`,
+			limits:     models.RunLimits{MaxSteps: 200000},
+			status:     RunLimitExceeded,
+			diagnostic: CodeStepLimit,
+			exitCode:   exitKilled,
+		},
+		{
+			name: "memoria",
+			code: `#include <vector>
+using namespace std;
+int main() {
+    vector<int> v;
+    while (true) { v.push_back(1); }
+}`,
+			limits:     models.RunLimits{MaxMemoryKB: 64},
+			status:     RunLimitExceeded,
+			diagnostic: CodeMemoryLimit,
+			exitCode:   exitKilled,
+		},
+		{
+			name: "salida",
+			code: `#include <iostream>
+using namespace std;
+int main() { while (true) { cout << "linea\n"; } }`,
+			limits:     models.RunLimits{MaxOutputKB: 1},
+			status:     RunLimitExceeded,
+			diagnostic: CodeOutputLimit,
+			exitCode:   exitKilled,
+		},
+		{
+			name:       "recursión infinita",
+			code:       "int f(int n) { return f(n + 1); }\nint main() { return f(0); }",
+			status:     RunRuntimeError,
+			diagnostic: CodeStackOverflow,
+			exitCode:   exitSegv,
+		},
+		{
+			name: "construcción no admitida",
+			code: `#include <cstdlib>
+int main() { system("ls"); return 0; }`,
+			status:     RunRuntimeError,
+			diagnostic: CodeUnsupported,
+			exitCode:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result models.RunResult
+			withTimeout(t, 20*time.Second, func() { result = Run(tt.code, "", tt.limits) })
+			if result.Status != tt.status || result.ExitCode != tt.exitCode {
+				t.Fatalf("estado = %s (salida %d), se esperaba %s (salida %d): %v",
+					result.Status, result.ExitCode, tt.status, tt.exitCode, result.Diagnostics)
+			}
+			if got := diagnosticCodes(result.Diagnostics); len(got) != 1 || got[0] != tt.diagnostic {
+				t.Errorf("códigos = %v, se esperaba [%s]", got, tt.diagnostic)
+			}
+		})
+	}
+}
+
+func TestRunPrograms(t *testing.T) {
+	tests := []struct {
+		name   string
+		code   string
+		stdin  string
+		stdout string
+	}{
+		{
+			name: "parámetro matriz sin primera dimensión",
+			code: `#include <iostream>
+using namespace std;
+int fila(int m[][3], int i) { return m[i][0] + m[i][1] + m[i][2]; }
+void poner(int m[][3]) { m[1][2] = 10; }
+int main() {
+    int m[2][3] = {{1, 2, 3}, {4, 5, 6}};
+    poner(m);
+    cout << fila(m, 0) << " " << fila(m, 1) << endl;
+    return 0;
+}`,
+			stdout: "6 19\n",
+		},
+		{
+			name: "aritmética con una cadena literal",
+			code: `#include <iostream>
+using namespace std;
+int main() {
+    const char* p = "texto";
+    cout << "hola" + 1 << " " << p + 2 << " " << *(p + 1) << endl;
+    return 0;
+}`,
+			stdout: "ola xto e\n",
+		},
+		{
+			name: "string con cadenas literales",
+			code: `#include <iostream>
+#include <string>
+using namespace std;
+int main() {
+    string s = "ab";
+    s += "c";
+    s = "<" + s + '>';
+    char a[] = "xyz";
+    a[0] = 'w';
+    cout << s << " " << (s == "<abc>") << " " << a << " " << sizeof(a) << endl;
+    return 0;
+}`,
+			stdout: "<abc> 1 wyz 4\n",
+		},
+		{
+			name:   "entrada",
+			code:   "#include <iostream>\nusing namespace std;\nint main() { int a, b; cin >> a >> b; cout << a + b; }",
+			stdin:  "2 40",
+			stdout: "42",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result models.RunResult
+			withTimeout(t, 10*time.Second, func() { result = Run(tt.code, tt.stdin, models.RunLimits{}) })
+			if result.Status != RunOK {
+				t.Fatalf("estado = %s: %v", result.Status, result.Diagnostics)
+			}
+			if result.Stdout != tt.stdout {
+				t.Errorf("salida = %q, se esperaba %q", result.Stdout, tt.stdout)
+			}
+		})
+	}
+}
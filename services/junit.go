@@ -0,0 +1,127 @@
+package services
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+// Nombre de la suite y clase de los casos del análisis en JUnit
+const junitAnalysis = "análisis"
+
+// JUnitReport convierte las entregas en un informe JUnit. Las entregas solo
+// analizadas forman una suite con un caso por entrega, que falla si tiene
+// errores. Cada entrega calificada es una suite con un caso para su
+// análisis y uno por caso de prueba.
+func JUnitReport(entries []ReportEntry) models.JUnitTestSuites {
+	report := models.JUnitTestSuites{Name: toolName}
+	analysis := models.JUnitTestSuite{Name: junitAnalysis}
+	for _, e := range entries {
+		if e.Grade == nil {
+			analysis.Cases = append(analysis.Cases, junitAnalysisCase(e))
+		}
+	}
+	if len(analysis.Cases) > 0 {
+		report.Suites = append(report.Suites, analysis)
+	}
+
+	for _, e := range entries {
+		if e.Grade == nil {
+			continue
+		}
+		grade := e.Grade
+		suite := models.JUnitTestSuite{
+			Name: e.reportName(),
+			Properties: &models.JUnitProperties{Properties: []models.JUnitProperty{
+				{Name: "engine", Value: grade.Engine},
+				{Name: "status", Value: grade.Status},
+				{Name: "score", Value: strconv.FormatFloat(grade.Score, 'g', -1, 64)},
+				{Name: "max_score", Value: strconv.FormatFloat(grade.MaxScore, 'g', -1, 64)},
+			}},
+			Cases:     []models.JUnitTestCase{junitAnalysisCase(e)},
+			SystemOut: junitOutput(grade.CompilerOutput),
+		}
+		for _, c := range grade.Cases {
+			suite.Cases = append(suite.Cases, junitGradeCase(e, c))
+		}
+		report.Suites = append(report.Suites, suite)
+	}
+
+	for i := range report.Suites {
+		suite := &report.Suites[i]
+		suite.Tests = len(suite.Cases)
+		for _, c := range suite.Cases {
+			if c.Failure != nil {
+				suite.Failures++
+			}
+			if c.Error != nil {
+				suite.Errors++
+			}
+		}
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+	}
+	return report
+}
+
+// junitAnalysisCase es el caso del análisis de una entrega. Los
+// diagnósticos van en el fallo o, si solo hay advertencias, en la salida.
+func junitAnalysisCase(e ReportEntry) models.JUnitTestCase {
+	r := e.Result
+	c := models.JUnitTestCase{Name: e.reportName(), Classname: junitAnalysis}
+
+	var lines []string
+	firstError := ""
+	for _, d := range AllDiagnostics(r.Analysis) {
+		lines = append(lines, DiagnosticText(submissionFile(r.Name, d.File), d))
+		if d.Severity == SeverityError && firstError == "" {
+			firstError = d.Code
+		}
+	}
+	if semantic := r.Analysis.SemanticAnalysis; semantic != nil && semantic.Status == SemanticSkipped {
+		lines = append(lines, "Análisis semántico: "+strings.ToLower(semantic.Reason[:1])+semantic.Reason[1:])
+	}
+	text := strings.Join(lines, "\n")
+
+	switch {
+	case r.Error != "":
+		c.Error = &models.JUnitFailure{Message: r.Error, Type: "analyzer", Text: text}
+	case r.Errors > 0:
+		c.Failure = &models.JUnitFailure{Message: plural(r.Errors, "error", "errores"), Type: firstError, Text: text}
+	default:
+		c.SystemOut = junitOutput(text)
+	}
+	return c
+}
+
+// junitOutput omite la salida vacía
+func junitOutput(text string) *models.JUnitOutput {
+	if text == "" {
+		return nil
+	}
+	return &models.JUnitOutput{Text: text}
+}
+
+// junitGradeCase es un caso de prueba calificado. Una respuesta incorrecta
+// es un fallo con el diff; un programa que no compila o no termina bien, un
+// error con su salida de errores y sus diagnósticos.
+func junitGradeCase(e ReportEntry, c models.CaseResult) models.JUnitTestCase {
+	result := models.JUnitTestCase{Name: c.Name, Classname: e.reportName()}
+	switch c.Status {
+	case CaseAccepted:
+	case CaseWrongAnswer:
+		result.Failure = &models.JUnitFailure{Message: c.Message, Type: c.Status, Text: c.Diff}
+	default:
+		lines := []string{}
+		if c.Stderr != "" {
+			lines = append(lines, strings.TrimRight(c.Stderr, "\n"))
+		}
+		for _, d := range c.Diagnostics {
+			lines = append(lines, DiagnosticText(submissionFile(e.Result.Name, d.File), d))
+		}
+		result.Error = &models.JUnitFailure{Message: c.Message, Type: c.Status, Text: strings.Join(lines, "\n")}
+	}
+	return result
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+func TestJUnitAnalysisCase(t *testing.T) {
+	undeclared := models.Diagnostic{Code: CodeUndeclared, Severity: SeverityError, Message: "Variable 'y' usada pero no declarada", StartLine: 2, StartColumn: 12}
+	warning := models.Diagnostic{Code: "SEM004", Severity: SeverityWarning, Message: "Variable 'x' declarada pero no usada", StartLine: 1, StartColumn: 5}
+	invalid := submissionWith("luis.cpp", warning, undeclared)
+	invalid.Errors, invalid.Warnings = 1, 1
+	warned := submissionWith("ana.cpp", warning)
+	warned.Warnings = 1
+	skipped := models.SubmissionResult{Name: "eva.cpp", Analysis: models.AnalysisResult{
+		SemanticAnalysis: &models.SemanticResult{Status: SemanticSkipped, Reason: "Se omitió porque el análisis sintáctico encontró 1 error"},
+	}}
+
+	tests := []struct {
+		name    string
+		result  models.SubmissionResult
+		kind    string // failure, error o el texto de system-out
+		message string
+		text    string
+	}{
+		{"sin problemas", submissionWith("a.cpp"), "", "", ""},
+		{"con errores", invalid, "failure", "1 error",
+			"luis.cpp:1:5: advertencia SEM004: Variable 'x' declarada pero no usada\nluis.cpp:2:12: error SEM002: Variable 'y' usada pero no declarada"},
+		{"solo advertencias", warned, "system-out", "", "ana.cpp:1:5: advertencia SEM004: Variable 'x' declarada pero no usada"},
+		{"semántico omitido", skipped, "system-out", "", "Análisis semántico: se omitió porque el análisis sintáctico encontró 1 error"},
+		{"fallo del análisis", models.SubmissionResult{Name: "c.cpp", Error: "El análisis no pudo terminar"}, "error", "El análisis no pudo terminar", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := junitAnalysisCase(ReportEntry{Result: tt.result})
+			if c.Name != tt.result.Name || c.Classname != junitAnalysis {
+				t.Errorf("caso %q de %q", c.Name, c.Classname)
+			}
+			var kind, message, text string
+			switch {
+			case c.Failure != nil:
+				kind, message, text = "failure", c.Failure.Message, c.Failure.Text
+				if c.Failure.Type != CodeUndeclared {
+					t.Errorf("tipo = %q, se esperaba el primer error", c.Failure.Type)
+				}
+			case c.Error != nil:
+				kind, message, text = "error", c.Error.Message, c.Error.Text
+			case c.SystemOut != nil:
+				kind, text = "system-out", c.SystemOut.Text
+			}
+			if kind != tt.kind || message != tt.message || text != tt.text {
+				t.Errorf("resultado = %s %q %q, se esperaba %s %q %q", kind, message, text, tt.kind, tt.message, tt.text)
+			}
+		})
+	}
+}
+
+func TestJUnitReport(t *testing.T) {
+	failed := submissionWith("luis.cpp", models.Diagnostic{Code: CodeUndeclared, Severity: SeverityError})
+	failed.Errors = 1
+	grade := &models.GradeResult{Engine: "interpreter", Status: "graded", Score: 5, MaxScore: 10, Cases: []models.CaseResult{
+		{Name: "suma", Status: CaseAccepted, Passed: true},
+		{Name: "resta", Status: CaseWrongAnswer, Message: "Salida incorrecta", Diff: "-1\n+2"},
+		{Name: "cero", Status: CaseRuntimeError, Message: "División entre cero", Stderr: "panic\n",
+			Diagnostics: []models.Diagnostic{{Code: "RUN001", Severity: SeverityError, Message: "División entre cero", StartLine: 3, StartColumn: 7}}},
+	}}
+
+	report := JUnitReport([]ReportEntry{
+		{Result: submissionWith("ana.cpp")},
+		{Result: submissionWith("eva.cpp"), Grade: grade},
+		{Result: failed},
+	})
+
+	var suites []string
+	for _, s := range report.Suites {
+		var cases []string
+		for _, c := range s.Cases {
+			state := "ok"
+			switch {
+			case c.Failure != nil:
+				state = "failure:" + c.Failure.Type
+			case c.Error != nil:
+				state = "error:" + c.Error.Type
+			}
+			cases = append(cases, c.Classname+"/"+c.Name+"="+state)
+		}
+		suites = append(suites, s.Name+" ["+strings.Join(cases, " ")+"]")
+	}
+	want := []string{
+		"análisis [análisis/ana.cpp=ok análisis/luis.cpp=failure:SEM002]",
+		"eva.cpp [análisis/eva.cpp=ok eva.cpp/suma=ok eva.cpp/resta=failure:wrong_answer eva.cpp/cero=error:runtime_error]",
+	}
+	if !reflect.DeepEqual(suites, want) {
+		t.Errorf("suites = %q\nse esperaba %q", suites, want)
+	}
+
+	if report.Tests != 6 || report.Failures != 2 || report.Errors != 1 {
+		t.Errorf("totales = %d/%d/%d, se esperaba 6 casos, 2 fallos y 1 error", report.Tests, report.Failures, report.Errors)
+	}
+	graded := report.Suites[1]
+	if graded.Tests != 4 || graded.Failures != 1 || graded.Errors != 1 {
+		t.Errorf("suite calificada = %d/%d/%d, se esperaba 4 casos, 1 fallo y 1 error", graded.Tests, graded.Failures, graded.Errors)
+	}
+	var properties []string
+	for _, p := range graded.Properties.Properties {
+		properties = append(properties, p.Name+"="+p.Value)
+	}
+	if want := []string{"engine=interpreter", "status=graded", "score=5", "max_score=10"}; !reflect.DeepEqual(properties, want) {
+		t.Errorf("propiedades = %v, se esperaba %v", properties, want)
+	}
+	if text := graded.Cases[3].Error.Text; text != "panic\neva.cpp:3:7: error RUN001: División entre cero" {
+		t.Errorf("detalle del error = %q", text)
+	}
+}
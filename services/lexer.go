@@ -0,0 +1,378 @@
+package services
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+// Tipos de token emitidos por el scanner
+const (
+	TokenKeyword    = "keyword"
+	TokenIdentifier = "identifier"
+	TokenNumber     = "number"
+	TokenString     = "string"
+	TokenChar       = "char"
+	TokenSymbol     = "symbol"
+	TokenDirective  = "directive"
+	TokenHeader     = "header"
+	TokenError      = "error"
+)
+
+// Palabras reservadas reales de C++ (las que el scanner marca como keyword)
+var cppKeywords = map[string]bool{
+	"int": true, "float": true, "double": true, "char": true, "bool": true, "void": true,
+	"if": true, "else": true, "while": true, "for": true, "do": true, "switch": true,
+	"case": true, "default": true, "break": true, "continue": true, "return": true,
+	"goto": true, "sizeof": true, "typedef": true, "struct": true, "union": true,
+	"enum": true, "class": true, "public": true, "private": true, "protected": true,
+	"virtual": true, "static": true, "const": true, "volatile": true, "extern": true,
+	"register": true, "auto": true, "signed": true, "unsigned": true, "long": true,
+	"short": true, "inline": true, "template": true, "typename": true, "namespace": true,
+	"using": true, "new": true, "delete": true, "this": true, "try": true, "catch": true,
+	"throw": true, "true": true, "false": true, "nullptr": true, "operator": true,
+	"friend": true, "mutable": true, "explicit": true, "constexpr": true,
+	"static_cast": true, "const_cast": true, "reinterpret_cast": true, "dynamic_cast": true,
+}
+
+// Operadores y signos de puntuación ordenados de mayor a menor longitud
+// para aplicar la regla del "maximal munch"
+var cppSymbols = []string{
+	"<<=", ">>=", "...", "->*",
+	"<<", ">>", "<=", ">=", "==", "!=", "&&", "||", "++", "--",
+	"+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "->", "::", ".*",
+	"+", "-", "*", "/", "%", "=", "<", ">", "!", "&", "|", "^", "~",
+	"(", ")", "{", "}", "[", "]", ";", ",", ".", ":", "?", "#",
+}
+
+// scanner recorre el código fuente una sola vez y produce la lista de tokens
+type scanner struct {
+	src         string
+	pos         int // offset en bytes
+	line        int
+	column      int // columna en caracteres, empezando en 1
+	tokens      []models.Token
+	diagnostics []models.Diagnostic
+}
+
+// Tokenize convierte el código fuente en una lista ordenada de tokens.
+// Los comentarios y espacios en blanco se descartan. Los fragmentos que no
+// forman un token válido se emiten como TokenError y además se informan
+// como diagnósticos léxicos.
+func Tokenize(code string) ([]models.Token, []models.Diagnostic) {
+	s := &scanner{src: code, line: 1, column: 1}
+	s.scan()
+	return s.tokens, s.diagnostics
+}
+
+func (s *scanner) scan() {
+	// includeLine indica que estamos dentro de una directiva #include,
+	// donde <archivo> se lee como un único token de cabecera
+	includeLine := 0
+
+	for s.pos < len(s.src) {
+		c := s.src[s.pos]
+
+		if c == '\n' || c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v' {
+			s.advance()
+			continue
+		}
+		// Una barra invertida al final de la línea la une con la siguiente
+		if c == '\\' && (strings.HasPrefix(s.src[s.pos+1:], "\n") || strings.HasPrefix(s.src[s.pos+1:], "\r\n")) {
+			s.advance()
+			continue
+		}
+
+		// Comentarios
+		if strings.HasPrefix(s.src[s.pos:], "//") {
+			for s.pos < len(s.src) && s.src[s.pos] != '\n' {
+				s.advance()
+			}
+			continue
+		}
+		if strings.HasPrefix(s.src[s.pos:], "/*") {
+			s.scanBlockComment()
+			continue
+		}
+
+		start, line, column := s.pos, s.line, s.column
+
+		switch {
+		case c == '#' && s.atLineStart():
+			s.scanDirective()
+			if s.tokens[len(s.tokens)-1].Lexeme == "#include" {
+				includeLine = line
+			}
+			continue
+		case c == '<' && includeLine == s.line:
+			if s.scanHeaderName() {
+				s.emit(TokenHeader, start, line, column)
+				continue
+			}
+		case isIdentStart(c):
+			for s.pos < len(s.src) && isIdentChar(s.src[s.pos]) {
+				s.advance()
+			}
+			kind := TokenIdentifier
+			if cppKeywords[s.src[start:s.pos]] {
+				kind = TokenKeyword
+			}
+			s.emit(kind, start, line, column)
+			continue
+		case isDigit(c) || (c == '.' && s.pos+1 < len(s.src) && isDigit(s.src[s.pos+1])):
+			kind := s.scanNumber()
+			s.emit(kind, start, line, column)
+			if kind == TokenError {
+				s.report(CodeMalformedNumber, "Número mal formado '"+s.src[start:s.pos]+"'")
+			}
+			continue
+		case c == '"':
+			kind := s.scanQuoted('"', TokenString)
+			s.emit(kind, start, line, column)
+			if kind == TokenError {
+				s.report(CodeUnterminatedString, "Cadena sin cerrar")
+			}
+			continue
+		case c == '\'':
+			kind := s.scanQuoted('\'', TokenChar)
+			empty := kind == TokenChar && s.pos-start == 2
+			if empty {
+				kind = TokenError
+			}
+			s.emit(kind, start, line, column)
+			if empty {
+				s.report(CodeEmptyChar, "Carácter literal vacío ''")
+			} else if kind == TokenError {
+				s.report(CodeUnterminatedChar, "Carácter literal sin cerrar")
+			}
+			continue
+		}
+
+		if sym := s.matchSymbol(); sym != "" {
+			for range sym {
+				s.advance()
+			}
+			s.emit(TokenSymbol, start, line, column)
+			continue
+		}
+
+		// Carácter que no pertenece al lenguaje
+		s.advance()
+		s.emit(TokenError, start, line, column)
+		s.report(CodeInvalidCharacter, "Carácter inválido '"+s.src[start:s.pos]+"'")
+	}
+}
+
+// report agrega un diagnóstico léxico sobre el último token emitido
+func (s *scanner) report(code, message string) {
+	tok := s.tokens[len(s.tokens)-1]
+	s.diagnostics = append(s.diagnostics, diagnosticAtToken(code, message, tok))
+}
+
+// advance consume un carácter completo (UTF-8) actualizando línea y columna
+func (s *scanner) advance() {
+	if s.src[s.pos] == '\n' {
+		s.pos++
+		s.line++
+		s.column = 1
+		return
+	}
+	_, size := utf8.DecodeRuneInString(s.src[s.pos:])
+	s.pos += size
+	s.column++
+}
+
+func (s *scanner) emit(kind string, start, line, column int) {
+	s.tokens = append(s.tokens, models.Token{
+		Kind:   kind,
+		Lexeme: s.src[start:s.pos],
+		Line:   line,
+		Column: column,
+		Offset: start,
+	})
+}
+
+// atLineStart indica si solo hay espacios entre el inicio de la línea y la posición actual
+func (s *scanner) atLineStart() bool {
+	for i := s.pos - 1; i >= 0; i-- {
+		switch s.src[i] {
+		case '\n':
+			return true
+		case ' ', '\t', '\r':
+			continue
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (s *scanner) scanBlockComment() {
+	line, column := s.line, s.column
+	s.advance()
+	s.advance()
+	for s.pos < len(s.src) {
+		if strings.HasPrefix(s.src[s.pos:], "*/") {
+			s.advance()
+			s.advance()
+			return
+		}
+		s.advance()
+	}
+	s.diagnostics = append(s.diagnostics,
+		newDiagnostic(CodeUnterminatedComment, "Comentario de bloque sin cerrar", line, column, line, column+2))
+}
+
+// scanDirective lee "#nombre" (permitiendo espacios tras el #) como un único token
+func (s *scanner) scanDirective() {
+	start, line, column := s.pos, s.line, s.column
+	s.advance()
+	for s.pos < len(s.src) && (s.src[s.pos] == ' ' || s.src[s.pos] == '\t') {
+		s.advance()
+	}
+	nameStart := s.pos
+	for s.pos < len(s.src) && isIdentChar(s.src[s.pos]) {
+		s.advance()
+	}
+	s.tokens = append(s.tokens, models.Token{
+		Kind:   TokenDirective,
+		Lexeme: "#" + s.src[nameStart:s.pos],
+		Line:   line,
+		Column: column,
+		Offset: start,
+	})
+}
+
+// scanHeaderName intenta leer <archivo> dentro de un #include
+func (s *scanner) scanHeaderName() bool {
+	end := s.pos + 1
+	for end < len(s.src) && s.src[end] != '>' && s.src[end] != '\n' {
+		end++
+	}
+	if end >= len(s.src) || s.src[end] != '>' {
+		return false
+	}
+	for s.pos <= end {
+		s.advance()
+	}
+	return true
+}
+
+// scanNumber lee enteros (decimales, hexadecimales, binarios, octales) y
+// flotantes con exponente y sufijos. Un número seguido de letras que no
+// forman un sufijo válido (por ejemplo 123abc) se devuelve como error.
+func (s *scanner) scanNumber() string {
+	start := s.pos
+	isFloat := false
+
+	if strings.HasPrefix(s.src[s.pos:], "0x") || strings.HasPrefix(s.src[s.pos:], "0X") ||
+		strings.HasPrefix(s.src[s.pos:], "0b") || strings.HasPrefix(s.src[s.pos:], "0B") {
+		s.advance()
+		s.advance()
+		for s.pos < len(s.src) && (isHexDigit(s.src[s.pos]) || s.src[s.pos] == '\'') {
+			s.advance()
+		}
+	} else {
+		for s.pos < len(s.src) && (isDigit(s.src[s.pos]) || s.src[s.pos] == '\'') {
+			s.advance()
+		}
+		if s.pos < len(s.src) && s.src[s.pos] == '.' {
+			isFloat = true
+			s.advance()
+			for s.pos < len(s.src) && isDigit(s.src[s.pos]) {
+				s.advance()
+			}
+		}
+		if s.pos < len(s.src) && (s.src[s.pos] == 'e' || s.src[s.pos] == 'E') {
+			next := s.pos + 1
+			if next < len(s.src) && (s.src[next] == '+' || s.src[next] == '-') {
+				next++
+			}
+			if next < len(s.src) && isDigit(s.src[next]) {
+				isFloat = true
+				for s.pos < next {
+					s.advance()
+				}
+				for s.pos < len(s.src) && isDigit(s.src[s.pos]) {
+					s.advance()
+				}
+			}
+		}
+	}
+
+	// Sufijos
+	suffixStart := s.pos
+	for s.pos < len(s.src) && isIdentChar(s.src[s.pos]) {
+		s.advance()
+	}
+	if !isValidNumberSuffix(strings.ToLower(s.src[suffixStart:s.pos]), isFloat) {
+		return TokenError
+	}
+	if s.src[start:s.pos] == "." {
+		return TokenError
+	}
+	return TokenNumber
+}
+
+func isValidNumberSuffix(suffix string, isFloat bool) bool {
+	if suffix == "" {
+		return true
+	}
+	if isFloat {
+		return suffix == "f" || suffix == "l"
+	}
+	switch suffix {
+	case "u", "l", "ul", "lu", "ll", "ull", "llu":
+		return true
+	}
+	return false
+}
+
+// scanQuoted lee una cadena o un carácter respetando secuencias de escape.
+// Si la línea termina antes de cerrar la comilla se devuelve un token de error.
+func (s *scanner) scanQuoted(quote byte, kind string) string {
+	s.advance()
+	for s.pos < len(s.src) {
+		c := s.src[s.pos]
+		switch {
+		case c == '\\' && s.pos+1 < len(s.src) && s.src[s.pos+1] != '\n':
+			s.advance()
+			s.advance()
+		case c == '\n':
+			return TokenError
+		case c == quote:
+			s.advance()
+			return kind
+		default:
+			s.advance()
+		}
+	}
+	return TokenError
+}
+
+func (s *scanner) matchSymbol() string {
+	rest := s.src[s.pos:]
+	for _, sym := range cppSymbols {
+		if strings.HasPrefix(rest, sym) {
+			return sym
+		}
+	}
+	return ""
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || isDigit(c)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
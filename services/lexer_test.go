@@ -0,0 +1,118 @@
+package services
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+// tokenText escribe cada token como tipo:lexema@línea:columna
+func tokenText(tokens []models.Token) []string {
+	text := []string{}
+	for _, tok := range tokens {
+		text = append(text, tok.Kind+":"+tok.Lexeme+"@"+strconv.Itoa(tok.Line)+":"+strconv.Itoa(tok.Column))
+	}
+	return text
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want []string
+	}{
+		{
+			name: "directiva e include",
+			code: "#include <iostream>\nint main() { return 0; }",
+			want: []string{
+				"directive:#include@1:1", "header:<iostream>@1:10",
+				"keyword:int@2:1", "identifier:main@2:5", "symbol:(@2:9", "symbol:)@2:10", "symbol:{@2:12",
+				"keyword:return@2:14", "number:0@2:21", "symbol:;@2:22", "symbol:}@2:24",
+			},
+		},
+		{
+			name: "símbolo más largo",
+			code: "a<<=b>>c->d...e::f",
+			want: []string{
+				"identifier:a@1:1", "symbol:<<=@1:2", "identifier:b@1:5", "symbol:>>@1:6", "identifier:c@1:8",
+				"symbol:->@1:9", "identifier:d@1:11", "symbol:...@1:12", "identifier:e@1:15", "symbol:::@1:16", "identifier:f@1:18",
+			},
+		},
+		{
+			name: "literales",
+			code: `x = 0x1Fu + 1.5e-3f + 'a' + "s\"t" + 1'000;`,
+			want: []string{
+				"identifier:x@1:1", "symbol:=@1:3", "number:0x1Fu@1:5", "symbol:+@1:11", "number:1.5e-3f@1:13",
+				"symbol:+@1:21", "char:'a'@1:23", "symbol:+@1:27", `string:"s\"t"@1:29`, "symbol:+@1:36",
+				"number:1'000@1:38", "symbol:;@1:43",
+			},
+		},
+		{
+			name: "comentarios",
+			code: "// línea\n/* bloque\n */ x = 1; // fin",
+			want: []string{"identifier:x@3:5", "symbol:=@3:7", "number:1@3:9", "symbol:;@3:10"},
+		},
+		{
+			// Las columnas cuentan caracteres, no bytes
+			name: "texto no ASCII en una cadena",
+			code: `"año" x`,
+			want: []string{`string:"año"@1:1`, "identifier:x@1:7"},
+		},
+		{
+			name: "código vacío",
+			code: "  \n\t",
+			want: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, diagnostics := Tokenize(tt.code)
+			if len(diagnostics) > 0 {
+				t.Fatalf("diagnósticos inesperados: %v", diagnostics)
+			}
+			if got := tokenText(tokens); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tokens =\n%v\nse esperaba\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenizeDiagnostics(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    string
+		want    string
+		message string
+		token   string // token de error emitido, si hay
+	}{
+		{"carácter inválido", "x = 1 @ 2;", CodeInvalidCharacter, "Carácter inválido '@'", "error:@@1:7"},
+		{"letra no ASCII", "int año;", CodeInvalidCharacter, "Carácter inválido 'ñ'", "error:ñ@1:6"},
+		{"número mal formado", "int 12abc;", CodeMalformedNumber, "Número mal formado '12abc'", "error:12abc@1:5"},
+		{"cadena sin cerrar", `s = "abc`, CodeUnterminatedString, "Cadena sin cerrar", `error:"abc@1:5`},
+		{"carácter sin cerrar", "c = 'a", CodeUnterminatedChar, "Carácter literal sin cerrar", "error:'a@1:5"},
+		{"carácter vacío", "c = '';", CodeEmptyChar, "Carácter literal vacío ''", "error:''@1:5"},
+		{"comentario sin cerrar", "x; /* sin fin", CodeUnterminatedComment, "Comentario de bloque sin cerrar", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, diagnostics := Tokenize(tt.code)
+			if len(diagnostics) != 1 || diagnostics[0].Code != tt.want || diagnostics[0].Message != tt.message {
+				t.Fatalf("diagnósticos = %v, se esperaba %s %q", diagnostics, tt.want, tt.message)
+			}
+			if diagnostics[0].Phase != PhaseLexical || diagnostics[0].Severity != SeverityError {
+				t.Errorf("fase %s y severidad %s, se esperaba un error léxico", diagnostics[0].Phase, diagnostics[0].Severity)
+			}
+			found := tt.token == ""
+			for _, text := range tokenText(tokens) {
+				found = found || text == tt.token
+			}
+			if !found {
+				t.Errorf("tokens = %v, se esperaba %s", tokenText(tokens), tt.token)
+			}
+		})
+	}
+}
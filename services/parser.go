@@ -0,0 +1,2022 @@
+package services
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+// tokenEOF marca el final de la lista de tokens dentro del parser
+const tokenEOF = "eof"
+
+// Palabras reservadas que pueden iniciar o formar parte de un tipo
+var typeKeywords = map[string]bool{
+	"int": true, "float": true, "double": true, "char": true, "bool": true, "void": true,
+	"long": true, "short": true, "unsigned": true, "signed": true, "auto": true,
+}
+
+// Especificadores que pueden preceder al tipo en una declaración
+var declSpecifiers = map[string]bool{
+	"const": true, "constexpr": true, "static": true, "inline": true, "extern": true,
+	"volatile": true, "register": true, "virtual": true, "explicit": true, "friend": true,
+	"mutable": true,
+}
+
+// Niveles de acceso de los miembros de una clase
+var accessKeywords = map[string]bool{"public": true, "protected": true, "private": true}
+
+// Precedencia de los operadores binarios (mayor número, mayor precedencia)
+var binaryPrecedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"|":  3,
+	"^":  4,
+	"&":  5,
+	"==": 6, "!=": 6,
+	"<": 7, "<=": 7, ">": 7, ">=": 7,
+	"<<": 8, ">>": 8,
+	"+": 9, "-": 9,
+	"*": 10, "/": 10, "%": 10,
+}
+
+var assignmentOperators = map[string]bool{
+	"=": true, "+=": true, "-=": true, "*=": true, "/=": true, "%=": true,
+	"&=": true, "|=": true, "^=": true, "<<=": true, ">>=": true,
+}
+
+// Delimitadores que se abren y se cierran, con su nombre para los mensajes
+var closingDelimiters = map[string]string{"(": ")", "[": "]", "{": "}"}
+var delimiterNames = map[string]string{"(": "Paréntesis", "[": "Corchete", "{": "Llave"}
+
+// Palabras que inician una sentencia, donde la recuperación puede continuar
+var statementKeywords = map[string]bool{
+	"if": true, "while": true, "for": true, "do": true, "switch": true,
+	"return": true, "break": true, "continue": true,
+}
+
+// parser de descenso recursivo para el subconjunto de C++ soportado
+type parser struct {
+	tokens    []models.Token
+	pos       int
+	errors    []models.Diagnostic
+	typeNames map[string]bool
+	lastError models.Token
+	errorPos  int    // posición del último error: sin avanzar, no se informa otro
+	storage   string // static o extern del último tipo leído
+	source    *sourceFiles
+
+	// Para ubicar las llaves que faltan o sobran se usa la sangría: la
+	// columna del primer token de cada línea
+	indent     map[int]int
+	misclosed  []closedBlock // bloques cerrados por una '}' con menos sangría
+	earlyClose int           // posición después de una '}' con más sangría que su bloque
+
+	depth   int  // niveles de anidamiento abiertos, acotados por maxNestingDepth
+	tooDeep bool // ya se informó que se superó el límite
+}
+
+// maxNestingDepth limita el anidamiento de sentencias, expresiones, listas
+// de inicialización y argumentos de plantilla. Cada nivel es recursión en
+// el parser y en los análisis del árbol: sin límite, unos cientos de KB de
+// '(' agotan la pila y terminan el proceso, algo que recover no atrapa.
+const maxNestingDepth = 1000
+
+// closedBlock es un bloque y la llave que lo cerró, por sus posiciones
+type closedBlock struct {
+	open, close int
+}
+
+// Parse preprocesa el código, construye el árbol sintáctico y devuelve los
+// errores encontrados (primero los del preprocesador). Ante un error el
+// parser se resincroniza y continúa, de modo que el árbol siempre contiene
+// todo lo que se pudo reconocer; los errores que son consecuencia de otro
+// no se informan.
+func Parse(code string) (*models.SyntaxNode, []models.Diagnostic) {
+	tokens, diagnostics := Preprocess(code)
+	return parseTokens(tokens, diagnostics, nil)
+}
+
+// parseTokens analiza los tokens ya preprocesados y agrega los errores del
+// parser a los diagnósticos del preprocesador. source solo se indica en
+// proyectos, para nombrar el archivo en los mensajes.
+func parseTokens(tokens []models.Token, diagnostics []models.Diagnostic, source *sourceFiles) (*models.SyntaxNode, []models.Diagnostic) {
+	p := newParser(tokens)
+	p.source = source
+	tree := p.parseProgram()
+	return tree, append(diagnostics, p.errors...)
+}
+
+func newParser(tokens []models.Token) *parser {
+	eofLine, eofColumn := 1, 1
+	if len(tokens) > 0 {
+		eofLine, eofColumn = tokenEnd(tokens[len(tokens)-1])
+	}
+	all := make([]models.Token, 0, len(tokens)+1)
+	all = append(all, tokens...)
+	all = append(all, models.Token{Kind: tokenEOF, Line: eofLine, Column: eofColumn})
+
+	indent := make(map[int]int)
+	for _, tok := range tokens {
+		if _, ok := indent[tok.Line]; !ok {
+			indent[tok.Line] = tok.Column
+		}
+	}
+
+	return &parser{
+		tokens:     all,
+		typeNames:  stdlibTypeNames(),
+		lastError:  models.Token{Line: -1},
+		errorPos:   -1,
+		indent:     indent,
+		earlyClose: -1,
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Utilidades sobre el flujo de tokens
+
+func (p *parser) peek() models.Token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) peekAt(n int) models.Token {
+	if p.pos+n >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+n]
+}
+
+func (p *parser) previous() models.Token {
+	if p.pos == 0 {
+		return p.tokens[0]
+	}
+	return p.tokens[p.pos-1]
+}
+
+func (p *parser) next() models.Token {
+	tok := p.tokens[p.pos]
+	if tok.Kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) atEnd() bool {
+	return p.peek().Kind == tokenEOF
+}
+
+// check compara el lexema del token actual. Las cadenas y caracteres
+// incluyen sus comillas, por lo que nunca coinciden con un símbolo.
+func (p *parser) check(lexeme string) bool {
+	return p.peek().Lexeme == lexeme && p.peek().Kind != tokenEOF
+}
+
+func (p *parser) accept(lexeme string) bool {
+	if p.check(lexeme) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(lexeme, code, message string) bool {
+	if p.accept(lexeme) {
+		return true
+	}
+	p.errorAt(p.peek(), code, message)
+	return false
+}
+
+// expectSemicolon informa la falta de ';' en la línea del último token de la sentencia
+func (p *parser) expectSemicolon() bool {
+	if p.accept(";") {
+		return true
+	}
+	last := p.previous()
+	diagnostic := diagnosticAfterToken(CodeMissingSemicolon, "Falta punto y coma", last)
+	diagnostic.Suggestion = "Agregar ';' al final de la sentencia"
+	diagnostic.Fixes = []models.Fix{insertFix("Agregar ';'", diagnostic.StartLine, diagnostic.StartColumn, ";")}
+	p.report(last, diagnostic)
+	return false
+}
+
+func (p *parser) errorAt(tok models.Token, code, message string) {
+	p.report(tok, diagnosticAtToken(code, message, tok))
+}
+
+// report agrega un error sobre el token indicado. Se descarta si repite el
+// token del anterior o si el parser no avanzó desde entonces: en ambos
+// casos es una consecuencia del mismo problema. También se descarta si el
+// error está en un token inválido o lo provoca el que sigue, porque el
+// analizador léxico ya informó ese token.
+func (p *parser) report(tok models.Token, diagnostic models.Diagnostic) {
+	if (tok.Line == p.lastError.Line && tok.Column == p.lastError.Column) || p.pos == p.errorPos {
+		return
+	}
+	p.lastError = tok
+	p.errorPos = p.pos
+	if tok.Kind == TokenError || p.peek().Kind == TokenError {
+		return
+	}
+	p.errors = append(p.errors, diagnostic)
+}
+
+// nest entra en un nivel de anidamiento; si devuelve true, quien llama
+// cierra el nivel con unnest. Al superar el límite se informa una vez y se
+// descarta el resto del archivo: cada nivel abierto fallaría de nuevo.
+func (p *parser) nest() bool {
+	if p.depth < maxNestingDepth {
+		p.depth++
+		return true
+	}
+	tok := p.peek()
+	p.pos = len(p.tokens) - 1
+	if !p.tooDeep {
+		p.tooDeep = true
+		p.errorAt(tok, CodeNestingDepth,
+			"Anidamiento demasiado profundo: se superaron los "+strconv.Itoa(maxNestingDepth)+" niveles; el resto del archivo no se analiza")
+	}
+	return false
+}
+
+func (p *parser) unnest() {
+	p.depth--
+}
+
+// closeDelimiter consume el delimitador que cierra open. Si falta, el error
+// señala el de apertura, que es el que hay que buscar en el código.
+func (p *parser) closeDelimiter(open models.Token) bool {
+	if p.accept(closingDelimiters[open.Lexeme]) {
+		return true
+	}
+	p.unclosed(open, "")
+	return false
+}
+
+// unclosed informa un delimitador que nunca se cerró. hint explica cómo se
+// detectó; si se omite, se indica el token donde se esperaba el cierre.
+func (p *parser) unclosed(open models.Token, hint string) {
+	closing := closingDelimiters[open.Lexeme]
+	if hint == "" {
+		hint = "se esperaba '" + closing + "' antes de " + describeToken(p.peek())
+		if p.atEnd() {
+			hint = "se esperaba '" + closing + "' antes del final del archivo"
+		}
+	}
+	diagnostic := diagnosticAtToken(CodeUnbalanced,
+		delimiterNames[open.Lexeme]+" '"+open.Lexeme+"' sin cerrar: "+hint, open)
+	diagnostic.Suggestion = "Agregar '" + closing + "' para cerrar el '" + open.Lexeme + "' de la línea " + p.source.lineRef(open.Line)
+	p.report(open, diagnostic)
+}
+
+// closeBlock consume la '}' del bloque que empieza en la posición open. Si
+// falta, la sangría indica cuál llave quedó abierta: cuando una '}' cerró
+// un bloque interior con menos sangría que la de su apertura, en realidad
+// correspondía a un bloque exterior, y el interior es el que quedó abierto.
+func (p *parser) closeBlock(open int) bool {
+	if p.check("}") {
+		before, closing := p.previous(), p.next()
+		opening := p.tokens[open]
+		lineStart := p.indent[closing.Line] == closing.Column
+		switch {
+		case lineStart && closing.Column < p.indent[opening.Line]:
+			p.misclosed = append(p.misclosed, closedBlock{open: open, close: p.pos - 1})
+		case lineStart && closing.Column > p.indent[opening.Line],
+			before.Lexeme == "}" && before.Line == closing.Line:
+			p.earlyClose = p.pos
+		}
+		return true
+	}
+
+	for _, block := range p.misclosed {
+		if block.open > open {
+			closing := p.tokens[block.close]
+			p.unclosed(p.tokens[block.open], "por la sangría, la '}' de la línea "+
+				p.source.lineRef(closing.Line)+" cierra un bloque exterior")
+			return false
+		}
+	}
+	p.unclosed(p.tokens[open], "")
+	return false
+}
+
+// startsFunctionDefinition indica si en la posición actual empieza la
+// definición de una función: tipo, nombre, parámetros y '{'. Como no hay
+// funciones anidadas, dentro de un bloque significa que a este le falta
+// su '}'. Solo mira los tokens, sin analizarlos.
+func (p *parser) startsFunctionDefinition() bool {
+	if !p.isDeclarationStart() {
+		return false
+	}
+	i := 0
+	for {
+		tok := p.peekAt(i)
+		isWord := tok.Kind == TokenIdentifier ||
+			(tok.Kind == TokenKeyword && (typeKeywords[tok.Lexeme] || declSpecifiers[tok.Lexeme]))
+		if !isWord && !isPointerOperator(tok) && tok.Lexeme != "::" && tok.Lexeme != "<" &&
+			tok.Lexeme != ">" && tok.Lexeme != ">>" && tok.Lexeme != "," {
+			break
+		}
+		i++
+	}
+	if i < 2 || p.peekAt(i).Lexeme != "(" || p.peekAt(i-1).Kind != TokenIdentifier {
+		return false
+	}
+	for depth := 0; ; i++ {
+		tok := p.peekAt(i)
+		switch {
+		case tok.Kind == tokenEOF, tok.Lexeme == ";", tok.Lexeme == "{", tok.Lexeme == "}":
+			return false
+		case tok.Lexeme == "(":
+			depth++
+		case tok.Lexeme == ")":
+			depth--
+		}
+		if depth == 0 {
+			break
+		}
+	}
+	i++
+	for p.peekAt(i).Lexeme == "const" {
+		i++
+	}
+	return p.peekAt(i).Lexeme == "{"
+}
+
+// describeToken devuelve una representación legible del token para los mensajes
+func describeToken(tok models.Token) string {
+	if tok.Kind == tokenEOF {
+		return "el final del archivo"
+	}
+	return "'" + tok.Lexeme + "'"
+}
+
+// finish ajusta el final del nodo al último token consumido
+func (p *parser) finish(node *models.SyntaxNode) *models.SyntaxNode {
+	last := p.previous()
+	endLine, endColumn := tokenEnd(last)
+	if endLine > node.EndLine || (endLine == node.EndLine && endColumn > node.EndColumn) {
+		node.EndLine, node.EndColumn = endLine, endColumn
+	}
+	return node
+}
+
+// spanNode crea un nodo que abarca desde first hasta last
+func spanNode(kind string, first, last *models.SyntaxNode) *models.SyntaxNode {
+	return &models.SyntaxNode{
+		Kind:      kind,
+		Line:      first.Line,
+		Column:    first.Column,
+		EndLine:   last.EndLine,
+		EndColumn: last.EndColumn,
+	}
+}
+
+// synchronize descarta tokens hasta un punto seguro para continuar:
+// después de un ';', antes de una llave o antes de una palabra que inicia sentencia
+func (p *parser) synchronize() {
+	startLine := p.peek().Line
+	for !p.atEnd() {
+		tok := p.peek()
+		switch tok.Lexeme {
+		case ";":
+			p.next()
+			return
+		case "{", "}":
+			return
+		}
+		if tok.Kind == TokenDirective || (tok.Kind == TokenKeyword && tok.Line > startLine && statementKeywords[tok.Lexeme]) {
+			return
+		}
+		p.next()
+	}
+}
+
+// skipDeclaration descarta el resto de una declaración inválida hasta su
+// ';'. A diferencia de synchronize, los paréntesis, corchetes y llaves se
+// saltan completos: el cuerpo de "enum Color { ROJO, VERDE };" o el
+// inicializador {1, 2, 3} no se leen como código suelto. Como synchronize,
+// se detiene antes de una '}' sin abrir, de una directiva y de una palabra
+// que inicia sentencia en otra línea; dentro de una llave, antes de una
+// definición de función al comienzo de una línea, por si quedó sin cerrar.
+func (p *parser) skipDeclaration() {
+	startLine := p.peek().Line
+	depth := 0
+	for !p.atEnd() {
+		tok := p.peek()
+		switch {
+		case tok.Kind == TokenDirective:
+			return
+		case depth == 0 && tok.Kind == TokenKeyword && tok.Line > startLine && statementKeywords[tok.Lexeme]:
+			return
+		case depth > 0 && p.indent[tok.Line] == tok.Column && p.startsFunctionDefinition():
+			return
+		case tok.Lexeme == "(" || tok.Lexeme == "[" || tok.Lexeme == "{":
+			depth++
+		case tok.Lexeme == ")" || tok.Lexeme == "]":
+			depth = max(depth-1, 0)
+		case tok.Lexeme == "}":
+			if depth == 0 {
+				return
+			}
+			depth--
+		case tok.Lexeme == ";" && depth == 0:
+			p.next()
+			return
+		}
+		p.next()
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Nivel superior
+
+func (p *parser) parseProgram() *models.SyntaxNode {
+	program := &models.SyntaxNode{Kind: NodeProgram, Line: 1, Column: 1, EndLine: 1, EndColumn: 1}
+
+	for !p.atEnd() {
+		start := p.pos
+		if node := p.parseTopLevel(); node != nil {
+			program.Children = append(program.Children, node)
+		}
+		// Garantizar que siempre se avanza
+		if p.pos == start {
+			p.next()
+		}
+	}
+
+	return p.finish(program)
+}
+
+func (p *parser) parseTopLevel() *models.SyntaxNode {
+	tok := p.peek()
+
+	switch {
+	case tok.Kind == TokenDirective:
+		return p.parseDirective()
+	case tok.Lexeme == "using":
+		return p.parseUsing()
+	case tok.Lexeme == ";":
+		p.next()
+		return nil
+	case tok.Lexeme == "}":
+		p.errorAt(tok, CodeUnbalanced, "Llave de cierre '}' sin llave de apertura")
+		p.next()
+		return nil
+	case (tok.Lexeme == "struct" || tok.Lexeme == "class") && tok.Kind == TokenKeyword:
+		return p.parseClass()
+	case p.isConstructorDefinition():
+		// Punto::Punto(...) y Punto::~Punto() no tienen tipo de retorno
+		return p.parseFunction(tok, "")
+	case p.isDeclarationStart():
+		return p.parseDeclarationOrFunction()
+	}
+
+	if p.pos == p.earlyClose {
+		p.parseStrayStatements()
+		return nil
+	}
+	p.errorAt(tok, CodeInvalidDecl, "Declaración inválida fuera de una función: "+describeToken(tok))
+	p.skipDeclaration()
+	return nil
+}
+
+// parseStrayStatements recupera las sentencias que quedaron fuera de una
+// función porque una '}' de más la cerró antes de tiempo. Se informa esa
+// llave y se leen las sentencias hasta la '}' que cerraba la función, sin
+// un error por cada una.
+func (p *parser) parseStrayStatements() {
+	closing := p.previous()
+	diagnostic := diagnosticAtToken(CodeUnbalanced,
+		"Llave de cierre '}' de más: cierra el bloque antes de tiempo y lo que sigue queda fuera de la función", closing)
+	diagnostic.Suggestion = "Quitar la '}' de la línea " + p.source.lineRef(closing.Line)
+	p.report(closing, diagnostic)
+
+	for !p.check("}") && !p.atEnd() && !p.startsFunctionDefinition() {
+		start := p.pos
+		p.parseStatement()
+		if p.pos == start {
+			p.next()
+		}
+	}
+	p.accept("}")
+}
+
+// parseDirective procesa una directiva del preprocesador. Solo #include se
+// representa con detalle; el resto se conserva como nodo Directive.
+func (p *parser) parseDirective() *models.SyntaxNode {
+	tok := p.next()
+
+	if tok.Lexeme != "#include" {
+		node := newNode(NodeDirective, tok)
+		node.Value = tok.Lexeme
+		p.skipLine(tok.Line)
+		return p.finish(node)
+	}
+
+	node := newNode(NodeInclude, tok)
+	target := p.peek()
+	if target.Line == tok.Line && (target.Kind == TokenHeader || target.Kind == TokenString) {
+		p.next()
+		node.Value = target.Lexeme
+		if extra := p.peek(); extra.Line == tok.Line && !p.atEnd() {
+			p.skipLine(tok.Line)
+			diagnostic := diagnosticAtToken(CodeMalformedInclude, "Include mal formado", extra)
+			_, targetEnd := tokenEnd(target)
+			if extra.Lexeme == ";" && p.previous() == extra {
+				diagnostic.Fixes = []models.Fix{replaceFix("Quitar el ';'", tok.Line, targetEnd, tok.Line, extra.Column+1, "")}
+			} else {
+				// Lo que sigue al archivo pasa a su propia línea
+				diagnostic.Fixes = []models.Fix{replaceFix("Pasar '"+extra.Lexeme+"' a la línea siguiente",
+					tok.Line, targetEnd, tok.Line, extra.Column, "\n")}
+			}
+			p.report(extra, diagnostic)
+		}
+		return p.finish(node)
+	}
+
+	start := p.pos
+	p.skipLine(tok.Line)
+	diagnostic := diagnosticAtToken(CodeMalformedInclude, "Include mal formado", tok)
+	if include := includeText(p.tokens[start:p.pos]); include != "" {
+		endLine, endColumn := tokenEnd(p.previous())
+		diagnostic.Fixes = []models.Fix{replaceFix("Escribir '"+include+"'", tok.Line, tok.Column, endLine, endColumn, include)}
+		diagnostic.Suggestion = "Escribir '" + include + "'"
+	}
+	p.report(tok, diagnostic)
+	return p.finish(node)
+}
+
+// includeText reconstruye el #include que se quiso escribir a partir de los
+// tokens que siguen a la directiva: #include iostream → #include <iostream>.
+// Los encabezados de la biblioteca estándar y los escritos con '<' van
+// entre < >; los demás archivos con extensión o con comillas, entre comillas.
+func includeText(tokens []models.Token) string {
+	var name strings.Builder
+	for _, tok := range tokens {
+		name.WriteString(tok.Lexeme)
+	}
+	text := strings.Trim(strings.TrimRight(name.String(), "; "), "<>\" ")
+	if text == "" || strings.ContainsAny(text, "<>\" ") {
+		return ""
+	}
+	_, standard := stdlibCatalogue.Headers[text]
+	quoted := strings.HasPrefix(tokens[0].Lexeme, "\"") || strings.Contains(text, ".")
+	if !standard && !strings.HasPrefix(tokens[0].Lexeme, "<") && quoted {
+		return "#include \"" + text + "\""
+	}
+	return "#include <" + text + ">"
+}
+
+// skipLine consume los tokens restantes de la línea indicada
+func (p *parser) skipLine(line int) {
+	for !p.atEnd() && p.peek().Line == line {
+		p.next()
+	}
+}
+
+func (p *parser) parseUsing() *models.SyntaxNode {
+	tok := p.next()
+	node := newNode(NodeUsing, tok)
+
+	if !p.accept("namespace") || p.peek().Kind != TokenIdentifier {
+		p.errorAt(tok, CodeMalformedUsing, "Declaración using namespace incorrecta")
+		p.synchronize()
+		return nil
+	}
+	node.Value = p.next().Lexeme
+	p.expectSemicolon()
+	return p.finish(node)
+}
+
+// ---------------------------------------------------------------------------
+// Declaraciones
+
+// isTypeName indica si el token actual inicia un nombre de tipo conocido
+func (p *parser) isTypeName() bool {
+	tok := p.peek()
+	switch tok.Kind {
+	case TokenKeyword:
+		return typeKeywords[tok.Lexeme] || declSpecifiers[tok.Lexeme]
+	case TokenIdentifier:
+		if p.typeNames[tok.Lexeme] {
+			return true
+		}
+		return tok.Lexeme == "std" && p.peekAt(1).Lexeme == "::" && p.typeNames[p.peekAt(2).Lexeme]
+	}
+	return false
+}
+
+// isDeclarationStart indica si la sentencia actual es una declaración.
+// Además de los tipos conocidos, "Nombre nombre" se interpreta como una
+// declaración con un tipo que el parser todavía no conoce.
+func (p *parser) isDeclarationStart() bool {
+	if p.isTypeName() {
+		return true
+	}
+	return p.peek().Kind == TokenIdentifier && p.peekAt(1).Kind == TokenIdentifier
+}
+
+// parseType lee especificadores y el tipo base, devolviendo su forma normalizada
+// (por ejemplo "const int", "unsigned int", "string")
+func (p *parser) parseType() (string, bool) {
+	isConst := false
+	storage := ""
+	for declSpecifiers[p.peek().Lexeme] && p.peek().Kind == TokenKeyword {
+		switch lexeme := p.next().Lexeme; lexeme {
+		case "const", "constexpr":
+			isConst = true
+		case "static", "extern":
+			storage = lexeme
+		}
+	}
+	// Los argumentos de plantilla leen sus propios tipos: se asigna al final
+	defer func() { p.storage = storage }()
+
+	var words []string
+	for p.peek().Kind == TokenKeyword && typeKeywords[p.peek().Lexeme] {
+		words = append(words, p.next().Lexeme)
+	}
+
+	base := ""
+	if len(words) > 0 {
+		base = normalizeBuiltinType(words)
+	} else if p.peek().Kind == TokenIdentifier {
+		if p.check("std") && p.peekAt(1).Lexeme == "::" {
+			p.next()
+			p.next()
+		}
+		if p.peek().Kind != TokenIdentifier {
+			p.errorAt(p.peek(), CodeInvalidDecl, "Se esperaba un tipo")
+			return "", false
+		}
+		base = p.next().Lexeme
+		if isStdlibTemplate(base) && p.check("<") {
+			args, ok := p.parseTemplateArgs()
+			if !ok {
+				return "", false
+			}
+			base += "<" + strings.Join(args, ", ") + ">"
+		}
+	} else {
+		p.errorAt(p.peek(), CodeInvalidDecl, "Se esperaba un tipo, se encontró "+describeToken(p.peek()))
+		return "", false
+	}
+
+	if p.accept("const") {
+		isConst = true
+	}
+	if isConst {
+		base = "const " + base
+	}
+	return base, true
+}
+
+// parseTemplateArgs lee los argumentos de una plantilla de la biblioteca
+// estándar: vector<int>, vector<vector<string>>
+func (p *parser) parseTemplateArgs() ([]string, bool) {
+	if !p.nest() {
+		return nil, false
+	}
+	defer p.unnest()
+	open := p.next()
+	var args []string
+	for {
+		if p.isTypeName() {
+			typ, ok := p.parseType()
+			if !ok {
+				return nil, false
+			}
+			args = append(args, p.parsePointerOperators(typ))
+		} else {
+			expr := p.parseUnary()
+			if expr == nil {
+				return nil, false
+			}
+			args = append(args, formatExpr(expr))
+		}
+		if !p.accept(",") {
+			break
+		}
+	}
+	if !p.closeTemplate() {
+		p.errorAt(open, CodeInvalidDecl, "Falta '>' para cerrar los argumentos de la plantilla")
+		return nil, false
+	}
+	return args, true
+}
+
+// closeTemplate consume el '>' que cierra los argumentos de una plantilla.
+// En vector<vector<int>> el lexer produce '>>', que se divide en dos.
+func (p *parser) closeTemplate() bool {
+	if p.accept(">") {
+		return true
+	}
+	if !p.check(">>") {
+		return false
+	}
+	tok := &p.tokens[p.pos]
+	tok.Lexeme = ">"
+	tok.Column++
+	tok.Offset++
+	return true
+}
+
+// normalizeBuiltinType combina palabras como "unsigned long int" en un nombre canónico
+func normalizeBuiltinType(words []string) string {
+	var kept []string
+	unsigned := false
+	for _, w := range words {
+		switch w {
+		case "unsigned":
+			unsigned = true
+		case "signed":
+		default:
+			kept = append(kept, w)
+		}
+	}
+
+	base := strings.Join(kept, " ")
+	switch base {
+	case "", "int":
+		base = "int"
+	case "short int":
+		base = "short"
+	case "long int":
+		base = "long"
+	case "long long int":
+		base = "long long"
+	}
+	if unsigned {
+		return "unsigned " + base
+	}
+	return base
+}
+
+func (p *parser) parseDeclarationOrFunction() *models.SyntaxNode {
+	start := p.peek()
+	typ, ok := p.parseType()
+	if !ok {
+		p.synchronize()
+		return nil
+	}
+
+	// Los '*' y '&' antes del nombre pertenecen al declarador; si después
+	// del nombre viene '(' forman parte del tipo de retorno
+	offset := 0
+	for isPointerOperator(p.peekAt(offset)) {
+		offset++
+	}
+	length := p.functionNameLength(offset)
+	if length == 0 {
+		p.errorAt(p.peekAt(offset), CodeInvalidDecl, "Se esperaba un nombre después del tipo '"+typ+"'")
+		p.synchronize()
+		return nil
+	}
+
+	if p.peekAt(offset+length).Lexeme == "(" {
+		return p.parseFunction(start, p.parsePointerOperators(typ))
+	}
+	return p.parseVarDeclRest(start, typ)
+}
+
+// functionNameLength cuenta los tokens del nombre que empieza en offset:
+// nombre, Clase::nombre, Clase::~Clase u operator<. Devuelve 0 si no hay un nombre.
+func (p *parser) functionNameLength(offset int) int {
+	n := 0
+	for {
+		tok := p.peekAt(offset + n)
+		switch {
+		case tok.Kind == TokenIdentifier:
+			n++
+		case tok.Lexeme == "~" && p.peekAt(offset+n+1).Kind == TokenIdentifier:
+			n += 2
+		case tok.Lexeme == "operator" && tok.Kind == TokenKeyword:
+			// operator() y operator[] ocupan dos tokens
+			next := p.peekAt(offset + n + 1)
+			if next.Kind != TokenSymbol {
+				return 0
+			}
+			n += 2
+			if (next.Lexeme == "(" || next.Lexeme == "[") && p.peekAt(offset+n).Kind == TokenSymbol {
+				n++
+			}
+			return n
+		default:
+			return 0
+		}
+		if p.peekAt(offset+n).Lexeme != "::" {
+			return n
+		}
+		n++
+	}
+}
+
+// isConstructorDefinition reconoce "Clase::Clase(" y "Clase::~Clase(" fuera de la clase
+func (p *parser) isConstructorDefinition() bool {
+	if p.peek().Kind != TokenIdentifier || p.peekAt(1).Lexeme != "::" {
+		return false
+	}
+	offset := 2
+	if p.peekAt(offset).Lexeme == "~" {
+		offset++
+	}
+	return p.peekAt(offset).Lexeme == p.peek().Lexeme && p.peekAt(offset+1).Lexeme == "("
+}
+
+// isPointerOperator reconoce los operadores de declarador '*', '&' y '&&'
+func isPointerOperator(tok models.Token) bool {
+	return tok.Kind == TokenSymbol && (tok.Lexeme == "*" || tok.Lexeme == "&" || tok.Lexeme == "&&")
+}
+
+// parsePointerOperators agrega al tipo los '*' y '&' que preceden al nombre
+// ("int" → "int*"). Un const después de '*' (puntero constante) se acepta
+// pero no se registra.
+func (p *parser) parsePointerOperators(typ string) string {
+	for isPointerOperator(p.peek()) {
+		typ += p.next().Lexeme
+		p.accept("const")
+	}
+	return typ
+}
+
+// parseArraySizes lee las dimensiones "[N]" o "[]" que siguen al nombre,
+// agrega un nodo ArraySize por cada una y devuelve el tipo completo
+// ("int" → "int[3][4]"). ok es false si hubo un error.
+func (p *parser) parseArraySizes(node *models.SyntaxNode, typ string) (string, bool) {
+	for p.check("[") {
+		open := p.next()
+		size := newNode(NodeArraySize, open)
+		if p.check("]") {
+			size.Children = []*models.SyntaxNode{newNode(NodeEmpty, p.peek())}
+			typ += "[]"
+		} else {
+			// El tamaño es una expresión constante, sin asignaciones: en
+			// "int a[3 = {1, 2, 3};" falta el ']' antes del '='
+			expr := p.parseTernary()
+			if expr == nil {
+				return typ, false
+			}
+			size.Children = []*models.SyntaxNode{expr}
+			typ += "[" + formatExpr(expr) + "]"
+		}
+		if !p.closeDelimiter(open) {
+			return typ, false
+		}
+		node.Children = append(node.Children, p.finish(size))
+	}
+	return typ, true
+}
+
+// parseConstructorArgs procesa la inicialización directa "Punto p(1, 2);" o
+// "int x(5);" como una InitList con Operator "()"
+func (p *parser) parseConstructorArgs() *models.SyntaxNode {
+	open := p.next()
+	node := newNode(NodeInitList, open)
+	node.Operator = "()"
+	for !p.check(")") && !p.atEnd() {
+		arg := p.parseAssignment()
+		if arg == nil {
+			return nil
+		}
+		node.Children = append(node.Children, arg)
+		if !p.accept(",") {
+			break
+		}
+	}
+	if !p.closeDelimiter(open) {
+		return nil
+	}
+	return p.finish(node)
+}
+
+// parseInitList procesa una lista de inicialización {a, b, {c, d}}
+func (p *parser) parseInitList() *models.SyntaxNode {
+	if !p.nest() {
+		return nil
+	}
+	defer p.unnest()
+	open := p.next()
+	node := newNode(NodeInitList, open)
+	for !p.check("}") && !p.atEnd() {
+		var item *models.SyntaxNode
+		if p.check("{") {
+			item = p.parseInitList()
+		} else {
+			item = p.parseAssignment()
+		}
+		if item == nil {
+			return nil
+		}
+		node.Children = append(node.Children, item)
+		if !p.accept(",") {
+			break
+		}
+	}
+	if !p.closeDelimiter(open) {
+		return nil
+	}
+	return p.finish(node)
+}
+
+// parseFunction procesa una definición o un prototipo de función
+func (p *parser) parseFunction(start models.Token, returnType string) *models.SyntaxNode {
+	node := newNode(NodeFunction, start)
+	for i := p.functionNameLength(0); i > 0; i-- {
+		node.Value += p.next().Lexeme
+	}
+	node.Type = returnType
+	open := p.next()
+
+	if p.check("void") && p.peekAt(1).Lexeme == ")" {
+		p.next()
+	}
+	for !p.check(")") && !p.atEnd() {
+		param := p.parseParam()
+		if param == nil {
+			break
+		}
+		node.Children = append(node.Children, param)
+		if !p.accept(",") {
+			break
+		}
+	}
+	if !p.closeDelimiter(open) {
+		for !p.atEnd() && !p.check(")") && !p.check("{") && !p.check(";") {
+			p.next()
+		}
+		p.accept(")")
+	}
+
+	// Calificadores de métodos: int getX() const override
+	for p.check("const") || p.check("override") || p.check("final") || p.check("noexcept") {
+		if p.next().Lexeme == "const" {
+			node.Operator = "const"
+		}
+	}
+	// virtual void f() = 0; Punto() = default;
+	if p.check("=") && (p.peekAt(1).Lexeme == "0" || p.peekAt(1).Lexeme == "default" || p.peekAt(1).Lexeme == "delete") {
+		p.next()
+		p.next()
+	}
+	if p.check(":") {
+		p.parseMemberInits(node)
+	}
+
+	switch {
+	case p.check("{"):
+		node.Children = append(node.Children, p.parseBlock())
+	case p.accept(";"):
+	default:
+		p.errorAt(p.previous(), CodeInvalidDecl, "Se esperaba '{' o ';' después de la declaración de '"+node.Value+"'")
+		p.synchronize()
+	}
+	return p.finish(node)
+}
+
+// parseMemberInits procesa la lista de inicialización de un constructor:
+// ": x(a), y{b}"
+func (p *parser) parseMemberInits(function *models.SyntaxNode) {
+	p.next() // :
+	for {
+		nameTok := p.peek()
+		if nameTok.Kind != TokenIdentifier {
+			p.errorAt(nameTok, CodeInvalidDecl, "Se esperaba el nombre de un atributo en la lista de inicialización")
+			return
+		}
+		p.next()
+		node := newNode(NodeMemberInit, nameTok)
+		node.Value = nameTok.Lexeme
+
+		if !p.check("(") && !p.check("{") {
+			p.errorAt(p.peek(), CodeInvalidDecl, "Se esperaba '(' después de '"+nameTok.Lexeme+"' en la lista de inicialización")
+			return
+		}
+		open := p.next()
+		closing := closingDelimiters[open.Lexeme]
+		for !p.check(closing) && !p.atEnd() {
+			arg := p.parseAssignment()
+			if arg == nil {
+				return
+			}
+			node.Children = append(node.Children, arg)
+			if !p.accept(",") {
+				break
+			}
+		}
+		if !p.closeDelimiter(open) {
+			return
+		}
+		function.Children = append(function.Children, p.finish(node))
+		if !p.accept(",") {
+			return
+		}
+	}
+}
+
+func (p *parser) parseParam() *models.SyntaxNode {
+	start := p.peek()
+	typ, ok := p.parseType()
+	if !ok {
+		return nil
+	}
+	node := newNode(NodeParam, start)
+	typ = p.parsePointerOperators(typ)
+	if p.peek().Kind == TokenIdentifier {
+		node.Value = p.next().Lexeme
+	}
+	if typ, ok = p.parseArraySizes(node, typ); !ok {
+		return nil
+	}
+	node.Type = typ
+	if p.accept("=") {
+		if value := p.parseAssignment(); value != nil {
+			node.Children = append(node.Children, value)
+		}
+	}
+	return p.finish(node)
+}
+
+// parseVarDeclRest procesa los declaradores de una declaración de variables
+// cuyo tipo ya fue leído, incluyendo el ';' final
+func (p *parser) parseVarDeclRest(start models.Token, typ string) *models.SyntaxNode {
+	node := newNode(NodeVarDecl, start)
+	node.Type = typ
+	node.Operator = p.storage
+
+	for {
+		declType := p.parsePointerOperators(typ)
+		nameTok := p.peek()
+		if nameTok.Kind != TokenIdentifier {
+			p.errorAt(nameTok, CodeInvalidDecl, "Declaración de variable incorrecta: se esperaba un nombre")
+			p.skipDeclaration()
+			return p.finish(node)
+		}
+		p.next()
+
+		declarator := newNode(NodeDeclarator, nameTok)
+		declarator.Value = nameTok.Lexeme
+		// Definición de un atributo static: int Clase::contador = 0;
+		for p.check("::") && p.peekAt(1).Kind == TokenIdentifier {
+			p.next()
+			declarator.Value += "::" + p.next().Lexeme
+		}
+		declType, ok := p.parseArraySizes(declarator, declType)
+		declarator.Type = declType
+		if !ok {
+			p.skipDeclaration()
+			node.Children = append(node.Children, p.finish(declarator))
+			return p.finish(node)
+		}
+
+		var value *models.SyntaxNode
+		hasInit := true
+		switch {
+		case p.check("{"):
+			value = p.parseInitList()
+		case p.check("("):
+			value = p.parseConstructorArgs()
+		case p.accept("="):
+			if p.check("{") {
+				value = p.parseInitList()
+			} else {
+				value = p.parseAssignment()
+			}
+		default:
+			hasInit = false
+		}
+		if hasInit {
+			if value == nil {
+				p.skipDeclaration()
+				node.Children = append(node.Children, p.finish(declarator))
+				return p.finish(node)
+			}
+			declarator.Children = append(declarator.Children, value)
+		}
+		node.Children = append(node.Children, p.finish(declarator))
+
+		if !p.accept(",") {
+			break
+		}
+	}
+
+	p.expectSemicolon()
+	return p.finish(node)
+}
+
+// parseClass procesa la declaración de un struct o una class, incluyendo
+// el ';' final. "struct Nodo;" es una declaración anticipada sin cuerpo.
+func (p *parser) parseClass() *models.SyntaxNode {
+	keyword := p.next()
+	node := newNode(NodeClass, keyword)
+	node.Type = keyword.Lexeme
+
+	nameTok := p.peek()
+	if nameTok.Kind != TokenIdentifier {
+		p.errorAt(nameTok, CodeInvalidDecl, "Se esperaba el nombre del "+keyword.Lexeme)
+		p.skipDeclaration()
+		return nil
+	}
+	p.next()
+	node.Value = nameTok.Lexeme
+	p.typeNames[node.Value] = true
+
+	if p.accept(";") {
+		return p.finish(node)
+	}
+
+	if p.accept(":") {
+		for {
+			base := newNode(NodeBase, p.peek())
+			base.Operator = "private"
+			if keyword.Lexeme == "struct" {
+				base.Operator = "public"
+			}
+			for accessKeywords[p.peek().Lexeme] || p.check("virtual") {
+				if lexeme := p.next().Lexeme; accessKeywords[lexeme] {
+					base.Operator = lexeme
+				}
+			}
+			if p.peek().Kind != TokenIdentifier {
+				p.errorAt(p.peek(), CodeInvalidDecl, "Se esperaba el nombre de la clase base de '"+node.Value+"'")
+				p.synchronize()
+				return p.finish(node)
+			}
+			base.Value = p.next().Lexeme
+			node.Children = append(node.Children, p.finish(base))
+			if !p.accept(",") {
+				break
+			}
+		}
+	}
+
+	if !p.check("{") {
+		p.errorAt(p.peek(), CodeInvalidDecl, "Se esperaba '{' en la declaración de '"+node.Value+"'")
+		p.synchronize()
+		return p.finish(node)
+	}
+	open := p.pos
+	body := newNode(NodeBlock, p.next())
+	for !p.check("}") && !p.atEnd() {
+		start := p.pos
+		if member := p.parseMember(node.Value); member != nil {
+			body.Children = append(body.Children, member)
+		}
+		if p.pos == start {
+			p.next()
+		}
+	}
+	closed := p.closeBlock(open)
+	node.Children = append(node.Children, p.finish(body))
+
+	if closed && !p.accept(";") {
+		diagnostic := diagnosticAfterToken(CodeMissingSemicolon,
+			"Falta punto y coma después de la declaración de '"+node.Value+"'", p.previous())
+		diagnostic.Suggestion = "Agregar ';' después de la llave de cierre"
+		diagnostic.Fixes = []models.Fix{insertFix("Agregar ';'", diagnostic.StartLine, diagnostic.StartColumn, ";")}
+		p.report(p.previous(), diagnostic)
+	}
+	return p.finish(node)
+}
+
+// parseMember procesa un miembro de una clase: etiqueta de acceso,
+// atributo, método, constructor, destructor o una directiva que quedó en
+// el cuerpo (#pragma)
+func (p *parser) parseMember(className string) *models.SyntaxNode {
+	tok := p.peek()
+
+	switch {
+	case tok.Kind == TokenDirective:
+		return p.parseDirective()
+	case accessKeywords[tok.Lexeme] && p.peekAt(1).Lexeme == ":":
+		node := newNode(NodeAccess, p.next())
+		node.Value = tok.Lexeme
+		p.next()
+		return p.finish(node)
+	case tok.Lexeme == ";":
+		p.next()
+		return nil
+	}
+
+	// Los especificadores como explicit o virtual no se registran
+	start := tok
+	offset := 0
+	for declSpecifiers[p.peekAt(offset).Lexeme] && p.peekAt(offset).Kind == TokenKeyword {
+		offset++
+	}
+	name := p.peekAt(offset)
+	isConstructor := name.Lexeme == className && p.peekAt(offset+1).Lexeme == "("
+	isDestructor := name.Lexeme == "~" && p.peekAt(offset+1).Lexeme == className
+	if isConstructor || isDestructor {
+		p.pos += offset
+		return p.parseFunction(start, "")
+	}
+
+	if p.isDeclarationStart() {
+		return p.parseDeclarationOrFunction()
+	}
+	p.errorAt(tok, CodeInvalidDecl, "Declaración inválida dentro de '"+className+"': "+describeToken(tok))
+	p.synchronize()
+	if p.check("{") {
+		p.parseBlock()
+	}
+	return nil
+}
+
+func (p *parser) parseLocalDeclaration() *models.SyntaxNode {
+	start := p.peek()
+	typ, ok := p.parseType()
+	if !ok {
+		p.synchronize()
+		return nil
+	}
+	return p.parseVarDeclRest(start, typ)
+}
+
+// ---------------------------------------------------------------------------
+// Sentencias
+
+func (p *parser) parseBlock() *models.SyntaxNode {
+	open := p.pos
+	node := newNode(NodeBlock, p.next())
+
+	for !p.check("}") && !p.atEnd() && !p.startsFunctionDefinition() {
+		start := p.pos
+		if stmt := p.parseStatement(); stmt != nil {
+			node.Children = append(node.Children, stmt)
+		}
+		if p.pos == start {
+			p.next()
+		}
+	}
+
+	p.closeBlock(open)
+	return p.finish(node)
+}
+
+func (p *parser) parseStatement() *models.SyntaxNode {
+	if !p.nest() {
+		return nil
+	}
+	defer p.unnest()
+	tok := p.peek()
+
+	if tok.Kind == TokenKeyword {
+		switch tok.Lexeme {
+		case "if":
+			return p.parseIf()
+		case "while":
+			return p.parseWhile()
+		case "do":
+			return p.parseDoWhile()
+		case "for":
+			return p.parseFor()
+		case "switch":
+			return p.parseSwitch()
+		case "return":
+			return p.parseReturn()
+		case "break", "continue":
+			kind := NodeBreak
+			if tok.Lexeme == "continue" {
+				kind = NodeContinue
+			}
+			node := newNode(kind, p.next())
+			p.expectSemicolon()
+			return p.finish(node)
+		case "else":
+			p.errorAt(tok, CodeMisplacedKeyword, "'else' sin 'if' correspondiente")
+			p.next()
+			return nil
+		case "case", "default":
+			p.errorAt(tok, CodeMisplacedKeyword, "'"+tok.Lexeme+"' fuera de un switch")
+			p.skipLabel()
+			return nil
+		}
+	}
+
+	switch {
+	case tok.Lexeme == "{":
+		return p.parseBlock()
+	case tok.Lexeme == ";":
+		return newNode(NodeEmpty, p.next())
+	case tok.Kind == TokenDirective:
+		return p.parseDirective()
+	case p.isDeclarationStart():
+		return p.parseLocalDeclaration()
+	}
+
+	expr := p.parseExpression()
+	if expr == nil {
+		p.synchronize()
+		return nil
+	}
+	node := spanNode(NodeExprStmt, expr, expr)
+	node.Children = []*models.SyntaxNode{expr}
+	p.expectSemicolon()
+	return p.finish(node)
+}
+
+// parseBody procesa el cuerpo de una estructura de control; si falta, usa un nodo Empty
+func (p *parser) parseBody() *models.SyntaxNode {
+	if stmt := p.parseStatement(); stmt != nil {
+		return stmt
+	}
+	return newNode(NodeEmpty, p.previous())
+}
+
+// parseCondition lee "( expresión )" después de una palabra de control
+func (p *parser) parseCondition(keyword models.Token) *models.SyntaxNode {
+	open := p.peek()
+	if !p.accept("(") {
+		p.errorAt(keyword, CodeMalformedControl, "Estructura de control mal formada: se esperaba '(' después de '"+keyword.Lexeme+"'")
+	}
+
+	cond := p.parseExpression()
+	if cond == nil {
+		cond = newNode(NodeEmpty, p.previous())
+		for !p.atEnd() && !p.check(")") && !p.check("{") && !p.check(";") {
+			p.next()
+		}
+	}
+
+	if open.Lexeme == "(" {
+		p.closeDelimiter(open)
+	} else {
+		p.accept(")")
+	}
+	return cond
+}
+
+func (p *parser) parseIf() *models.SyntaxNode {
+	keyword := p.next()
+	node := newNode(NodeIf, keyword)
+	node.Children = append(node.Children, p.parseCondition(keyword), p.parseBody())
+	if p.accept("else") {
+		node.Children = append(node.Children, p.parseBody())
+	}
+	return p.finish(node)
+}
+
+func (p *parser) parseWhile() *models.SyntaxNode {
+	keyword := p.next()
+	node := newNode(NodeWhile, keyword)
+	node.Children = append(node.Children, p.parseCondition(keyword), p.parseBody())
+	return p.finish(node)
+}
+
+func (p *parser) parseDoWhile() *models.SyntaxNode {
+	keyword := p.next()
+	node := newNode(NodeDoWhile, keyword)
+	body := p.parseBody()
+
+	whileTok := p.peek()
+	if !p.accept("while") {
+		p.errorAt(whileTok, CodeMalformedControl, "Estructura de control mal formada: se esperaba 'while' después del cuerpo de 'do'")
+		node.Children = append(node.Children, body, newNode(NodeEmpty, whileTok))
+		return p.finish(node)
+	}
+	node.Children = append(node.Children, body, p.parseCondition(whileTok))
+	p.expectSemicolon()
+	return p.finish(node)
+}
+
+func (p *parser) parseFor() *models.SyntaxNode {
+	keyword := p.next()
+	node := newNode(NodeFor, keyword)
+
+	open := p.peek()
+	if !p.accept("(") {
+		p.errorAt(keyword, CodeMalformedControl, "Estructura de control mal formada: se esperaba '(' después de 'for'")
+	}
+
+	// Inicialización
+	var init *models.SyntaxNode
+	switch {
+	case p.check(";"):
+		init = newNode(NodeEmpty, p.next())
+	case p.isDeclarationStart():
+		if rangeFor := p.tryParseRangeFor(keyword, open); rangeFor != nil {
+			return rangeFor
+		}
+		init = p.parseLocalDeclaration()
+	default:
+		init = p.parseExpressionStatement()
+	}
+	if init == nil {
+		init = newNode(NodeEmpty, p.previous())
+	}
+
+	// Condición
+	cond := newNode(NodeEmpty, p.peek())
+	if !p.check(";") {
+		if expr := p.parseExpression(); expr != nil {
+			cond = expr
+		}
+	}
+	if !p.accept(";") {
+		p.errorAt(p.previous(), CodeMalformedControl, "Estructura de control mal formada: se esperaba ';' en 'for'")
+	}
+
+	// Incremento
+	post := newNode(NodeEmpty, p.peek())
+	if !p.check(")") {
+		if expr := p.parseExpression(); expr != nil {
+			post = expr
+		}
+	}
+	if !p.accept(")") {
+		if open.Lexeme == "(" {
+			p.unclosed(open, "")
+		}
+		for !p.atEnd() && !p.check(")") && !p.check("{") && !p.check(";") {
+			p.next()
+		}
+		p.accept(")")
+	}
+
+	node.Children = append(node.Children, init, cond, post, p.parseBody())
+	return p.finish(node)
+}
+
+// tryParseRangeFor reconoce "for (tipo nombre : rango) cuerpo". Si la
+// declaración no va seguida de ':' restaura la posición y devuelve nil.
+func (p *parser) tryParseRangeFor(keyword, open models.Token) *models.SyntaxNode {
+	start := p.pos
+	errorCount, lastError, errorPos := len(p.errors), p.lastError, p.errorPos
+	startTok := p.peek()
+	typ, ok := p.parseType()
+	if ok {
+		typ = p.parsePointerOperators(typ)
+	}
+	if !ok || p.peek().Kind != TokenIdentifier || p.peekAt(1).Lexeme != ":" {
+		p.pos = start
+		p.errors, p.lastError, p.errorPos = p.errors[:errorCount], lastError, errorPos
+		return nil
+	}
+
+	node := newNode(NodeRangeFor, keyword)
+	decl := newNode(NodeVarDecl, startTok)
+	decl.Type = strings.TrimRight(typ, "*&")
+	declarator := newNode(NodeDeclarator, p.next())
+	declarator.Value = p.previous().Lexeme
+	declarator.Type = typ
+	decl.Children = []*models.SyntaxNode{declarator}
+	decl.EndLine, decl.EndColumn = declarator.EndLine, declarator.EndColumn
+	p.next() // :
+
+	rangeExpr := p.parseExpression()
+	if rangeExpr == nil {
+		p.synchronize()
+		return nil
+	}
+	if open.Lexeme == "(" {
+		p.closeDelimiter(open)
+	} else {
+		p.accept(")")
+	}
+	node.Children = []*models.SyntaxNode{decl, rangeExpr, p.parseBody()}
+	return p.finish(node)
+}
+
+// parseExpressionStatement lee "expresión ;" y devuelve un nodo ExprStmt
+func (p *parser) parseExpressionStatement() *models.SyntaxNode {
+	expr := p.parseExpression()
+	if expr == nil {
+		p.synchronize()
+		return nil
+	}
+	node := spanNode(NodeExprStmt, expr, expr)
+	node.Children = []*models.SyntaxNode{expr}
+	p.expectSemicolon()
+	return p.finish(node)
+}
+
+func (p *parser) parseSwitch() *models.SyntaxNode {
+	keyword := p.next()
+	node := newNode(NodeSwitch, keyword)
+	node.Children = append(node.Children, p.parseCondition(keyword))
+
+	open := p.pos
+	if !p.expect("{", CodeMalformedControl, "Estructura de control mal formada: se esperaba '{' después de 'switch'") {
+		p.synchronize()
+		return p.finish(node)
+	}
+
+	var current *models.SyntaxNode
+	for !p.check("}") && !p.atEnd() && !p.startsFunctionDefinition() {
+		tok := p.peek()
+		start := p.pos
+
+		switch tok.Lexeme {
+		case "case":
+			p.next()
+			current = newNode(NodeCase, tok)
+			value := p.parseTernary()
+			if value == nil {
+				value = newNode(NodeEmpty, tok)
+			}
+			current.Children = append(current.Children, value)
+			p.expect(":", CodeMalformedControl, "Se esperaba ':' después de 'case'")
+			node.Children = append(node.Children, current)
+		case "default":
+			p.next()
+			current = newNode(NodeDefault, tok)
+			p.expect(":", CodeMalformedControl, "Se esperaba ':' después de 'default'")
+			node.Children = append(node.Children, current)
+		default:
+			stmt := p.parseStatement()
+			if current == nil {
+				p.errorAt(tok, CodeMisplacedKeyword, "Sentencia fuera de un 'case' dentro del switch")
+			} else if stmt != nil {
+				current.Children = append(current.Children, stmt)
+				p.finish(current)
+			}
+		}
+
+		if p.pos == start {
+			p.next()
+		}
+	}
+
+	p.closeBlock(open)
+	return p.finish(node)
+}
+
+// skipLabel descarta un "case valor:" o "default:" mal ubicado
+func (p *parser) skipLabel() {
+	line := p.peek().Line
+	for !p.atEnd() && p.peek().Line == line {
+		if p.next().Lexeme == ":" {
+			return
+		}
+	}
+}
+
+func (p *parser) parseReturn() *models.SyntaxNode {
+	node := newNode(NodeReturn, p.next())
+	if !p.check(";") {
+		if value := p.parseExpression(); value != nil {
+			node.Children = append(node.Children, value)
+		} else {
+			p.synchronize()
+			return p.finish(node)
+		}
+	}
+	p.expectSemicolon()
+	return p.finish(node)
+}
+
+// ---------------------------------------------------------------------------
+// Expresiones
+
+// parseExpression incluye el operador coma
+func (p *parser) parseExpression() *models.SyntaxNode {
+	left := p.parseAssignment()
+	if left == nil {
+		return nil
+	}
+	for p.check(",") {
+		op := p.next()
+		right := p.parseAssignment()
+		if right == nil {
+			return nil
+		}
+		node := spanNode(NodeBinary, left, right)
+		node.Operator = op.Lexeme
+		node.Children = []*models.SyntaxNode{left, right}
+		left = node
+	}
+	return left
+}
+
+func (p *parser) parseAssignment() *models.SyntaxNode {
+	if !p.nest() {
+		return nil
+	}
+	defer p.unnest()
+	left := p.parseTernary()
+	if left == nil {
+		return nil
+	}
+
+	tok := p.peek()
+	if tok.Kind == TokenSymbol && assignmentOperators[tok.Lexeme] {
+		p.next()
+		right := p.parseAssignment()
+		if right == nil {
+			return nil
+		}
+		node := spanNode(NodeAssign, left, right)
+		node.Operator = tok.Lexeme
+		node.Children = []*models.SyntaxNode{left, right}
+		return node
+	}
+	return left
+}
+
+func (p *parser) parseTernary() *models.SyntaxNode {
+	cond := p.parseBinary(1)
+	if cond == nil || !p.check("?") {
+		return cond
+	}
+	p.next()
+
+	whenTrue := p.parseExpression()
+	if whenTrue == nil || !p.expect(":", CodeInvalidExpression, "Se esperaba ':' en el operador ternario") {
+		return nil
+	}
+	whenFalse := p.parseAssignment()
+	if whenFalse == nil {
+		return nil
+	}
+
+	node := spanNode(NodeTernary, cond, whenFalse)
+	node.Children = []*models.SyntaxNode{cond, whenTrue, whenFalse}
+	return node
+}
+
+// parseBinary aplica precedencia por escalada para los operadores binarios
+func (p *parser) parseBinary(minPrecedence int) *models.SyntaxNode {
+	left := p.parseUnary()
+	if left == nil {
+		return nil
+	}
+
+	for {
+		tok := p.peek()
+		precedence, ok := binaryPrecedence[tok.Lexeme]
+		if !ok || tok.Kind != TokenSymbol || precedence < minPrecedence {
+			return left
+		}
+		p.next()
+
+		right := p.parseBinary(precedence + 1)
+		if right == nil {
+			return nil
+		}
+		node := spanNode(NodeBinary, left, right)
+		node.Operator = tok.Lexeme
+		node.Children = []*models.SyntaxNode{left, right}
+		left = node
+	}
+}
+
+func (p *parser) parseUnary() *models.SyntaxNode {
+	if !p.nest() {
+		return nil
+	}
+	defer p.unnest()
+	tok := p.peek()
+
+	if tok.Kind == TokenSymbol {
+		switch tok.Lexeme {
+		case "++", "--", "+", "-", "!", "~", "*", "&":
+			p.next()
+			operand := p.parseUnary()
+			if operand == nil {
+				return nil
+			}
+			node := newNode(NodeUnary, tok)
+			node.Operator = tok.Lexeme
+			node.Children = []*models.SyntaxNode{operand}
+			node.EndLine, node.EndColumn = operand.EndLine, operand.EndColumn
+			return node
+		case "(":
+			if cast := p.tryParseCast(); cast != nil {
+				return cast
+			}
+		}
+	}
+
+	if tok.Kind == TokenKeyword {
+		switch tok.Lexeme {
+		case "sizeof":
+			return p.parseSizeof()
+		case "new":
+			return p.parseNew()
+		case "delete":
+			return p.parseDelete()
+		}
+	}
+
+	return p.parsePostfix()
+}
+
+// tryParseCast reconoce un cast al estilo C "(tipo) expresión". Si lo que
+// sigue al paréntesis no es un tipo, restaura la posición y devuelve nil.
+func (p *parser) tryParseCast() *models.SyntaxNode {
+	start := p.pos
+	open := p.next()
+	if !p.isTypeName() {
+		p.pos = start
+		return nil
+	}
+
+	errorCount, lastError, errorPos := len(p.errors), p.lastError, p.errorPos
+	typ, ok := p.parseType()
+	if ok {
+		typ = p.parsePointerOperators(typ)
+	}
+	if !ok || !p.accept(")") {
+		p.pos = start
+		p.errors, p.lastError, p.errorPos = p.errors[:errorCount], lastError, errorPos
+		return nil
+	}
+
+	operand := p.parseUnary()
+	if operand == nil {
+		return nil
+	}
+	node := newNode(NodeCast, open)
+	node.Type = typ
+	node.Operator = "()"
+	node.Children = []*models.SyntaxNode{operand}
+	node.EndLine, node.EndColumn = operand.EndLine, operand.EndColumn
+	return node
+}
+
+func (p *parser) parseSizeof() *models.SyntaxNode {
+	node := newNode(NodeSizeof, p.next())
+
+	if p.check("(") {
+		start := p.pos
+		p.next()
+		if p.isTypeName() {
+			if typ, ok := p.parseType(); ok {
+				typ = p.parsePointerOperators(typ)
+				if p.accept(")") {
+					node.Type = typ
+					return p.finish(node)
+				}
+			}
+		}
+		p.pos = start
+	}
+
+	operand := p.parseUnary()
+	if operand == nil {
+		return nil
+	}
+	node.Children = []*models.SyntaxNode{operand}
+	return p.finish(node)
+}
+
+// parseNew procesa new Tipo, new Tipo(args), new Tipo{args} y new Tipo[n]
+func (p *parser) parseNew() *models.SyntaxNode {
+	node := newNode(NodeNew, p.next())
+	typ, ok := p.parseType()
+	if !ok {
+		return nil
+	}
+	node.Type = p.parsePointerOperators(typ)
+
+	closing := ""
+	switch {
+	case p.check("["):
+		node.Operator = "[]"
+		closing = "]"
+	case p.check("("):
+		closing = ")"
+	case p.check("{"):
+		node.Operator = "{}"
+		closing = "}"
+	default:
+		return p.finish(node)
+	}
+	open := p.next()
+	for !p.check(closing) && !p.atEnd() {
+		arg := p.parseAssignment()
+		if arg == nil {
+			return nil
+		}
+		node.Children = append(node.Children, arg)
+		if !p.accept(",") {
+			break
+		}
+	}
+	if !p.closeDelimiter(open) {
+		return nil
+	}
+	return p.finish(node)
+}
+
+// parseDelete procesa delete p y delete[] p
+func (p *parser) parseDelete() *models.SyntaxNode {
+	node := newNode(NodeDelete, p.next())
+	if p.check("[") && p.peekAt(1).Lexeme == "]" {
+		p.next()
+		p.next()
+		node.Operator = "[]"
+	}
+	operand := p.parseUnary()
+	if operand == nil {
+		return nil
+	}
+	node.Children = []*models.SyntaxNode{operand}
+	return p.finish(node)
+}
+
+func (p *parser) parsePostfix() *models.SyntaxNode {
+	expr := p.parsePrimary()
+	if expr == nil {
+		return nil
+	}
+
+	for {
+		tok := p.peek()
+		switch {
+		case tok.Lexeme == "(" && tok.Kind == TokenSymbol:
+			open := p.next()
+			call := spanNode(NodeCall, expr, expr)
+			call.Children = []*models.SyntaxNode{expr}
+			for !p.check(")") && !p.atEnd() {
+				arg := p.parseAssignment()
+				if arg == nil {
+					break
+				}
+				call.Children = append(call.Children, arg)
+				if !p.accept(",") {
+					break
+				}
+			}
+			if !p.closeDelimiter(open) {
+				return p.finish(call)
+			}
+			expr = p.finish(call)
+		case (tok.Lexeme == "." || tok.Lexeme == "->") && tok.Kind == TokenSymbol:
+			p.next()
+			name := p.peek()
+			if name.Kind != TokenIdentifier {
+				p.errorAt(name, CodeInvalidExpression, "Se esperaba el nombre de un miembro después de '"+tok.Lexeme+"'")
+				return nil
+			}
+			p.next()
+			node := spanNode(NodeMember, expr, expr)
+			node.Value = name.Lexeme
+			node.Operator = tok.Lexeme
+			node.Children = []*models.SyntaxNode{expr}
+			expr = p.finish(node)
+		case tok.Lexeme == "[" && tok.Kind == TokenSymbol:
+			open := p.next()
+			index := p.parseExpression()
+			if index == nil {
+				return nil
+			}
+			node := spanNode(NodeIndex, expr, expr)
+			node.Children = []*models.SyntaxNode{expr, index}
+			if !p.closeDelimiter(open) {
+				return p.finish(node)
+			}
+			expr = p.finish(node)
+		case (tok.Lexeme == "++" || tok.Lexeme == "--") && tok.Kind == TokenSymbol:
+			p.next()
+			node := spanNode(NodePostfix, expr, expr)
+			node.Operator = tok.Lexeme
+			node.Children = []*models.SyntaxNode{expr}
+			expr = p.finish(node)
+		default:
+			return expr
+		}
+	}
+}
+
+func (p *parser) parsePrimary() *models.SyntaxNode {
+	tok := p.peek()
+
+	switch tok.Kind {
+	case TokenNumber:
+		p.next()
+		kind := NodeIntLiteral
+		if isFloatLiteral(tok.Lexeme) {
+			kind = NodeFloatLiteral
+		}
+		node := newNode(kind, tok)
+		node.Value = tok.Lexeme
+		return node
+
+	case TokenString:
+		// Las cadenas adyacentes se concatenan ("a" "b")
+		node := newNode(NodeStringLiteral, tok)
+		for p.peek().Kind == TokenString {
+			lexeme := p.next().Lexeme
+			node.Value += lexeme[1 : len(lexeme)-1]
+		}
+		return p.finish(node)
+
+	case TokenChar:
+		p.next()
+		node := newNode(NodeCharLiteral, tok)
+		node.Value = tok.Lexeme[1 : len(tok.Lexeme)-1]
+		return node
+
+	case TokenIdentifier:
+		// vector<int>(3): el tipo hace de función que crea un objeto temporal
+		offset := 0
+		if tok.Lexeme == "std" && p.peekAt(1).Lexeme == "::" {
+			offset = 2
+		}
+		if isStdlibTemplate(p.peekAt(offset).Lexeme) && p.peekAt(offset+1).Lexeme == "<" {
+			typ, ok := p.parseType()
+			if !ok {
+				return nil
+			}
+			node := newNode(NodeIdentifier, tok)
+			node.Value = typ
+			if !p.check("(") {
+				p.errorAt(p.peek(), CodeInvalidExpression, "Se esperaba '(' después de '"+typ+"'")
+			}
+			return p.finish(node)
+		}
+
+		p.next()
+		node := newNode(NodeIdentifier, tok)
+		node.Value = tok.Lexeme
+		for p.check("::") && p.peekAt(1).Kind == TokenIdentifier {
+			p.next()
+			node.Value += "::" + p.next().Lexeme
+		}
+		return p.finish(node)
+
+	case TokenKeyword:
+		switch tok.Lexeme {
+		case "true", "false":
+			p.next()
+			node := newNode(NodeBoolLiteral, tok)
+			node.Value = tok.Lexeme
+			return node
+		case "nullptr":
+			p.next()
+			return newNode(NodeNullLiteral, tok)
+		case "this":
+			p.next()
+			node := newNode(NodeIdentifier, tok)
+			node.Value = "this"
+			return node
+		case "static_cast", "const_cast", "reinterpret_cast", "dynamic_cast":
+			return p.parseNamedCast()
+		}
+
+	case TokenSymbol:
+		if tok.Lexeme == "(" {
+			p.next()
+			inner := p.parseExpression()
+			if inner == nil {
+				return nil
+			}
+			p.closeDelimiter(tok)
+			return inner
+		}
+
+	case TokenError:
+		// El analizador léxico ya lo informó
+		p.next()
+		return nil
+	}
+
+	if tok.Kind == tokenEOF {
+		p.errorAt(p.previous(), CodeInvalidExpression, "Expresión incompleta al final del archivo")
+	} else {
+		p.errorAt(tok, CodeInvalidExpression, "Expresión inválida: se encontró "+describeToken(tok))
+	}
+	return nil
+}
+
+// parseNamedCast procesa static_cast<tipo>(expresión) y sus variantes
+func (p *parser) parseNamedCast() *models.SyntaxNode {
+	keyword := p.next()
+	node := newNode(NodeCast, keyword)
+	node.Operator = keyword.Lexeme
+
+	if !p.expect("<", CodeInvalidExpression, "Se esperaba '<' después de '"+keyword.Lexeme+"'") {
+		return nil
+	}
+	typ, ok := p.parseType()
+	if !ok {
+		return nil
+	}
+	node.Type = p.parsePointerOperators(typ)
+	if !p.expect(">", CodeInvalidExpression, "Se esperaba '>' en '"+keyword.Lexeme+"'") {
+		return nil
+	}
+	open := p.peek()
+	if !p.expect("(", CodeInvalidExpression, "Se esperaba '(' en '"+keyword.Lexeme+"'") {
+		return nil
+	}
+	operand := p.parseExpression()
+	if operand == nil {
+		return nil
+	}
+	node.Children = []*models.SyntaxNode{operand}
+	p.closeDelimiter(open)
+	return p.finish(node)
+}
+
+// isFloatLiteral distingue literales flotantes de enteros (incluyendo hexadecimales)
+func isFloatLiteral(lexeme string) bool {
+	lower := strings.ToLower(lexeme)
+	if strings.HasPrefix(lower, "0x") || strings.HasPrefix(lower, "0b") {
+		return false
+	}
+	return strings.ContainsAny(lower, ".e")
+}
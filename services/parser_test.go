@@ -0,0 +1,165 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+func TestParserRecovery(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    string
+		want    []string
+		message string // texto que debe aparecer en el primer diagnóstico
+	}{
+		{
+			name: "directiva dentro de una clase",
+			code: "class A {\n#pragma pack(1)\n    int x;\n};\nint main() { return 0; }\n",
+			want: []string{},
+		},
+		{
+			// El analizador léxico ya informa los tokens inválidos
+			name: "carácter vacío",
+			code: "int main() { char c = ''; return 0; }\n",
+			want: []string{},
+		},
+		{
+			name: "carácter inválido entre operandos",
+			code: "int main() { int x = 3 @ 4; return x; }\n",
+			want: []string{},
+		},
+		{
+			name:    "error después de un carácter inválido",
+			code:    "int main() {\n    int x = 3 @ 4;\n    int y = 5\n    return x + y;\n}\n",
+			want:    []string{CodeMissingSemicolon},
+			message: "Falta punto y coma",
+		},
+		{
+			name:    "enum no admitido",
+			code:    "enum Color { ROJO, VERDE };\nint main() { return 0; }\n",
+			want:    []string{CodeInvalidDecl},
+			message: "'enum'",
+		},
+		{
+			name:    "corchete sin cerrar en un global",
+			code:    "int a[3 = {1, 2, 3};\nint main() { return a[0]; }\n",
+			want:    []string{CodeUnbalanced},
+			message: "Corchete '[' sin cerrar",
+		},
+		{
+			name:    "corchete sin cerrar en un local",
+			code:    "int main() {\n    int a[3 = {1, 2, 3};\n    return 0;\n}\n",
+			want:    []string{CodeUnbalanced},
+			message: "Corchete '[' sin cerrar",
+		},
+		{
+			name:    "llave de cierre de más",
+			code:    "int main() { return 0; }\n}\n",
+			want:    []string{CodeUnbalanced},
+			message: "sin llave de apertura",
+		},
+		{
+			name:    "clase sin cerrar",
+			code:    "class A {\n    int x;\n",
+			want:    []string{CodeUnbalanced, CodeMissingMain},
+			message: "Llave '{' sin cerrar",
+		},
+		{
+			name: "solo delimitadores",
+			code: ")))] ]]} {{{ ((( ;;; }}}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result models.SyntaxResult
+			withTimeout(t, 2*time.Second, func() { result = AnalyzeSyntax(tt.code) })
+			if tt.want == nil {
+				return
+			}
+			if got := diagnosticCodes(result.Diagnostics); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("códigos = %v, se esperaba %v (%v)", got, tt.want, result.Diagnostics)
+			}
+			if tt.message != "" && !strings.Contains(result.Diagnostics[0].Message, tt.message) {
+				t.Errorf("mensaje = %q, se esperaba que contuviera %q", result.Diagnostics[0].Message, tt.message)
+			}
+		})
+	}
+}
+
+// Cada prefijo de un programa válido es un programa con errores en un punto
+// distinto; el parser debe terminar con todos
+func TestParserTerminatesOnPrefixes(t *testing.T) {
+	code := `#include <iostream>
+#include <vector>
+#define MAX(a, b) ((a) > (b) ? (a) : (b))
+using namespace std;
+
+struct Punto {
+#pragma once
+    int x, y;
+    Punto(int x, int y) : x(x), y(y) {}
+    int suma() const { return x + y; }
+};
+
+template <typename T>
+T mayor(const vector<T>& v) {
+    T m = v[0];
+    for (const T& e : v) { m = MAX(m, e); }
+    return m;
+}
+
+int main() {
+    int a[3] = {1, 2, 3};
+    char c = 'x';
+    string s = "hola" + string("!");
+    Punto p(1, 2);
+    switch (a[0]) { case 1: break; default: return 1; }
+    do { a[0]--; } while (a[0] > 0);
+    cout << mayor(vector<int>{a[0], a[1]}) << p.suma() << c << s << endl;
+    return 0;
+}
+`
+	withTimeout(t, 20*time.Second, func() {
+		for i := range code {
+			AnalyzeSyntax(code[:i])
+		}
+	})
+}
+
+func TestParserNestingDepth(t *testing.T) {
+	nested := func(open, inner, close string, n int) string {
+		return strings.Repeat(open, n) + inner + strings.Repeat(close, n)
+	}
+	const n = 50000
+	tests := []struct {
+		name string
+		code string
+		want []string
+	}{
+		{"paréntesis", "int main() { int x = " + nested("(", "1", ")", n) + "; return x; }", []string{CodeNestingDepth}},
+		{"operadores unarios", "int main() { int x = " + strings.Repeat("-", n) + "1; return x; }", []string{CodeNestingDepth}},
+		{"asignaciones encadenadas", "int main() { int x; x" + strings.Repeat(" = x", n) + "; return x; }", []string{CodeNestingDepth}},
+		{"llamadas", "int f(int x) { return x; }\nint main() { return " + nested("f(", "1", ")", n) + "; }", []string{CodeNestingDepth}},
+		{"ternarios", "int main() { int x = 1; return " + strings.Repeat("x ? x : ", n) + "x; }", []string{CodeNestingDepth}},
+		{"bloques", "int main() { " + nested("{", "", "}", n) + " return 0; }", []string{CodeNestingDepth}},
+		{"else if", "int main() { int x = 0; " + strings.Repeat("if (x) x++; else ", n) + "x++; return x; }", []string{CodeNestingDepth}},
+		{"lista de inicialización", "int main() { int a[1] = " + nested("{", "1", "}", n) + "; return 0; }", []string{CodeNestingDepth}},
+		{"plantillas", "#include <vector>\nint main() { std::" + nested("vector<", "int", ">", n) + " v; return 0; }", []string{CodeNestingDepth}},
+		{"anidamiento razonable", "int main() { int x = " + nested("(", "1", ")", 200) + "; return x; }", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result models.SyntaxResult
+			withTimeout(t, 10*time.Second, func() { result = AnalyzeSyntax(tt.code) })
+			if got := diagnosticCodes(result.Diagnostics); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("códigos = %v, se esperaba %v", got, tt.want)
+			}
+		})
+	}
+}
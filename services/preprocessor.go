@@ -0,0 +1,879 @@
+package services
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+// macro es una macro definida con #define. Las macros de función tienen
+// parámetros; __VA_ARGS__ recibe los argumentos sobrantes si es variádica.
+type macro struct {
+	name     string
+	function bool
+	params   []string
+	variadic bool
+	body     []models.Token
+	line     int
+}
+
+func (m *macro) param(name string) int {
+	if !m.function {
+		return -1
+	}
+	for i, param := range m.params {
+		if param == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// sameDefinition indica si dos definiciones son idénticas, en cuyo caso
+// redefinir la macro no es un error
+func (m *macro) sameDefinition(other *macro) bool {
+	if m.function != other.function || strings.Join(m.params, ",") != strings.Join(other.params, ",") ||
+		len(m.body) != len(other.body) {
+		return false
+	}
+	for i, tok := range m.body {
+		if tok.Lexeme != other.body[i].Lexeme {
+			return false
+		}
+	}
+	return true
+}
+
+// conditional es un bloque #if/#ifdef/#ifndef abierto
+type conditional struct {
+	directive    models.Token
+	parentActive bool // el bloque que lo contiene se procesa
+	active       bool // la rama actual se procesa
+	taken        bool // alguna rama anterior ya se eligió
+	sawElse      bool
+}
+
+// ppToken es un token durante la expansión junto con las macros que ya se
+// expandieron para producirlo y que no deben volver a expandirse en él
+type ppToken struct {
+	models.Token
+	hidden []string
+}
+
+func (t ppToken) isHidden(name string) bool {
+	for _, hidden := range t.hidden {
+		if hidden == name {
+			return true
+		}
+	}
+	return false
+}
+
+// preprocessor procesa las directivas y expande las macros sobre la lista
+// de tokens del scanner, antes del análisis sintáctico
+type preprocessor struct {
+	continued   map[int]bool // líneas que terminan en '\'
+	macros      map[string]*macro
+	conditions  []*conditional
+	output      []models.Token
+	diagnostics []models.Diagnostic
+
+	// Solo en proyectos de varios archivos: archivos en proceso de
+	// inclusión, del punto de entrada al actual, y los marcados con #pragma once
+	source    *sourceFiles
+	including []string
+	once      map[string]bool
+	guards    map[string]string // macro de la guarda #ifndef/#define de cada archivo
+
+	// Expansiones y tokens producidos, acotados por maxExpansions y
+	// maxExpandedTokens
+	expansions     int
+	expandedTokens int
+	limited        bool
+}
+
+// Preprocess tokeniza el código y aplica el preprocesador: #define, #undef
+// y la compilación condicional. Los tokens expandidos conservan la posición
+// de la macro en el código y la de su #define en OriginLine/OriginColumn.
+// Las directivas procesadas quedan como un único token para el parser; las
+// ramas descartadas por #if no aparecen en el resultado.
+func Preprocess(code string) ([]models.Token, []models.Diagnostic) {
+	tokens, _ := Tokenize(code)
+	pp := newPreprocessor()
+	pp.addContinuedLines(code, 0)
+	pp.run(tokens)
+	return pp.output, pp.diagnostics
+}
+
+func newPreprocessor() *preprocessor {
+	return &preprocessor{
+		continued: make(map[int]bool),
+		macros: map[string]*macro{
+			"__cplusplus": {name: "__cplusplus", body: []models.Token{{Kind: TokenNumber, Lexeme: "201703L"}}},
+		},
+		once:   make(map[string]bool),
+		guards: make(map[string]string),
+	}
+}
+
+// addContinuedLines registra las líneas unidas a la siguiente con '\'
+func (pp *preprocessor) addContinuedLines(code string, base int) {
+	for i, line := range strings.Split(code, "\n") {
+		if strings.HasSuffix(strings.TrimRight(line, " \t\r"), "\\") {
+			pp.continued[base+i+1] = true
+		}
+	}
+}
+
+// runFile procesa un archivo del proyecto con sus líneas desplazadas al
+// rango que le corresponde en la unidad de traducción
+func (pp *preprocessor) runFile(name string) {
+	code := pp.source.files[name]
+	base := pp.source.base(name)
+	tokens, _ := Tokenize(code)
+	for i := range tokens {
+		tokens[i].Line += base
+	}
+	pp.addContinuedLines(code, base)
+	pp.guards[name] = includeGuard(tokens)
+
+	pp.including = append(pp.including, name)
+	pp.run(tokens)
+	pp.including = pp.including[:len(pp.including)-1]
+}
+
+// include procesa #include "archivo" en un proyecto: el contenido del
+// archivo se inserta a continuación de la directiva
+func (pp *preprocessor) include(target models.Token) {
+	from := pp.including[len(pp.including)-1]
+	file, err := strconv.Unquote(target.Lexeme)
+	if err != nil {
+		file = strings.Trim(target.Lexeme, `"`)
+	}
+	name, ok := pp.source.resolve(from, file)
+	if !ok {
+		pp.report(diagnosticAtToken(CodeIncludeNotFound,
+			"No se encontró el archivo '"+file+"' incluido desde "+from, target))
+		return
+	}
+	if pp.once[name] {
+		return
+	}
+	if _, ok := pp.source.includedAt[name]; !ok && name != pp.including[0] {
+		pp.source.includedAt[name] = position{target.Line, target.Column}
+	}
+	for i, open := range pp.including {
+		if open == name {
+			// Con la guarda ya definida la inclusión no agrega nada
+			if guard := pp.guards[name]; guard != "" && pp.macros[guard] != nil {
+				return
+			}
+			pp.report(diagnosticAtToken(CodeIncludeCycle,
+				"Inclusión circular: "+includeChain(pp.including[i:], name), target))
+			return
+		}
+	}
+	pp.runFile(name)
+}
+
+func (pp *preprocessor) report(diagnostic models.Diagnostic) {
+	pp.diagnostics = append(pp.diagnostics, diagnostic)
+}
+
+// active indica si las líneas actuales se compilan
+func (pp *preprocessor) active() bool {
+	return len(pp.conditions) == 0 || pp.conditions[len(pp.conditions)-1].active
+}
+
+// includeGuard reconoce la guarda de un encabezado: un archivo que empieza
+// con #ifndef X seguido de #define X
+func includeGuard(tokens []models.Token) string {
+	if len(tokens) < 4 || tokens[0].Lexeme != "#ifndef" || tokens[2].Lexeme != "#define" ||
+		tokens[1].Lexeme != tokens[3].Lexeme || tokens[1].Line != tokens[0].Line {
+		return ""
+	}
+	return tokens[1].Lexeme
+}
+
+// run procesa los tokens de un archivo. Los bloques condicionales deben
+// cerrarse en el mismo archivo en que se abren.
+func (pp *preprocessor) run(tokens []models.Token) {
+	opened := len(pp.conditions)
+	var text []models.Token
+	flush := func() {
+		pp.output = append(pp.output, pp.expand(wrapTokens(text))...)
+		text = nil
+	}
+
+	for i := 0; i < len(tokens); {
+		tok := tokens[i]
+		if tok.Kind != TokenDirective {
+			if pp.active() {
+				text = append(text, tok)
+			}
+			i++
+			continue
+		}
+
+		// Una directiva ocupa su línea y las que se le unen con '\'
+		last := tok.Line
+		for pp.continued[last] {
+			last++
+		}
+		end := i + 1
+		for end < len(tokens) && tokens[end].Line <= last {
+			end++
+		}
+		flush()
+		pp.directive(tok, tokens[i+1:end])
+		i = end
+	}
+	flush()
+
+	for _, open := range pp.conditions[opened:] {
+		pp.report(diagnosticAtToken(CodeUnbalancedIf,
+			"Falta #endif para el "+open.directive.Lexeme+" de la línea "+pp.source.lineRef(open.directive.Line),
+			open.directive))
+	}
+	pp.conditions = pp.conditions[:opened]
+}
+
+func (pp *preprocessor) directive(tok models.Token, args []models.Token) {
+	name := strings.TrimPrefix(tok.Lexeme, "#")
+	if pp.conditional(name, tok, args) || !pp.active() {
+		return
+	}
+
+	switch name {
+	case "define":
+		pp.define(tok, args)
+	case "undef":
+		if len(args) == 0 || !isMacroName(args[0]) {
+			pp.report(diagnosticAtToken(CodeMalformedDirective, "Falta el nombre de la macro en #undef", tok))
+		} else {
+			delete(pp.macros, args[0].Lexeme)
+		}
+	case "include":
+		// El parser valida el archivo incluido
+		pp.output = append(pp.output, tok)
+		pp.output = append(pp.output, args...)
+		if pp.source != nil && len(args) == 1 && args[0].Kind == TokenString {
+			pp.include(args[0])
+		}
+		return
+	case "error", "warning":
+		code := CodeErrorDirective
+		message := "#" + name
+		if text := joinTokens(args); text != "" {
+			message += ": " + text
+		}
+		diagnostic := diagnosticAtToken(code, message, tok)
+		if name == "warning" {
+			diagnostic.Severity = SeverityWarning
+		}
+		pp.report(diagnostic)
+	case "pragma":
+		if len(args) > 0 && args[0].Lexeme == "once" && len(pp.including) > 0 {
+			pp.once[pp.including[len(pp.including)-1]] = true
+		}
+	case "line", "":
+	default:
+		pp.report(diagnosticAtToken(CodeUnknownDirective, "Directiva desconocida '"+tok.Lexeme+"'", tok))
+	}
+	pp.output = append(pp.output, tok)
+}
+
+// conditional procesa #if, #ifdef, #ifndef, #elif, #else y #endif.
+// Devuelve false si la directiva no es condicional.
+func (pp *preprocessor) conditional(name string, tok models.Token, args []models.Token) bool {
+	var top *conditional
+	if n := len(pp.conditions); n > 0 {
+		top = pp.conditions[n-1]
+	}
+
+	switch name {
+	case "if", "ifdef", "ifndef":
+		c := &conditional{directive: tok, parentActive: pp.active()}
+		if c.parentActive {
+			c.active = pp.condition(name, tok, args)
+			c.taken = c.active
+		}
+		pp.conditions = append(pp.conditions, c)
+		if c.parentActive {
+			pp.output = append(pp.output, tok)
+		}
+		return true
+
+	case "elif", "else", "endif":
+		if top == nil {
+			pp.report(diagnosticAtToken(CodeUnbalancedIf, tok.Lexeme+" sin #if", tok))
+			return true
+		}
+		if top.sawElse && name != "endif" {
+			pp.report(diagnosticAtToken(CodeUnbalancedIf,
+				tok.Lexeme+" después de #else (el #if es de la línea "+pp.source.lineRef(top.directive.Line)+")", tok))
+			return true
+		}
+		switch name {
+		case "elif":
+			top.active = top.parentActive && !top.taken && pp.condition("if", tok, args)
+			top.taken = top.taken || top.active
+		case "else":
+			top.active = top.parentActive && !top.taken
+			top.taken = true
+			top.sawElse = true
+		case "endif":
+			pp.conditions = pp.conditions[:len(pp.conditions)-1]
+		}
+		if top.parentActive {
+			pp.output = append(pp.output, tok)
+		}
+		return true
+	}
+	return false
+}
+
+// condition evalúa la condición de #if, #ifdef o #ifndef
+func (pp *preprocessor) condition(name string, tok models.Token, args []models.Token) bool {
+	if name != "if" {
+		if len(args) == 0 || !isMacroName(args[0]) {
+			pp.report(diagnosticAtToken(CodeMalformedDirective, "Falta el nombre de la macro en "+tok.Lexeme, tok))
+			return false
+		}
+		_, defined := pp.macros[args[0].Lexeme]
+		return defined == (name == "ifdef")
+	}
+
+	if len(args) == 0 {
+		pp.report(diagnosticAtToken(CodeMalformedDirective, "Falta la condición en "+tok.Lexeme, tok))
+		return false
+	}
+	expr := &ppExpr{tokens: pp.expand(wrapTokens(pp.replaceDefined(args)))}
+	value := expr.parseConditional()
+	if expr.err == "" && expr.pos < len(expr.tokens) {
+		expr.err = "no se esperaba '" + expr.tokens[expr.pos].Lexeme + "'"
+	}
+	if expr.err != "" {
+		pp.report(diagnosticAtToken(CodeMalformedDirective, "Condición inválida en "+tok.Lexeme+": "+expr.err, tok))
+		return false
+	}
+	return value != 0
+}
+
+// replaceDefined reemplaza defined(X) y defined X por 1 o 0 antes de
+// expandir las macros de la condición
+func (pp *preprocessor) replaceDefined(args []models.Token) []models.Token {
+	var result []models.Token
+	for i := 0; i < len(args); i++ {
+		if args[i].Lexeme != "defined" {
+			result = append(result, args[i])
+			continue
+		}
+		j := i + 1
+		parens := j < len(args) && args[j].Lexeme == "("
+		if parens {
+			j++
+		}
+		if j >= len(args) || !isMacroName(args[j]) || (parens && (j+1 >= len(args) || args[j+1].Lexeme != ")")) {
+			result = append(result, args[i])
+			continue
+		}
+		value := "0"
+		if _, ok := pp.macros[args[j].Lexeme]; ok {
+			value = "1"
+		}
+		result = append(result, models.Token{Kind: TokenNumber, Lexeme: value, Line: args[i].Line, Column: args[i].Column, Offset: args[i].Offset})
+		if parens {
+			j++
+		}
+		i = j
+	}
+	return result
+}
+
+// define registra una macro: #define N 10 o #define MAX(a, b) ((a) > (b) ? (a) : (b))
+func (pp *preprocessor) define(tok models.Token, args []models.Token) {
+	if len(args) == 0 || !isMacroName(args[0]) {
+		pp.report(diagnosticAtToken(CodeMalformedDirective, "Falta el nombre de la macro en #define", tok))
+		return
+	}
+	nameTok := args[0]
+	m := &macro{name: nameTok.Lexeme, line: tok.Line}
+	if m.name == "defined" {
+		pp.report(diagnosticAtToken(CodeMalformedDirective, "'defined' no puede usarse como nombre de macro", nameTok))
+		return
+	}
+
+	rest := args[1:]
+	// Es macro de función solo si '(' va pegado al nombre
+	if len(rest) > 0 && rest[0].Lexeme == "(" && rest[0].Offset == nameTok.Offset+len(nameTok.Lexeme) {
+		m.function = true
+		i := 1
+		for i < len(rest) && rest[i].Lexeme != ")" {
+			switch {
+			case rest[i].Lexeme == "..." && !m.variadic:
+				m.variadic = true
+				m.params = append(m.params, "__VA_ARGS__")
+			case isMacroName(rest[i]) && !m.variadic && m.param(rest[i].Lexeme) < 0:
+				m.params = append(m.params, rest[i].Lexeme)
+			default:
+				pp.report(diagnosticAtToken(CodeMalformedDirective,
+					"Parámetro inválido en la macro '"+m.name+"': "+describeToken(rest[i]), rest[i]))
+				return
+			}
+			i++
+			if i < len(rest) && rest[i].Lexeme == "," {
+				i++
+			}
+		}
+		if i >= len(rest) {
+			pp.report(diagnosticAtToken(CodeMalformedDirective,
+				"Falta ')' en los parámetros de la macro '"+m.name+"'", nameTok))
+			return
+		}
+		rest = rest[i+1:]
+	}
+
+	body, ok := pp.macroBody(m, rest)
+	if !ok {
+		return
+	}
+	m.body = body
+
+	if previous := pp.macros[m.name]; previous != nil && !previous.sameDefinition(m) {
+		message := "La macro '" + m.name + "' se redefine"
+		if previous.line > 0 {
+			message += " (definida antes en la línea " + strconv.Itoa(previous.line) + ")"
+		}
+		pp.report(diagnosticAtToken(CodeMacroRedefined, message, nameTok))
+	}
+	pp.macros[m.name] = m
+}
+
+// macroBody une los '#' '#' consecutivos en el operador "##" y valida los
+// operadores # y ##
+func (pp *preprocessor) macroBody(m *macro, tokens []models.Token) ([]models.Token, bool) {
+	var body []models.Token
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		if tok.Lexeme == "#" && i+1 < len(tokens) && tokens[i+1].Lexeme == "#" && tokens[i+1].Offset == tok.Offset+1 {
+			tok.Lexeme = "##"
+			i++
+		}
+		body = append(body, tok)
+	}
+
+	for i, tok := range body {
+		switch {
+		case tok.Lexeme == "##" && (i == 0 || i == len(body)-1):
+			pp.report(diagnosticAtToken(CodeMalformedDirective,
+				"'##' no puede estar al principio ni al final de la macro '"+m.name+"'", tok))
+			return nil, false
+		case tok.Lexeme == "#" && m.function && (i+1 >= len(body) || m.param(body[i+1].Lexeme) < 0):
+			pp.report(diagnosticAtToken(CodeMalformedDirective,
+				"'#' debe ir seguido de un parámetro de la macro '"+m.name+"'", tok))
+			return nil, false
+		}
+	}
+	return body, true
+}
+
+func isMacroName(tok models.Token) bool {
+	return tok.Kind == TokenIdentifier || tok.Kind == TokenKeyword
+}
+
+func wrapTokens(tokens []models.Token) []ppToken {
+	wrapped := make([]ppToken, len(tokens))
+	for i, tok := range tokens {
+		wrapped[i] = ppToken{Token: tok}
+	}
+	return wrapped
+}
+
+// Límites de la expansión de macros en una unidad de traducción: una
+// cadena de macros que se duplican en cada nivel produce una cantidad de
+// tokens exponencial en pocas líneas
+const (
+	maxExpansions     = 100000
+	maxExpandedTokens = 1000000
+)
+
+// expand reemplaza las macros de la lista hasta que no quede ninguna por
+// expandir. El resultado de cada expansión se vuelve a examinar, pero una
+// macro nunca se expande dentro de su propio resultado. Los tokens
+// pendientes se guardan en orden inverso para que cada expansión se apile
+// al final sin copiar el resto.
+func (pp *preprocessor) expand(input []ppToken) []models.Token {
+	var output []models.Token
+	pending := pushTokens(nil, input)
+	for len(pending) > 0 {
+		tok := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		if tok.Lexeme == "__LINE__" && tok.Kind == TokenIdentifier {
+			tok.Kind, tok.Lexeme, tok.Macro = TokenNumber, strconv.Itoa(tok.Line%fileLineStride), "__LINE__"
+			output = append(output, tok.Token)
+			continue
+		}
+		m := pp.macros[tok.Lexeme]
+		if !isMacroName(tok.Token) || m == nil || tok.isHidden(m.name) || pp.expansionLimit(tok) {
+			output = append(output, tok.Token)
+			continue
+		}
+
+		if !m.function {
+			pending = pushTokens(pending, pp.substitute(m, tok, nil))
+			continue
+		}
+		// Sin paréntesis el nombre de una macro de función no se expande
+		if len(pending) == 0 || pending[len(pending)-1].Lexeme != "(" {
+			output = append(output, tok.Token)
+			continue
+		}
+		args, rest, ok := pp.collectArgs(m, tok, pending)
+		if !ok {
+			output = append(output, tok.Token)
+			continue
+		}
+		pending = pushTokens(rest, pp.substitute(m, tok, args))
+	}
+	return output
+}
+
+// pushTokens apila los tokens sobre los pendientes, el primero arriba
+func pushTokens(pending, tokens []ppToken) []ppToken {
+	for i := len(tokens) - 1; i >= 0; i-- {
+		pending = append(pending, tokens[i])
+	}
+	return pending
+}
+
+// expansionLimit indica si la unidad ya agotó los límites de expansión. La
+// primera vez se informa en el token que los superó; desde entonces las
+// macros quedan sin expandir.
+func (pp *preprocessor) expansionLimit(tok ppToken) bool {
+	if pp.limited {
+		return true
+	}
+	if pp.expansions < maxExpansions && pp.expandedTokens < maxExpandedTokens {
+		return false
+	}
+	pp.limited = true
+	pp.report(diagnosticAtToken(CodeExpansionLimit,
+		"La expansión de macros superó el límite de "+strconv.Itoa(maxExpansions)+" expansiones o "+
+			strconv.Itoa(maxExpandedTokens)+" tokens; las macros siguientes no se expanden", tok.Token))
+	return true
+}
+
+// collectArgs separa los argumentos de una llamada a macro por las comas
+// que no están entre paréntesis. pending es la pila de tokens pendientes,
+// con el '(' arriba; devuelve la pila sin la llamada.
+func (pp *preprocessor) collectArgs(m *macro, call ppToken, pending []ppToken) ([][]ppToken, []ppToken, bool) {
+	var args [][]ppToken
+	var current []ppToken
+	depth := 0
+	for i := len(pending) - 2; i >= 0; i-- {
+		tok := pending[i]
+		switch {
+		case tok.Lexeme == "(":
+			depth++
+		case tok.Lexeme == ")" && depth > 0:
+			depth--
+		case tok.Lexeme == ")":
+			args = append(args, current)
+			return pp.checkArgCount(m, call, args), pending[:i], true
+		case tok.Lexeme == "," && depth == 0 && !(m.variadic && len(args) == len(m.params)-1):
+			args = append(args, current)
+			current = nil
+			continue
+		}
+		current = append(current, tok)
+	}
+	pp.report(diagnosticAtToken(CodeMacroArguments, "Falta ')' en el uso de la macro '"+m.name+"'", call.Token))
+	return nil, nil, false
+}
+
+// checkArgCount valida la cantidad de argumentos. Ante un error se informa
+// y se completan o descartan argumentos para expandir igual la macro.
+func (pp *preprocessor) checkArgCount(m *macro, call ppToken, args [][]ppToken) [][]ppToken {
+	// M() es una llamada sin argumentos
+	if len(args) == 1 && len(args[0]) == 0 && len(m.params) == 0 {
+		return nil
+	}
+	expected := len(m.params)
+	if m.variadic && len(args) == expected-1 {
+		args = append(args, nil)
+	}
+	if len(args) != expected {
+		pp.report(diagnosticAtToken(CodeMacroArguments,
+			"La macro '"+m.name+"' espera "+strconv.Itoa(expected)+" argumento(s), se pasaron "+strconv.Itoa(len(args)),
+			call.Token))
+		for len(args) < expected {
+			args = append(args, nil)
+		}
+		args = args[:expected]
+	}
+	return args
+}
+
+// substitute produce el cuerpo de la macro con los argumentos reemplazados
+// y los operadores # y ## aplicados
+func (pp *preprocessor) substitute(m *macro, call ppToken, args [][]ppToken) []ppToken {
+	hidden := append(append([]string(nil), call.hidden...), m.name)
+	var result []ppToken
+	for i := 0; i < len(m.body); i++ {
+		tok := m.body[i]
+		pasted := (i > 0 && m.body[i-1].Lexeme == "##") || (i+1 < len(m.body) && m.body[i+1].Lexeme == "##")
+
+		if tok.Lexeme == "#" && m.function {
+			i++
+			result = append(result, ppToken{Token: stringify(args[m.param(m.body[i].Lexeme)], call.Token, m), hidden: hidden})
+			continue
+		}
+		if index := m.param(tok.Lexeme); index >= 0 && isMacroName(tok) {
+			// Los argumentos se expanden antes de reemplazarlos, salvo junto a ##
+			arg := args[index]
+			if !pasted {
+				arg = wrapTokens(pp.expand(append([]ppToken(nil), arg...)))
+			}
+			for _, argTok := range arg {
+				argTok.hidden = append(append([]string(nil), argTok.hidden...), hidden...)
+				result = append(result, argTok)
+			}
+			continue
+		}
+		result = append(result, ppToken{Token: expandedToken(tok, call.Token, m), hidden: hidden})
+	}
+	result = pp.paste(m, result)
+	pp.expansions++
+	pp.expandedTokens += len(result)
+	return result
+}
+
+// expandedToken ubica un token del cuerpo de la macro en el lugar donde se usó
+func expandedToken(tok, call models.Token, m *macro) models.Token {
+	tok.OriginLine, tok.OriginColumn = tok.Line, tok.Column
+	tok.Line, tok.Column, tok.Offset = call.Line, call.Column, call.Offset
+	tok.Macro = m.name
+	return tok
+}
+
+// stringify convierte un argumento en una cadena: #x con x = a + b da "a + b"
+func stringify(arg []ppToken, call models.Token, m *macro) models.Token {
+	var text strings.Builder
+	for i, tok := range arg {
+		if i > 0 && tok.Offset > arg[i-1].Offset+len(arg[i-1].Lexeme) {
+			text.WriteByte(' ')
+		}
+		lexeme := tok.Lexeme
+		if tok.Kind == TokenString || tok.Kind == TokenChar {
+			lexeme = strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(lexeme)
+		}
+		text.WriteString(lexeme)
+	}
+	tok := models.Token{Kind: TokenString, Lexeme: `"` + text.String() + `"`, Line: call.Line, Column: call.Column, Offset: call.Offset}
+	tok.Macro = m.name
+	return tok
+}
+
+// paste aplica el operador ##, que une el token anterior con el siguiente
+func (pp *preprocessor) paste(m *macro, tokens []ppToken) []ppToken {
+	var result []ppToken
+	for i := 0; i < len(tokens); i++ {
+		if tokens[i].Lexeme != "##" || tokens[i].Macro != m.name {
+			result = append(result, tokens[i])
+			continue
+		}
+		if i+1 >= len(tokens) || len(result) == 0 {
+			// Un argumento vacío a un lado de ## deja solo el otro
+			continue
+		}
+		left, right := result[len(result)-1], tokens[i+1]
+		i++
+		lexed, _ := Tokenize(left.Lexeme + right.Lexeme)
+		if len(lexed) != 1 {
+			pp.report(diagnosticAtToken(CodeMalformedDirective,
+				"'##' no forma un token válido con '"+left.Lexeme+"' y '"+right.Lexeme+"'", left.Token))
+			result = append(result, right)
+			continue
+		}
+		left.Kind, left.Lexeme = lexed[0].Kind, lexed[0].Lexeme
+		result[len(result)-1] = left
+	}
+	return result
+}
+
+// joinTokens reconstruye el texto de una línea de tokens
+func joinTokens(tokens []models.Token) string {
+	var parts []string
+	for _, tok := range tokens {
+		parts = append(parts, tok.Lexeme)
+	}
+	return strings.Join(parts, " ")
+}
+
+// ppExpr evalúa la expresión constante entera de un #if
+type ppExpr struct {
+	tokens []models.Token
+	pos    int
+	err    string
+}
+
+// Precedencia de los operadores binarios en #if
+var ppPrecedence = map[string]int{
+	"||": 1, "&&": 2, "|": 3, "^": 4, "&": 5,
+	"==": 6, "!=": 6, "<": 7, "<=": 7, ">": 7, ">=": 7,
+	"<<": 8, ">>": 8, "+": 9, "-": 9, "*": 10, "/": 10, "%": 10,
+}
+
+func (e *ppExpr) peek() string {
+	if e.pos < len(e.tokens) {
+		return e.tokens[e.pos].Lexeme
+	}
+	return ""
+}
+
+func (e *ppExpr) parseConditional() int64 {
+	condition := e.parseBinary(1)
+	if e.peek() != "?" {
+		return condition
+	}
+	e.pos++
+	whenTrue := e.parseConditional()
+	if e.peek() != ":" {
+		e.fail("se esperaba ':'")
+		return 0
+	}
+	e.pos++
+	whenFalse := e.parseConditional()
+	if condition != 0 {
+		return whenTrue
+	}
+	return whenFalse
+}
+
+func (e *ppExpr) parseBinary(minPrecedence int) int64 {
+	left := e.parseUnary()
+	for e.err == "" {
+		op := e.peek()
+		precedence, ok := ppPrecedence[op]
+		if !ok || precedence < minPrecedence {
+			return left
+		}
+		e.pos++
+		right := e.parseBinary(precedence + 1)
+		left = e.apply(op, left, right)
+	}
+	return left
+}
+
+func (e *ppExpr) apply(op string, left, right int64) int64 {
+	switch op {
+	case "||":
+		return boolInt(left != 0 || right != 0)
+	case "&&":
+		return boolInt(left != 0 && right != 0)
+	case "|":
+		return left | right
+	case "^":
+		return left ^ right
+	case "&":
+		return left & right
+	case "==":
+		return boolInt(left == right)
+	case "!=":
+		return boolInt(left != right)
+	case "<":
+		return boolInt(left < right)
+	case "<=":
+		return boolInt(left <= right)
+	case ">":
+		return boolInt(left > right)
+	case ">=":
+		return boolInt(left >= right)
+	case "<<":
+		return left << uint64(right)
+	case ">>":
+		return left >> uint64(right)
+	case "+":
+		return left + right
+	case "-":
+		return left - right
+	case "*":
+		return left * right
+	}
+	if right == 0 {
+		e.fail("división entre cero")
+		return 0
+	}
+	if op == "/" {
+		return left / right
+	}
+	return left % right
+}
+
+func (e *ppExpr) parseUnary() int64 {
+	switch e.peek() {
+	case "!":
+		e.pos++
+		return boolInt(e.parseUnary() == 0)
+	case "~":
+		e.pos++
+		return ^e.parseUnary()
+	case "-":
+		e.pos++
+		return -e.parseUnary()
+	case "+":
+		e.pos++
+		return e.parseUnary()
+	case "(":
+		e.pos++
+		value := e.parseConditional()
+		if e.peek() != ")" {
+			e.fail("falta ')'")
+			return 0
+		}
+		e.pos++
+		return value
+	}
+
+	if e.pos >= len(e.tokens) {
+		e.fail("expresión incompleta")
+		return 0
+	}
+	tok := e.tokens[e.pos]
+	e.pos++
+	switch tok.Kind {
+	case TokenNumber:
+		value, err := strconv.ParseInt(strings.TrimRight(strings.ToLower(tok.Lexeme), "ul"), 0, 64)
+		if err != nil {
+			e.fail("'" + tok.Lexeme + "' no es un entero")
+		}
+		return value
+	case TokenChar:
+		if text, err := strconv.Unquote(tok.Lexeme); err == nil && len(text) > 0 {
+			return int64(text[0])
+		}
+		return 0
+	case TokenIdentifier, TokenKeyword:
+		// Los nombres que no son macros valen 0; true vale 1
+		return boolInt(tok.Lexeme == "true")
+	}
+	e.fail("no se esperaba '" + tok.Lexeme + "'")
+	return 0
+}
+
+func (e *ppExpr) fail(message string) {
+	if e.err == "" {
+		e.err = message
+	}
+	e.pos = len(e.tokens)
+}
+
+func boolInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+// codeText reconstruye el código que recibe el parser, sin las directivas
+func codeText(tokens []models.Token) string {
+	var code []models.Token
+	for _, tok := range tokens {
+		if tok.Kind != TokenDirective {
+			code = append(code, tok)
+		}
+	}
+	return joinTokens(code)
+}
+
+func TestPreprocess(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want string
+	}{
+		{"macro de objeto", "#define N 10\nint a[N];", "int a [ 10 ] ;"},
+		{"macro de función", "#define CUAD(x) ((x) * (x))\nint y = CUAD(2 + 1);", "int y = ( ( 2 + 1 ) * ( 2 + 1 ) ) ;"},
+		{"macro anidada", "#define A B\n#define B 2\nint x = A;", "int x = 2 ;"},
+		{"macro recursiva", "#define X X + 1\nint x = X;", "int x = X + 1 ;"},
+		{"#undef", "#define N 1\n#undef N\nint x = N;", "int x = N ;"},
+		{"#ifdef y #else", "#define DEBUG\n#ifdef DEBUG\nint a;\n#else\nint b;\n#endif", "int a ;"},
+		{"#if con expresión", "#define V 3\n#if V > 2 && defined(V)\nint a;\n#elif V == 2\nint b;\n#endif", "int a ;"},
+		{"#ifndef anidado", "#ifndef A\n#if 0\nint a;\n#endif\nint b;\n#endif", "int b ;"},
+		{"operador #", "#define STR(x) #x\nconst char* s = STR(hola);", `const char * s = "hola" ;`},
+		{"operador ##", "#define UNIR(a, b) a##b\nint UNIR(va, lor) = 1;", "int valor = 1 ;"},
+		{"línea continuada", "#define SUMA(a, b) \\\n    ((a) + (b))\nint s = SUMA(1, 2);", "int s = ( ( 1 ) + ( 2 ) ) ;"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, diagnostics := Preprocess(tt.code)
+			if len(diagnostics) > 0 {
+				t.Fatalf("diagnósticos inesperados: %v", diagnostics)
+			}
+			if got := codeText(tokens); got != tt.want {
+				t.Errorf("código = %q, se esperaba %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreprocessDiagnostics(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want []string
+	}{
+		{"#if sin #endif", "#if 1\nint a;\n", []string{CodeUnbalancedIf}},
+		{"#endif de más", "int a;\n#endif\n", []string{CodeUnbalancedIf}},
+		{"argumentos de menos", "#define F(a, b) a\nint x = F(1);", []string{CodeMacroArguments}},
+		{"macro redefinida", "#define N 1\n#define N 2\n", []string{CodeMacroRedefined}},
+		{"#error", "#error falta configurar\n", []string{CodeErrorDirective}},
+		{"directiva desconocida", "#fooo\n", []string{CodeUnknownDirective}},
+		{"#if sin expresión", "#if\n#endif\n", []string{CodeMalformedDirective}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var diagnostics []models.Diagnostic
+			withTimeout(t, 2*time.Second, func() { _, diagnostics = Preprocess(tt.code) })
+			if got := diagnosticCodes(diagnostics); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("códigos = %v, se esperaba %v (%v)", got, tt.want, diagnostics)
+			}
+		})
+	}
+}
+
+func TestPreprocessExpansionLimit(t *testing.T) {
+	var chain strings.Builder
+	chain.WriteString("#define A0 x\n")
+	for i := 1; i <= 26; i++ {
+		fmt.Fprintf(&chain, "#define A%d A%d A%d\n", i, i-1, i-1)
+	}
+	chain.WriteString("int y = A26;\n")
+
+	var uses strings.Builder
+	uses.WriteString("#define N 1\nint main() {\n    int s = 0;\n")
+	for i := 0; i < 4000; i++ {
+		uses.WriteString("    s += N;\n")
+	}
+	uses.WriteString("    return s;\n}\n")
+
+	tests := []struct {
+		name   string
+		code   string
+		want   []string
+		tokens int // tokens de código esperados, o 0 para no comprobarlo
+	}{
+		{"expansión exponencial", chain.String(), []string{CodeExpansionLimit}, 0},
+		{"miles de usos", uses.String(), []string{}, 10 + 4000*4 + 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tokens []models.Token
+			var diagnostics []models.Diagnostic
+			withTimeout(t, 5*time.Second, func() { tokens, diagnostics = Preprocess(tt.code) })
+			if got := diagnosticCodes(diagnostics); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("códigos = %v, se esperaba %v", got, tt.want)
+			}
+			if got := len(strings.Fields(codeText(tokens))); tt.tokens > 0 && got != tt.tokens {
+				t.Errorf("%d tokens de código, se esperaban %d", got, tt.tokens)
+			}
+		})
+	}
+}
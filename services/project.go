@@ -0,0 +1,443 @@
+package services
+
+import (
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+// fileLineStride separa los rangos de líneas de los archivos de un proyecto:
+// la línea n del archivo i se representa como i*fileLineStride + n
+const fileLineStride = 1 << 20
+
+// sourceFiles describe un programa de varios archivos. Los tokens de cada
+// archivo se numeran en un rango de líneas propio para que todas las
+// posiciones de la unidad de traducción sean únicas durante el análisis;
+// al terminar, las posiciones de los resultados se traducen a archivo y línea.
+type sourceFiles struct {
+	files      map[string]string
+	names      []string // en orden de inclusión; el primero es el punto de entrada
+	ids        map[string]int
+	includedAt map[string]position // posición del primer #include de cada archivo
+}
+
+// position es una línea y columna de la unidad de traducción
+type position struct {
+	line, column int
+}
+
+func newSourceFiles(files map[string]string) *sourceFiles {
+	s := &sourceFiles{
+		files:      make(map[string]string),
+		ids:        make(map[string]int),
+		includedAt: make(map[string]position),
+	}
+	for name, code := range files {
+		s.files[path.Clean(name)] = code
+	}
+	return s
+}
+
+// base registra el archivo y devuelve el desplazamiento que se suma a sus líneas
+func (s *sourceFiles) base(name string) int {
+	id, ok := s.ids[name]
+	if !ok {
+		id = len(s.names)
+		s.ids[name] = id
+		s.names = append(s.names, name)
+	}
+	return id * fileLineStride
+}
+
+// resolve busca el archivo de un #include "..." primero junto al archivo
+// que lo incluye y después desde la raíz del proyecto
+func (s *sourceFiles) resolve(from, target string) (string, bool) {
+	for _, name := range []string{path.Join(path.Dir(from), target), path.Clean(target)} {
+		if _, ok := s.files[name]; ok {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// locate traduce una línea de la unidad de traducción a archivo y línea
+func (s *sourceFiles) locate(line int) (string, int) {
+	if s == nil || len(s.names) == 0 {
+		return "", line
+	}
+	id := line / fileLineStride
+	if id >= len(s.names) {
+		id = 0
+	}
+	return s.names[id], line - id*fileLineStride
+}
+
+// lineRef escribe una línea para los mensajes: "7", o "7 de util.h" si no
+// está en el punto de entrada
+func (s *sourceFiles) lineRef(line int) string {
+	file, line := s.locate(line)
+	if s == nil || len(s.names) == 0 || file == s.names[0] {
+		return strconv.Itoa(line)
+	}
+	return strconv.Itoa(line) + " de " + file
+}
+
+// fileLineRef escribe una línea nombrando siempre su archivo, para los
+// mensajes que relacionan dos unidades de traducción: en ellos no hay un
+// archivo que se sobrentienda
+func (s *sourceFiles) fileLineRef(line int) string {
+	file, line := s.locate(line)
+	if file == "" {
+		return strconv.Itoa(line)
+	}
+	return strconv.Itoa(line) + " de " + file
+}
+
+// before indica si la primera posición aparece antes que la segunda en la
+// unidad de traducción. Las posiciones de archivos distintos se comparan en
+// el primer archivo que las contiene a ambas, a través de sus #include.
+func (s *sourceFiles) before(line1, column1, line2, column2 int) bool {
+	chain1, chain2 := s.chain(position{line1, column1}), s.chain(position{line2, column2})
+	for i := 0; i < len(chain1) && i < len(chain2); i++ {
+		a, b := chain1[i], chain2[i]
+		if a != b {
+			return a.line < b.line || (a.line == b.line && a.column < b.column)
+		}
+	}
+	return false
+}
+
+// chain devuelve las posiciones de los #include que llevan del punto de
+// entrada hasta la posición indicada, terminando en ella
+func (s *sourceFiles) chain(pos position) []position {
+	chain := []position{pos}
+	if s == nil {
+		return chain
+	}
+	for {
+		file, _ := s.locate(pos.line)
+		at, ok := s.includedAt[file]
+		if !ok {
+			return chain
+		}
+		pos = at
+		chain = append([]position{pos}, chain...)
+	}
+}
+
+// projectEntry elige el punto de entrada: el indicado, main.cpp, el único
+// archivo o el primer .cpp en orden alfabético
+func projectEntry(files map[string]string, entry string) string {
+	if entry != "" {
+		return path.Clean(entry)
+	}
+	if _, ok := files["main.cpp"]; ok || len(files) == 0 {
+		return "main.cpp"
+	}
+	names := sortedKeys(files)
+	for _, name := range names {
+		if strings.HasSuffix(name, ".cpp") {
+			return name
+		}
+	}
+	return names[0]
+}
+
+// translationUnit es un archivo fuente del proyecto junto con los archivos
+// que incluye. Como en el compilador, cada unidad se preprocesa y se analiza
+// por separado; sus definiciones se enlazan después.
+type translationUnit struct {
+	file        string
+	tree        *models.SyntaxNode
+	diagnostics []models.Diagnostic
+}
+
+// unitFiles devuelve los archivos que forman una unidad de traducción: el
+// punto de entrada y los demás archivos fuente en orden alfabético
+func unitFiles(files map[string]string, entry string) []string {
+	names := []string{entry}
+	for _, name := range sortedKeys(files) {
+		if name != entry && sourceExtensions[strings.ToLower(path.Ext(name))] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// parseUnits preprocesa y analiza sintácticamente cada unidad de traducción
+// del proyecto. Todas comparten los rangos de líneas de los archivos, así que
+// un encabezado incluido en varias unidades tiene las mismas posiciones.
+func parseUnits(files map[string]string, entry string) (*sourceFiles, []translationUnit) {
+	source := newSourceFiles(files)
+	entry = projectEntry(source.files, entry)
+	source.base(entry)
+	if _, ok := source.files[entry]; !ok {
+		tree, diagnostics := parseTokens(nil, nil, source)
+		diagnostics = append(diagnostics, newDiagnostic(CodeIncludeNotFound,
+			"No se encontró el archivo de entrada '"+entry+"'", 1, 1, 1, 1))
+		return source, []translationUnit{{file: entry, tree: tree, diagnostics: diagnostics}}
+	}
+
+	var units []translationUnit
+	for _, file := range unitFiles(source.files, entry) {
+		pp := newPreprocessor()
+		pp.source = source
+		pp.runFile(file)
+		tree, diagnostics := parseTokens(pp.output, pp.diagnostics, source)
+		units = append(units, translationUnit{file: file, tree: tree, diagnostics: diagnostics})
+	}
+	return source, units
+}
+
+// linkUnits une las unidades en el árbol del programa completo. Las
+// declaraciones de un encabezado incluido en varias unidades, y sus
+// diagnósticos, aparecen una sola vez.
+func linkUnits(units []translationUnit) (*models.SyntaxNode, []models.Diagnostic) {
+	tree := *units[0].tree
+	tree.Children = nil
+	seen := make(map[position]bool)
+	var diagnostics []models.Diagnostic
+	for _, unit := range units {
+		for _, node := range unit.tree.Children {
+			if at := (position{node.Line, node.Column}); !seen[at] {
+				seen[at] = true
+				tree.Children = append(tree.Children, node)
+			}
+		}
+		diagnostics = append(diagnostics, unit.diagnostics...)
+	}
+	return &tree, uniqueDiagnostics(diagnostics)
+}
+
+// analyzeUnits aplica el análisis semántico a cada unidad por separado y
+// enlaza sus funciones: una función llamada que no se define en ninguna
+// unidad, o que se define en más de una, es un error como en el enlazador.
+// Si una variable global está sin usar se decide en el análisis del
+// programa enlazado, porque otra unidad puede usarla.
+func analyzeUnits(source *sourceFiles, units []translationUnit, linked *semanticAnalyzer) []models.Diagnostic {
+	var diagnostics []models.Diagnostic
+	definitions := make(map[string]*models.SyntaxNode)
+	var calls []*models.SyntaxNode
+	selected := make(map[*models.SyntaxNode]*models.SyntaxNode)
+	for _, unit := range units {
+		a := runAnalyzer(unit.tree, source)
+		globals := unusedGlobals(a)
+		for _, d := range a.diagnostics {
+			if d.Code != CodeUnusedVariable || !globals[position{d.StartLine, d.StartColumn}] {
+				diagnostics = append(diagnostics, d)
+			}
+		}
+
+		defined := make(map[string]bool)
+		for _, node := range unit.tree.Children {
+			if node.Kind != NodeFunction || functionBody(node) == nil {
+				continue
+			}
+			key := node.Value + parameterSignature(node)
+			previous, ok := definitions[key]
+			switch {
+			case !ok:
+				definitions[key] = node
+			case !defined[key] && (previous.Line != node.Line || previous.Column != node.Column):
+				diagnostics = append(diagnostics, diagnosticAtNode(CodeMultipleDefinition,
+					"La función '"+node.Value+"' ya fue definida en la línea "+source.fileLineRef(previous.Line), node))
+			}
+			defined[key] = true
+		}
+
+		topLevel := make(map[*models.SyntaxNode]bool, len(unit.tree.Children))
+		for _, node := range unit.tree.Children {
+			topLevel[node] = true
+		}
+		for call, function := range a.calls {
+			if topLevel[function] && functionBody(function) == nil {
+				calls = append(calls, call)
+				selected[call] = function
+			}
+		}
+	}
+
+	sort.Slice(calls, func(i, j int) bool {
+		return calls[i].Line < calls[j].Line || (calls[i].Line == calls[j].Line && calls[i].Column < calls[j].Column)
+	})
+	reported := make(map[string]bool)
+	for _, call := range calls {
+		function := selected[call]
+		key := function.Value + parameterSignature(function)
+		if definitions[key] == nil && !reported[key] {
+			reported[key] = true
+			diagnostics = append(diagnostics, diagnosticAtNode(CodeUndefinedFunction,
+				"La función '"+function.Value+"' se declaró en la línea "+source.lineRef(function.Line)+
+					" pero no se definió en ningún archivo del proyecto", call.Children[0]))
+		}
+	}
+
+	globals := unusedGlobals(linked)
+	for _, d := range linked.diagnostics {
+		if d.Code == CodeUnusedVariable && globals[position{d.StartLine, d.StartColumn}] {
+			diagnostics = append(diagnostics, d)
+		}
+	}
+	return uniqueDiagnostics(diagnostics)
+}
+
+// unusedGlobals devuelve la posición del aviso de cada variable global sin usar
+func unusedGlobals(a *semanticAnalyzer) map[position]bool {
+	positions := make(map[position]bool)
+	for _, sym := range a.table.global().order {
+		if sym.Kind == SymbolVariable && !sym.Used {
+			d := nameDiagnostic(CodeUnusedVariable, "", sym.node, sym.Name)
+			positions[position{d.StartLine, d.StartColumn}] = true
+		}
+	}
+	return positions
+}
+
+// uniqueDiagnostics descarta los diagnósticos repetidos, por ejemplo los de
+// un encabezado incluido en varias unidades
+func uniqueDiagnostics(diagnostics []models.Diagnostic) []models.Diagnostic {
+	type key struct {
+		code, message string
+		line, column  int
+	}
+	seen := make(map[key]bool)
+	result := diagnostics[:0:0]
+	for _, d := range diagnostics {
+		k := key{d.Code, d.Message, d.StartLine, d.StartColumn}
+		if !seen[k] {
+			seen[k] = true
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
+// parseProject analiza sintácticamente cada unidad de traducción del
+// proyecto y devuelve el árbol del programa enlazado
+func parseProject(files map[string]string, entry string) (*sourceFiles, *models.SyntaxNode, []models.Diagnostic) {
+	source, units := parseUnits(files, entry)
+	tree, diagnostics := linkUnits(units)
+	return source, tree, diagnostics
+}
+
+// compileProject enlaza el proyecto en un programa para el intérprete. Sus
+// errores son los del análisis de cada unidad y los del enlace.
+func compileProject(files map[string]string, entry string) *program {
+	source, units := parseUnits(files, entry)
+	tree, diagnostics := linkUnits(units)
+	analyzer := runAnalyzer(tree, source)
+	diagnostics = append(checkProgram(tree, diagnostics).Diagnostics, analyzeUnits(source, units, analyzer)...)
+	p := newProgram(tree, analyzer, diagnostics, source)
+	p.addLexicalErrors(AnalyzeLexicalProject(files).Diagnostics)
+	return p
+}
+
+// AnalyzeLexicalProject tokeniza cada archivo del proyecto; los tokens y
+// diagnósticos indican el archivo al que pertenecen
+func AnalyzeLexicalProject(files map[string]string) models.LexicalResult {
+	var tokens []models.Token
+	var diagnostics []models.Diagnostic
+	for _, name := range sortedKeys(files) {
+		fileTokens, fileDiagnostics := Tokenize(files[name])
+		for i := range fileTokens {
+			fileTokens[i].File = name
+		}
+		for i := range fileDiagnostics {
+			fileDiagnostics[i].File = name
+		}
+		tokens = append(tokens, fileTokens...)
+		diagnostics = append(diagnostics, fileDiagnostics...)
+	}
+	return summarizeTokens(tokens, diagnostics)
+}
+
+// AnalyzeSyntaxProject analiza sintácticamente cada unidad de traducción:
+// el punto de entrada y cada archivo fuente, con los archivos que incluyen
+// con #include "...". El árbol es el del programa enlazado.
+func AnalyzeSyntaxProject(files map[string]string, entry string) models.SyntaxResult {
+	result, _ := analyzeSyntaxProject(files, entry)
+	return result
+}
+
+// analyzeSyntaxProject es AnalyzeSyntaxProject junto con los archivos que
+// forman las unidades de traducción
+func analyzeSyntaxProject(files map[string]string, entry string) (models.SyntaxResult, *sourceFiles) {
+	source, tree, diagnostics := parseProject(files, entry)
+	result := checkProgram(tree, diagnostics)
+	source.relocateDiagnostics(result.Diagnostics)
+	source.relocateTree(result.AST)
+	return result, source
+}
+
+// AnalyzeSemanticProject aplica el análisis semántico a cada unidad de
+// traducción, donde son visibles las declaraciones de los encabezados que
+// incluye, y enlaza sus funciones. La tabla de símbolos y los totales son
+// los del programa enlazado.
+func AnalyzeSemanticProject(files map[string]string, entry string) models.SemanticResult {
+	source, units := parseUnits(files, entry)
+	tree, _ := linkUnits(units)
+	linked := runAnalyzer(tree, source)
+	result := semanticResult(linked)
+	result.Diagnostics = diagnosticList(analyzeUnits(source, units, linked))
+	source.relocateSemantic(&result)
+	return result
+}
+
+// AnalyzeControlFlowProject construye el grafo de flujo de control de las
+// funciones de toda la unidad de traducción
+func AnalyzeControlFlowProject(files map[string]string, entry string) models.ControlFlowResult {
+	source, tree, diagnostics := parseProject(files, entry)
+	result := controlFlowResult(tree, diagnostics, source)
+	source.relocateDiagnostics(result.Diagnostics)
+	return result
+}
+
+func (s *sourceFiles) relocateDiagnostics(diagnostics []models.Diagnostic) {
+	for i := range diagnostics {
+		d := &diagnostics[i]
+		d.File, d.StartLine = s.locate(d.StartLine)
+		_, d.EndLine = s.locate(d.EndLine)
+		for j := range d.Path {
+			d.Path[j].File, d.Path[j].Line = s.locate(d.Path[j].Line)
+		}
+		for _, fix := range d.Fixes {
+			for j := range fix.Edits {
+				edit := &fix.Edits[j]
+				edit.File, edit.StartLine = s.locate(edit.StartLine)
+				_, edit.EndLine = s.locate(edit.EndLine)
+			}
+		}
+	}
+}
+
+// relocateSemantic ubica en sus archivos los diagnósticos y los ámbitos y
+// símbolos de la tabla
+func (s *sourceFiles) relocateSemantic(result *models.SemanticResult) {
+	s.relocateDiagnostics(result.Diagnostics)
+	for i := range result.SymbolTable.Scopes {
+		scope := &result.SymbolTable.Scopes[i]
+		scope.File, scope.Line = s.locate(scope.Line)
+		for j := range scope.Symbols {
+			scope.Symbols[j].File, scope.Symbols[j].Line = s.locate(scope.Symbols[j].Line)
+		}
+	}
+}
+
+func (s *sourceFiles) relocateTree(node *models.SyntaxNode) {
+	if node == nil {
+		return
+	}
+	node.File, node.Line = s.locate(node.Line)
+	_, node.EndLine = s.locate(node.EndLine)
+	for _, child := range node.Children {
+		s.relocateTree(child)
+	}
+}
+
+// includeChain describe un ciclo de inclusiones: main.cpp → a.h → main.cpp
+func includeChain(files []string, name string) string {
+	return strings.Join(append(append([]string{}, files...), name), " → ")
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+func TestProjectIncludes(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []string
+	}{
+		{
+			name: "guarda de inclusión",
+			files: map[string]string{
+				"main.cpp": "#include \"a.h\"\n#include \"a.h\"\nint main() { return uno(); }\n",
+				"a.h":      "#ifndef A_H\n#define A_H\nint uno() { return 1; }\n#endif\n",
+			},
+			want: []string{},
+		},
+		{
+			name: "relativo al archivo que incluye",
+			files: map[string]string{
+				"main.cpp":     "#include \"lib/a.h\"\nint main() { return dos(); }\n",
+				"lib/a.h":      "#include \"b.h\"\nint dos() { return uno() + 1; }\n",
+				"lib/b.h":      "int uno() { return 1; }\n",
+				"lib/unused.h": "int nada;\n",
+			},
+			want: []string{},
+		},
+		{
+			name:  "archivo inexistente",
+			files: map[string]string{"main.cpp": "#include \"falta.h\"\nint main() { return 0; }\n"},
+			want:  []string{CodeIncludeNotFound},
+		},
+		{
+			name: "ciclo de inclusiones",
+			files: map[string]string{
+				"main.cpp": "#include \"a.h\"\nint main() { return 0; }\n",
+				"a.h":      "#include \"b.h\"\n",
+				"b.h":      "#include \"a.h\"\n",
+			},
+			want: []string{CodeIncludeCycle},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result models.SyntaxResult
+			withTimeout(t, 2*time.Second, func() { result = AnalyzeSyntaxProject(tt.files, "") })
+			if got := diagnosticCodes(result.Diagnostics); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("códigos = %v, se esperaba %v (%v)", got, tt.want, result.Diagnostics)
+			}
+			for _, d := range result.Diagnostics {
+				if d.File == "" || strings.Contains(d.File, "..") {
+					t.Errorf("el diagnóstico %s no indica un archivo del proyecto: %q", d.Code, d.File)
+				}
+			}
+		})
+	}
+}
+
+func TestProjectLinking(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		code    string
+		message string
+	}{
+		{
+			name: "main en dos archivos",
+			files: map[string]string{
+				"a.cpp": "int main() { return 0; }\n",
+				"b.cpp": "int main() { return 1; }\n",
+			},
+			code:    CodeMultipleDefinition,
+			message: "La función 'main' ya fue definida en la línea 1 de a.cpp",
+		},
+		{
+			name: "función declarada en un encabezado",
+			files: map[string]string{
+				"main.cpp": "#include \"util.h\"\nint main() { return doble(2); }\n",
+				"util.h":   "\nint doble(int x);\n",
+			},
+			code:    CodeUndefinedFunction,
+			message: "La función 'doble' se declaró en la línea 2 de util.h pero no se definió en ningún archivo del proyecto",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := AnalyzeSemanticProject(tt.files, "")
+			if len(result.Diagnostics) != 1 {
+				t.Fatalf("diagnósticos = %v, se esperaba uno %s", result.Diagnostics, tt.code)
+			}
+			if d := result.Diagnostics[0]; d.Code != tt.code || d.Message != tt.message {
+				t.Errorf("%s %q, se esperaba %s %q", d.Code, d.Message, tt.code, tt.message)
+			}
+		})
+	}
+}
+
+func TestRunProject(t *testing.T) {
+	main := "#include <iostream>\n#include \"util.h\"\nint main() { std::cout << doble(21); return 0; }\n"
+	tests := []struct {
+		name   string
+		files  map[string]string
+		status string
+		codes  []string
+		stdout string
+	}{
+		{
+			name: "definición en otro archivo",
+			files: map[string]string{
+				"main.cpp": main,
+				"util.h":   "int doble(int x);\n",
+				"util.cpp": "#include \"util.h\"\nint doble(int x) { return 2 * x; }\n",
+			},
+			status: RunOK,
+			codes:  []string{},
+			stdout: "42",
+		},
+		{
+			name: "error en otra unidad",
+			files: map[string]string{
+				"main.cpp": main,
+				"util.h":   "int doble(int x);\n",
+				"util.cpp": "int doble(int x) { return 2 * zzz }\n",
+			},
+			status: RunCompileError,
+			codes:  []string{CodeMissingSemicolon, CodeUndeclared},
+		},
+		{
+			name: "función sin definir",
+			files: map[string]string{
+				"main.cpp": main,
+				"util.h":   "int doble(int x);\n",
+			},
+			status: RunCompileError,
+			codes:  []string{CodeUndefinedFunction},
+		},
+		{
+			name: "función definida dos veces",
+			files: map[string]string{
+				"main.cpp": main + "int doble(int x) { return x; }\n",
+				"util.h":   "int doble(int x);\n",
+				"util.cpp": "int doble(int x) { return 2 * x; }\n",
+			},
+			status: RunCompileError,
+			codes:  []string{CodeMultipleDefinition},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result models.RunResult
+			withTimeout(t, 10*time.Second, func() { result = RunProject(tt.files, "", "", models.RunLimits{}) })
+			if result.Status != tt.status || result.Stdout != tt.stdout {
+				t.Fatalf("estado = %s, salida = %q; se esperaba %s, %q: %v",
+					result.Status, result.Stdout, tt.status, tt.stdout, result.Diagnostics)
+			}
+			if got := diagnosticCodes(result.Diagnostics); !equalCodes(got, tt.codes) {
+				t.Errorf("códigos = %v, se esperaba %v", got, tt.codes)
+			}
+		})
+	}
+}
+
+// equalCodes compara dos listas de códigos sin importar el orden
+func equalCodes(got, want []string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	count := make(map[string]int)
+	for _, code := range got {
+		count[code]++
+	}
+	for _, code := range want {
+		count[code]--
+		if count[code] < 0 {
+			return false
+		}
+	}
+	return true
+}
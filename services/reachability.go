@@ -0,0 +1,73 @@
+package services
+
+import (
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+// checkReachability recorre el grafo de la función para informar el código
+// que nunca se ejecuta (después de return, break, continue o de un ciclo
+// infinito) y las funciones no void que pueden terminar sin return
+func (a *semanticAnalyzer) checkReachability(graph *controlFlowGraph) {
+	reachable := graph.reachable()
+
+	// Cada zona inalcanzable empieza en un bloque sin predecesores; el
+	// resto de la zona no se vuelve a informar
+	for _, block := range graph.blocks {
+		if reachable[block] || len(block.preds) > 0 || len(block.elements) == 0 {
+			continue
+		}
+		first := block.elements[0]
+		message := "Código inalcanzable: esta sentencia nunca se ejecuta"
+		switch first.role {
+		case ElementIncrement:
+			message = "El incremento del for nunca se ejecuta: el cuerpo siempre sale del ciclo"
+		case ElementCondition:
+			message = "La condición del ciclo nunca se evalúa: el cuerpo siempre sale del ciclo"
+		}
+		diagnostic := diagnosticAtNode(CodeUnreachable, message, first.node)
+		diagnostic.Suggestion = "Eliminar el código que no se ejecuta o revisar el salto anterior"
+		a.report(diagnostic)
+	}
+
+	a.checkMissingReturn(graph, reachable)
+}
+
+// checkMissingReturn informa las funciones no void en las que algún camino
+// llega al final del cuerpo sin ejecutar un return. main es la excepción:
+// retorna 0 implícitamente.
+func (a *semanticAnalyzer) checkMissingReturn(graph *controlFlowGraph, reachable map[*cfgBlock]bool) {
+	function := graph.function
+	if function.Value == "main" || function.Type == "" || typeFromString(function.Type).isVoid() {
+		return
+	}
+
+	var fallsOff *cfgBlock
+	for _, block := range graph.exit.preds {
+		for _, edge := range block.succs {
+			if fallsOff == nil && edge.to == graph.exit && edge.kind != EdgeReturn && reachable[block] {
+				fallsOff = block
+			}
+		}
+	}
+	if fallsOff == nil {
+		return
+	}
+
+	body := functionBody(function)
+	diagnostic := newDiagnostic(CodeMissingReturn,
+		"La función '"+graph.name+"' no retorna un valor en todos los caminos",
+		body.EndLine, body.EndColumn-1, body.EndLine, body.EndColumn)
+	if fallsOff != graph.entry {
+		route := graph.route(graph.entry, func(block *cfgBlock) (bool, bool) {
+			return block == fallsOff, true
+		})
+		diagnostic.Path = branchSteps(route)
+	}
+	diagnostic.Path = append(diagnostic.Path, models.PathStep{
+		Message: "La ejecución llega al final de '" + graph.name + "' sin return",
+		Line:    body.EndLine,
+		Column:  body.EndColumn - 1,
+	})
+	diagnostic.Suggestion = "Agregar un 'return' de tipo " + function.Type + " al final de la función"
+	a.report(diagnostic)
+}
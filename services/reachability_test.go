@@ -0,0 +1,47 @@
+package services
+
+import "testing"
+
+func TestReachability(t *testing.T) {
+	checkSemantic(t, []semanticCase{
+		{
+			name:    "después de return",
+			code:    "int main() {\n    int x = 1;\n    return x;\n    x = 2;\n}\n",
+			want:    []string{CodeUnreachable},
+			message: "Código inalcanzable: esta sentencia nunca se ejecuta",
+		},
+		{
+			name:    "después de break",
+			code:    "int main() {\n    int s = 0;\n    for (int i = 0; i < 3; i++) {\n        break;\n        s++;\n    }\n    return s;\n}\n",
+			want:    []string{CodeUnreachable},
+			message: "Código inalcanzable",
+		},
+		{
+			name:    "falta return en una rama",
+			code:    "int f(int a) {\n    if (a > 0) return 1;\n}\nint main() { return f(1); }\n",
+			want:    []string{CodeMissingReturn},
+			message: "La función 'f' no retorna un valor en todos los caminos",
+		},
+		{
+			name: "return en ambas ramas",
+			code: "int f(int a) {\n    if (a > 0) return 1;\n    else return 2;\n}\nint main() { return f(1); }\n",
+			want: []string{},
+		},
+		{
+			name: "ciclo infinito con return",
+			code: "int f(int a) {\n    while (true) {\n        if (a > 0) return 1;\n    }\n}\nint main() { return f(1); }\n",
+			want: []string{},
+		},
+		{
+			name: "switch con default",
+			code: "int f(int a) {\n    switch (a) {\n    case 1: return 1;\n    default: return 0;\n    }\n}\nint main() { return f(1); }\n",
+			want: []string{},
+		},
+		{
+			// main devuelve 0 implícitamente
+			name: "main sin return",
+			code: "int main() {\n}\n",
+			want: []string{},
+		},
+	})
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+// ReportEntry es una entrega para los informes JUnit y HTML: su código, el
+// resultado del análisis y, si se calificó, la calificación
+type ReportEntry struct {
+	Submission models.Submission
+	Result     models.SubmissionResult
+	Grade      *models.GradeResult
+}
+
+// SeverityNames son los nombres de las severidades en los informes
+var SeverityNames = map[string]string{
+	SeverityError:   "error",
+	SeverityWarning: "advertencia",
+	SeverityInfo:    "nota",
+}
+
+// DiagnosticText describe el diagnóstico como un compilador
+// (archivo:línea:columna: severidad código: mensaje) y, en las líneas
+// siguientes, los pasos de su camino y la sugerencia
+func DiagnosticText(file string, d models.Diagnostic) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s:%d:%d: %s %s: %s", file, d.StartLine, d.StartColumn, SeverityNames[d.Severity], d.Code, d.Message)
+	for _, step := range d.Path {
+		fmt.Fprintf(&b, "\n    línea %d: %s", step.Line, step.Message)
+	}
+	if d.Suggestion != "" {
+		fmt.Fprintf(&b, "\n    sugerencia: %s", d.Suggestion)
+	}
+	return b.String()
+}
+
+// submissionFile es la ruta de un archivo de la entrega: su nombre si es
+// un solo archivo o nombre/archivo en un proyecto. Un análisis sin nombre
+// de un solo archivo es main.cpp, como al calificarlo.
+func submissionFile(name, file string) string {
+	p := path.Join(filepath.ToSlash(name), filepath.ToSlash(file))
+	if p == "" {
+		p = "main.cpp"
+	}
+	return p
+}
+
+// reportName es el nombre de la entrega en los informes
+func (e ReportEntry) reportName() string {
+	switch {
+	case e.Result.Name != "":
+		return e.Result.Name
+	case e.Submission.Name != "":
+		return e.Submission.Name
+	case len(e.Submission.Files) > 0:
+		return "Programa"
+	}
+	return submissionFile("", "")
+}
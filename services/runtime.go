@@ -0,0 +1,498 @@
+package services
+
+import (
+	"strconv"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+// rtValue es un valor durante la ejecución. t es su tipo, sin const ni
+// referencia, y decide qué campo se usa: i para enteros, bool y char (los
+// tipos sin signo de 64 bits guardan el patrón de bits), f para float y
+// double, s para string, ptr para punteros e iteradores y blk para el
+// contenido de arreglos y vectores. Los flujos y manipuladores de
+// <iostream> usan s para su nombre e i para su argumento: setw(5).
+type rtValue struct {
+	t   cppType
+	i   int64
+	f   float64
+	s   string
+	ptr location
+	blk *block
+}
+
+// block es una zona de memoria del programa con elementos del mismo tipo:
+// una variable (un solo elemento), el contenido de un arreglo o vector o lo
+// reservado con new. Los elementos se guardan en el slice que corresponde
+// al tipo para no gastar un rtValue por cada entero.
+type block struct {
+	elem   cppType
+	ints   []int64
+	floats []float64
+	strs   []string
+	vals   []rtValue
+	name   string // variable a la que pertenece, para los mensajes
+	bytes  int64  // memoria del programa que ocupa, sin los bloques anidados
+	heap   bool   // reservado con new
+	freed  bool
+}
+
+// location es un lugar de la memoria: el elemento index de un bloque o, si
+// inString, el carácter offset de la cadena guardada en ese elemento. Los
+// punteros e iteradores son locations que admiten aritmética; blk nil es
+// el puntero nulo.
+type location struct {
+	blk      *block
+	index    int
+	inString bool
+	offset   int
+}
+
+// Forma de guardar los elementos de un bloque
+const (
+	storeInts = iota
+	storeFloats
+	storeStrings
+	storeValues
+)
+
+func storageOf(t cppType) int {
+	switch {
+	case t.isIntegral():
+		return storeInts
+	case t.isFloating():
+		return storeFloats
+	case t.isString():
+		return storeStrings
+	}
+	return storeValues
+}
+
+func isVectorType(t cppType) bool {
+	return t.Name == "vector" && t.isPlain() && len(t.Args) == 1
+}
+
+// ownsBlock indica si el valor es dueño de su bloque: copiar un vector
+// copia sus elementos y un arreglo vive mientras viva su variable
+func ownsBlock(t cppType) bool {
+	return t.isArray() || isVectorType(t)
+}
+
+// elementSize estima lo que ocupa un elemento en un programa real; el
+// contenido de los arreglos anidados se cuenta en su propio bloque
+func elementSize(t cppType) int64 {
+	switch {
+	case t.isArray():
+		return 0
+	case t.Pointer > 0:
+		return 8
+	case t.isString():
+		return 32
+	case isVectorType(t):
+		return 24
+	}
+	if size, ok := typeSizes[t.Name]; ok {
+		return size
+	}
+	return 8
+}
+
+// typeBytes calcula lo que ocupa un objeto del tipo, con todas sus
+// dimensiones. Un tamaño absurdo se satura para que la reserva se rechace.
+func typeBytes(t cppType) int64 {
+	count := int64(1)
+	for _, dim := range t.Dims {
+		if count *= int64(dim); count > 1<<40 {
+			return 1 << 62
+		}
+	}
+	base := t
+	base.Dims = nil
+	return count * elementSize(base)
+}
+
+func (b *block) length() int {
+	switch storageOf(b.elem) {
+	case storeInts:
+		return len(b.ints)
+	case storeFloats:
+		return len(b.floats)
+	case storeStrings:
+		return len(b.strs)
+	}
+	return len(b.vals)
+}
+
+func (b *block) get(i int) rtValue {
+	switch storageOf(b.elem) {
+	case storeInts:
+		return rtValue{t: b.elem, i: b.ints[i]}
+	case storeFloats:
+		return rtValue{t: b.elem, f: b.floats[i]}
+	case storeStrings:
+		return rtValue{t: b.elem, s: b.strs[i]}
+	}
+	return b.vals[i]
+}
+
+// set guarda un valor ya convertido al tipo de los elementos
+func (b *block) set(i int, v rtValue) {
+	switch storageOf(b.elem) {
+	case storeInts:
+		b.ints[i] = v.i
+	case storeFloats:
+		b.floats[i] = v.f
+	case storeStrings:
+		b.strs[i] = v.s
+	default:
+		b.vals[i] = v
+	}
+}
+
+// newBlock crea un bloque de n elementos con su valor inicial. No lo
+// contabiliza: eso ocurre cuando el bloque pasa a pertenecer a una
+// variable (adopt), pero sí rechaza un bloque que no cabe en la memoria.
+func (in *interpreter) newBlock(elem cppType, n int, name string, node *models.SyntaxNode) *block {
+	elem = elem.unqualified()
+	in.checkAvailable(int64(n)*elementSize(elem), node)
+	b := &block{elem: elem, name: name, bytes: int64(n) * elementSize(elem)}
+	switch storageOf(elem) {
+	case storeInts:
+		b.ints = make([]int64, n)
+	case storeFloats:
+		b.floats = make([]float64, n)
+	case storeStrings:
+		b.strs = make([]string, n)
+	default:
+		b.vals = make([]rtValue, n)
+		for i := range b.vals {
+			b.vals[i] = in.zero(elem, name, node)
+		}
+	}
+	return b
+}
+
+// zero crea el valor inicial de un objeto del tipo: cero, cadena vacía,
+// vector vacío o un arreglo con todos sus elementos en cero
+func (in *interpreter) zero(t cppType, name string, node *models.SyntaxNode) rtValue {
+	t = t.unqualified()
+	switch {
+	case t.isArray():
+		if t.Dims[0] < 0 {
+			in.fail(node, CodeUnsupported, "No se conoce el tamaño del arreglo '"+name+"'", 1)
+		}
+		in.checkAvailable(typeBytes(t), node)
+		return rtValue{t: t, blk: in.newBlock(t.elementType(), t.Dims[0], name, node)}
+	case isVectorType(t):
+		return rtValue{t: t, blk: in.newBlock(t.Args[0], 0, name, node)}
+	}
+	return rtValue{t: t}
+}
+
+// blockBytes suma la memoria del bloque y de los bloques que le pertenecen
+func blockBytes(b *block) int64 {
+	total := b.bytes
+	for _, v := range b.vals {
+		if ownsBlock(v.t) && v.blk != nil {
+			total += blockBytes(v.blk)
+		}
+	}
+	for _, s := range b.strs {
+		total += int64(len(s))
+	}
+	return total
+}
+
+// adopt contabiliza la memoria de un valor que pasa a pertenecer a una
+// variable; disown la descuenta cuando la variable deja de existir o se
+// reemplaza su valor
+func (in *interpreter) adopt(v rtValue, node *models.SyntaxNode) {
+	if ownsBlock(v.t) && v.blk != nil {
+		in.charge(blockBytes(v.blk), node)
+	}
+}
+
+func (in *interpreter) disown(v rtValue) {
+	if ownsBlock(v.t) && v.blk != nil {
+		in.memory -= blockBytes(v.blk)
+	}
+}
+
+// charge suma memoria en uso y detiene el programa si supera el límite
+func (in *interpreter) charge(bytes int64, node *models.SyntaxNode) {
+	in.memory += bytes
+	if in.memory > in.peak {
+		in.peak = in.memory
+	}
+	if in.memory > in.maxMemory {
+		in.failLimit(node, CodeMemoryLimit,
+			"Se superó el límite de memoria de "+strconv.FormatInt(in.maxMemory/1024, 10)+" KB")
+	}
+}
+
+// checkAvailable rechaza antes de crearla una reserva que no cabe
+func (in *interpreter) checkAvailable(bytes int64, node *models.SyntaxNode) {
+	if bytes < 0 || in.memory+bytes > in.maxMemory {
+		in.failLimit(node, CodeMemoryLimit,
+			"Se superó el límite de memoria de "+strconv.FormatInt(in.maxMemory/1024, 10)+
+				" KB al reservar "+strconv.FormatInt(bytes/1024, 10)+" KB")
+	}
+}
+
+// variable crea el bloque de una variable en el ámbito actual con el valor
+// inicial de su tipo y devuelve su ubicación
+func (in *interpreter) variable(t cppType, name string, node *models.SyntaxNode) location {
+	t = t.unqualified()
+	holder := &block{elem: t, name: name, bytes: elementSize(t)}
+	in.charge(holder.bytes, node)
+	switch storageOf(t) {
+	case storeInts:
+		holder.ints = []int64{0}
+	case storeFloats:
+		holder.floats = []float64{0}
+	case storeStrings:
+		holder.strs = []string{""}
+	default:
+		value := in.zero(t, name, node)
+		in.adopt(value, node)
+		holder.vals = []rtValue{value}
+	}
+	frame := in.frame()
+	frame.scopes[len(frame.scopes)-1] = append(frame.scopes[len(frame.scopes)-1], holder)
+	return location{blk: holder}
+}
+
+// temporary guarda un valor sin nombre, por ejemplo el argumento de un
+// parámetro const string& o el objeto de to_string(x).size()
+func (in *interpreter) temporary(v rtValue, node *models.SyntaxNode) location {
+	loc := in.variable(v.t, "", node)
+	in.store(loc, v, node)
+	return loc
+}
+
+// releaseScope descuenta la memoria de las variables del ámbito que termina
+func (in *interpreter) releaseScope(holders []*block) {
+	for _, holder := range holders {
+		in.memory -= blockBytes(holder)
+	}
+}
+
+// locationType es el tipo del objeto que hay en la ubicación
+func locationType(loc location) cppType {
+	if loc.inString {
+		return cppType{Name: "char"}
+	}
+	return loc.blk.elem
+}
+
+// check valida que la ubicación se pueda usar: puntero no nulo, memoria no
+// liberada e índice dentro del bloque
+func (in *interpreter) check(loc location, node *models.SyntaxNode) {
+	switch {
+	case loc.blk == nil:
+		in.fail(node, CodeInvalidMemory, "Se usó un puntero nulo", exitSegv)
+	case loc.blk.freed:
+		in.fail(node, CodeInvalidMemory, "Se usó memoria que ya fue liberada con delete", exitSegv)
+	case loc.index < 0 || loc.index >= loc.blk.length():
+		in.fail(node, CodeOutOfBounds, "Índice "+strconv.Itoa(loc.index)+" fuera de los límites de "+
+			blockName(loc.blk)+" (tamaño "+strconv.Itoa(loc.blk.length())+")", exitSegv)
+	}
+}
+
+func blockName(b *block) string {
+	switch {
+	case b.heap:
+		return "la memoria reservada con new"
+	case b.name == "":
+		return "un objeto temporal"
+	}
+	return "'" + b.name + "'"
+}
+
+// load lee el valor guardado en la ubicación
+func (in *interpreter) load(loc location, node *models.SyntaxNode) rtValue {
+	in.check(loc, node)
+	if !loc.inString {
+		return loc.blk.get(loc.index)
+	}
+	text := loc.blk.strs[loc.index]
+	switch {
+	case loc.offset == len(text):
+		// s[s.size()] es el '\0' final
+		return rtValue{t: cppType{Name: "char"}}
+	case loc.offset < 0 || loc.offset > len(text):
+		in.fail(node, CodeOutOfBounds, "Posición "+strconv.Itoa(loc.offset)+" fuera de la cadena "+
+			blockName(loc.blk)+" (longitud "+strconv.Itoa(len(text))+")", exitSegv)
+	}
+	return rtValue{t: cppType{Name: "char"}, i: int64(int8(text[loc.offset]))}
+}
+
+// store convierte el valor al tipo de la ubicación y lo guarda, llevando la
+// cuenta de la memoria de las cadenas y de los bloques que reemplaza
+func (in *interpreter) store(loc location, v rtValue, node *models.SyntaxNode) {
+	in.check(loc, node)
+	b := loc.blk
+	if loc.inString {
+		text := b.strs[loc.index]
+		if loc.offset < 0 || loc.offset >= len(text) {
+			in.fail(node, CodeOutOfBounds, "Posición "+strconv.Itoa(loc.offset)+" fuera de la cadena "+
+				blockName(b)+" (longitud "+strconv.Itoa(len(text))+")", exitSegv)
+		}
+		c := in.convert(v, cppType{Name: "char"}, node)
+		in.work(len(text), node) // se copia el string completo
+		b.strs[loc.index] = text[:loc.offset] + string([]byte{byte(c.i)}) + text[loc.offset+1:]
+		return
+	}
+
+	v = in.convert(v, b.elem, node)
+	switch storageOf(b.elem) {
+	case storeStrings:
+		in.charge(int64(len(v.s)-len(b.strs[loc.index])), node)
+	case storeValues:
+		old := b.vals[loc.index]
+		if ownsBlock(v.t) && old.blk == v.blk {
+			break
+		}
+		in.disown(old)
+		in.adopt(v, node)
+	}
+	b.set(loc.index, v)
+}
+
+// wrapInteger ajusta un entero al rango del tipo como lo hace el
+// procesador: módulo 2^n, en complemento a dos para los tipos con signo
+func wrapInteger(x int64, t cppType) int64 {
+	switch t.Name {
+	case "bool":
+		if x != 0 {
+			return 1
+		}
+		return 0
+	case "char":
+		return int64(int8(x))
+	case "unsigned char":
+		return int64(uint8(x))
+	case "short":
+		return int64(int16(x))
+	case "unsigned short":
+		return int64(uint16(x))
+	case "int":
+		return int64(int32(x))
+	case "unsigned int":
+		return int64(uint32(x))
+	}
+	return x
+}
+
+// isUnsigned64 indica si el tipo guarda en i el patrón de bits de un uint64
+func isUnsigned64(t cppType) bool {
+	return isUnsigned(t) && integerBits[t.Name] == 64
+}
+
+// toFloat devuelve el valor numérico como float64
+func toFloat(v rtValue) float64 {
+	switch {
+	case v.t.isFloating():
+		return v.f
+	case isUnsigned64(v.t):
+		return float64(uint64(v.i))
+	}
+	return float64(v.i)
+}
+
+// convert aplica la conversión implícita o explícita al tipo indicado
+func (in *interpreter) convert(v rtValue, t cppType, node *models.SyntaxNode) rtValue {
+	t = t.value().unqualified()
+	switch {
+	case t.isUnknown():
+		return v
+	case t.Name == "bool" && t.isPlain():
+		return rtValue{t: t, i: boolInt(in.truth(v))}
+	case t.isIntegral():
+		if v.t.isFloating() {
+			if isUnsigned64(t) && v.f >= 1<<63 {
+				return rtValue{t: t, i: int64(uint64(v.f))}
+			}
+			return rtValue{t: t, i: wrapInteger(int64(v.f), t)}
+		}
+		return rtValue{t: t, i: wrapInteger(v.i, t)}
+	case t.isFloating():
+		f := toFloat(v)
+		if t.Name == "float" {
+			f = float64(float32(f))
+		}
+		return rtValue{t: t, f: f}
+	case t.isString():
+		if v.t.Name == "char" && v.t.isPlain() {
+			return rtValue{t: t, s: string([]byte{byte(v.i)})}
+		}
+		if v.t.Pointer > 0 || v.t.isArray() {
+			return rtValue{t: t, s: in.cString(v, node)}
+		}
+		return rtValue{t: t, s: v.s}
+	case t.isPointer():
+		switch {
+		case v.t.isArray():
+			return rtValue{t: t, ptr: location{blk: v.blk}}
+		case v.t.isString():
+			// const char* p = "hola"; apunta a una copia con el '\0' final
+			in.work(len(v.s), node)
+			b := in.newBlock(cppType{Name: "char"}, len(v.s)+1, "", node)
+			for i := 0; i < len(v.s); i++ {
+				b.ints[i] = int64(int8(v.s[i]))
+			}
+			return rtValue{t: t, ptr: location{blk: b}}
+		}
+		return rtValue{t: t, ptr: v.ptr}
+	case isVectorType(t) && v.blk != nil:
+		return rtValue{t: t, blk: in.copyBlock(v.blk, node)}
+	}
+	return v
+}
+
+// copyBlock copia los elementos de un vector, que tiene semántica de valor
+func (in *interpreter) copyBlock(b *block, node *models.SyntaxNode) *block {
+	in.checkAvailable(blockBytes(b), node)
+	in.work(int(blockBytes(b)), node)
+	c := &block{elem: b.elem, name: b.name, bytes: b.bytes}
+	c.ints = append([]int64(nil), b.ints...)
+	c.floats = append([]float64(nil), b.floats...)
+	c.strs = append([]string(nil), b.strs...)
+	for _, v := range b.vals {
+		c.vals = append(c.vals, in.convert(v, v.t, node))
+	}
+	return c
+}
+
+// cString lee la cadena terminada en '\0' a la que apunta un char* o char[]
+func (in *interpreter) cString(v rtValue, node *models.SyntaxNode) string {
+	loc := v.ptr
+	if v.t.isArray() {
+		loc = location{blk: v.blk}
+	}
+	var text []byte
+	for {
+		c := in.load(loc, node)
+		if c.i == 0 {
+			in.work(len(text), node)
+			return string(text)
+		}
+		text = append(text, byte(c.i))
+		loc.index++
+	}
+}
+
+// truth convierte un valor en condición
+func (in *interpreter) truth(v rtValue) bool {
+	switch {
+	case v.t.Name == typeIstream:
+		return !in.input.failed
+	case v.t.isArray():
+		return true
+	case v.t.Pointer > 0:
+		return v.ptr.blk != nil
+	case v.t.isFloating():
+		return v.f != 0
+	}
+	return v.i != 0
+}
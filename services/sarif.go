@@ -0,0 +1,164 @@
+package services
+
+import (
+	"net/url"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+// Nombre del analizador en los informes y versión de SARIF que se genera
+const (
+	toolName     = "analizador-cpp"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+)
+
+// Niveles de SARIF para cada severidad
+var sarifLevels = map[string]string{
+	SeverityError:   "error",
+	SeverityWarning: "warning",
+	SeverityInfo:    "note",
+}
+
+// SarifLog convierte el análisis de las entregas en un informe SARIF 2.1.0
+// con una sola ejecución. Las reglas son el catálogo completo de
+// diagnósticos; la ubicación de cada resultado es el archivo de la entrega
+// (Name/File, o main.cpp en un análisis sin nombre), el camino de
+// Diagnostic.Path es su codeFlow y sus correcciones son fixes.
+func SarifLog(results []models.SubmissionResult) models.SarifLog {
+	rules := make([]models.SarifRule, len(diagnosticRules))
+	ruleIndex := make(map[string]int, len(diagnosticRules))
+	for i, rule := range diagnosticRules {
+		rules[i] = models.SarifRule{
+			ID:                   rule.Code,
+			ShortDescription:     models.SarifMessage{Text: rule.Title},
+			DefaultConfiguration: models.SarifConfiguration{Level: sarifLevels[rule.Severity]},
+			Properties:           map[string]string{"phase": rule.Phase},
+		}
+		ruleIndex[rule.Code] = i
+	}
+
+	invocation := models.SarifInvocation{ExecutionSuccessful: true}
+	sarifResults := []models.SarifResult{}
+	for _, r := range results {
+		for _, d := range AllDiagnostics(r.Analysis) {
+			sarifResults = append(sarifResults, sarifResult(r.Name, d, ruleIndex))
+		}
+
+		location := []models.SarifLocation{{PhysicalLocation: models.SarifPhysicalLocation{
+			ArtifactLocation: models.SarifArtifactLocation{URI: artifactURI(r.Name, "")},
+		}}}
+		if r.Error != "" {
+			invocation.ExecutionSuccessful = false
+			invocation.Notifications = append(invocation.Notifications, models.SarifNotification{
+				Level: "error", Message: models.SarifMessage{Text: r.Error}, Locations: location,
+			})
+		}
+		if semantic := r.Analysis.SemanticAnalysis; semantic != nil && semantic.Status == SemanticSkipped {
+			invocation.Notifications = append(invocation.Notifications, models.SarifNotification{
+				Level: "note", Message: models.SarifMessage{Text: semantic.Reason}, Locations: location,
+			})
+		}
+	}
+
+	return models.SarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []models.SarifRun{{
+			Tool:        models.SarifTool{Driver: models.SarifDriver{Name: toolName, Rules: rules}},
+			Invocations: []models.SarifInvocation{invocation},
+			Results:     sarifResults,
+			ColumnKind:  "unicodeCodePoints",
+		}},
+	}
+}
+
+// sarifResult convierte un diagnóstico de la entrega indicada. Un código
+// fuera del catálogo queda con ruleIndex -1, que SARIF interpreta como
+// ausente.
+func sarifResult(name string, d models.Diagnostic, ruleIndex map[string]int) models.SarifResult {
+	index, ok := ruleIndex[d.Code]
+	if !ok {
+		index = -1
+	}
+	result := models.SarifResult{
+		RuleID:    d.Code,
+		RuleIndex: index,
+		Level:     sarifLevels[d.Severity],
+		Message:   models.SarifMessage{Text: d.Message},
+		Locations: []models.SarifLocation{{PhysicalLocation: models.SarifPhysicalLocation{
+			ArtifactLocation: models.SarifArtifactLocation{URI: artifactURI(name, d.File)},
+			Region: &models.SarifRegion{
+				StartLine:   d.StartLine,
+				StartColumn: d.StartColumn,
+				EndLine:     d.EndLine,
+				EndColumn:   d.EndColumn,
+			},
+		}}},
+		Properties: map[string]string{"phase": d.Phase},
+	}
+	if d.Suggestion != "" {
+		result.Properties["suggestion"] = d.Suggestion
+	}
+
+	if len(d.Path) > 0 {
+		flow := models.SarifThreadFlow{}
+		for _, step := range d.Path {
+			file := step.File
+			if file == "" {
+				file = d.File
+			}
+			flow.Locations = append(flow.Locations, models.SarifThreadFlowLocation{Location: models.SarifLocation{
+				PhysicalLocation: models.SarifPhysicalLocation{
+					ArtifactLocation: models.SarifArtifactLocation{URI: artifactURI(name, file)},
+					Region:           &models.SarifRegion{StartLine: step.Line, StartColumn: step.Column},
+				},
+				Message: &models.SarifMessage{Text: step.Message},
+			}})
+		}
+		result.CodeFlows = []models.SarifCodeFlow{{ThreadFlows: []models.SarifThreadFlow{flow}}}
+	}
+
+	for _, fix := range d.Fixes {
+		result.Fixes = append(result.Fixes, sarifFix(name, d.File, fix))
+	}
+	return result
+}
+
+// sarifFix agrupa las ediciones de la corrección por archivo, en el orden
+// en que aparecen
+func sarifFix(name, file string, fix models.Fix) models.SarifFix {
+	result := models.SarifFix{Description: models.SarifMessage{Text: fix.Title}}
+	changes := make(map[string]int)
+	for _, edit := range fix.Edits {
+		uri := artifactURI(name, file)
+		if edit.File != "" {
+			uri = artifactURI(name, edit.File)
+		}
+		i, ok := changes[uri]
+		if !ok {
+			i = len(result.ArtifactChanges)
+			changes[uri] = i
+			result.ArtifactChanges = append(result.ArtifactChanges, models.SarifArtifactChange{
+				ArtifactLocation: models.SarifArtifactLocation{URI: uri},
+			})
+		}
+
+		replacement := models.SarifReplacement{DeletedRegion: models.SarifRegion{
+			StartLine:   edit.StartLine,
+			StartColumn: edit.StartColumn,
+			EndLine:     edit.EndLine,
+			EndColumn:   edit.EndColumn,
+		}}
+		if edit.NewText != "" {
+			replacement.InsertedContent = &models.SarifMessage{Text: edit.NewText}
+		}
+		result.ArtifactChanges[i].Replacements = append(result.ArtifactChanges[i].Replacements, replacement)
+	}
+	return result
+}
+
+// artifactURI es la referencia relativa al archivo de una entrega
+func artifactURI(name, file string) string {
+	return (&url.URL{Path: submissionFile(name, file)}).String()
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+// submissionWith es una entrega cuyo análisis semántico tiene los diagnósticos indicados
+func submissionWith(name string, ds ...models.Diagnostic) models.SubmissionResult {
+	return models.SubmissionResult{Name: name, Analysis: models.AnalysisResult{
+		SemanticAnalysis: &models.SemanticResult{Status: SemanticAnalyzed, Diagnostics: ds},
+	}}
+}
+
+func TestSarifLocations(t *testing.T) {
+	d := models.Diagnostic{Phase: PhaseSemantic, Code: CodeUndeclared, Severity: SeverityError,
+		Message: "Variable 'y' usada pero no declarada", StartLine: 2, StartColumn: 12, EndLine: 2, EndColumn: 13}
+	inFile := d
+	inFile.File = "src/main.cpp"
+
+	tests := []struct {
+		name   string
+		result models.SubmissionResult
+		uri    string
+	}{
+		{"sin nombre", submissionWith("", d), "main.cpp"},
+		{"archivo", submissionWith("ana.cpp", d), "ana.cpp"},
+		{"proyecto", submissionWith("equipo", inFile), "equipo/src/main.cpp"},
+		{"espacios", submissionWith("mi tarea.cpp", d), "mi%20tarea.cpp"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := SarifLog([]models.SubmissionResult{tt.result}).Runs[0]
+			if len(run.Results) != 1 {
+				t.Fatalf("%d resultados, se esperaba 1", len(run.Results))
+			}
+			r := run.Results[0]
+			location := r.Locations[0].PhysicalLocation
+			if location.ArtifactLocation.URI != tt.uri {
+				t.Errorf("uri = %q, se esperaba %q", location.ArtifactLocation.URI, tt.uri)
+			}
+			want := models.SarifRegion{StartLine: 2, StartColumn: 12, EndLine: 2, EndColumn: 13}
+			if *location.Region != want {
+				t.Errorf("región = %+v, se esperaba %+v", *location.Region, want)
+			}
+			if r.RuleID != CodeUndeclared || r.Level != "error" || run.Tool.Driver.Rules[r.RuleIndex].ID != CodeUndeclared {
+				t.Errorf("regla = %s (%d), nivel %s", r.RuleID, r.RuleIndex, r.Level)
+			}
+		})
+	}
+}
+
+func TestSarifLevelsAndRules(t *testing.T) {
+	tests := []struct {
+		severity, code string
+		level          string
+		ruleIndex      bool
+	}{
+		{SeverityError, CodeUndeclared, "error", true},
+		{SeverityWarning, CodeUndeclared, "warning", true},
+		{SeverityInfo, CodeUndeclared, "note", true},
+		{SeverityError, "XYZ999", "error", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.severity+" "+tt.code, func(t *testing.T) {
+			log := SarifLog([]models.SubmissionResult{submissionWith("a.cpp", models.Diagnostic{Code: tt.code, Severity: tt.severity})})
+			r := log.Runs[0].Results[0]
+			if r.Level != tt.level {
+				t.Errorf("nivel = %q, se esperaba %q", r.Level, tt.level)
+			}
+			if (r.RuleIndex >= 0) != tt.ruleIndex {
+				t.Errorf("ruleIndex = %d", r.RuleIndex)
+			}
+		})
+	}
+
+	log := SarifLog(nil)
+	if log.Version != "2.1.0" || len(log.Runs) != 1 || len(log.Runs[0].Tool.Driver.Rules) != len(diagnosticRules) {
+		t.Errorf("versión %s, %d ejecuciones, se esperaban todas las reglas del catálogo", log.Version, len(log.Runs))
+	}
+	if log.Runs[0].Results == nil {
+		t.Error("sin diagnósticos, results debe ser una lista vacía")
+	}
+}
+
+func TestSarifCodeFlowsAndFixes(t *testing.T) {
+	d := models.Diagnostic{Code: CodeUndeclared, Severity: SeverityWarning, File: "main.cpp",
+		Path: []models.PathStep{
+			{Message: "se declara", Line: 1, Column: 5},
+			{Message: "se usa", File: "util.cpp", Line: 3, Column: 9},
+		},
+		Fixes: []models.Fix{{Title: "Inicializar", Edits: []models.TextEdit{
+			{StartLine: 1, StartColumn: 10, EndLine: 1, EndColumn: 10, NewText: " = 0"},
+			{File: "util.cpp", StartLine: 3, StartColumn: 1, EndLine: 3, EndColumn: 4},
+			{StartLine: 2, StartColumn: 1, EndLine: 2, EndColumn: 1, NewText: "x"},
+		}}},
+	}
+	r := SarifLog([]models.SubmissionResult{submissionWith("equipo", d)}).Runs[0].Results[0]
+
+	var steps []string
+	for _, l := range r.CodeFlows[0].ThreadFlows[0].Locations {
+		steps = append(steps, l.Location.PhysicalLocation.ArtifactLocation.URI+" "+l.Location.Message.Text)
+	}
+	if want := []string{"equipo/main.cpp se declara", "equipo/util.cpp se usa"}; !reflect.DeepEqual(steps, want) {
+		t.Errorf("pasos = %v, se esperaba %v", steps, want)
+	}
+
+	changes := r.Fixes[0].ArtifactChanges
+	tests := []struct {
+		uri      string
+		inserted []string
+	}{
+		{"equipo/main.cpp", []string{" = 0", "x"}},
+		{"equipo/util.cpp", []string{""}},
+	}
+	if len(changes) != len(tests) {
+		t.Fatalf("%d archivos cambiados, se esperaban %d", len(changes), len(tests))
+	}
+	for i, tt := range tests {
+		var inserted []string
+		for _, rep := range changes[i].Replacements {
+			text := ""
+			if rep.InsertedContent != nil {
+				text = rep.InsertedContent.Text
+			}
+			inserted = append(inserted, text)
+		}
+		if changes[i].ArtifactLocation.URI != tt.uri || !reflect.DeepEqual(inserted, tt.inserted) {
+			t.Errorf("cambio %d = %s %q, se esperaba %s %q", i, changes[i].ArtifactLocation.URI, inserted, tt.uri, tt.inserted)
+		}
+	}
+}
+
+func TestSarifNotifications(t *testing.T) {
+	skipped := models.SubmissionResult{Name: "b.cpp", Analysis: models.AnalysisResult{
+		SemanticAnalysis: &models.SemanticResult{Status: SemanticSkipped, Reason: "Se omitió"},
+	}}
+	failed := models.SubmissionResult{Name: "c.cpp", Error: "El análisis no pudo terminar"}
+
+	tests := []struct {
+		name       string
+		results    []models.SubmissionResult
+		successful bool
+		levels     []string
+	}{
+		{"sin avisos", []models.SubmissionResult{submissionWith("a.cpp")}, true, nil},
+		{"semántico omitido", []models.SubmissionResult{skipped}, true, []string{"note"}},
+		{"fallo del análisis", []models.SubmissionResult{skipped, failed}, false, []string{"note", "error"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invocation := SarifLog(tt.results).Runs[0].Invocations[0]
+			if invocation.ExecutionSuccessful != tt.successful {
+				t.Errorf("executionSuccessful = %v, se esperaba %v", invocation.ExecutionSuccessful, tt.successful)
+			}
+			var levels []string
+			for _, n := range invocation.Notifications {
+				levels = append(levels, n.Level)
+			}
+			if !reflect.DeepEqual(levels, tt.levels) {
+				t.Errorf("avisos = %v, se esperaba %v", levels, tt.levels)
+			}
+		})
+	}
+}
@@ -2,297 +2,385 @@ package services
 
 import (
 	"regexp"
-	"strconv"
 	"strings"
 	"github.com/didiercito/api-go-examen2/models"
 )
 
-type CppVariable struct {
-	Name         string
-	Type         string
-	Value        string
-	Line         int
-	IsInitialized bool
-}
+// semanticAnalyzer recorre el árbol sintáctico manteniendo la tabla de
+// símbolos con sus ámbitos anidados
+type semanticAnalyzer struct {
+	table       *symbolTable
+	diagnostics []models.Diagnostic
+	variables   int
+	functions   int
+	undeclared  map[string]bool
+	function    *models.SyntaxNode // función que se está analizando
+	classes     map[string]*classInfo
+	class       *classInfo // clase del método que se está analizando
+	source      *sourceFiles // archivos del proyecto, o nil con un solo archivo
+
+	// Símbolo al que se resolvió cada identificador, cada obj.miembro y cada
+	// función llamada, y símbolo creado por cada Declarator o Param, para el
+	// flujo de datos y el editor
+	references   map[*models.SyntaxNode]*symbol
+	declarations map[*models.SyntaxNode]*symbol
+
+	// Sobrecarga elegida en cada llamada a una función del programa, para
+	// el intérprete
+	calls map[*models.SyntaxNode]*models.SyntaxNode
+
+	// Valor de las expresiones constantes ya evaluadas; nil si no lo son
+	constants map[*models.SyntaxNode]*constValue
+
+	// Biblioteca estándar: encabezados disponibles, símbolos y clases
+	// usados hasta ahora y nombres ya informados como faltantes
+	headers         map[string]bool
+	unknownIncludes bool
+	usingStd        bool
+	library        *scope
+	libraryClasses map[string]*classInfo
+	missingLibrary map[string]bool
 
-type CppFunction struct {
-	Name       string
-	ReturnType string
-	Parameters []string
-	Line       int
+	// Para las correcciones automáticas: la declaración de cada variable
+	// local escrita como sentencia propia y el último #include de cada archivo
+	statements  map[*models.SyntaxNode]declStatement
+	lastInclude map[int]*models.SyntaxNode
 }
 
 func AnalyzeSemantic(code string) models.SemanticResult {
-	lines := strings.Split(code, "\n")
-	variables := 0
-	functions := 0
-	var errors []string
-	var declaredVars []CppVariable
-	var declaredFuncs []CppFunction
-	var usedVars []string
-
-	for lineNum, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "//") || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "using namespace") {
-			continue
-		}
-		
-		// Analizar declaraciones de funciones
-		if isFunctionDeclaration(line) {
-			functions++
-			function := parseFunctionDeclaration(line, lineNum+1)
-			if function.Name != "" {
-				declaredFuncs = append(declaredFuncs, function)
-			}
-		}
-		
-		// Analizar declaraciones de variables
-		if isVariableDeclaration(line) {
-			variableCount := countVariablesInDeclaration(line)
-			variables += variableCount
-			
-			parsedVars := parseVariableDeclaration(line, lineNum+1)
-			for _, variable := range parsedVars {
-				if variable.Name != "" {
-					// Verificar si ya existe la variable
-					if isVariableAlreadyDeclared(variable.Name, declaredVars) {
-						errors = append(errors, 
-							"Línea "+strconv.Itoa(lineNum+1)+": Variable '"+variable.Name+
-							"' ya fue declarada anteriormente")
-					} else {
-						declaredVars = append(declaredVars, variable)
-						
-						// Verificar compatibilidad de tipos en asignación
-						if variable.IsInitialized {
-							if !isTypeCompatible(variable.Type, variable.Value) {
-								errors = append(errors, 
-									"Línea "+strconv.Itoa(lineNum+1)+": Error de tipo - Variable '"+variable.Name+
-									"' de tipo "+variable.Type+" no puede ser asignada con valor de tipo "+inferValueType(variable.Value))
-							}
-						}
-					}
-				}
-			}
-		}
-		
-		// Analizar asignaciones a variables existentes
-		if isAssignment(line) && !isVariableDeclaration(line) {
-			varName, value := parseAssignment(line)
-			if varName != "" {
-				if !isVariableAlreadyDeclared(varName, declaredVars) {
-					errors = append(errors, 
-						"Línea "+strconv.Itoa(lineNum+1)+": Variable '"+varName+
-						"' usada pero no declarada")
-				} else {
-					// Verificar compatibilidad de tipos
-					varType := getVariableType(varName, declaredVars)
-					if !isTypeCompatible(varType, value) {
-						errors = append(errors, 
-							"Línea "+strconv.Itoa(lineNum+1)+": Error de tipo - No se puede asignar "+
-							inferValueType(value)+" a variable de tipo "+varType)
-					}
-				}
-			}
-		}
-		
-		// Extraer variables usadas en la línea (excluyendo string literals)
-		varsInLine := extractVariablesFromLine(line, declaredFuncs)
-		usedVars = append(usedVars, varsInLine...)
+	tree, _ := Parse(code)
+	return analyzeTree(tree, nil)
+}
+
+func analyzeTree(tree *models.SyntaxNode, source *sourceFiles) models.SemanticResult {
+	return semanticResult(runAnalyzer(tree, source))
+}
+
+// semanticResult es el resultado del análisis ya hecho
+func semanticResult(a *semanticAnalyzer) models.SemanticResult {
+	return models.SemanticResult{
+		Variables:   a.variables,
+		Functions:   a.functions,
+		Diagnostics: diagnosticList(a.diagnostics),
+		SymbolTable: a.table.export(),
+		Status:      SemanticAnalyzed,
 	}
-	
-	// Verificar variables usadas pero no declaradas
-	uniqueUsedVars := removeDuplicates(usedVars)
-	for _, usedVar := range uniqueUsedVars {
-		if !isVariableAlreadyDeclared(usedVar, declaredVars) && 
-		   !isCppBuiltinOrKeyword(usedVar) && 
-		   !isFunctionName(usedVar, declaredFuncs) {
-			errors = append(errors, "Variable '"+usedVar+"' usada pero no declarada")
+}
+
+// runAnalyzer analiza el árbol y devuelve el analizador con sus
+// resoluciones, que el intérprete usa para ejecutar el programa
+func runAnalyzer(tree *models.SyntaxNode, source *sourceFiles) *semanticAnalyzer {
+	a := &semanticAnalyzer{
+		table:      newSymbolTable(),
+		undeclared: make(map[string]bool),
+		classes:    make(map[string]*classInfo),
+		source:     source,
+
+		references:   make(map[*models.SyntaxNode]*symbol),
+		declarations: make(map[*models.SyntaxNode]*symbol),
+		calls:        make(map[*models.SyntaxNode]*models.SyntaxNode),
+		constants:    make(map[*models.SyntaxNode]*constValue),
+
+		library:        &scope{kind: ScopeLibrary, name: "std", symbols: make(map[string]*symbol)},
+		libraryClasses: make(map[string]*classInfo),
+		missingLibrary: make(map[string]bool),
+
+		statements:  make(map[*models.SyntaxNode]declStatement),
+		lastInclude: make(map[int]*models.SyntaxNode),
+	}
+	a.collectIncludes(tree)
+	a.analyzeProgram(tree)
+	return a
+}
+
+func (a *semanticAnalyzer) report(diagnostic models.Diagnostic) {
+	a.diagnostics = append(a.diagnostics, diagnostic)
+}
+
+func (a *semanticAnalyzer) analyzeProgram(tree *models.SyntaxNode) {
+	// Registrar primero todas las clases y funciones para conocer sus
+	// firmas; el uso antes de la declaración se informa en cada llamada
+	for _, node := range tree.Children {
+		switch {
+		case node.Kind == NodeClass:
+			a.declareClass(node)
+		case node.Kind == NodeFunction && strings.Contains(node.Value, "::"):
+			a.declareMethodDefinition(node)
+		case node.Kind == NodeFunction:
+			a.declareFunction(node)
 		}
 	}
 
-	// Verificar variables declaradas pero no usadas (excluyendo main que es especial)
-	for _, declaredVar := range declaredVars {
-		if !contains(usedVars, declaredVar.Name) && declaredVar.Name != "main" {
-			errors = append(errors, "Variable '"+declaredVar.Name+"' declarada pero no usada")
+	for _, node := range tree.Children {
+		switch {
+		case node.Kind == NodeClass:
+			a.analyzeClass(node)
+		case node.Kind == NodeFunction && strings.Contains(node.Value, "::"):
+			a.analyzeMethod(node)
+		case node.Kind == NodeFunction:
+			a.analyzeFunction(node)
+		case node.Kind == NodeVarDecl:
+			a.analyzeVarDecl(node)
 		}
 	}
 
-	return models.SemanticResult{
-		Variables: variables,
-		Functions: functions,
-		Errors:    errors,
+	a.reportUnusedVariables()
+	for _, graph := range functionGraphs(tree) {
+		a.checkReachability(graph)
+		a.checkDataFlow(graph)
 	}
 }
 
-func countVariablesInDeclaration(line string) int {
-	// Contar cuántas variables se declaran en una línea
-	// Ejemplo: int a, b, c; = 3 variables
-	if !isVariableDeclaration(line) {
-		return 0
+func (a *semanticAnalyzer) analyzeFunction(node *models.SyntaxNode) {
+	body := functionBody(node)
+	if body == nil {
+		return
 	}
-	
-	// Remover el tipo y quedarnos con las variables
-	re := regexp.MustCompile(`^\s*(int|float|double|char|bool|string)\s+(.+);?\s*$`)
-	matches := re.FindStringSubmatch(line)
-	
-	if len(matches) >= 3 {
-		varPart := matches[2]
-		varPart = strings.TrimSuffix(varPart, ";")
-		
-		// Dividir por comas para contar variables
-		vars := strings.Split(varPart, ",")
-		return len(vars)
+
+	if node.Type != "" {
+		a.checkTypeName(node.Type, node)
 	}
-	
-	return 1
-}
 
-func isFunctionDeclaration(line string) bool {
-	// Buscar patrones de declaración de función
-	patterns := []string{
-		`(int|void|float|double|char|bool|string)\s+\w+\s*\([^)]*\)\s*\{?`,
+	// Los parámetros y el cuerpo comparten el ámbito de la función
+	a.function = node
+	a.table.push(ScopeFunction, node.Value, node.Line)
+	for _, param := range node.Children {
+		if param.Kind != NodeParam {
+			continue
+		}
+		a.checkTypeName(param.Type, param)
+		a.checkArraySizes(param)
+		if param.Value == "" {
+			continue
+		}
+		a.declareVariable(param, param.Value, a.resolvedArrayType(param), SymbolParameter, true)
 	}
-	
-	for _, pattern := range patterns {
-		if matched, _ := regexp.MatchString(pattern, line); matched {
-			return true
+	a.checkMemberInits(node)
+	for i, stmt := range body.Children {
+		if stmt.Kind == NodeVarDecl {
+			a.recordStatement(body, i)
 		}
+		a.analyzeStatement(stmt)
 	}
-	return false
+	a.table.pop()
+	a.function = nil
 }
 
-func parseFunctionDeclaration(line string, lineNum int) CppFunction {
-	// Extraer información de la función
-	re := regexp.MustCompile(`(int|void|float|double|char|bool|string)\s+(\w+)\s*\(([^)]*)\)`)
-	matches := re.FindStringSubmatch(line)
-	
-	if len(matches) >= 3 {
-		returnType := strings.TrimSpace(matches[1])
-		name := strings.TrimSpace(matches[2])
-		params := strings.TrimSpace(matches[3])
-		
-		var parameters []string
-		if params != "" {
-			parameters = strings.Split(params, ",")
-			for i, param := range parameters {
-				parameters[i] = strings.TrimSpace(param)
+func (a *semanticAnalyzer) analyzeStatement(node *models.SyntaxNode) {
+	switch node.Kind {
+	case NodeBlock:
+		a.table.push(ScopeBlock, "", node.Line)
+		for i, stmt := range node.Children {
+			if stmt.Kind == NodeVarDecl {
+				a.recordStatement(node, i)
+			}
+			a.analyzeStatement(stmt)
+		}
+		a.table.pop()
+
+	case NodeVarDecl:
+		a.analyzeVarDecl(node)
+
+	case NodeIf, NodeWhile:
+		a.checkCondition(node.Children[0])
+		for _, branch := range node.Children[1:] {
+			a.analyzeStatement(branch)
+		}
+
+	case NodeDoWhile:
+		a.analyzeStatement(node.Children[0])
+		a.checkCondition(node.Children[1])
+
+	case NodeFor:
+		// Las variables declaradas en la inicialización solo existen dentro del for
+		a.table.push(ScopeFor, "", node.Line)
+		a.analyzeStatement(node.Children[0])
+		a.checkCondition(node.Children[1])
+		a.checkExpr(node.Children[2])
+		a.analyzeStatement(node.Children[3])
+		a.table.pop()
+
+	case NodeRangeFor:
+		a.table.push(ScopeFor, "", node.Line)
+		a.analyzeRangeFor(node)
+		a.table.pop()
+
+	case NodeSwitch:
+		if t := a.checkExpr(node.Children[0]); !t.isUnknown() && !t.isIntegral() {
+			a.report(diagnosticAtNode(CodeTypeMismatch,
+				"La expresión del switch debe ser entera, se encontró "+t.String(), node.Children[0]))
+		}
+		a.table.push(ScopeBlock, "", node.Line)
+		cases := make(map[string]*models.SyntaxNode)
+		for _, label := range node.Children[1:] {
+			stmts := label.Children
+			if label.Kind == NodeCase {
+				a.checkCaseLabel(label.Children[0], cases)
+				stmts = label.Children[1:]
+			}
+			for _, stmt := range stmts {
+				a.analyzeStatement(stmt)
 			}
 		}
-		
-		return CppFunction{
-			Name:       name,
-			ReturnType: returnType,
-			Parameters: parameters,
-			Line:       lineNum,
+		a.table.pop()
+
+	case NodeReturn:
+		a.checkReturn(node)
+
+	case NodeExprStmt:
+		for _, child := range node.Children {
+			a.checkExpr(child)
 		}
 	}
-	
-	return CppFunction{}
 }
 
-func parseVariableDeclaration(line string, lineNum int) []CppVariable {
-	var variables []CppVariable
-	
-	// Patrón para declaraciones: tipo var1 = val1, var2 = val2;
-	re := regexp.MustCompile(`^\s*(int|float|double|char|bool|string)\s+(.+);?\s*$`)
-	matches := re.FindStringSubmatch(line)
-	
-	if len(matches) >= 3 {
-		varType := strings.TrimSpace(matches[1])
-		varsPart := strings.TrimSpace(matches[2])
-		varsPart = strings.TrimSuffix(varsPart, ";")
-		
-		// Dividir por comas para manejar múltiples variables
-		varDecls := strings.Split(varsPart, ",")
-		
-		for _, varDecl := range varDecls {
-			varDecl = strings.TrimSpace(varDecl)
-			
-			if strings.Contains(varDecl, "=") {
-				// Variable con inicialización
-				parts := strings.SplitN(varDecl, "=", 2)
-				name := strings.TrimSpace(parts[0])
-				value := strings.TrimSpace(parts[1])
-				
-				variables = append(variables, CppVariable{
-					Name:          name,
-					Type:          varType,
-					Value:         value,
-					Line:          lineNum,
-					IsInitialized: true,
-				})
-			} else {
-				// Variable sin inicialización
-				name := strings.TrimSpace(varDecl)
-				variables = append(variables, CppVariable{
-					Name:          name,
-					Type:          varType,
-					Value:         "",
-					Line:          lineNum,
-					IsInitialized: false,
-				})
-			}
+func (a *semanticAnalyzer) analyzeVarDecl(node *models.SyntaxNode) {
+	a.checkTypeName(node.Type, node)
+	for _, declarator := range node.Children {
+		if strings.Contains(declarator.Value, "::") {
+			a.checkStaticDefinition(declarator)
+			continue
+		}
+		a.checkArraySizes(declarator)
+		init := initializer(declarator)
+		initType := unknownType
+		if init != nil && init.Kind != NodeInitList {
+			initType = a.checkExpr(init)
+		}
+
+		typ := a.resolvedArrayType(declarator)
+		if isAutoType(node.Type) {
+			typ = deduceAuto(declarator.Type, initType)
 		}
+		sym := a.declareVariable(declarator, declarator.Value, typ, SymbolVariable, init != nil)
+		if sym == nil {
+			continue
+		}
+		a.variables++
+		a.checkInitializer(declarator, sym, init, initType)
+		a.recordConstant(sym, init)
 	}
-	
-	return variables
 }
 
-func isAssignment(line string) bool {
-	// Buscar patrones de asignación (excluyendo declaraciones)
-	return strings.Contains(line, "=") && 
-		   !strings.Contains(line, "==") && 
-		   !strings.Contains(line, "!=") &&
-		   !strings.Contains(line, "<=") &&
-		   !strings.Contains(line, ">=") &&
-		   !isVariableDeclaration(line)
+// declareVariable agrega una variable o parámetro al ámbito actual,
+// informando redeclaraciones en el mismo ámbito y ocultamientos de ámbitos externos
+func (a *semanticAnalyzer) declareVariable(node *models.SyntaxNode, name, typ, kind string, initialized bool) *symbol {
+	if existing := a.table.current.lookupLocal(name); existing != nil {
+		a.report(nameDiagnostic(CodeRedeclared,
+			"Variable '"+name+"' ya fue declarada en este ámbito (línea "+a.source.lineRef(existing.Line)+")", node, name))
+		return nil
+	}
+
+	// Un parámetro con el nombre de un atributo (Punto(int x) : x(x)) es
+	// habitual y no se advierte
+	if outer := a.table.current.lookup(name); outer != nil && (outer.Kind == SymbolVariable || outer.Kind == SymbolParameter) {
+		a.report(nameDiagnostic(CodeShadowedVariable,
+			"La variable '"+name+"' oculta a la declarada en la línea "+a.source.lineRef(outer.Line), node, name))
+	}
+
+	sym := &symbol{
+		Name:        name,
+		Kind:        kind,
+		Type:        typ,
+		Line:        node.Line,
+		Column:      node.Column,
+		Initialized: initialized,
+		node:        node,
+	}
+	a.table.declare(sym)
+	a.declarations[node] = sym
+	return sym
 }
 
-func parseAssignment(line string) (string, string) {
-	if !strings.Contains(line, "=") {
-		return "", ""
+// nameDiagnostic crea un diagnóstico que cubre solo el nombre declarado
+func nameDiagnostic(code, message string, node *models.SyntaxNode, name string) models.Diagnostic {
+	line, column := node.Line, node.Column
+	if node.Kind == NodeParam || node.Kind == NodeFunction {
+		// En parámetros y funciones el nodo empieza en el tipo
+		return diagnosticAtNode(code, message, node)
 	}
-	
-	parts := strings.SplitN(line, "=", 2)
-	if len(parts) >= 2 {
-		varName := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
-		value = strings.TrimSuffix(value, ";")
-		
-		// Extraer solo el nombre de la variable (sin tipo)
-		varParts := strings.Fields(varName)
-		if len(varParts) > 0 {
-			varName = varParts[len(varParts)-1]
+	return newDiagnostic(code, message, line, column, line, column+len(name))
+}
+
+// resolveIdentifier busca el identificador desde el ámbito actual hacia
+// afuera. called indica que se usa como función en una llamada, para
+// nombrarlo así si no está declarado.
+func (a *semanticAnalyzer) resolveIdentifier(node *models.SyntaxNode, called bool) *symbol {
+	name := node.Value
+	if sym := a.table.current.lookup(name); sym != nil {
+		sym.Used = true
+		a.references[node] = sym
+		if sym.access != "" {
+			a.checkAccess(sym, node)
 		}
-		
-		return varName, value
+		return sym
 	}
-	
-	return "", ""
+
+	// Miembro calificado: Clase::contador, Clase::crear(), string::npos
+	if className, member, ok := splitQualified(name); ok {
+		if info := a.classOf(typeFromString(className)); info != nil && info.scope != nil {
+			if sym := info.lookupMember(member); sym != nil {
+				sym.Used = true
+				a.checkAccess(sym, node)
+				return sym
+			}
+			a.report(diagnosticAtNode(CodeUnknownMember,
+				"La clase '"+className+"' no tiene un miembro llamado '"+member+"'", node))
+			return nil
+		}
+	}
+
+	// Biblioteca estándar: std::sqrt o sqrt
+	if base := strings.TrimPrefix(name, "std::"); !strings.Contains(base, "::") {
+		if sym := a.librarySymbol(base); sym != nil {
+			sym.Used = true
+			a.references[node] = sym
+			a.checkLibraryUse(sym, node, base != name)
+			return sym
+		}
+	}
+
+	if strings.HasPrefix(name, "std::") || isCppBuiltinOrKeyword(name) {
+		return nil
+	}
+
+	// Informar cada nombre no declarado una sola vez
+	if !a.undeclared[name] {
+		a.undeclared[name] = true
+		message := "Variable '" + name + "' usada pero no declarada"
+		if called {
+			message = "Función '" + name + "' llamada pero no declarada"
+		}
+		a.report(diagnosticAtNode(CodeUndeclared, message, node))
+	}
+	return nil
 }
 
-func isTypeCompatible(varType, value string) bool {
-	// Si es un string literal, no validar como variable
-	if isStringLiteral(value) {
-		return varType == "string"
+// literalText reconstruye el texto de un literal tal como aparece en el código
+func literalText(node *models.SyntaxNode) string {
+	switch node.Kind {
+	case NodeStringLiteral:
+		return `"` + node.Value + `"`
+	case NodeCharLiteral:
+		return "'" + node.Value + "'"
+	case NodeIntLiteral, NodeFloatLiteral, NodeBoolLiteral:
+		return node.Value
 	}
-	
-	valueType := inferValueType(value)
-	
-	switch varType {
-	case "int":
-		return valueType == "int"
-	case "float", "double":
-		return valueType == "float" || valueType == "int"
-	case "char":
-		return valueType == "char"
-	case "bool":
-		return valueType == "bool"
-	case "string":
-		return valueType == "string"
+	return ""
+}
+
+// reportUnusedVariables advierte sobre las variables que nunca se referencian
+func (a *semanticAnalyzer) reportUnusedVariables() {
+	for _, s := range a.table.scopes {
+		for _, sym := range s.order {
+			if sym.Kind == SymbolVariable && !sym.Used {
+				diagnostic := nameDiagnostic(CodeUnusedVariable,
+					"Variable '"+sym.Name+"' declarada pero no usada", sym.node, sym.Name)
+				diagnostic.Fixes = a.removeDeclarationFix(sym)
+				a.report(diagnostic)
+			}
+		}
 	}
-	
-	return false
 }
 
 func isStringLiteral(value string) bool {
@@ -331,74 +419,6 @@ func inferValueType(value string) string {
 	return "identifier"
 }
 
-func isVariableAlreadyDeclared(varName string, declaredVars []CppVariable) bool {
-	for _, v := range declaredVars {
-		if v.Name == varName {
-			return true
-		}
-	}
-	return false
-}
-
-func getVariableType(varName string, declaredVars []CppVariable) string {
-	for _, v := range declaredVars {
-		if v.Name == varName {
-			return v.Type
-		}
-	}
-	return ""
-}
-
-func isFunctionName(name string, functions []CppFunction) bool {
-	for _, f := range functions {
-		if f.Name == name {
-			return true
-		}
-	}
-	return false
-}
-
-func extractVariablesFromLine(line string, declaredFuncs []CppFunction) []string {
-	var variables []string
-	
-	// Remover string literals para no analizarlos
-	cleanLine := removeStringLiteralsForSemantic(line)
-	
-	// Extraer identificadores (posibles variables)
-	re := regexp.MustCompile(`\b[a-zA-Z_][a-zA-Z0-9_]*\b`)
-	matches := re.FindAllString(cleanLine, -1)
-	
-	for _, match := range matches {
-		if !isCppBuiltinOrKeyword(match) && 
-		   !isFunctionName(match, declaredFuncs) && 
-		   match != "main" { // main es función especial
-			variables = append(variables, match)
-		}
-	}
-	
-	return variables
-}
-
-func removeStringLiteralsForSemantic(line string) string {
-	// Remover string literals para análisis semántico
-	stringRegex := regexp.MustCompile(`"[^"]*"`)
-	return stringRegex.ReplaceAllString(line, `""`)
-}
-
-func removeDuplicates(slice []string) []string {
-	keys := make(map[string]bool)
-	var result []string
-	
-	for _, item := range slice {
-		if !keys[item] {
-			keys[item] = true
-			result = append(result, item)
-		}
-	}
-	
-	return result
-}
-
 func isCppBuiltinOrKeyword(word string) bool {
 	keywords := []string{
 		"int", "float", "double", "char", "bool", "void", "string",
@@ -409,7 +429,7 @@ func isCppBuiltinOrKeyword(word string) bool {
 		"auto", "signed", "unsigned", "long", "short", "inline",
 		"template", "typename", "namespace", "using", "new", "delete",
 		"this", "try", "catch", "throw", "true", "false", "nullptr",
-		"main", "std", "cout", "cin", "endl", "include", "define",
+		"main", "std", "include", "define",
 	}
 	
 	for _, keyword := range keywords {
@@ -420,12 +440,3 @@ func isCppBuiltinOrKeyword(word string) bool {
 	return false
 }
 
-// Función auxiliar reutilizada
-func contains(slice []string, item string) bool {
-	for _, s := range slice {
-		if s == item {
-			return true
-		}
-	}
-	return false
-}
\ No newline at end of file
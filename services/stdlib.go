@@ -0,0 +1,510 @@
+package services
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+// Catálogo de la biblioteca estándar que se usa si no se carga otro
+//
+//go:embed stdlib.json
+var defaultStdlib []byte
+
+// StdlibCatalogue describe los símbolos de la biblioteca estándar que
+// conoce el análisis semántico, agrupados por encabezado. Un símbolo solo
+// está disponible si su encabezado, o uno que lo incluya, aparece en un
+// #include del programa.
+type StdlibCatalogue struct {
+	Headers map[string]StdlibHeader `json:"headers"`
+}
+
+// StdlibHeader lista los símbolos que declara un encabezado y los
+// encabezados que incluye a su vez
+type StdlibHeader struct {
+	Includes []string       `json:"includes,omitempty"`
+	Global   bool           `json:"global,omitempty"` // heredados de C: se usan sin std::
+	Symbols  []StdlibSymbol `json:"symbols,omitempty"`
+}
+
+// StdlibSymbol describe un objeto, una función o un tipo. Las firmas se
+// escriben "retorno(param, ...)": un parámetro terminado en '?' tiene valor
+// por defecto, auto acepta cualquier tipo y una sola letra mayúscula es un
+// parámetro de plantilla que toma el tipo del argumento.
+type StdlibSymbol struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"` // object, function o type
+
+	Type       string   `json:"type,omitempty"`       // objetos
+	Signatures []string `json:"signatures,omitempty"` // funciones
+
+	// Tipos: un alias de otro tipo o una clase con sus miembros
+	Alias        string              `json:"alias,omitempty"`
+	Template     []string            `json:"template,omitempty"`
+	Element      string              `json:"element,omitempty"` // tipo de v[i] y de los elementos en un for de rango
+	List         bool                `json:"list,omitempty"`    // admite inicializarse con {a, b, c}
+	Constructors []string            `json:"constructors,omitempty"`
+	Fields       map[string]string   `json:"fields,omitempty"`
+	Methods      map[string][]string `json:"methods,omitempty"`
+}
+
+// stdlibEntry es un símbolo del catálogo junto con el encabezado que lo declara
+type stdlibEntry struct {
+	header string
+	symbol *StdlibSymbol
+}
+
+// Catálogo activo e índice por nombre de símbolo. Un mismo nombre puede
+// aparecer en varios encabezados (abs en <cmath> y <cstdlib>).
+var (
+	stdlibCatalogue StdlibCatalogue
+	stdlibIndex     map[string][]stdlibEntry
+)
+
+func init() {
+	if err := LoadStdlibCatalogue(bytes.NewReader(defaultStdlib)); err != nil {
+		panic("catálogo de la biblioteca estándar inválido: " + err.Error())
+	}
+}
+
+// LoadStdlibCatalogue reemplaza el catálogo de la biblioteca estándar por
+// el JSON leído de r. Debe llamarse antes de analizar código.
+func LoadStdlibCatalogue(r io.Reader) error {
+	var catalogue StdlibCatalogue
+	if err := json.NewDecoder(r).Decode(&catalogue); err != nil {
+		return err
+	}
+
+	index := make(map[string][]stdlibEntry)
+	for _, header := range sortedHeaders(catalogue) {
+		for _, included := range catalogue.Headers[header].Includes {
+			if _, ok := catalogue.Headers[included]; !ok {
+				return fmt.Errorf("<%s> incluye <%s>, que no está en el catálogo", header, included)
+			}
+		}
+		symbols := catalogue.Headers[header].Symbols
+		for i := range symbols {
+			if err := validateStdlibSymbol(&symbols[i]); err != nil {
+				return fmt.Errorf("<%s>: %v", header, err)
+			}
+			index[symbols[i].Name] = append(index[symbols[i].Name], stdlibEntry{header, &symbols[i]})
+		}
+	}
+
+	stdlibCatalogue, stdlibIndex = catalogue, index
+	return nil
+}
+
+func sortedHeaders(catalogue StdlibCatalogue) []string {
+	headers := make([]string, 0, len(catalogue.Headers))
+	for header := range catalogue.Headers {
+		headers = append(headers, header)
+	}
+	sort.Strings(headers)
+	return headers
+}
+
+func validateStdlibSymbol(sym *StdlibSymbol) error {
+	var signatures []string
+	switch sym.Kind {
+	case "object":
+		if sym.Type == "" {
+			return fmt.Errorf("el objeto '%s' no tiene tipo", sym.Name)
+		}
+	case "function":
+		if len(sym.Signatures) == 0 {
+			return fmt.Errorf("la función '%s' no tiene firmas", sym.Name)
+		}
+		signatures = sym.Signatures
+	case "type":
+		signatures = sym.Constructors
+		for _, overloads := range sym.Methods {
+			signatures = append(signatures, overloads...)
+		}
+	default:
+		return fmt.Errorf("'%s' tiene un tipo de símbolo desconocido: %q", sym.Name, sym.Kind)
+	}
+	for _, signature := range signatures {
+		if _, _, ok := splitSignature(signature); !ok {
+			return fmt.Errorf("firma inválida en '%s': %q", sym.Name, signature)
+		}
+	}
+	return nil
+}
+
+// splitSignature separa "double(double, int?)" en el tipo de retorno y los
+// tipos de los parámetros
+func splitSignature(signature string) (string, []string, bool) {
+	open := strings.Index(signature, "(")
+	if open < 0 || !strings.HasSuffix(signature, ")") {
+		return "", nil, false
+	}
+	return strings.TrimSpace(signature[:open]), splitTypeList(signature[open+1 : len(signature)-1]), true
+}
+
+// splitTypeList separa una lista de tipos por las comas que no están dentro
+// de los argumentos de una plantilla: "string, vector<int>"
+func splitTypeList(list string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range list {
+		switch r {
+		case '<', '(':
+			depth++
+		case '>', ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(list[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if last := strings.TrimSpace(list[start:]); last != "" {
+		parts = append(parts, last)
+	}
+	return parts
+}
+
+// stdlibType devuelve la descripción de un tipo de la biblioteca estándar
+func stdlibType(name string) (stdlibEntry, bool) {
+	for _, entry := range stdlibIndex[name] {
+		if entry.symbol.Kind == "type" {
+			return entry, true
+		}
+	}
+	return stdlibEntry{}, false
+}
+
+// stdlibTypeNames devuelve los nombres de tipo del catálogo, que el parser
+// reconoce como tipos aunque falte el #include
+func stdlibTypeNames() map[string]bool {
+	names := make(map[string]bool)
+	for name := range stdlibIndex {
+		if _, ok := stdlibType(name); ok {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// isStdlibTemplate indica si el tipo se escribe con argumentos: vector<int>
+func isStdlibTemplate(name string) bool {
+	entry, ok := stdlibType(name)
+	return ok && len(entry.symbol.Template) > 0
+}
+
+// stdlibAlias devuelve el tipo al que equivale un alias como size_t
+func stdlibAlias(name string) (string, bool) {
+	entry, ok := stdlibType(name)
+	if !ok || entry.symbol.Alias == "" {
+		return "", false
+	}
+	return entry.symbol.Alias, true
+}
+
+// includedHeaders calcula los encabezados disponibles a partir de los
+// #include del programa, siguiendo los que cada encabezado incluye
+func includedHeaders(direct []string) map[string]bool {
+	available := make(map[string]bool)
+	var visit func(header string)
+	visit = func(header string) {
+		if available[header] {
+			return
+		}
+		available[header] = true
+		for _, included := range stdlibCatalogue.Headers[header].Includes {
+			visit(included)
+		}
+	}
+	for _, header := range direct {
+		visit(header)
+	}
+	return available
+}
+
+// collectIncludes registra los encabezados estándar incluidos y si el
+// programa declara using namespace std
+func (a *semanticAnalyzer) collectIncludes(tree *models.SyntaxNode) {
+	var headers []string
+	for _, node := range tree.Children {
+		switch node.Kind {
+		case NodeInclude:
+			a.lastInclude[node.Line/fileLineStride] = node
+			if node.Value == "" {
+				// Con un #include mal formado no se sabe qué encabezados faltan
+				a.unknownIncludes = true
+			}
+			if strings.HasPrefix(node.Value, "<") {
+				headers = append(headers, strings.Trim(node.Value, "<>"))
+			}
+		case NodeUsing:
+			if node.Value == "std" {
+				a.usingStd = true
+			}
+		}
+	}
+	a.headers = includedHeaders(headers)
+}
+
+// stdlibEntryFor elige la entrada de un nombre del catálogo, prefiriendo
+// la de un encabezado incluido
+func (a *semanticAnalyzer) stdlibEntryFor(name string) (stdlibEntry, bool) {
+	entries := stdlibIndex[name]
+	if len(entries) == 0 {
+		return stdlibEntry{}, false
+	}
+	for _, entry := range entries {
+		if a.headers[entry.header] {
+			return entry, true
+		}
+	}
+	return entries[0], true
+}
+
+// librarySymbol devuelve el símbolo de la biblioteca estándar con ese
+// nombre. Los símbolos se crean al usarse por primera vez en un ámbito
+// propio que no forma parte de la tabla exportada.
+func (a *semanticAnalyzer) librarySymbol(name string) *symbol {
+	if sym := a.library.lookupLocal(name); sym != nil {
+		return sym
+	}
+	entry, ok := a.stdlibEntryFor(name)
+	if !ok {
+		return nil
+	}
+
+	sym := &symbol{Name: name, Initialized: true, header: entry.header}
+	switch entry.symbol.Kind {
+	case "object":
+		sym.Kind, sym.Type = SymbolVariable, entry.symbol.Type
+	case "function":
+		sym.Kind = SymbolFunction
+		for _, signature := range entry.symbol.Signatures {
+			sym.overloads = append(sym.overloads, signatureNode(name, signature, nil))
+		}
+		sym.Type = functionSignature(sym.overloads[0])
+	case "type":
+		sym.Kind, sym.Type = SymbolClass, "class"
+	}
+	a.library.add(sym)
+	return sym
+}
+
+// signatureNode construye la declaración equivalente a una firma del
+// catálogo, reemplazando los parámetros de plantilla según bindings
+func signatureNode(name, signature string, bindings map[string]string) *models.SyntaxNode {
+	returns, params, _ := splitSignature(signature)
+	node := &models.SyntaxNode{Kind: NodeFunction, Value: name, Type: substituteTemplate(returns, bindings)}
+	for _, param := range params {
+		p := &models.SyntaxNode{Kind: NodeParam, Type: substituteTemplate(strings.TrimSuffix(param, "?"), bindings)}
+		if strings.HasSuffix(param, "?") {
+			// Un inicializador vacío marca el valor por defecto
+			p.Children = []*models.SyntaxNode{{Kind: NodeEmpty}}
+		}
+		node.Children = append(node.Children, p)
+	}
+	return node
+}
+
+var templateParamPattern = regexp.MustCompile(`\b[A-Z]\b`)
+
+// substituteTemplate reemplaza los parámetros de plantilla de un tipo:
+// con T = int, "vector<T>&" → "vector<int>&"
+func substituteTemplate(typ string, bindings map[string]string) string {
+	if bindings == nil {
+		return typ
+	}
+	return templateParamPattern.ReplaceAllStringFunc(typ, func(param string) string {
+		if bound, ok := bindings[param]; ok {
+			return bound
+		}
+		return "auto"
+	})
+}
+
+// checkLibraryUse informa el uso de un símbolo de la biblioteca estándar
+// sin su #include o, si no es heredado de C, sin std:: ni using namespace std.
+// Cada nombre se informa una sola vez.
+func (a *semanticAnalyzer) checkLibraryUse(sym *symbol, node *models.SyntaxNode, qualified bool) {
+	if !a.headers[sym.header] && !a.unknownIncludes {
+		a.reportMissingInclude(sym.Name, sym.header, node)
+		return
+	}
+	if qualified || a.usingStd || stdlibCatalogue.Headers[sym.header].Global || a.missingLibrary["std::"+sym.Name] {
+		return
+	}
+	a.missingLibrary["std::"+sym.Name] = true
+	diagnostic := diagnosticAtNode(CodeMissingNamespace,
+		"'"+sym.Name+"' pertenece al espacio de nombres std", node)
+	diagnostic.Suggestion = "Escribir 'std::" + sym.Name + "' o agregar 'using namespace std;' después de los #include"
+	diagnostic.Fixes = []models.Fix{
+		a.afterIncludesFix("Agregar 'using namespace std;'", "using namespace std;", node),
+		insertFix("Escribir 'std::"+sym.Name+"'", node.Line, node.Column, "std::"),
+	}
+	a.report(diagnostic)
+}
+
+func (a *semanticAnalyzer) reportMissingInclude(name, header string, at *models.SyntaxNode) {
+	if a.missingLibrary[name] {
+		return
+	}
+	a.missingLibrary[name] = true
+	diagnostic := nameDiagnosticAt(CodeMissingInclude,
+		"'"+name+"' requiere #include <"+header+">", at)
+	diagnostic.Suggestion = "Agregar '#include <" + header + ">' al inicio del archivo"
+	diagnostic.Fixes = []models.Fix{a.afterIncludesFix("Agregar '#include <"+header+">'", "#include <"+header+">", at)}
+	a.report(diagnostic)
+}
+
+// libraryClass devuelve la clase de la biblioteca estándar que corresponde
+// al tipo, instanciando sus miembros con los argumentos de la plantilla
+// (en vector<int>, push_back recibe un int)
+func (a *semanticAnalyzer) libraryClass(t cppType) *classInfo {
+	entry, ok := stdlibType(t.Name)
+	if !ok || entry.symbol.Alias != "" {
+		return nil
+	}
+	key := t.unqualified().value().String()
+	if info := a.libraryClasses[key]; info != nil {
+		return info
+	}
+
+	desc := entry.symbol
+	var bindings map[string]string
+	if len(desc.Template) > 0 {
+		bindings = make(map[string]string)
+		for i, param := range desc.Template {
+			if i < len(t.Args) && !t.Args[i].isUnknown() {
+				bindings[param] = t.Args[i].String()
+			}
+		}
+	}
+
+	info := &classInfo{
+		name:    t.Name,
+		kind:    "class",
+		scope:   &scope{kind: ScopeClass, name: t.Name, symbols: make(map[string]*symbol)},
+		element: typeFromString(substituteTemplate(desc.Element, bindings)),
+		list:    desc.List,
+		library: true,
+	}
+
+	for _, name := range sortedKeys(desc.Fields) {
+		info.scope.add(&symbol{
+			Name:        name,
+			Kind:        SymbolField,
+			Type:        substituteTemplate(desc.Fields[name], bindings),
+			Initialized: true,
+			access:      "public",
+		})
+	}
+	for _, name := range sortedKeys(desc.Methods) {
+		method := &symbol{Name: name, Kind: SymbolMethod, Initialized: true, access: "public"}
+		for _, signature := range desc.Methods[name] {
+			method.overloads = append(method.overloads, signatureNode(name, signature, bindings))
+		}
+		method.Type = functionSignature(method.overloads[0])
+		info.scope.add(method)
+	}
+	if len(desc.Constructors) > 0 {
+		constructor := &symbol{Name: t.Name, Kind: SymbolConstructor, Initialized: true, access: "public"}
+		for _, signature := range desc.Constructors {
+			constructor.overloads = append(constructor.overloads, signatureNode(t.Name, signature, bindings))
+		}
+		info.scope.add(constructor)
+	}
+
+	a.libraryClasses[key] = info
+	return info
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// instantiateCall resuelve los parámetros de plantilla de una función de la
+// biblioteca con los tipos de los argumentos: max(a, b) con a y b int
+// devuelve int. Los argumentos de un mismo parámetro deben coincidir.
+func (a *semanticAnalyzer) instantiateCall(sym *symbol, function *models.SyntaxNode, args []*models.SyntaxNode, argTypes []cppType) *models.SyntaxNode {
+	bindings := make(map[string]string)
+	generic := false
+	for i, param := range functionParams(function) {
+		name := typeFromString(param.Type).Name
+		if !templateParamPattern.MatchString(name) || len(name) != 1 {
+			continue
+		}
+		generic = true
+		if i >= len(argTypes) || argTypes[i].isUnknown() {
+			continue
+		}
+		t := argTypes[i].value().decay().unqualified()
+		bound, ok := bindings[name]
+		if !ok {
+			bindings[name] = t.String()
+			continue
+		}
+		if previous := typeFromString(bound); !previous.equals(t) {
+			a.report(diagnosticAtNode(CodeArgumentType,
+				"Los argumentos de '"+sym.Name+"' deben ser del mismo tipo, se encontró "+
+					previous.String()+" y "+t.String(), args[i]))
+			return nil
+		}
+	}
+	if !generic {
+		return function
+	}
+
+	instance := *function
+	instance.Type = substituteTemplate(function.Type, bindings)
+	instance.Children = nil
+	for _, param := range function.Children {
+		p := *param
+		p.Type = substituteTemplate(param.Type, bindings)
+		instance.Children = append(instance.Children, &p)
+	}
+	return &instance
+}
+
+// checkTypeName valida que un tipo escrito en una declaración exista: los
+// tipos de la biblioteca necesitan su #include y el resto debe ser
+// primitivo o una clase declarada
+func (a *semanticAnalyzer) checkTypeName(typ string, at *models.SyntaxNode) {
+	a.checkType(typeFromString(typ), at)
+}
+
+func (a *semanticAnalyzer) checkType(t cppType, at *models.SyntaxNode) {
+	for _, arg := range t.Args {
+		a.checkType(arg, at)
+	}
+	name := t.Name
+	if _, arithmetic := arithmeticRank[name]; arithmetic || name == "" || name == "void" ||
+		a.classes[name] != nil || strings.ContainsAny(name[:1], "0123456789") {
+		return
+	}
+	if entry, ok := a.stdlibEntryFor(name); ok && entry.symbol.Kind == "type" {
+		if !a.headers[entry.header] && !a.unknownIncludes {
+			a.reportMissingInclude(name, entry.header, at)
+		}
+		return
+	}
+	if name == typeManipulator || name == typeNullptr {
+		return
+	}
+	if !a.undeclared[name] {
+		a.undeclared[name] = true
+		a.report(nameDiagnosticAt(CodeUnknownType, "El tipo '"+name+"' no está declarado", at))
+	}
+}
@@ -0,0 +1,49 @@
+package services
+
+import "testing"
+
+func TestStandardLibrary(t *testing.T) {
+	checkSemantic(t, []semanticCase{
+		{
+			name:    "cout sin include",
+			code:    "int main() { cout << 1; return 0; }\n",
+			want:    []string{CodeMissingInclude},
+			message: "'cout' requiere #include <iostream>",
+		},
+		{
+			name:    "vector sin include",
+			code:    "int main() { std::vector<int> v; v.push_back(1); return 0; }\n",
+			want:    []string{CodeMissingInclude},
+			message: "'vector' requiere #include <vector>",
+		},
+		{
+			name:    "cout sin std",
+			code:    "#include <iostream>\nint main() { cout << 1; return 0; }\n",
+			want:    []string{CodeMissingNamespace},
+			message: "'cout' pertenece al espacio de nombres std",
+		},
+		{
+			name:    "método de string inexistente",
+			code:    "#include <string>\nint main() { std::string s = \"a\"; return s.lenght(); }\n",
+			want:    []string{CodeUnknownMember},
+			message: "La clase 'string' no tiene un miembro llamado 'lenght'",
+		},
+		{
+			name:    "argumento de una función de cmath",
+			code:    "#include <cmath>\nint main() { double r = std::sqrt(\"x\"); return r > 1; }\n",
+			want:    []string{CodeArgumentType},
+			message: "Argumento 1 de 'sqrt': se esperaba double, se encontró const char[2]",
+		},
+		{
+			name: "cmath con y sin std",
+			code: "#include <cmath>\nint main() { double r = sqrt(2.0) + std::pow(2.0, 3); return (int)r; }\n",
+			want: []string{},
+		},
+		{
+			name: "varios encabezados con using namespace",
+			code: "#include <iostream>\n#include <vector>\n#include <string>\n#include <algorithm>\nusing namespace std;\n" +
+				"int main() {\n    vector<int> v = {3, 1, 2};\n    sort(v.begin(), v.end());\n    string s = to_string(v[0]);\n    cout << s.size() << max(1, 2) << endl;\n    return 0;\n}\n",
+			want: []string{},
+		},
+	})
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+// Tipos de ámbito
+const (
+	ScopeGlobal   = "global"
+	ScopeFunction = "function"
+	ScopeBlock    = "block"
+	ScopeFor      = "for"
+	ScopeClass    = "class"
+	ScopeLibrary  = "library"
+)
+
+// Tipos de símbolo
+const (
+	SymbolVariable    = "variable"
+	SymbolParameter   = "parameter"
+	SymbolFunction    = "function"
+	SymbolClass       = "class"
+	SymbolField       = "field"
+	SymbolMethod      = "method"
+	SymbolConstructor = "constructor"
+)
+
+// symbol es una entrada de la tabla de símbolos
+type symbol struct {
+	Name        string
+	Kind        string
+	Type        string
+	Line        int
+	Column      int
+	Used        bool
+	Initialized bool
+	node        *models.SyntaxNode
+	scope       *scope
+	access      string      // solo para miembros de clases
+	header      string      // solo para la biblioteca estándar
+	constant    *constValue // solo para las constantes enteras con valor conocido
+
+	// Solo para funciones: una declaración por cada sobrecarga (la
+	// definición reemplaza al prototipo) y la posición de la primera
+	// declaración, para detectar llamadas anteriores a ella
+	overloads  []*models.SyntaxNode
+	declLine   int
+	declColumn int
+}
+
+// scope agrupa los símbolos declarados en un mismo ámbito. Las búsquedas
+// recorren la cadena de ámbitos desde el más interno hacia el global.
+type scope struct {
+	id      int
+	kind    string
+	name    string
+	line    int
+	parent  *scope
+	symbols map[string]*symbol
+	order   []*symbol
+}
+
+// lookupLocal busca un símbolo solo en este ámbito
+func (s *scope) lookupLocal(name string) *symbol {
+	return s.symbols[name]
+}
+
+// lookup busca un símbolo en este ámbito y en los ámbitos que lo contienen
+func (s *scope) lookup(name string) *symbol {
+	for current := s; current != nil; current = current.parent {
+		if sym := current.symbols[name]; sym != nil {
+			return sym
+		}
+	}
+	return nil
+}
+
+// symbolTable mantiene todos los ámbitos creados y el ámbito actual
+type symbolTable struct {
+	scopes  []*scope
+	current *scope
+}
+
+func newSymbolTable() *symbolTable {
+	table := &symbolTable{}
+	table.push(ScopeGlobal, "", 0)
+	return table
+}
+
+func (t *symbolTable) global() *scope {
+	return t.scopes[0]
+}
+
+// push abre un ámbito anidado dentro del actual
+func (t *symbolTable) push(kind, name string, line int) *scope {
+	s := &scope{
+		id:      len(t.scopes) + 1,
+		kind:    kind,
+		name:    name,
+		line:    line,
+		parent:  t.current,
+		symbols: make(map[string]*symbol),
+	}
+	t.scopes = append(t.scopes, s)
+	t.current = s
+	return s
+}
+
+// pop cierra el ámbito actual y vuelve al que lo contiene
+func (t *symbolTable) pop() {
+	if t.current.parent != nil {
+		t.current = t.current.parent
+	}
+}
+
+// declare agrega el símbolo al ámbito actual
+func (t *symbolTable) declare(sym *symbol) {
+	t.current.add(sym)
+}
+
+// add agrega el símbolo a este ámbito
+func (s *scope) add(sym *symbol) {
+	sym.scope = s
+	s.symbols[sym.Name] = sym
+	s.order = append(s.order, sym)
+}
+
+// export convierte la tabla al formato de respuesta de la API
+func (t *symbolTable) export() models.SymbolTable {
+	var result models.SymbolTable
+	for _, s := range t.scopes {
+		exported := models.Scope{
+			ID:      s.id,
+			Kind:    s.kind,
+			Name:    s.name,
+			Line:    s.line,
+			Symbols: []models.Symbol{},
+		}
+		if s.parent != nil {
+			exported.Parent = s.parent.id
+		}
+		for _, sym := range s.order {
+			value := ""
+			if sym.constant != nil && !sym.constant.invalid {
+				value = sym.constant.value.String()
+			}
+			exported.Symbols = append(exported.Symbols, models.Symbol{
+				Name:        sym.Name,
+				Kind:        sym.Kind,
+				Type:        sym.Type,
+				Value:       value,
+				Line:        sym.Line,
+				Column:      sym.Column,
+				Used:        sym.Used,
+				Initialized: sym.Initialized,
+				Access:      sym.access,
+			})
+		}
+		result.Scopes = append(result.Scopes, exported)
+	}
+	return result
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestScopes(t *testing.T) {
+	checkSemantic(t, []semanticCase{
+		{
+			name:    "redeclaración en el mismo ámbito",
+			code:    "int main() {\n    int x = 1;\n    int x = 2;\n    return x;\n}\n",
+			want:    []string{CodeRedeclared},
+			message: "Variable 'x' ya fue declarada en este ámbito (línea 2)",
+		},
+		{
+			name:    "parámetro redeclarado en el cuerpo",
+			code:    "int f(int a) {\n    int a = 2;\n    return a;\n}\nint main() { return f(1); }\n",
+			want:    []string{CodeRedeclared},
+			message: "'a' ya fue declarada",
+		},
+		{
+			name:    "ocultar en un bloque interior",
+			code:    "int main() {\n    int x = 1;\n    int r = 0;\n    {\n        int x = 2;\n        r = x;\n    }\n    return x + r;\n}\n",
+			want:    []string{CodeShadowedVariable},
+			message: "La variable 'x' oculta a la declarada en la línea 2",
+		},
+		{
+			name:    "ocultar una global",
+			code:    "int g = 1;\nint main() {\n    int g = 2;\n    return g;\n}\n",
+			want:    []string{CodeShadowedVariable, CodeUnusedVariable},
+			message: "oculta a la declarada en la línea 1",
+		},
+		{
+			name:    "variable del for fuera del ciclo",
+			code:    "int main() {\n    for (int i = 0; i < 3; i++) {}\n    return i;\n}\n",
+			want:    []string{CodeUndeclared},
+			message: "Variable 'i' usada pero no declarada",
+		},
+		{
+			name:    "variable de un bloque ya cerrado",
+			code:    "int main() {\n    {\n        int y = 1;\n        if (y > 0) return y;\n    }\n    return y;\n}\n",
+			want:    []string{CodeUndeclared},
+			message: "Variable 'y'",
+		},
+		{
+			name: "mismo nombre en ramas hermanas",
+			code: "int main() {\n    int x = 1;\n    if (x) { int z = x; return z; } else { int z = 2; return z; }\n}\n",
+			want: []string{},
+		},
+		{
+			name: "global usada desde una función",
+			code: "int total = 0;\nvoid sumar(int n) { total += n; }\nint main() { sumar(2); return total; }\n",
+			want: []string{},
+		},
+	})
+}
+
+func TestSymbolTable(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want []string // cada ámbito como "tipo nombre: símbolos" con el padre entre corchetes
+	}{
+		{
+			name: "función con bloque",
+			code: "int main() {\n    int x = 1;\n    {\n        int y = x;\n        return y;\n    }\n}\n",
+			want: []string{"global [0]: main", "function main [1]: x", "block [2]: y"},
+		},
+		{
+			name: "for y parámetros",
+			code: "int suma(int a, int b) { return a + b; }\nint main() {\n    int s = 0;\n    for (int i = 0; i < 3; i++) { s += suma(i, 1); }\n    return s;\n}\n",
+			want: []string{"global [0]: suma main", "function suma [1]: a b", "function main [1]: s", "for [3]: i", "block [4]:"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := AnalyzeSemantic(tt.code)
+			got := []string{}
+			for _, scope := range result.SymbolTable.Scopes {
+				text := strings.TrimSpace(scope.Kind+" "+scope.Name) + " [" + strconv.Itoa(scope.Parent) + "]:"
+				for _, sym := range scope.Symbols {
+					text += " " + sym.Name
+				}
+				got = append(got, text)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ámbitos =\n%v\nse esperaba\n%v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,516 @@
+package services
+
+import (
+	"strings"
+
+	"github.com/didiercito/api-go-examen2/models"
+)
+
+// checkExpr resuelve los identificadores de la expresión, valida sus
+// operadores y devuelve el tipo resultante
+func (a *semanticAnalyzer) checkExpr(node *models.SyntaxNode) cppType {
+	switch node.Kind {
+	case NodeIntLiteral:
+		a.checkIntegerLiteral(node)
+		return literalType(node)
+	case NodeFloatLiteral, NodeStringLiteral, NodeCharLiteral, NodeBoolLiteral:
+		return literalType(node)
+	case NodeNullLiteral:
+		return cppType{Name: typeNullptr}
+
+	case NodeIdentifier:
+		if node.Value == "this" {
+			return a.thisType(node)
+		}
+		sym := a.resolveIdentifier(node, false)
+		if sym == nil || sym.Kind != SymbolVariable && sym.Kind != SymbolParameter && sym.Kind != SymbolField {
+			return unknownType
+		}
+		return typeFromString(sym.Type).value()
+
+	case NodeCall:
+		return a.checkCall(node)
+	case NodeIndex:
+		return a.checkIndex(node)
+	case NodeMember:
+		return a.checkMember(node)
+	case NodeNew:
+		return a.checkNew(node)
+	case NodeDelete:
+		return a.checkDelete(node)
+	case NodeAssign:
+		return a.checkAssign(node)
+	case NodeBinary:
+		return a.checkBinary(node)
+	case NodeUnary:
+		return a.checkUnary(node)
+	case NodePostfix:
+		return a.checkIncrement(node, node.Children[0])
+	case NodeTernary:
+		return a.checkTernary(node)
+	case NodeCast:
+		return a.checkCast(node)
+
+	case NodeSizeof:
+		for _, child := range node.Children {
+			a.checkExpr(child)
+		}
+		return cppType{Name: "unsigned long"}
+	}
+
+	for _, child := range node.Children {
+		a.checkExpr(child)
+	}
+	return unknownType
+}
+
+// literalType clasifica un literal. inferValueType reconoce las formas
+// simples; los sufijos y exponentes se resuelven aquí y el tipo de los
+// enteros depende además de su valor (integerLiteralType).
+func literalType(node *models.SyntaxNode) cppType {
+	lower := strings.ToLower(node.Value)
+	switch node.Kind {
+	case NodeFloatLiteral:
+		if strings.HasSuffix(lower, "f") {
+			return cppType{Name: "float"}
+		}
+		if strings.HasSuffix(lower, "l") {
+			return cppType{Name: "long double"}
+		}
+		return cppType{Name: "double"}
+	case NodeIntLiteral:
+		return integerLiteralType(node.Value)
+	case NodeStringLiteral:
+		// "hola" es un const char[5]: el '\0' final ocupa un lugar más
+		return cppType{Name: "char", Const: true, Dims: []int{stringLiteralLength(node) + 1}}
+	}
+
+	switch inferValueType(literalText(node)) {
+	case "char":
+		return cppType{Name: "char"}
+	case "bool":
+		return cppType{Name: "bool"}
+	}
+	return unknownType
+}
+
+// isLValue indica si la expresión designa un objeto que se puede modificar
+func (a *semanticAnalyzer) isLValue(node *models.SyntaxNode) bool {
+	switch node.Kind {
+	case NodeIdentifier:
+		sym := a.table.current.lookup(node.Value)
+		return sym == nil || sym.Kind == SymbolVariable || sym.Kind == SymbolParameter || sym.Kind == SymbolField
+	case NodeUnary:
+		return node.Operator == "++" || node.Operator == "--" || node.Operator == "*"
+	case NodeAssign, NodeIndex:
+		return true
+	case NodeMember:
+		return node.Operator == "->" || a.isLValue(node.Children[0])
+	}
+	return false
+}
+
+// checkCondition valida que la condición de una estructura de control se
+// pueda evaluar como verdadero o falso
+func (a *semanticAnalyzer) checkCondition(node *models.SyntaxNode) {
+	if node.Kind == NodeEmpty {
+		return
+	}
+	t := a.checkExpr(node)
+	if t.isUnknown() || t.isScalar() || t.Name == typeIstream {
+		return
+	}
+	a.report(diagnosticAtNode(CodeTypeMismatch,
+		"La condición debe ser un valor lógico o numérico, se encontró "+t.String(), node))
+}
+
+// checkConversion valida la conversión implícita de un valor al tipo de
+// destino (inicialización, asignación, argumento o retorno)
+func (a *semanticAnalyzer) checkConversion(from, to cppType, value *models.SyntaxNode, code, message string) {
+	if from.isVoid() {
+		a.report(diagnosticAtNode(CodeTypeMismatch, "La expresión no produce un valor (es de tipo void)", value))
+		return
+	}
+
+	if target := to.value(); target.isPointer() {
+		switch {
+		case value.Kind == NodeIntLiteral && value.Value == "0":
+			// int* p = 0; es la forma antigua de nullptr
+			return
+		case value.Kind == NodeStringLiteral && target.Name == "char" && target.Pointer == 1:
+			if !target.Const {
+				a.report(diagnosticAtNode(code,
+					"Una cadena literal es constante: se necesita const char* en lugar de char*", value))
+			}
+			return
+		}
+	}
+
+	if a.isBaseConversion(from, to) {
+		return
+	}
+	if from.isIntegral() && !a.checkConstantFits(value, to) {
+		return
+	}
+
+	switch classifyConversion(from, to) {
+	case conversionInvalid:
+		a.report(diagnosticAtNode(code, message, value))
+	case conversionNarrowing:
+		// float f = 3.14; es habitual y el valor se conserva razonablemente
+		if value.Kind == NodeFloatLiteral && to.isFloating() {
+			return
+		}
+		a.report(diagnosticAtNode(CodeNarrowing,
+			"Conversión de "+from.unqualified().String()+" a "+to.unqualified().String()+" con posible pérdida de datos", value))
+	}
+}
+
+// isBaseConversion reconoce la conversión de un objeto, referencia o
+// puntero de una clase derivada a su clase base
+func (a *semanticAnalyzer) isBaseConversion(from, to cppType) bool {
+	from, to = from.decay(), to.value()
+	if from.Pointer != to.Pointer || from.isArray() || to.isArray() || from.Name == to.Name {
+		return false
+	}
+	derived, base := a.classes[from.Name], a.classes[to.Name]
+	return derived != nil && base != nil && derived.derivesFrom(base)
+}
+
+// thisType devuelve el tipo de this dentro de un método
+func (a *semanticAnalyzer) thisType(node *models.SyntaxNode) cppType {
+	if a.class == nil {
+		a.report(diagnosticAtNode(CodeUndeclared, "'this' solo puede usarse dentro de un método", node))
+		return unknownType
+	}
+	return cppType{Name: a.class.name, Pointer: 1}
+}
+
+func (a *semanticAnalyzer) checkAssign(node *models.SyntaxNode) cppType {
+	target, value := node.Children[0], node.Children[1]
+	targetType := a.checkExpr(target)
+	valueType := a.checkExpr(value)
+
+	if !a.isLValue(target) {
+		a.report(diagnosticAtNode(CodeNotAssignable, "La expresión a la izquierda de '"+node.Operator+"' no es asignable", target))
+		return unknownType
+	}
+	if targetType.isArray() {
+		a.report(diagnosticAtNode(CodeNotAssignable,
+			"Un arreglo no se puede asignar completo; se asigna elemento por elemento", target))
+		return unknownType
+	}
+	if targetType.isConstObject() {
+		a.reportConstModified(target)
+		return targetType
+	}
+
+	if node.Operator == "=" {
+		a.checkConversion(valueType, targetType, value, CodeTypeMismatch,
+			"Error de tipo - No se puede asignar "+valueType.String()+" a variable de tipo "+targetType.String())
+		return targetType
+	}
+
+	// Asignación compuesta: a += b se valida como a + b
+	op := strings.TrimSuffix(node.Operator, "=")
+	result, ok := binaryResultType(op, targetType, valueType)
+	if !ok {
+		a.reportInvalidOperands(node, op, targetType, valueType)
+	} else if result.isIntegral() {
+		a.checkFolding(node, value, op)
+	}
+	return targetType
+}
+
+func (a *semanticAnalyzer) checkBinary(node *models.SyntaxNode) cppType {
+	left := a.checkExpr(node.Children[0])
+	right := a.checkExpr(node.Children[1])
+
+	switch {
+	case node.Operator == ",":
+		return right
+	case node.Operator == "<<" && left.Name == typeOstream:
+		if right.isVoid() {
+			a.report(diagnosticAtNode(CodeTypeMismatch, "No se puede imprimir una expresión de tipo void", node.Children[1]))
+		}
+		return left
+	case node.Operator == ">>" && left.Name == typeIstream:
+		if !a.isLValue(node.Children[1]) {
+			a.report(diagnosticAtNode(CodeNotAssignable, "cin solo puede leer en una variable", node.Children[1]))
+		} else if right.isConstObject() {
+			a.reportConstModified(node.Children[1])
+		}
+		return left
+	}
+
+	if result, handled := a.checkClassOperator(node, node.Operator, left, right); handled {
+		return result
+	}
+	result, ok := binaryResultType(node.Operator, left, right)
+	if !ok {
+		a.reportInvalidOperands(node, node.Operator, left, right)
+		return unknownType
+	}
+	if result.isIntegral() {
+		a.checkFolding(node, node.Children[1], node.Operator)
+	}
+	return result
+}
+
+// binaryResultType calcula el tipo de "left op right"; ok es false si los
+// operandos no son válidos para el operador
+func binaryResultType(op string, left, right cppType) (cppType, bool) {
+	boolType := cppType{Name: "bool"}
+
+	if left.isUnknown() || right.isUnknown() {
+		switch op {
+		case "<", ">", "<=", ">=", "==", "!=", "&&", "||":
+			return boolType, true
+		}
+		return unknownType, true
+	}
+
+	left, right = left.decay(), right.decay()
+	if left.isString() || right.isString() {
+		return stringResultType(op, left, right)
+	}
+	if !left.isPlain() || !right.isPlain() || left.Name == typeNullptr || right.Name == typeNullptr {
+		return pointerResultType(op, left, right)
+	}
+
+	switch op {
+	case "+", "-", "*", "/":
+		if left.isArithmetic() && right.isArithmetic() {
+			return arithmeticResult(left, right), true
+		}
+	case "%", "&", "|", "^", "<<", ">>":
+		if left.isIntegral() && right.isIntegral() {
+			return arithmeticResult(left, right), true
+		}
+	case "<", ">", "<=", ">=", "==", "!=":
+		if left.isArithmetic() && right.isArithmetic() {
+			return boolType, true
+		}
+	case "&&", "||":
+		if left.isScalar() && right.isScalar() {
+			return boolType, true
+		}
+	}
+	return unknownType, false
+}
+
+// stringResultType calcula el tipo de una operación en la que al menos un
+// operando es std::string: el otro puede ser un string o una cadena de C
+// (char* o un literal), y en + también un char
+func stringResultType(op string, left, right cppType) (cppType, bool) {
+	text := func(t cppType) bool { return t.isString() || (t.Name == "char" && t.Pointer == 1) }
+	switch op {
+	case "+":
+		char := func(t cppType) bool { return t.Name == "char" && t.isPlain() }
+		return cppType{Name: "string"}, (text(left) || char(left)) && (text(right) || char(right))
+	case "<", ">", "<=", ">=", "==", "!=":
+		return cppType{Name: "bool"}, text(left) && text(right)
+	}
+	return unknownType, false
+}
+
+// pointerResultType calcula el tipo de las operaciones con punteros:
+// aritmética con enteros, diferencia y comparación entre punteros
+func pointerResultType(op string, left, right cppType) (cppType, bool) {
+	boolType := cppType{Name: "bool"}
+	samePointer := left.isPointer() && right.isPointer() && left.Name == right.Name && left.Pointer == right.Pointer
+	isNull := func(t cppType) bool { return t.Name == typeNullptr }
+
+	switch op {
+	case "+":
+		if left.isPointer() && right.isIntegral() {
+			return left, true
+		}
+		if left.isIntegral() && right.isPointer() {
+			return right, true
+		}
+	case "-":
+		if left.isPointer() && right.isIntegral() {
+			return left, true
+		}
+		if samePointer {
+			return cppType{Name: "long"}, true
+		}
+	case "==", "!=", "<", ">", "<=", ">=":
+		if samePointer || (isNull(left) && (right.isPointer() || isNull(right))) || (left.isPointer() && isNull(right)) {
+			return boolType, true
+		}
+	case "&&", "||":
+		if left.isScalar() && right.isScalar() {
+			return boolType, true
+		}
+	}
+	return unknownType, false
+}
+
+// reportConstModified informa un intento de modificar un objeto const
+func (a *semanticAnalyzer) reportConstModified(target *models.SyntaxNode) {
+	message := "No se puede modificar una expresión constante"
+	if target.Kind == NodeIdentifier {
+		message = "No se puede modificar '" + target.Value + "' porque es constante"
+	}
+	a.report(diagnosticAtNode(CodeConstModified, message, target))
+}
+
+func (a *semanticAnalyzer) reportInvalidOperands(node *models.SyntaxNode, op string, left, right cppType) {
+	message := "Operandos inválidos para '" + op + "': " + left.String() + " y " + right.String()
+	if op == "%" && (left.isFloating() || right.isFloating()) {
+		message = "El operador '%' requiere operandos enteros, se encontró " + left.String() + " y " + right.String()
+	}
+	diagnostic := diagnosticAtNode(CodeInvalidOperands, message, node)
+	if l, r := left.decay(), right.decay(); op == "+" && l.Name == "char" && l.Pointer == 1 && r.Name == "char" && r.Pointer == 1 {
+		// "a" + "b" suma dos punteros: la concatenación necesita un std::string
+		diagnostic.Suggestion = "Convertir una de las cadenas a std::string, por ejemplo string(\"...\") + \"...\""
+	}
+	a.report(diagnostic)
+}
+
+func (a *semanticAnalyzer) checkUnary(node *models.SyntaxNode) cppType {
+	operand := node.Children[0]
+	if node.Operator == "++" || node.Operator == "--" {
+		return a.checkIncrement(node, operand)
+	}
+
+	t := a.checkExpr(operand)
+	if node.Operator == "&" {
+		return a.checkAddressOf(operand, t)
+	}
+	if t.isUnknown() {
+		if node.Operator == "!" {
+			return cppType{Name: "bool"}
+		}
+		return unknownType
+	}
+
+	switch node.Operator {
+	case "*":
+		if (t.isPointer() || t.isArray()) && !t.elementType().isVoid() {
+			return t.elementType()
+		}
+		a.report(diagnosticAtNode(CodeInvalidPointer,
+			"No se puede desreferenciar una expresión de tipo "+t.String(), node))
+		return unknownType
+	case "!":
+		if t.isScalar() {
+			return cppType{Name: "bool"}
+		}
+	case "-", "+":
+		if t.isArithmetic() {
+			if t.isIntegral() {
+				a.checkFolding(node, nil, node.Operator)
+			}
+			return promote(t)
+		}
+	case "~":
+		if t.isIntegral() {
+			return promote(t)
+		}
+	default:
+		return unknownType
+	}
+
+	a.report(diagnosticAtNode(CodeInvalidOperands,
+		"Operando inválido para '"+node.Operator+"': "+t.String(), node))
+	return unknownType
+}
+
+// checkAddressOf valida &expr: solo las variables y elementos tienen dirección
+func (a *semanticAnalyzer) checkAddressOf(operand *models.SyntaxNode, t cppType) cppType {
+	if !a.isLValue(operand) {
+		a.report(diagnosticAtNode(CodeInvalidPointer,
+			"Solo se puede obtener la dirección de una variable", operand))
+		return unknownType
+	}
+	if t.isUnknown() || t.isArray() {
+		return unknownType
+	}
+	t.Pointer++
+	return t
+}
+
+// checkIndex valida a[i] y devuelve el tipo del elemento
+func (a *semanticAnalyzer) checkIndex(node *models.SyntaxNode) cppType {
+	base := a.checkExpr(node.Children[0])
+	index := a.checkExpr(node.Children[1])
+	if !index.isUnknown() && !index.isIntegral() {
+		a.report(diagnosticAtNode(CodeInvalidArray,
+			"El índice debe ser un entero, se encontró "+index.String(), node.Children[1]))
+	}
+
+	switch {
+	case base.isUnknown():
+		return unknownType
+	case base.isArray() || base.isPointer():
+		return base.elementType()
+	case base.isString():
+		return cppType{Name: "char", Const: base.Const}
+	}
+	if info := a.classOf(base); info != nil && !info.element.isUnknown() {
+		return info.element
+	}
+	a.report(diagnosticAtNode(CodeInvalidArray,
+		"No se puede indexar '"+formatExpr(node.Children[0])+"': es de tipo "+base.String()+", no un arreglo", node.Children[0]))
+	return unknownType
+}
+
+// checkIncrement valida ++ y -- (prefijos o sufijos)
+func (a *semanticAnalyzer) checkIncrement(node, operand *models.SyntaxNode) cppType {
+	t := a.checkExpr(operand)
+	if !a.isLValue(operand) {
+		a.report(diagnosticAtNode(CodeNotAssignable, "El operando de '"+node.Operator+"' debe ser una variable", operand))
+		return unknownType
+	}
+	if t.isConstObject() {
+		a.reportConstModified(operand)
+		return t.unqualified()
+	}
+	if t.isPointer() {
+		return t
+	}
+	if !t.isUnknown() && !t.isArithmetic() {
+		a.report(diagnosticAtNode(CodeInvalidOperands,
+			"Operando inválido para '"+node.Operator+"': "+t.String(), node))
+		return unknownType
+	}
+	return t.unqualified()
+}
+
+func (a *semanticAnalyzer) checkTernary(node *models.SyntaxNode) cppType {
+	a.checkCondition(node.Children[0])
+	whenTrue := a.checkExpr(node.Children[1])
+	whenFalse := a.checkExpr(node.Children[2])
+
+	switch {
+	case whenTrue.isUnknown() || whenFalse.isUnknown():
+		return unknownType
+	case whenTrue.isArithmetic() && whenFalse.isArithmetic():
+		return arithmeticResult(whenTrue, whenFalse)
+	case whenTrue.equals(whenFalse):
+		return whenTrue.unqualified()
+	}
+
+	a.report(diagnosticAtNode(CodeInvalidOperands,
+		"Los resultados del operador ternario tienen tipos incompatibles: "+whenTrue.String()+" y "+whenFalse.String(), node))
+	return unknownType
+}
+
+// checkCast valida las conversiones explícitas (tipo) expr y static_cast<tipo>(expr)
+func (a *semanticAnalyzer) checkCast(node *models.SyntaxNode) cppType {
+	from := a.checkExpr(node.Children[0])
+	to := typeFromString(node.Type)
+	a.checkType(to, node)
+
+	valid := from.isUnknown() || to.isUnknown() || from.equals(to) ||
+		(from.isArithmetic() && to.isArithmetic()) || to.isVoid() ||
+		(to.isPointer() && (from.decay().isPointer() || from.Name == typeNullptr))
+	if !valid {
+		a.report(diagnosticAtNode(CodeInvalidCast,
+			"No se puede convertir "+from.String()+" a "+to.String(), node))
+	}
+	return to
+}
@@ -0,0 +1,59 @@
+package services
+
+import "testing"
+
+func TestTypeCheck(t *testing.T) {
+	checkSemantic(t, []semanticCase{
+		{
+			name:    "cadena en un int",
+			code:    "int main() {\n    int x = \"hola\";\n    return x;\n}\n",
+			want:    []string{CodeTypeMismatch},
+			message: "Variable 'x' de tipo int no puede ser asignada con valor de tipo const char[5]",
+		},
+		{
+			name:    "string por int",
+			code:    "#include <string>\nint main() {\n    std::string s = \"a\";\n    int n = s * 2;\n    return n;\n}\n",
+			want:    []string{CodeInvalidOperands},
+			message: "Operandos inválidos para '*': string y int",
+		},
+		{
+			name:    "módulo con double",
+			code:    "int main() {\n    double d = 1.5;\n    int m = d % 2;\n    return m;\n}\n",
+			want:    []string{CodeInvalidOperands},
+			message: "El operador '%' requiere operandos enteros",
+		},
+		{
+			name:    "double en un int",
+			code:    "int main() {\n    double d = 3.7;\n    int n = d;\n    return n;\n}\n",
+			want:    []string{CodeNarrowing},
+			message: "Conversión de double a int con posible pérdida de datos",
+		},
+		{
+			name:    "asignar a un literal",
+			code:    "int main() {\n    int a = 1;\n    5 = a;\n    return a;\n}\n",
+			want:    []string{CodeNotAssignable},
+			message: "La expresión a la izquierda de '=' no es asignable",
+		},
+		{
+			name:    "static_cast de int a puntero",
+			code:    "int main() {\n    int a = 1;\n    int* p = static_cast<int*>(a);\n    return *p;\n}\n",
+			want:    []string{CodeInvalidCast},
+			message: "No se puede convertir int a int*",
+		},
+		{
+			name: "aritmética mixta",
+			code: "int main() {\n    int a = 7;\n    double b = a / 2.0 + 'c';\n    long long c = a * 2LL;\n    bool ok = b > c && a;\n    return ok ? 0 : 1;\n}\n",
+			want: []string{},
+		},
+		{
+			name: "operadores unarios",
+			code: "int main() {\n    int a = 1;\n    bool b = !a;\n    int c = ~a % 3;\n    return b + c;\n}\n",
+			want: []string{},
+		},
+		{
+			name: "conversiones explícitas",
+			code: "int main() {\n    double d = 2.5;\n    int n = (int)d + static_cast<int>(d);\n    return n;\n}\n",
+			want: []string{},
+		},
+	})
+}
@@ -0,0 +1,330 @@
+package services
+
+import (
+	"strconv"
+	"strings"
+)
+
+// cppType es la representación interna de un tipo durante el análisis
+// semántico. Un Name vacío representa un tipo desconocido: las expresiones
+// con tipo desconocido no generan errores para evitar errores en cascada.
+//
+// En los punteros Const se refiere al valor apuntado (const int*). Dims
+// guarda las dimensiones de un arreglo de la más externa a la más interna;
+// -1 indica un tamaño omitido o que no es un literal.
+type cppType struct {
+	Name      string
+	Const     bool
+	Pointer   int
+	Reference bool
+	Dims      []int
+	Args      []cppType // argumentos de plantilla: vector<int>
+}
+
+var unknownType = cppType{}
+
+// Tipos de la biblioteca estándar con reglas propias en las expresiones
+const (
+	typeOstream     = "ostream"
+	typeIstream     = "istream"
+	typeManipulator = "manipulator"
+	typeNullptr     = "nullptr_t"
+)
+
+// Rango de los tipos aritméticos para las conversiones usuales
+var arithmeticRank = map[string]int{
+	"bool":               1,
+	"char":               2,
+	"unsigned char":      2,
+	"short":              3,
+	"unsigned short":     3,
+	"int":                4,
+	"unsigned int":       5,
+	"long":               6,
+	"unsigned long":      7,
+	"long long":          8,
+	"unsigned long long": 9,
+	"float":              10,
+	"double":             11,
+	"long double":        12,
+}
+
+// typeFromString interpreta un tipo tal como lo escribe el parser
+// ("const int", "string", "char*[]", "int&", "double[3][4]", "vector<int>")
+func typeFromString(s string) cppType {
+	s = strings.TrimSpace(s)
+	t := cppType{}
+	if strings.HasPrefix(s, "const ") {
+		t.Const = true
+		s = strings.TrimPrefix(s, "const ")
+	}
+
+	// Las dimensiones se leen desde el final: la última es la más interna
+	for strings.HasSuffix(s, "]") {
+		open := strings.LastIndex(s, "[")
+		if open < 0 {
+			break
+		}
+		size, err := strconv.Atoi(s[open+1 : len(s)-1])
+		if err != nil || size <= 0 {
+			size = -1
+		}
+		t.Dims = append([]int{size}, t.Dims...)
+		s = s[:open]
+	}
+	if strings.HasSuffix(s, "&&") {
+		s = strings.TrimSuffix(s, "&&")
+	}
+	if strings.HasSuffix(s, "&") {
+		t.Reference = true
+		s = strings.TrimSuffix(s, "&")
+	}
+	for strings.HasSuffix(s, "*") {
+		t.Pointer++
+		s = strings.TrimSuffix(s, "*")
+	}
+
+	s = strings.TrimPrefix(strings.TrimSpace(s), "std::")
+	if open := strings.Index(s, "<"); open > 0 && strings.HasSuffix(s, ">") {
+		for _, arg := range splitTypeList(s[open+1 : len(s)-1]) {
+			t.Args = append(t.Args, typeFromString(arg))
+		}
+		s = strings.TrimSpace(s[:open])
+	}
+
+	t.Name = s
+	if alias, ok := stdlibAlias(t.Name); ok {
+		t.Name = alias
+	}
+	if t.Name == "auto" {
+		t.Name = ""
+	}
+	return t
+}
+
+func (t cppType) String() string {
+	if t.Name == "" {
+		return "desconocido"
+	}
+	text := t.Name
+	if len(t.Args) > 0 {
+		var args []string
+		for _, arg := range t.Args {
+			args = append(args, arg.String())
+		}
+		text += "<" + strings.Join(args, ", ") + ">"
+	}
+	if t.Const {
+		text = "const " + text
+	}
+	text += strings.Repeat("*", t.Pointer)
+	if t.Reference {
+		text += "&"
+	}
+	for _, size := range t.Dims {
+		if size < 0 {
+			text += "[]"
+		} else {
+			text += "[" + strconv.Itoa(size) + "]"
+		}
+	}
+	return text
+}
+
+func (t cppType) isUnknown() bool {
+	return t.Name == ""
+}
+
+// unqualified devuelve el tipo sin el calificador const
+func (t cppType) unqualified() cppType {
+	t.Const = false
+	return t
+}
+
+// value devuelve el tipo del valor designado: una referencia se comporta
+// como el objeto al que se refiere
+func (t cppType) value() cppType {
+	t.Reference = false
+	return t
+}
+
+func (t cppType) isArray() bool {
+	return len(t.Dims) > 0
+}
+
+func (t cppType) isPointer() bool {
+	return t.Pointer > 0 && !t.isArray()
+}
+
+// elementType devuelve el tipo de los elementos de un arreglo o el tipo
+// apuntado por un puntero
+func (t cppType) elementType() cppType {
+	switch {
+	case t.isArray():
+		t.Dims = t.Dims[1:]
+	case t.Pointer > 0:
+		t.Pointer--
+	}
+	t.Reference = false
+	return t
+}
+
+// decay convierte un arreglo en un puntero a su primer elemento, como
+// ocurre al pasarlo a una función o al operar con él
+func (t cppType) decay() cppType {
+	if !t.isArray() {
+		return t
+	}
+	element := t.elementType()
+	if element.isArray() {
+		return t
+	}
+	element.Pointer++
+	return element
+}
+
+// isConstObject indica si el objeto designado no se puede modificar: en
+// const int* p el puntero sí se puede reasignar
+func (t cppType) isConstObject() bool {
+	return t.Const && t.Pointer == 0
+}
+
+// isPlain indica que no es puntero ni arreglo
+func (t cppType) isPlain() bool {
+	return t.Pointer == 0 && !t.isArray()
+}
+
+func (t cppType) isArithmetic() bool {
+	_, ok := arithmeticRank[t.Name]
+	return ok && t.isPlain()
+}
+
+func (t cppType) isIntegral() bool {
+	return t.isArithmetic() && !t.isFloating()
+}
+
+func (t cppType) isFloating() bool {
+	return t.Name == "float" || t.Name == "double" || t.Name == "long double"
+}
+
+func (t cppType) isString() bool {
+	return t.Name == "string" && t.isPlain()
+}
+
+func (t cppType) isVoid() bool {
+	return t.Name == "void" && t.isPlain()
+}
+
+// isScalar indica si el tipo puede usarse como condición
+func (t cppType) isScalar() bool {
+	return t.isArithmetic() || t.isPointer() || t.isArray() || t.Name == typeNullptr
+}
+
+// equals compara dos tipos sin tener en cuenta const de primer nivel ni
+// referencias. Un tamaño de arreglo desconocido coincide con cualquiera.
+func (t cppType) equals(other cppType) bool {
+	if t.Name != other.Name || t.Pointer != other.Pointer || len(t.Dims) != len(other.Dims) ||
+		len(t.Args) != len(other.Args) {
+		return false
+	}
+	for i, arg := range t.Args {
+		if !arg.equals(other.Args[i]) {
+			return false
+		}
+	}
+	if t.Pointer > 0 && t.Const != other.Const {
+		return false
+	}
+	for i, size := range t.Dims {
+		if size >= 0 && other.Dims[i] >= 0 && size != other.Dims[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// arithmeticResult aplica las conversiones aritméticas usuales: los tipos
+// menores que int se promueven a int y gana el de mayor rango
+func arithmeticResult(left, right cppType) cppType {
+	result := left.unqualified()
+	if arithmeticRank[right.Name] > arithmeticRank[result.Name] {
+		result = right.unqualified()
+	}
+	if arithmeticRank[result.Name] < arithmeticRank["int"] {
+		result = cppType{Name: "int"}
+	}
+	return result
+}
+
+// promote aplica la promoción entera de los operadores unarios
+func promote(t cppType) cppType {
+	if t.isArithmetic() && arithmeticRank[t.Name] < arithmeticRank["int"] {
+		return cppType{Name: "int"}
+	}
+	return t.unqualified()
+}
+
+// conversion describe si un valor de tipo from puede usarse donde se espera to
+type conversion int
+
+const (
+	conversionOK conversion = iota
+	conversionNarrowing
+	conversionInvalid
+)
+
+// classifyConversion decide si la conversión implícita from → to es válida,
+// con pérdida de datos o inválida
+func classifyConversion(from, to cppType) conversion {
+	to = to.value()
+	if from.isUnknown() || to.isUnknown() || from.equals(to) {
+		return conversionOK
+	}
+
+	switch {
+	case to.isArray():
+		// Solo al pasar argumentos: un parámetro int v[] recibe cualquier
+		// arreglo o puntero de int
+		if to.Dims[0] < 0 && from.decay().equals(to.decay()) {
+			return conversionOK
+		}
+		return conversionInvalid
+	case to.isPointer():
+		if from.Name == typeNullptr {
+			return conversionOK
+		}
+		from = from.decay()
+		if !from.isPointer() || from.Pointer != to.Pointer ||
+			(from.Name != to.Name && to.Name != "void") {
+			return conversionInvalid
+		}
+		// Se puede agregar const al valor apuntado, pero no quitarlo
+		if from.Const && !to.Const {
+			return conversionInvalid
+		}
+		return conversionOK
+	case !from.isPlain():
+		if to.Name == "bool" && to.isPlain() {
+			return conversionOK
+		}
+		// string s = p; con p de tipo char* o char[]
+		if pointee := from.decay(); to.isString() && pointee.Name == "char" && pointee.Pointer == 1 {
+			return conversionOK
+		}
+		return conversionInvalid
+	case from.isArithmetic() && to.isArithmetic():
+		if from.isFloating() && to.isIntegral() {
+			return conversionNarrowing
+		}
+		if from.isFloating() && to.isFloating() && arithmeticRank[from.Name] > arithmeticRank[to.Name] {
+			return conversionNarrowing
+		}
+		return conversionOK
+	case to.isString():
+		if from.Name == "char" {
+			return conversionOK
+		}
+		return conversionInvalid
+	}
+	return conversionInvalid
+}